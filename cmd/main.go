@@ -2,12 +2,14 @@ package main
 
 import (
 	"context"
+	"flag"
 	"os"
 	"os/signal"
 	"syscall"
 	"time"
 
 	"github.com/ferdian3456/virdanproject/internal/config"
+	"github.com/ferdian3456/virdanproject/internal/constant"
 	middleware "github.com/ferdian3456/virdanproject/internal/exception"
 	"github.com/gofiber/fiber/v2/middleware/compress"
 	zapLog "go.uber.org/zap"
@@ -15,6 +17,12 @@ import (
 
 func main() {
 	time.Local = time.UTC
+
+	if len(os.Args) > 1 && os.Args[1] == "gc-objects" {
+		collectOrphanedObjects(os.Args[2:])
+		return
+	}
+
 	// Flush zap buffered log first then cancel the context for graceful shutdown
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
@@ -34,14 +42,20 @@ func main() {
 		Level: compress.LevelBestSpeed,
 	}))
 
-	config.Server(&config.ServerConfig{
+	serverConfig := &config.ServerConfig{
 		Router:  fiber,
 		DB:      postgresql,
 		DBCache: rds,
 		Log:     zap,
 		Config:  koanf,
 		MinIO:   minio,
-	})
+	}
+
+	config.Server(serverConfig)
+
+	workerCtx, stopWorker := context.WithCancel(context.Background())
+	defer stopWorker()
+	go config.Worker(workerCtx, serverConfig)
 
 	GO_SERVER_PORT := koanf.String("GO_SERVER")
 
@@ -60,6 +74,7 @@ func main() {
 
 	<-stop
 	zap.Info("got one of stop signals")
+	stopWorker()
 
 	err = fiber.ShutdownWithContext(ctx)
 	if err != nil {
@@ -71,3 +86,34 @@ func main() {
 	zap.Info("server has shut down gracefully")
 	_ = zap.Sync()
 }
+
+// collectOrphanedObjects is the gc-objects subcommand, it removes or with -dry-run only reports
+// bucket objects no image row points to and exits
+func collectOrphanedObjects(args []string) {
+	flags := flag.NewFlagSet("gc-objects", flag.ExitOnError)
+	dryRun := flags.Bool("dry-run", false, "only report orphaned objects without removing them")
+	minAge := flags.Duration("min-age", constant.ORPHAN_OBJECT_MIN_AGE, "skip objects modified more recently than this")
+	_ = flags.Parse(args)
+
+	zap := config.NewZap()
+	koanf := config.NewKoanf(zap)
+	postgresql := config.NewPostgresqlPool(koanf, zap)
+	defer postgresql.Close()
+	minio := config.NewMinIO(koanf, zap)
+
+	serverConfig := &config.ServerConfig{
+		DB:     postgresql,
+		Log:    zap,
+		Config: koanf,
+		MinIO:  minio,
+	}
+
+	err := config.CollectOrphanedObjects(context.Background(), serverConfig, *dryRun, *minAge)
+	if err != nil {
+		zap.Error("failed to collect orphaned objects", zapLog.Error(err))
+		_ = zap.Sync()
+		os.Exit(1)
+	}
+
+	_ = zap.Sync()
+}
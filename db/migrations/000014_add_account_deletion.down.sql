@@ -0,0 +1,12 @@
+DELETE FROM server_post_comments WHERE author_id IS NULL;
+ALTER TABLE server_post_comments DROP CONSTRAINT IF EXISTS server_post_comments_author_id_fkey;
+ALTER TABLE server_post_comments ADD CONSTRAINT server_post_comments_author_id_fkey FOREIGN KEY (author_id) REFERENCES users(id) ON DELETE CASCADE;
+ALTER TABLE server_post_comments ALTER COLUMN author_id SET NOT NULL;
+
+ALTER TABLE servers DROP CONSTRAINT IF EXISTS servers_owner_id_fkey;
+ALTER TABLE servers ADD CONSTRAINT servers_owner_id_fkey FOREIGN KEY (owner_id) REFERENCES users(id) ON DELETE CASCADE;
+
+DROP INDEX IF EXISTS idx_users_01;
+
+ALTER TABLE users DROP COLUMN IF EXISTS delete_scheduled_datetime;
+ALTER TABLE users DROP COLUMN IF EXISTS delete_requested_datetime;
@@ -0,0 +1,13 @@
+ALTER TABLE users ADD COLUMN IF NOT EXISTS delete_requested_datetime timestamptz NULL;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS delete_scheduled_datetime timestamptz NULL;
+
+CREATE INDEX IF NOT EXISTS idx_users_01 ON users(delete_scheduled_datetime) WHERE delete_scheduled_datetime IS NOT NULL;
+
+-- Deleting a user must never take a whole community with it
+ALTER TABLE servers DROP CONSTRAINT IF EXISTS servers_owner_id_fkey;
+ALTER TABLE servers ADD CONSTRAINT servers_owner_id_fkey FOREIGN KEY (owner_id) REFERENCES users(id) ON DELETE RESTRICT;
+
+-- Comments of deleted users stay in the thread without an author
+ALTER TABLE server_post_comments ALTER COLUMN author_id DROP NOT NULL;
+ALTER TABLE server_post_comments DROP CONSTRAINT IF EXISTS server_post_comments_author_id_fkey;
+ALTER TABLE server_post_comments ADD CONSTRAINT server_post_comments_author_id_fkey FOREIGN KEY (author_id) REFERENCES users(id) ON DELETE SET NULL;
@@ -0,0 +1,17 @@
+-- Keep only the newest avatar row of every user before enforcing one avatar per user
+DELETE FROM user_avatar_images A
+USING user_avatar_images B
+WHERE A.user_id = B.user_id
+  AND (A.create_datetime, A.id) < (B.create_datetime, B.id);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_user_avatar_images_uk_01 ON user_avatar_images(user_id);
+
+UPDATE users A
+SET avatar_image_id = B.id
+FROM user_avatar_images B
+WHERE B.user_id = A.id;
+
+UPDATE users A
+SET avatar_image_id = NULL
+WHERE A.avatar_image_id IS NOT NULL
+  AND NOT EXISTS (SELECT 1 FROM user_avatar_images B WHERE B.id = A.avatar_image_id);
@@ -0,0 +1 @@
+UPDATE server_roles SET permissions = '{}'::jsonb WHERE name = 'Member' AND permissions = '{"create_invite": true, "create_post": true}'::jsonb;
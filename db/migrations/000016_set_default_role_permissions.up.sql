@@ -0,0 +1,4 @@
+-- Roles created before permissions were enforced carry an empty document,
+-- give them what every member could already do
+UPDATE server_roles SET permissions = '{"create_invite": true, "create_post": true}'::jsonb WHERE name = 'Member' AND permissions = '{}'::jsonb;
+UPDATE server_roles SET permissions = '{"*": true}'::jsonb WHERE name = 'Owner';
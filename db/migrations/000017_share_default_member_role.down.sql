@@ -0,0 +1,2 @@
+-- Shared default roles are kept, the previous layout can not be restored
+SELECT 1;
@@ -0,0 +1,29 @@
+-- Fold every per-member copy of the default role into the oldest one of its server
+WITH ranked AS (
+    SELECT id, server_id,
+           FIRST_VALUE(id) OVER (PARTITION BY server_id ORDER BY create_datetime, id) AS keep_id
+    FROM server_roles
+    WHERE LOWER(name) = 'member'
+)
+UPDATE server_members A
+SET server_role_id = ranked.keep_id
+FROM ranked
+WHERE A.server_role_id = ranked.id AND ranked.id <> ranked.keep_id;
+
+WITH ranked AS (
+    SELECT id,
+           FIRST_VALUE(id) OVER (PARTITION BY server_id ORDER BY create_datetime, id) AS keep_id
+    FROM server_roles
+    WHERE LOWER(name) = 'member'
+)
+DELETE FROM server_roles A
+USING ranked
+WHERE A.id = ranked.id AND ranked.id <> ranked.keep_id;
+
+UPDATE server_roles SET name = 'Member' WHERE LOWER(name) = 'member' AND name <> 'Member';
+
+-- Every server gets its default role up front
+INSERT INTO server_roles (id, server_id, name, permissions, create_datetime, update_datetime, create_user_id, update_user_id)
+SELECT gen_random_uuid(), A.id, 'Member', '{"create_invite": true, "create_post": true}'::jsonb, NOW(), NOW(), A.owner_id, A.owner_id
+FROM servers A
+WHERE NOT EXISTS (SELECT 1 FROM server_roles B WHERE B.server_id = A.id AND B.name = 'Member');
@@ -0,0 +1,3 @@
+ALTER TABLE server_invites DROP CONSTRAINT IF EXISTS server_invites_server_id_fkey;
+
+DROP TABLE IF EXISTS server_invite_uses;
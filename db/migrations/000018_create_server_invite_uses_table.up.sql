@@ -0,0 +1,20 @@
+CREATE TABLE IF NOT EXISTS server_invite_uses (
+    id          uuid PRIMARY KEY,
+    invite_id   uuid NOT NULL,
+    server_id   uuid NOT NULL,
+    user_id     uuid NOT NULL,
+    used_datetime timestamptz NOT NULL,
+    create_user_id uuid NOT NULL,
+    update_user_id uuid NOT NULL,
+    create_datetime timestamptz NOT NULL,
+    update_datetime timestamptz NOT NULL,
+    FOREIGN KEY (invite_id) REFERENCES server_invites(id) ON DELETE CASCADE,
+    FOREIGN KEY (server_id) REFERENCES servers(id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_server_invite_uses_01 ON server_invite_uses(invite_id);
+
+-- Invites of a deleted server must not be usable anymore
+DELETE FROM server_invites A WHERE NOT EXISTS (SELECT 1 FROM servers B WHERE B.id = A.server_id);
+ALTER TABLE server_invites ADD CONSTRAINT server_invites_server_id_fkey FOREIGN KEY (server_id) REFERENCES servers(id) ON DELETE CASCADE;
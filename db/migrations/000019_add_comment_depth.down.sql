@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_server_post_comments_02;
+DROP INDEX IF EXISTS idx_server_post_comments_01;
+
+ALTER TABLE server_post_comments DROP COLUMN IF EXISTS depth;
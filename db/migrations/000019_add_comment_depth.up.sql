@@ -0,0 +1,13 @@
+ALTER TABLE server_post_comments ADD COLUMN IF NOT EXISTS depth smallint NOT NULL DEFAULT 0;
+
+-- Top level comments keep depth 0, every reply sits one level below its parent
+WITH RECURSIVE tree AS (
+    SELECT id, 0 AS depth FROM server_post_comments WHERE parent_id IS NULL
+    UNION ALL
+    SELECT A.id, tree.depth + 1 FROM server_post_comments A
+    INNER JOIN tree ON A.parent_id = tree.id
+)
+UPDATE server_post_comments A SET depth = tree.depth FROM tree WHERE A.id = tree.id AND tree.depth > 0;
+
+CREATE INDEX IF NOT EXISTS idx_server_post_comments_01 ON server_post_comments(post_id, create_datetime) WHERE parent_id IS NULL;
+CREATE INDEX IF NOT EXISTS idx_server_post_comments_02 ON server_post_comments(parent_id, create_datetime);
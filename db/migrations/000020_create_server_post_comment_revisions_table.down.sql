@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS server_post_comment_revisions;
+
+ALTER TABLE server_post_comments DROP COLUMN IF EXISTS edited_datetime;
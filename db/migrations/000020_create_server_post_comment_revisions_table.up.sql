@@ -0,0 +1,16 @@
+ALTER TABLE server_post_comments ADD COLUMN IF NOT EXISTS edited_datetime timestamptz NULL;
+
+CREATE TABLE IF NOT EXISTS server_post_comment_revisions (
+    id          uuid PRIMARY KEY,
+    comment_id  uuid NOT NULL,
+    content     text NOT NULL,
+    -- When the saved content was written, the creation or the previous edit
+    written_datetime timestamptz NOT NULL,
+    create_user_id uuid NOT NULL,
+    update_user_id uuid NOT NULL,
+    create_datetime timestamptz NOT NULL,
+    update_datetime timestamptz NOT NULL,
+    FOREIGN KEY (comment_id) REFERENCES server_post_comments(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_server_post_comment_revisions_01 ON server_post_comment_revisions(comment_id, create_datetime);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS server_post_comment_reactions;
+DROP TABLE IF EXISTS server_post_reactions;
@@ -0,0 +1,27 @@
+CREATE TABLE IF NOT EXISTS server_post_reactions (
+    id          uuid PRIMARY KEY,
+    post_id     uuid NOT NULL,
+    user_id     uuid NOT NULL,
+    reaction    varchar(32) NOT NULL,
+    create_user_id uuid NOT NULL,
+    update_user_id uuid NOT NULL,
+    create_datetime timestamptz NOT NULL,
+    update_datetime timestamptz NOT NULL,
+    FOREIGN KEY (post_id) REFERENCES server_posts(id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    UNIQUE (post_id, user_id)
+);
+
+CREATE TABLE IF NOT EXISTS server_post_comment_reactions (
+    id          uuid PRIMARY KEY,
+    comment_id  uuid NOT NULL,
+    user_id     uuid NOT NULL,
+    reaction    varchar(32) NOT NULL,
+    create_user_id uuid NOT NULL,
+    update_user_id uuid NOT NULL,
+    create_datetime timestamptz NOT NULL,
+    update_datetime timestamptz NOT NULL,
+    FOREIGN KEY (comment_id) REFERENCES server_post_comments(id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    UNIQUE (comment_id, user_id)
+);
@@ -0,0 +1,14 @@
+ALTER TABLE server_posts ADD COLUMN IF NOT EXISTS post_image_id uuid NULL;
+
+UPDATE server_posts A SET post_image_id = B.post_image_id
+FROM server_post_media B
+WHERE B.post_id = A.id AND B.position = 0;
+
+-- Only the first image of a carousel fits the single image column, text only posts can not be kept
+DELETE FROM server_post_images WHERE id IN (SELECT post_image_id FROM server_post_media WHERE position > 0);
+DELETE FROM server_posts WHERE post_image_id IS NULL;
+
+ALTER TABLE server_posts ALTER COLUMN post_image_id SET NOT NULL;
+ALTER TABLE server_posts ADD CONSTRAINT server_posts_post_image_id_fkey FOREIGN KEY (post_image_id) REFERENCES server_post_images(id) ON DELETE CASCADE;
+
+DROP TABLE IF EXISTS server_post_media;
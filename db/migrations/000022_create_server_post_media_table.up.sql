@@ -0,0 +1,19 @@
+CREATE TABLE IF NOT EXISTS server_post_media (
+    id              uuid PRIMARY KEY,
+    post_id         uuid NOT NULL,
+    post_image_id   uuid NOT NULL,
+    position        smallint NOT NULL,
+    create_user_id uuid NOT NULL,
+    update_user_id uuid NOT NULL,
+    create_datetime timestamptz NOT NULL,
+    update_datetime timestamptz NOT NULL,
+    FOREIGN KEY (post_id) REFERENCES server_posts(id) ON DELETE CASCADE,
+    FOREIGN KEY (post_image_id) REFERENCES server_post_images(id) ON DELETE CASCADE,
+    UNIQUE (post_id, position)
+);
+
+INSERT INTO server_post_media (id, post_id, post_image_id, position, create_user_id, update_user_id, create_datetime, update_datetime)
+SELECT gen_random_uuid(), id, post_image_id, 0, create_user_id, update_user_id, create_datetime, update_datetime
+FROM server_posts;
+
+ALTER TABLE server_posts DROP COLUMN IF EXISTS post_image_id;
@@ -0,0 +1,10 @@
+-- Point every image back to a converted copy, the originals and the other variants are left in storage
+UPDATE server_post_images SET object_key = variants->>'medium' WHERE variants ? 'medium';
+UPDATE server_banner_images SET object_key = variants->>'medium' WHERE variants ? 'medium';
+UPDATE server_avatar_images SET object_key = variants->>'medium' WHERE variants ? 'medium';
+UPDATE user_avatar_images SET object_key = variants->>'medium' WHERE variants ? 'medium';
+
+ALTER TABLE server_post_images DROP COLUMN IF EXISTS variants;
+ALTER TABLE server_banner_images DROP COLUMN IF EXISTS variants;
+ALTER TABLE server_avatar_images DROP COLUMN IF EXISTS variants;
+ALTER TABLE user_avatar_images DROP COLUMN IF EXISTS variants;
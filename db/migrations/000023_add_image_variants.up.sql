@@ -0,0 +1,11 @@
+-- object_key keeps pointing to the original upload, variants maps each resized copy to its object key.
+-- Images stored before variants existed only have their single converted object, it becomes their medium variant
+ALTER TABLE user_avatar_images ADD COLUMN IF NOT EXISTS variants jsonb NOT NULL DEFAULT '{}';
+ALTER TABLE server_avatar_images ADD COLUMN IF NOT EXISTS variants jsonb NOT NULL DEFAULT '{}';
+ALTER TABLE server_banner_images ADD COLUMN IF NOT EXISTS variants jsonb NOT NULL DEFAULT '{}';
+ALTER TABLE server_post_images ADD COLUMN IF NOT EXISTS variants jsonb NOT NULL DEFAULT '{}';
+
+UPDATE user_avatar_images SET variants = jsonb_build_object('medium', object_key);
+UPDATE server_avatar_images SET variants = jsonb_build_object('medium', object_key);
+UPDATE server_banner_images SET variants = jsonb_build_object('medium', object_key);
+UPDATE server_post_images SET variants = jsonb_build_object('medium', object_key);
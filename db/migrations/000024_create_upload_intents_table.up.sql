@@ -0,0 +1,19 @@
+-- user_id has no foreign key on purpose, an intent must outlive its user until the expired intents
+-- are collected, the row is the only pointer to the object uploaded under it
+CREATE TABLE IF NOT EXISTS upload_intents (
+    id               uuid PRIMARY KEY,
+    user_id          uuid NOT NULL,
+    purpose          varchar(20) NOT NULL,
+    target_id        uuid NULL,
+    bucket           varchar(50) NOT NULL,
+    object_key       varchar(255) NOT NULL,
+    content_type     varchar(50) NOT NULL,
+    expires_datetime timestamptz NOT NULL,
+    create_user_id uuid NOT NULL,
+    update_user_id uuid NOT NULL,
+    create_datetime timestamptz NOT NULL,
+    update_datetime timestamptz NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_upload_intents_01 ON upload_intents(user_id);
+CREATE INDEX IF NOT EXISTS idx_upload_intents_02 ON upload_intents(expires_datetime);
@@ -0,0 +1,23 @@
+-- actor_id is kept as NULL when the actor deletes the account, the notification stays in the inbox
+CREATE TABLE IF NOT EXISTS notifications (
+    id              uuid PRIMARY KEY,
+    user_id         uuid NOT NULL,
+    actor_id        uuid NULL,
+    type            varchar(30) NOT NULL,
+    server_id       uuid NULL,
+    post_id         uuid NULL,
+    comment_id      uuid NULL,
+    read_datetime   timestamptz NULL,
+    create_user_id uuid NOT NULL,
+    update_user_id uuid NOT NULL,
+    create_datetime timestamptz NOT NULL,
+    update_datetime timestamptz NOT NULL,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (actor_id) REFERENCES users(id) ON DELETE SET NULL,
+    FOREIGN KEY (server_id) REFERENCES servers(id) ON DELETE CASCADE,
+    FOREIGN KEY (post_id) REFERENCES server_posts(id) ON DELETE CASCADE,
+    FOREIGN KEY (comment_id) REFERENCES server_post_comments(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_notifications_01 ON notifications(user_id, create_datetime DESC, id DESC);
+CREATE INDEX IF NOT EXISTS idx_notifications_02 ON notifications(user_id) WHERE read_datetime IS NULL;
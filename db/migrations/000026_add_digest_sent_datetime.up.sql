@@ -0,0 +1,2 @@
+-- The end of the window covered by the last digest, NULL means the first digest starts at the signup
+ALTER TABLE users ADD COLUMN IF NOT EXISTS digest_sent_datetime timestamptz NULL;
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_server_post_likes_01;
+DROP INDEX IF EXISTS idx_server_posts_01;
+DROP INDEX IF EXISTS idx_server_members_01;
@@ -0,0 +1,4 @@
+-- The feed reads the newest posts of every server of a member, each server through its own index range
+CREATE INDEX IF NOT EXISTS idx_server_members_01 ON server_members(user_id, status);
+CREATE INDEX IF NOT EXISTS idx_server_posts_01 ON server_posts(server_id, create_datetime DESC, id DESC);
+CREATE INDEX IF NOT EXISTS idx_server_post_likes_01 ON server_post_likes(post_id, user_id);
@@ -1,6 +1,8 @@
 package config
 
 import (
+	"context"
+
 	http "github.com/ferdian3456/virdanproject/internal/delivery/http"
 	"github.com/ferdian3456/virdanproject/internal/delivery/http/middleware"
 	"github.com/ferdian3456/virdanproject/internal/delivery/http/route"
@@ -26,25 +28,46 @@ type ServerConfig struct {
 
 func Server(config *ServerConfig) {
 	serverRepository := repository.NewServerRepository(config.Log, config.DB, config.DBCache, config.MinIO)
-	serverUsecase := usecase.NewServerUsecase(serverRepository, config.DB, config.Log, config.Config)
+	userRepository := repository.NewUserRepository(config.Log, config.DB, config.DBCache, config.MinIO)
+	eventRepository := repository.NewEventRepository(config.Log, config.DB, config.DBCache, config.MinIO)
+	notificationRepository := repository.NewNotificationRepository(config.Log, config.DB, config.DBCache, config.MinIO)
+
+	serverUsecase := usecase.NewServerUsecase(serverRepository, userRepository, eventRepository, notificationRepository, config.DB, config.Log, config.Config)
 	serverController := http.NewServerController(serverUsecase, config.Log, config.Config)
 
-	userRepository := repository.NewUserRepository(config.Log, config.DB, config.DBCache, config.MinIO)
 	userUsecase := usecase.NewUserUsecase(userRepository, serverRepository, config.DB, config.Log, config.Config)
 	userController := http.NewUserController(userUsecase, config.Log, config.Config)
 
 	postRepository := repository.NewPostRepository(config.Log, config.DB, config.DBCache, config.MinIO)
-	postUsecase := usecase.NewPostUsecase(postRepository, config.DB, config.Log, config.Config)
+	postUsecase := usecase.NewPostUsecase(postRepository, serverRepository, eventRepository, notificationRepository, config.DB, config.Log, config.Config)
 	postController := http.NewPostController(postUsecase, config.Log, config.Config)
 
+	uploadRepository := repository.NewUploadRepository(config.Log, config.DB, config.DBCache, config.MinIO)
+	uploadUsecase := usecase.NewUploadUsecase(uploadRepository, userUsecase, serverUsecase, postUsecase, config.Log, config.Config)
+	uploadController := http.NewUploadController(uploadUsecase, config.Log, config.Config)
+
+	// The subscription lives as long as the process, every instance pushes the events of all instances
+	eventUsecase := usecase.NewEventUsecase(eventRepository, config.Log, config.Config)
+	err := eventUsecase.Start(context.Background())
+	if err != nil {
+		config.Log.Fatal("failed to subscribe to server events", zap.Error(err))
+	}
+	eventController := http.NewEventController(eventUsecase, userUsecase, config.Log, config.Config)
+
+	notificationUsecase := usecase.NewNotificationUsecase(notificationRepository, config.Log, config.Config)
+	notificationController := http.NewNotificationController(notificationUsecase, config.Log, config.Config)
+
 	authMiddleware := middleware.NewAuthMiddleware(config.Router, config.Log, config.Config, userUsecase)
 
 	routeConfig := route.RouteConfig{
-		App:              config.Router,
-		UserController:   userController,
-		ServerController: serverController,
-		PostController:   postController,
-		AuthMiddleware:   authMiddleware,
+		App:                    config.Router,
+		UserController:         userController,
+		ServerController:       serverController,
+		PostController:         postController,
+		UploadController:       uploadController,
+		EventController:        eventController,
+		NotificationController: notificationController,
+		AuthMiddleware:         authMiddleware,
 	}
 
 	routeConfig.SetupRoute()
@@ -4,6 +4,7 @@ import (
 	"time"
 
 	"github.com/bytedance/sonic"
+	"github.com/ferdian3456/virdanproject/internal/constant"
 	"github.com/gofiber/fiber/v2"
 )
 
@@ -12,7 +13,7 @@ func NewFiber() *fiber.App {
 		//Prefork:               true,
 		Prefork:               false,
 		AppName:               "",
-		BodyLimit:             4 * 1024 * 1024, // 4MB
+		BodyLimit:             constant.MAX_FILE_SIZE + 1024*1024, // one image and the other form fields, bigger uploads go through upload intents
 		ReadBufferSize:        4096,
 		WriteBufferSize:       4096,
 		Concurrency:           256 * 1024,
@@ -2,6 +2,8 @@ package config
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
 
 	"github.com/knadh/koanf/v2"
 	"github.com/minio/minio-go/v7"
@@ -37,9 +39,43 @@ func NewMinIO(config *koanf.Koanf, log *zap.Logger) *minio.Client {
 		log.Info("Successfully created minio bucket")
 	}
 
+	err = SetBucketPolicy(ctx, minioClient, bucketName)
+	if err != nil {
+		log.Fatal("failed to set minio bucket policy", zap.Error(err))
+	}
+
 	return minioClient
 }
 
+// publicObjectPrefixes can be read without credentials so their urls stay cacheable by a CDN. Everything else,
+// such as the media of private servers and pending uploads, is only reachable through presigned urls
+var publicObjectPrefixes = []string{"server/", "user/"}
+
+// SetBucketPolicy lets anyone read the objects under the public prefixes of the bucket
+func SetBucketPolicy(ctx context.Context, minioClient *minio.Client, bucketName string) error {
+	resources := make([]string, 0, len(publicObjectPrefixes))
+	for _, prefix := range publicObjectPrefixes {
+		resources = append(resources, fmt.Sprintf("arn:aws:s3:::%s/%s*", bucketName, prefix))
+	}
+
+	policy, err := json.Marshal(map[string]interface{}{
+		"Version": "2012-10-17",
+		"Statement": []map[string]interface{}{
+			{
+				"Effect":    "Allow",
+				"Principal": map[string][]string{"AWS": {"*"}},
+				"Action":    []string{"s3:GetObject"},
+				"Resource":  resources,
+			},
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	return minioClient.SetBucketPolicy(ctx, bucketName, string(policy))
+}
+
 // Optimized upload with parallel processing
 //func (m *MinIOClient) UploadObject(ctx context.Context, bucketName, objectName string, data []byte) error {
 //	// Use context with timeout for extreme performance
@@ -0,0 +1,31 @@
+package config
+
+import (
+	"context"
+	"time"
+
+	"github.com/ferdian3456/virdanproject/internal/repository"
+	"github.com/ferdian3456/virdanproject/internal/usecase"
+
+	"go.uber.org/zap"
+)
+
+// CollectOrphanedObjects runs the object garbage collector once and logs what it found
+func CollectOrphanedObjects(ctx context.Context, config *ServerConfig, dryRun bool, minAge time.Duration) error {
+	objectRepository := repository.NewObjectRepository(config.Log, config.DB, config.DBCache, config.MinIO)
+	objectUsecase := usecase.NewObjectUsecase(objectRepository, config.Log, config.Config)
+
+	report, err := objectUsecase.CollectOrphanedObjects(ctx, dryRun, minAge)
+	if err != nil {
+		return err
+	}
+
+	config.Log.Info("orphaned object collection finished",
+		zap.Bool("dryRun", report.DryRun),
+		zap.Int("scanned", report.Scanned),
+		zap.Int("orphaned", len(report.Orphaned)),
+		zap.Int64("orphanedSize", report.OrphanedSize),
+		zap.Int("removed", report.Removed))
+
+	return nil
+}
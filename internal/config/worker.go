@@ -0,0 +1,72 @@
+package config
+
+import (
+	"context"
+	"time"
+
+	"github.com/ferdian3456/virdanproject/internal/constant"
+	"github.com/ferdian3456/virdanproject/internal/repository"
+	"github.com/ferdian3456/virdanproject/internal/usecase"
+
+	"go.uber.org/zap"
+)
+
+// Worker runs the periodic background jobs until ctx is cancelled
+func Worker(ctx context.Context, config *ServerConfig) {
+	serverRepository := repository.NewServerRepository(config.Log, config.DB, config.DBCache, config.MinIO)
+	userRepository := repository.NewUserRepository(config.Log, config.DB, config.DBCache, config.MinIO)
+	eventRepository := repository.NewEventRepository(config.Log, config.DB, config.DBCache, config.MinIO)
+	notificationRepository := repository.NewNotificationRepository(config.Log, config.DB, config.DBCache, config.MinIO)
+	userUsecase := usecase.NewUserUsecase(userRepository, serverRepository, config.DB, config.Log, config.Config)
+	serverUsecase := usecase.NewServerUsecase(serverRepository, userRepository, eventRepository, notificationRepository, config.DB, config.Log, config.Config)
+
+	postRepository := repository.NewPostRepository(config.Log, config.DB, config.DBCache, config.MinIO)
+	postUsecase := usecase.NewPostUsecase(postRepository, serverRepository, eventRepository, notificationRepository, config.DB, config.Log, config.Config)
+
+	uploadRepository := repository.NewUploadRepository(config.Log, config.DB, config.DBCache, config.MinIO)
+	uploadUsecase := usecase.NewUploadUsecase(uploadRepository, userUsecase, serverUsecase, postUsecase, config.Log, config.Config)
+
+	digestRepository := repository.NewDigestRepository(config.Log, config.DB, config.DBCache, config.MinIO)
+	digestUsecase := usecase.NewDigestUsecase(digestRepository, config.Log, config.Config)
+
+	accountPurgeTicker := time.NewTicker(time.Hour)
+	defer accountPurgeTicker.Stop()
+
+	uploadPurgeTicker := time.NewTicker(constant.UPLOAD_INTENT_EXPIRY)
+	defer uploadPurgeTicker.Stop()
+
+	mediaRelocationTicker := time.NewTicker(time.Hour)
+	defer mediaRelocationTicker.Stop()
+
+	// Digests are due at different times per user, the hourly run picks up whoever crossed their cutoff
+	digestTicker := time.NewTicker(time.Hour)
+	defer digestTicker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			config.Log.Info("background worker stopped")
+			return
+		case <-accountPurgeTicker.C:
+			err := userUsecase.PurgeDeletedAccounts(ctx)
+			if err != nil {
+				config.Log.Error("failed to purge deleted accounts", zap.Error(err))
+			}
+		case <-uploadPurgeTicker.C:
+			err := uploadUsecase.PurgeExpiredUploadIntents(ctx)
+			if err != nil {
+				config.Log.Error("failed to purge expired upload intents", zap.Error(err))
+			}
+		case <-mediaRelocationTicker.C:
+			err := serverUsecase.RelocatePostImages(ctx, nil)
+			if err != nil {
+				config.Log.Error("failed to relocate post images", zap.Error(err))
+			}
+		case <-digestTicker.C:
+			_, err := digestUsecase.SendDigests(ctx, time.Now().UTC())
+			if err != nil {
+				config.Log.Error("failed to send digests", zap.Error(err))
+			}
+		}
+	}
+}
@@ -1,5 +1,17 @@
 package constant
 
+import "time"
+
 const MAX_FILE_SIZE = 5 * 1024 * 1024 // 5MB
 const DEFAULT_LIMIT = 10
 const MAX_LIMIT = 20
+const PASSWORD_RESET_MAX_ATTEMPTS = 5
+const ACCOUNT_DELETION_GRACE_PERIOD = 30 * 24 * time.Hour
+const DEFAULT_COMMENT_MAX_DEPTH = 3
+const DEFAULT_REPLY_PREVIEW_LIMIT = 3
+const MAX_POST_MEDIA = 10
+const UPLOAD_INTENT_EXPIRY = 15 * time.Minute
+const MAX_PENDING_UPLOADS = 20
+const PRIVATE_MEDIA_URL_EXPIRY = 15 * time.Minute
+const ORPHAN_OBJECT_MIN_AGE = 24 * time.Hour
+const SERVER_EVENT_CHANNEL = "server_events"
@@ -0,0 +1,135 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/ferdian3456/virdanproject/internal/constant"
+	"github.com/ferdian3456/virdanproject/internal/model"
+	"github.com/ferdian3456/virdanproject/internal/usecase"
+	"github.com/ferdian3456/virdanproject/internal/util"
+	"github.com/gofiber/contrib/websocket"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/knadh/koanf/v2"
+	"go.uber.org/zap"
+)
+
+// eventPingInterval keeps idle connections alive through proxies that close silent sockets, the session of
+// the connection is checked again on every ping
+const eventPingInterval = 30 * time.Second
+
+type EventController struct {
+	EventUsecase *usecase.EventUsecase
+	UserUsecase  *usecase.UserUsecase
+	Log          *zap.Logger
+	Config       *koanf.Koanf
+	PingInterval time.Duration
+}
+
+func NewEventController(eventUsecase *usecase.EventUsecase, userUsecase *usecase.UserUsecase, zap *zap.Logger, koanf *koanf.Koanf) *EventController {
+	return &EventController{
+		EventUsecase: eventUsecase,
+		UserUsecase:  userUsecase,
+		Log:          zap,
+		Config:       koanf,
+		PingInterval: eventPingInterval,
+	}
+}
+
+// Stream pushes the events of the servers the user is a member of until the connection closes
+func (controller *EventController) Stream() fiber.Handler {
+	return websocket.New(func(conn *websocket.Conn) {
+		userId := conn.Locals("userId").(uuid.UUID)
+		sessionId := conn.Locals("sessionId").(uuid.UUID)
+		accessToken := conn.Locals("accessToken").(string)
+
+		client, err := controller.EventUsecase.Register(context.Background(), userId)
+		if err != nil {
+			controller.Log.Error("failed to register event connection", zap.String("userId", userId.String()), zap.Error(err))
+			_ = conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseInternalServerErr, ""))
+			return
+		}
+		defer controller.EventUsecase.Unregister(client)
+
+		connected, err := controller.EventUsecase.ConnectedEvent()
+		if err != nil {
+			controller.Log.Error("failed to encode connected event", zap.Error(err))
+			return
+		}
+
+		err = conn.WriteMessage(websocket.TextMessage, connected)
+		if err != nil {
+			return
+		}
+
+		// Clients only listen, reading is still needed to notice the close and answer pings
+		closed := make(chan struct{})
+		go func() {
+			defer close(closed)
+			for {
+				_, _, err := conn.ReadMessage()
+				if err != nil {
+					return
+				}
+			}
+		}()
+
+		pingTicker := time.NewTicker(controller.PingInterval)
+		defer pingTicker.Stop()
+
+		for {
+			select {
+			case <-closed:
+				return
+			case event, ok := <-client.Events:
+				if !ok {
+					return
+				}
+
+				err = conn.WriteMessage(websocket.TextMessage, event)
+				if err != nil {
+					return
+				}
+			case <-pingTicker.C:
+				// Logout, a revoked session or an expired token end the connection like they end requests
+				err = controller.checkSession(userId, sessionId, accessToken)
+				if err != nil {
+					closeCode := websocket.ClosePolicyViolation
+					var validationErr *model.ValidationError
+					if !errors.As(err, &validationErr) {
+						controller.Log.Error("failed to check event connection session", zap.String("userId", userId.String()), zap.Error(err))
+						closeCode = websocket.CloseInternalServerErr
+					}
+
+					_ = conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(closeCode, ""))
+					return
+				}
+
+				err = conn.WriteMessage(websocket.PingMessage, nil)
+				if err != nil {
+					return
+				}
+			}
+		}
+	})
+}
+
+// checkSession runs the checks of ProtectedRoute again, the token must still be valid and its session alive
+func (controller *EventController) checkSession(userId uuid.UUID, sessionId uuid.UUID, accessToken string) error {
+	tokenString, tokenUserId, tokenSessionId, err := util.ValidateAccessToken(accessToken, controller.Log, controller.Config.String("JWT_SECRET_KEY"))
+	if err != nil {
+		return err
+	}
+
+	if tokenUserId != userId || tokenSessionId != sessionId {
+		return &model.ValidationError{
+			Code:    constant.ERR_UNATHORIZED_ERROR,
+			Message: "Authentication token is invalid",
+			Param:   "accessToken",
+		}
+	}
+
+	return controller.UserUsecase.CheckAccessToken(context.Background(), userId, sessionId, tokenString)
+}
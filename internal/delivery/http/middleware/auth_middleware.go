@@ -3,10 +3,12 @@ package middleware
 import (
 	"errors"
 
+	"github.com/ferdian3456/virdanproject/internal/constant"
 	"github.com/ferdian3456/virdanproject/internal/model"
 	"github.com/ferdian3456/virdanproject/internal/usecase"
 	"github.com/ferdian3456/virdanproject/internal/util"
 
+	"github.com/gofiber/contrib/websocket"
 	"github.com/gofiber/fiber/v2"
 	"github.com/knadh/koanf/v2"
 	"go.uber.org/zap"
@@ -33,7 +35,7 @@ func (middleware *AuthMiddleware) ProtectedRoute() fiber.Handler {
 		var validationErr *model.ValidationError
 
 		accessToken := ctx.Get("Authorization")
-		tokenString, userId, err := util.ValidateAccessToken(accessToken, middleware.Log, middleware.Config.String("JWT_SECRET_KEY"))
+		tokenString, userId, sessionId, err := util.ValidateAccessToken(accessToken, middleware.Log, middleware.Config.String("JWT_SECRET_KEY"))
 		if err != nil {
 			if errors.As(err, &validationErr) {
 				return util.SendErrorResponseNotFound(ctx, err)
@@ -42,7 +44,7 @@ func (middleware *AuthMiddleware) ProtectedRoute() fiber.Handler {
 			return util.SendErrorResponseInternalServer(ctx, middleware.Log, err)
 		}
 
-		err = middleware.UserUsecase.GetAccessToken(ctx, userId, tokenString)
+		err = middleware.UserUsecase.GetAccessToken(ctx, userId, sessionId, tokenString)
 		if err != nil {
 			if errors.As(err, &validationErr) {
 				return util.SendErrorResponseNotFound(ctx, err)
@@ -52,9 +54,34 @@ func (middleware *AuthMiddleware) ProtectedRoute() fiber.Handler {
 		}
 
 		ctx.Locals("userId", userId)
+		ctx.Locals("sessionId", sessionId)
 
 		middleware.Log.Debug("middleware here", zap.String("userId", userId.String()))
 
 		return ctx.Next()
 	}
 }
+
+// WebSocketRoute authenticates a WebSocket handshake like ProtectedRoute. Browsers can not set headers on a
+// handshake, so the access token may also come from the accessToken query parameter
+func (middleware *AuthMiddleware) WebSocketRoute() fiber.Handler {
+	protectedRoute := middleware.ProtectedRoute()
+
+	return func(ctx *fiber.Ctx) error {
+		if !websocket.IsWebSocketUpgrade(ctx) {
+			return util.SendErrorResponse(ctx, &model.ValidationError{
+				Code:    constant.ERR_VALIDATION_CODE,
+				Message: "WebSocket upgrade required",
+			})
+		}
+
+		if ctx.Get("Authorization") == "" && ctx.Query("accessToken") != "" {
+			ctx.Request().Header.Set("Authorization", util.BearerPrefix+ctx.Query("accessToken"))
+		}
+
+		// The connection outlives the handshake, it keeps the token to re-check the session while open
+		ctx.Locals("accessToken", ctx.Get("Authorization"))
+
+		return protectedRoute(ctx)
+	}
+}
@@ -0,0 +1,156 @@
+package http
+
+import (
+	"errors"
+
+	"github.com/ferdian3456/virdanproject/internal/constant"
+	"github.com/ferdian3456/virdanproject/internal/model"
+	"github.com/ferdian3456/virdanproject/internal/usecase"
+	"github.com/ferdian3456/virdanproject/internal/util"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/knadh/koanf/v2"
+	"go.uber.org/zap"
+)
+
+type NotificationController struct {
+	NotificationUsecase *usecase.NotificationUsecase
+	Log                 *zap.Logger
+	Config              *koanf.Koanf
+}
+
+func NewNotificationController(notificationUsecase *usecase.NotificationUsecase, zap *zap.Logger, koanf *koanf.Koanf) *NotificationController {
+	return &NotificationController{
+		NotificationUsecase: notificationUsecase,
+		Log:                 zap,
+		Config:              koanf,
+	}
+}
+
+func (controller *NotificationController) GetNotifications(ctx *fiber.Ctx) error {
+	userId := ctx.Locals("userId").(uuid.UUID)
+
+	var validationErr *model.ValidationError
+
+	response, err := controller.NotificationUsecase.GetNotifications(ctx, userId)
+	if err != nil {
+		if errors.As(err, &validationErr) {
+			return util.SendErrorResponseNotFound(ctx, err)
+		}
+
+		return util.SendErrorResponseInternalServer(ctx, controller.Log, err)
+	}
+
+	return util.SendSuccessResponseWithData(ctx, response)
+}
+
+func (controller *NotificationController) GetUnreadCount(ctx *fiber.Ctx) error {
+	userId := ctx.Locals("userId").(uuid.UUID)
+
+	response, err := controller.NotificationUsecase.GetUnreadCount(ctx, userId)
+	if err != nil {
+		return util.SendErrorResponseInternalServer(ctx, controller.Log, err)
+	}
+
+	return util.SendSuccessResponseWithData(ctx, response)
+}
+
+func (controller *NotificationController) MarkNotificationRead(ctx *fiber.Ctx) error {
+	userId := ctx.Locals("userId").(uuid.UUID)
+	notificationId := ctx.Params("notificationId")
+
+	var validationErr *model.ValidationError
+
+	err := controller.NotificationUsecase.MarkNotificationRead(ctx, userId, notificationId)
+	if err != nil {
+		if errors.As(err, &validationErr) {
+			return util.SendErrorResponseNotFound(ctx, err)
+		}
+
+		return util.SendErrorResponseInternalServer(ctx, controller.Log, err)
+	}
+
+	return util.SendSuccessResponseNoData(ctx)
+}
+
+func (controller *NotificationController) MarkAllNotificationsRead(ctx *fiber.Ctx) error {
+	userId := ctx.Locals("userId").(uuid.UUID)
+
+	err := controller.NotificationUsecase.MarkAllNotificationsRead(ctx, userId)
+	if err != nil {
+		return util.SendErrorResponseInternalServer(ctx, controller.Log, err)
+	}
+
+	return util.SendSuccessResponseNoData(ctx)
+}
+
+func (controller *NotificationController) GetNotificationSettings(ctx *fiber.Ctx) error {
+	userId := ctx.Locals("userId").(uuid.UUID)
+
+	response, err := controller.NotificationUsecase.GetNotificationSettings(ctx, userId)
+	if err != nil {
+		return util.SendErrorResponseInternalServer(ctx, controller.Log, err)
+	}
+
+	return util.SendSuccessResponseWithData(ctx, response)
+}
+
+func (controller *NotificationController) UpdateNotificationSettings(ctx *fiber.Ctx) error {
+	userId := ctx.Locals("userId").(uuid.UUID)
+
+	var payload model.NotificationSettings
+	err := util.ReadRequestBody(ctx, &payload)
+	if err != nil {
+		return util.SendErrorResponse(ctx, &model.ValidationError{
+			Code:    constant.ERR_INVALID_REQUEST_BODY_ERROR_CODE,
+			Message: constant.ERR_INVALID_REQUEST_BODY_MESSAGE,
+		})
+	}
+
+	var validationErr *model.ValidationError
+
+	response, err := controller.NotificationUsecase.UpdateNotificationSettings(ctx, userId, payload)
+	if err != nil {
+		if errors.As(err, &validationErr) {
+			return util.SendErrorResponseNotFound(ctx, err)
+		}
+
+		return util.SendErrorResponseInternalServer(ctx, controller.Log, err)
+	}
+
+	return util.SendSuccessResponseWithData(ctx, response)
+}
+
+func (controller *NotificationController) GetDigestUnsubscribe(ctx *fiber.Ctx) error {
+	token := ctx.Query("token")
+
+	var validationErr *model.ValidationError
+
+	response, err := controller.NotificationUsecase.GetDigestUnsubscribe(ctx, token)
+	if err != nil {
+		if errors.As(err, &validationErr) {
+			return util.SendErrorResponseNotFound(ctx, err)
+		}
+
+		return util.SendErrorResponseInternalServer(ctx, controller.Log, err)
+	}
+
+	return util.SendSuccessResponseWithData(ctx, response)
+}
+
+func (controller *NotificationController) UnsubscribeDigest(ctx *fiber.Ctx) error {
+	token := ctx.Query("token")
+
+	var validationErr *model.ValidationError
+
+	err := controller.NotificationUsecase.UnsubscribeDigest(ctx, token)
+	if err != nil {
+		if errors.As(err, &validationErr) {
+			return util.SendErrorResponseNotFound(ctx, err)
+		}
+
+		return util.SendErrorResponseInternalServer(ctx, controller.Log, err)
+	}
+
+	return util.SendSuccessResponseNoData(ctx)
+}
@@ -122,6 +122,23 @@ func (controller *PostController) GetServerPosts(ctx *fiber.Ctx) error {
 	return util.SendSuccessResponseWithData(ctx, response)
 }
 
+func (controller *PostController) GetFeed(ctx *fiber.Ctx) error {
+	userId := ctx.Locals("userId").(uuid.UUID)
+
+	var validationErr *model.ValidationError
+
+	response, err := controller.PostUsecase.GetFeed(ctx, userId)
+	if err != nil {
+		if errors.As(err, &validationErr) {
+			return util.SendErrorResponseNotFound(ctx, err)
+		}
+
+		return util.SendErrorResponseInternalServer(ctx, controller.Log, err)
+	}
+
+	return util.SendSuccessResponseWithData(ctx, response)
+}
+
 func (controller *PostController) GetPost(ctx *fiber.Ctx) error {
 	userId := ctx.Locals("userId").(uuid.UUID)
 
@@ -226,6 +243,75 @@ func (controller *PostController) GetComments(ctx *fiber.Ctx) error {
 	return util.SendSuccessResponseWithData(ctx, response)
 }
 
+func (controller *PostController) GetCommentReplies(ctx *fiber.Ctx) error {
+	userId := ctx.Locals("userId").(uuid.UUID)
+
+	postIdParam := ctx.Params("postId")
+	commentIdParam := ctx.Params("commentId")
+
+	var validationErr *model.ValidationError
+
+	response, err := controller.PostUsecase.GetCommentReplies(ctx, postIdParam, commentIdParam, userId)
+	if err != nil {
+		if errors.As(err, &validationErr) {
+			return util.SendErrorResponseNotFound(ctx, err)
+		}
+
+		return util.SendErrorResponseInternalServer(ctx, controller.Log, err)
+	}
+
+	return util.SendSuccessResponseWithData(ctx, response)
+}
+
+func (controller *PostController) UpdateComment(ctx *fiber.Ctx) error {
+	userId := ctx.Locals("userId").(uuid.UUID)
+
+	postIdParam := ctx.Params("postId")
+	commentIdParam := ctx.Params("commentId")
+
+	var payload model.ServerCommentUpdateRequest
+	err := util.ReadRequestBody(ctx, &payload)
+	if err != nil {
+		return util.SendErrorResponse(ctx, &model.ValidationError{
+			Code:    constant.ERR_INVALID_REQUEST_BODY_ERROR_CODE,
+			Message: constant.ERR_INVALID_REQUEST_BODY_MESSAGE,
+		})
+	}
+
+	var validationErr *model.ValidationError
+
+	response, err := controller.PostUsecase.UpdateComment(ctx, postIdParam, commentIdParam, userId, payload)
+	if err != nil {
+		if errors.As(err, &validationErr) {
+			return util.SendErrorResponseNotFound(ctx, err)
+		}
+
+		return util.SendErrorResponseInternalServer(ctx, controller.Log, err)
+	}
+
+	return util.SendSuccessResponseWithData(ctx, response)
+}
+
+func (controller *PostController) GetCommentRevisions(ctx *fiber.Ctx) error {
+	userId := ctx.Locals("userId").(uuid.UUID)
+
+	postIdParam := ctx.Params("postId")
+	commentIdParam := ctx.Params("commentId")
+
+	var validationErr *model.ValidationError
+
+	response, err := controller.PostUsecase.GetCommentRevisions(ctx, postIdParam, commentIdParam, userId)
+	if err != nil {
+		if errors.As(err, &validationErr) {
+			return util.SendErrorResponseNotFound(ctx, err)
+		}
+
+		return util.SendErrorResponseInternalServer(ctx, controller.Log, err)
+	}
+
+	return util.SendSuccessResponseWithData(ctx, response)
+}
+
 func (controller *PostController) DeleteComment(ctx *fiber.Ctx) error {
 	userId := ctx.Locals("userId").(uuid.UUID)
 
@@ -245,3 +331,99 @@ func (controller *PostController) DeleteComment(ctx *fiber.Ctx) error {
 
 	return util.SendSuccessResponseNoData(ctx)
 }
+
+func (controller *PostController) ReactToPost(ctx *fiber.Ctx) error {
+	userId := ctx.Locals("userId").(uuid.UUID)
+
+	postIdParam := ctx.Params("postId")
+
+	var payload model.ReactionRequest
+	err := util.ReadRequestBody(ctx, &payload)
+	if err != nil {
+		return util.SendErrorResponse(ctx, &model.ValidationError{
+			Code:    constant.ERR_INVALID_REQUEST_BODY_ERROR_CODE,
+			Message: constant.ERR_INVALID_REQUEST_BODY_MESSAGE,
+		})
+	}
+
+	var validationErr *model.ValidationError
+
+	response, err := controller.PostUsecase.ReactToPost(ctx, postIdParam, userId, payload)
+	if err != nil {
+		if errors.As(err, &validationErr) {
+			return util.SendErrorResponseNotFound(ctx, err)
+		}
+
+		return util.SendErrorResponseInternalServer(ctx, controller.Log, err)
+	}
+
+	return util.SendSuccessResponseWithData(ctx, response)
+}
+
+func (controller *PostController) RemovePostReaction(ctx *fiber.Ctx) error {
+	userId := ctx.Locals("userId").(uuid.UUID)
+
+	postIdParam := ctx.Params("postId")
+
+	var validationErr *model.ValidationError
+
+	response, err := controller.PostUsecase.RemovePostReaction(ctx, postIdParam, userId)
+	if err != nil {
+		if errors.As(err, &validationErr) {
+			return util.SendErrorResponseNotFound(ctx, err)
+		}
+
+		return util.SendErrorResponseInternalServer(ctx, controller.Log, err)
+	}
+
+	return util.SendSuccessResponseWithData(ctx, response)
+}
+
+func (controller *PostController) ReactToComment(ctx *fiber.Ctx) error {
+	userId := ctx.Locals("userId").(uuid.UUID)
+
+	postIdParam := ctx.Params("postId")
+	commentIdParam := ctx.Params("commentId")
+
+	var payload model.ReactionRequest
+	err := util.ReadRequestBody(ctx, &payload)
+	if err != nil {
+		return util.SendErrorResponse(ctx, &model.ValidationError{
+			Code:    constant.ERR_INVALID_REQUEST_BODY_ERROR_CODE,
+			Message: constant.ERR_INVALID_REQUEST_BODY_MESSAGE,
+		})
+	}
+
+	var validationErr *model.ValidationError
+
+	response, err := controller.PostUsecase.ReactToComment(ctx, postIdParam, commentIdParam, userId, payload)
+	if err != nil {
+		if errors.As(err, &validationErr) {
+			return util.SendErrorResponseNotFound(ctx, err)
+		}
+
+		return util.SendErrorResponseInternalServer(ctx, controller.Log, err)
+	}
+
+	return util.SendSuccessResponseWithData(ctx, response)
+}
+
+func (controller *PostController) RemoveCommentReaction(ctx *fiber.Ctx) error {
+	userId := ctx.Locals("userId").(uuid.UUID)
+
+	postIdParam := ctx.Params("postId")
+	commentIdParam := ctx.Params("commentId")
+
+	var validationErr *model.ValidationError
+
+	response, err := controller.PostUsecase.RemoveCommentReaction(ctx, postIdParam, commentIdParam, userId)
+	if err != nil {
+		if errors.As(err, &validationErr) {
+			return util.SendErrorResponseNotFound(ctx, err)
+		}
+
+		return util.SendErrorResponseInternalServer(ctx, controller.Log, err)
+	}
+
+	return util.SendSuccessResponseWithData(ctx, response)
+}
@@ -8,11 +8,14 @@ import (
 )
 
 type RouteConfig struct {
-	App              *fiber.App
-	AuthMiddleware   *middleware.AuthMiddleware
-	UserController   *http.UserController
-	ServerController *http.ServerController
-	PostController   *http.PostController
+	App                    *fiber.App
+	AuthMiddleware         *middleware.AuthMiddleware
+	UserController         *http.UserController
+	ServerController       *http.ServerController
+	PostController         *http.PostController
+	UploadController       *http.UploadController
+	EventController        *http.EventController
+	NotificationController *http.NotificationController
 }
 
 func (c *RouteConfig) SetupRoute() {
@@ -22,6 +25,8 @@ func (c *RouteConfig) SetupRoute() {
 		return c.JSON(fiber.Map{"status": "ok"})
 	})
 
+	api.Get("/ws", c.AuthMiddleware.WebSocketRoute(), c.EventController.Stream())
+
 	authGroup := api.Group("/auth")
 	authGroup.Post("/signup/start", c.UserController.StartSignup)
 	authGroup.Post("/signup/otp", c.UserController.VerifyOtp)
@@ -30,19 +35,39 @@ func (c *RouteConfig) SetupRoute() {
 	authGroup.Get("/signup/:sessionId/status", c.UserController.GetSignupStatus)
 	//authGroup.Post("/register", c.UserController.Register)
 	authGroup.Post("/login", c.UserController.Login)
-	//authGroup.Post("/refresh", c.UserController.Refresh)
-	//authGroup.Post("/forgot-password", c.UserController.ForgotPassword)
-	//authGroup.Post("/reset-password", c.UserController.ResetPassword)
+	authGroup.Post("/refresh", c.UserController.Refresh)
+	authGroup.Post("/forgot-password", c.UserController.ForgotPassword)
+	authGroup.Post("/reset-password", c.UserController.ResetPassword)
+
+	// Digest emails link here, the signed token replaces the login. GET only confirms, POST unsubscribes and is
+	// also the one-click unsubscribe of mail clients
+	api.Get("/notifications/digest/unsubscribe", c.NotificationController.GetDigestUnsubscribe)
+	api.Post("/notifications/digest/unsubscribe", c.NotificationController.UnsubscribeDigest)
 
 	userGroup := api.Group("/users", c.AuthMiddleware.ProtectedRoute())
 	userGroup.Get("/me", c.UserController.GetUserInfo)
 	userGroup.Post("/logout", c.UserController.Logout)
+	userGroup.Get("/me/sessions", c.UserController.GetSessions)
+	userGroup.Post("/me/sessions/logout-others", c.UserController.LogoutOtherSessions)
+	userGroup.Delete("/me/sessions/:id", c.UserController.DeleteSession)
 	userGroup.Put("/username", c.UserController.UpdateUsername)
 	userGroup.Put("/fullname", c.UserController.UpdateFullname)
 	userGroup.Put("/bio", c.UserController.UpdateBio)
-	//userGroup.Put("/avatar", c.UserController.UpdateAvatar)
-	//userGroup.Patch("/password", c.UserController.ChangePassword)
-	//userGroup.Delete("/account", c.UserController.DeleteAccount)
+	userGroup.Put("/avatar", c.UserController.UpdateAvatar)
+	userGroup.Delete("/avatar", c.UserController.DeleteAvatar)
+	userGroup.Patch("/password", c.UserController.ChangePassword)
+	userGroup.Delete("/account", c.UserController.DeleteAccount)
+	userGroup.Get("/account/export", c.UserController.ExportAccountData)
+	userGroup.Get("/me/notifications", c.NotificationController.GetNotifications)
+	userGroup.Get("/me/notifications/unread-count", c.NotificationController.GetUnreadCount)
+	userGroup.Post("/me/notifications/read-all", c.NotificationController.MarkAllNotificationsRead)
+	userGroup.Get("/me/notifications/settings", c.NotificationController.GetNotificationSettings)
+	userGroup.Put("/me/notifications/settings", c.NotificationController.UpdateNotificationSettings)
+	userGroup.Post("/me/notifications/:notificationId/read", c.NotificationController.MarkNotificationRead)
+
+	// Public server routes must be registered before the protected group, its middleware covers every /servers path
+	serverPublicGroup := api.Group("/servers")
+	serverPublicGroup.Get("/invites/:inviteCode", c.ServerController.GetServerInfoForInvite)
 
 	serverGroup := api.Group("/servers", c.AuthMiddleware.ProtectedRoute())
 
@@ -54,6 +79,8 @@ func (c *RouteConfig) SetupRoute() {
 
 	// Invite and join routes
 	serverGroup.Post("/:serverId/invites", c.ServerController.CreateInviteLink)
+	serverGroup.Get("/:id/invites", c.ServerController.GetServerInvites)
+	serverGroup.Delete("/:id/invites/:code", c.ServerController.RevokeServerInvite)
 	serverGroup.Post("/join", c.ServerController.JoinServerFromInvite)
 	serverGroup.Post("/create", c.ServerController.CreateServer)
 	serverGroup.Get("/", c.ServerController.GetDiscoveryServer)
@@ -70,16 +97,41 @@ func (c *RouteConfig) SetupRoute() {
 	serverGroup.Put("/:id/description", c.ServerController.UpdateServerDescription)
 	serverGroup.Put("/:id/settings", c.ServerController.UpdateServerSettings)
 	serverGroup.Delete("/:id", c.ServerController.DeleteServer)
+	serverGroup.Post("/:id/transfer-ownership", c.ServerController.TransferOwnership)
+
+	// Role routes
+	serverGroup.Get("/:id/roles", c.ServerController.GetServerRoles)
+	serverGroup.Post("/:id/roles", c.ServerController.CreateServerRole)
+	serverGroup.Put("/:id/roles/:roleId", c.ServerController.UpdateServerRole)
+	serverGroup.Delete("/:id/roles/:roleId", c.ServerController.DeleteServerRole)
+	serverGroup.Put("/:id/members/:userId/role", c.ServerController.UpdateServerMemberRole)
+
+	// Member routes
+	serverGroup.Get("/:id/members", c.ServerController.GetServerMembers)
+	serverGroup.Post("/:id/leave", c.ServerController.LeaveServer)
+	serverGroup.Delete("/:id/members/:userId", c.ServerController.KickServerMember)
+	serverGroup.Post("/:id/bans/:userId", c.ServerController.BanServerMember)
+	serverGroup.Delete("/:id/bans/:userId", c.ServerController.UnbanServerMember)
 
 	postGroup := api.Group("/posts", c.AuthMiddleware.ProtectedRoute())
 	postGroup.Get("/:postId", c.PostController.GetPost)
 	// postGroup.Delete("/:postId", c.PostController.DeletePost)
 	postGroup.Post("/:postId/likes", c.PostController.LikePost)
 	postGroup.Delete("/:postId/likes", c.PostController.UnlikePost)
+	postGroup.Put("/:postId/reactions", c.PostController.ReactToPost)
+	postGroup.Delete("/:postId/reactions", c.PostController.RemovePostReaction)
 	postGroup.Post("/:postId/comments", c.PostController.CreateComment)
 	postGroup.Get("/:postId/comments", c.PostController.GetComments)
+	postGroup.Get("/:postId/comments/:commentId/replies", c.PostController.GetCommentReplies)
+	postGroup.Get("/:postId/comments/:commentId/revisions", c.PostController.GetCommentRevisions)
+	postGroup.Put("/:postId/comments/:commentId", c.PostController.UpdateComment)
 	postGroup.Delete("/:postId/comments/:commentId", c.PostController.DeleteComment)
+	postGroup.Put("/:postId/comments/:commentId/reactions", c.PostController.ReactToComment)
+	postGroup.Delete("/:postId/comments/:commentId/reactions", c.PostController.RemoveCommentReaction)
 
-	serverPublicGroup := api.Group("/servers")
-	serverPublicGroup.Get("/invites/:inviteCode", c.ServerController.GetServerInfoForInvite)
+	api.Get("/feed", c.AuthMiddleware.ProtectedRoute(), c.PostController.GetFeed)
+
+	uploadGroup := api.Group("/uploads", c.AuthMiddleware.ProtectedRoute())
+	uploadGroup.Post("/", c.UploadController.CreateUploadIntent)
+	uploadGroup.Post("/:uploadId/finalize", c.UploadController.FinalizeUpload)
 }
@@ -412,3 +412,280 @@ func (controller *ServerController) UpdateServerSettings(ctx *fiber.Ctx) error {
 
 	return util.SendSuccessResponseNoData(ctx)
 }
+
+func (controller *ServerController) GetServerRoles(ctx *fiber.Ctx) error {
+	userId := ctx.Locals("userId").(uuid.UUID)
+	serverIdParam := ctx.Params("id")
+
+	var validationErr *model.ValidationError
+
+	response, err := controller.ServerUsecase.GetServerRoles(ctx, userId, serverIdParam)
+	if err != nil {
+		if errors.As(err, &validationErr) {
+			return util.SendErrorResponseNotFound(ctx, err)
+		}
+
+		return util.SendErrorResponseInternalServer(ctx, controller.Log, err)
+	}
+
+	return util.SendSuccessResponseWithData(ctx, response)
+}
+
+func (controller *ServerController) CreateServerRole(ctx *fiber.Ctx) error {
+	userId := ctx.Locals("userId").(uuid.UUID)
+	serverIdParam := ctx.Params("id")
+
+	var payload model.ServerRoleCreateRequest
+	err := util.ReadRequestBody(ctx, &payload)
+	if err != nil {
+		return util.SendErrorResponse(ctx, &model.ValidationError{
+			Code:    constant.ERR_INVALID_REQUEST_BODY_ERROR_CODE,
+			Message: constant.ERR_INVALID_REQUEST_BODY_MESSAGE,
+		})
+	}
+
+	var validationErr *model.ValidationError
+
+	response, err := controller.ServerUsecase.CreateServerRole(ctx, userId, serverIdParam, payload)
+	if err != nil {
+		if errors.As(err, &validationErr) {
+			return util.SendErrorResponseNotFound(ctx, err)
+		}
+
+		return util.SendErrorResponseInternalServer(ctx, controller.Log, err)
+	}
+
+	return util.SendSuccessResponseWithData(ctx, response)
+}
+
+func (controller *ServerController) UpdateServerRole(ctx *fiber.Ctx) error {
+	userId := ctx.Locals("userId").(uuid.UUID)
+	serverIdParam := ctx.Params("id")
+	roleIdParam := ctx.Params("roleId")
+
+	var payload model.ServerRoleUpdateRequest
+	err := util.ReadRequestBody(ctx, &payload)
+	if err != nil {
+		return util.SendErrorResponse(ctx, &model.ValidationError{
+			Code:    constant.ERR_INVALID_REQUEST_BODY_ERROR_CODE,
+			Message: constant.ERR_INVALID_REQUEST_BODY_MESSAGE,
+		})
+	}
+
+	var validationErr *model.ValidationError
+
+	response, err := controller.ServerUsecase.UpdateServerRole(ctx, userId, serverIdParam, roleIdParam, payload)
+	if err != nil {
+		if errors.As(err, &validationErr) {
+			return util.SendErrorResponseNotFound(ctx, err)
+		}
+
+		return util.SendErrorResponseInternalServer(ctx, controller.Log, err)
+	}
+
+	return util.SendSuccessResponseWithData(ctx, response)
+}
+
+func (controller *ServerController) DeleteServerRole(ctx *fiber.Ctx) error {
+	userId := ctx.Locals("userId").(uuid.UUID)
+	serverIdParam := ctx.Params("id")
+	roleIdParam := ctx.Params("roleId")
+
+	var validationErr *model.ValidationError
+
+	err := controller.ServerUsecase.DeleteServerRole(ctx, userId, serverIdParam, roleIdParam)
+	if err != nil {
+		if errors.As(err, &validationErr) {
+			return util.SendErrorResponseNotFound(ctx, err)
+		}
+
+		return util.SendErrorResponseInternalServer(ctx, controller.Log, err)
+	}
+
+	return util.SendSuccessResponseNoData(ctx)
+}
+
+func (controller *ServerController) UpdateServerMemberRole(ctx *fiber.Ctx) error {
+	userId := ctx.Locals("userId").(uuid.UUID)
+	serverIdParam := ctx.Params("id")
+	memberIdParam := ctx.Params("userId")
+
+	var payload model.ServerMemberRoleUpdateRequest
+	err := util.ReadRequestBody(ctx, &payload)
+	if err != nil {
+		return util.SendErrorResponse(ctx, &model.ValidationError{
+			Code:    constant.ERR_INVALID_REQUEST_BODY_ERROR_CODE,
+			Message: constant.ERR_INVALID_REQUEST_BODY_MESSAGE,
+		})
+	}
+
+	var validationErr *model.ValidationError
+
+	err = controller.ServerUsecase.UpdateServerMemberRole(ctx, userId, serverIdParam, memberIdParam, payload)
+	if err != nil {
+		if errors.As(err, &validationErr) {
+			return util.SendErrorResponseNotFound(ctx, err)
+		}
+
+		return util.SendErrorResponseInternalServer(ctx, controller.Log, err)
+	}
+
+	return util.SendSuccessResponseNoData(ctx)
+}
+
+func (controller *ServerController) GetServerMembers(ctx *fiber.Ctx) error {
+	userId := ctx.Locals("userId").(uuid.UUID)
+	serverIdParam := ctx.Params("id")
+
+	var validationErr *model.ValidationError
+
+	response, err := controller.ServerUsecase.GetServerMembers(ctx, userId, serverIdParam)
+	if err != nil {
+		if errors.As(err, &validationErr) {
+			return util.SendErrorResponseNotFound(ctx, err)
+		}
+
+		return util.SendErrorResponseInternalServer(ctx, controller.Log, err)
+	}
+
+	return util.SendSuccessResponseWithData(ctx, response)
+}
+
+func (controller *ServerController) LeaveServer(ctx *fiber.Ctx) error {
+	userId := ctx.Locals("userId").(uuid.UUID)
+	serverIdParam := ctx.Params("id")
+
+	var validationErr *model.ValidationError
+
+	err := controller.ServerUsecase.LeaveServer(ctx, userId, serverIdParam)
+	if err != nil {
+		if errors.As(err, &validationErr) {
+			return util.SendErrorResponseNotFound(ctx, err)
+		}
+
+		return util.SendErrorResponseInternalServer(ctx, controller.Log, err)
+	}
+
+	return util.SendSuccessResponseNoData(ctx)
+}
+
+func (controller *ServerController) KickServerMember(ctx *fiber.Ctx) error {
+	userId := ctx.Locals("userId").(uuid.UUID)
+	serverIdParam := ctx.Params("id")
+	memberIdParam := ctx.Params("userId")
+
+	var validationErr *model.ValidationError
+
+	err := controller.ServerUsecase.KickServerMember(ctx, userId, serverIdParam, memberIdParam)
+	if err != nil {
+		if errors.As(err, &validationErr) {
+			return util.SendErrorResponseNotFound(ctx, err)
+		}
+
+		return util.SendErrorResponseInternalServer(ctx, controller.Log, err)
+	}
+
+	return util.SendSuccessResponseNoData(ctx)
+}
+
+func (controller *ServerController) BanServerMember(ctx *fiber.Ctx) error {
+	userId := ctx.Locals("userId").(uuid.UUID)
+	serverIdParam := ctx.Params("id")
+	memberIdParam := ctx.Params("userId")
+
+	var validationErr *model.ValidationError
+
+	err := controller.ServerUsecase.BanServerMember(ctx, userId, serverIdParam, memberIdParam)
+	if err != nil {
+		if errors.As(err, &validationErr) {
+			return util.SendErrorResponseNotFound(ctx, err)
+		}
+
+		return util.SendErrorResponseInternalServer(ctx, controller.Log, err)
+	}
+
+	return util.SendSuccessResponseNoData(ctx)
+}
+
+func (controller *ServerController) UnbanServerMember(ctx *fiber.Ctx) error {
+	userId := ctx.Locals("userId").(uuid.UUID)
+	serverIdParam := ctx.Params("id")
+	memberIdParam := ctx.Params("userId")
+
+	var validationErr *model.ValidationError
+
+	err := controller.ServerUsecase.UnbanServerMember(ctx, userId, serverIdParam, memberIdParam)
+	if err != nil {
+		if errors.As(err, &validationErr) {
+			return util.SendErrorResponseNotFound(ctx, err)
+		}
+
+		return util.SendErrorResponseInternalServer(ctx, controller.Log, err)
+	}
+
+	return util.SendSuccessResponseNoData(ctx)
+}
+
+func (controller *ServerController) TransferOwnership(ctx *fiber.Ctx) error {
+	userId := ctx.Locals("userId").(uuid.UUID)
+	serverIdParam := ctx.Params("id")
+
+	var payload model.ServerTransferOwnershipRequest
+	err := util.ReadRequestBody(ctx, &payload)
+	if err != nil {
+		return util.SendErrorResponse(ctx, &model.ValidationError{
+			Code:    constant.ERR_INVALID_REQUEST_BODY_ERROR_CODE,
+			Message: constant.ERR_INVALID_REQUEST_BODY_MESSAGE,
+		})
+	}
+
+	var validationErr *model.ValidationError
+
+	err = controller.ServerUsecase.TransferOwnership(ctx, userId, serverIdParam, payload)
+	if err != nil {
+		if errors.As(err, &validationErr) {
+			return util.SendErrorResponseNotFound(ctx, err)
+		}
+
+		return util.SendErrorResponseInternalServer(ctx, controller.Log, err)
+	}
+
+	return util.SendSuccessResponseNoData(ctx)
+}
+
+func (controller *ServerController) GetServerInvites(ctx *fiber.Ctx) error {
+	userId := ctx.Locals("userId").(uuid.UUID)
+	serverIdParam := ctx.Params("id")
+
+	var validationErr *model.ValidationError
+
+	response, err := controller.ServerUsecase.GetServerInvites(ctx, userId, serverIdParam)
+	if err != nil {
+		if errors.As(err, &validationErr) {
+			return util.SendErrorResponseNotFound(ctx, err)
+		}
+
+		return util.SendErrorResponseInternalServer(ctx, controller.Log, err)
+	}
+
+	return util.SendSuccessResponseWithData(ctx, response)
+}
+
+func (controller *ServerController) RevokeServerInvite(ctx *fiber.Ctx) error {
+	userId := ctx.Locals("userId").(uuid.UUID)
+	serverIdParam := ctx.Params("id")
+	inviteCode := ctx.Params("code")
+
+	var validationErr *model.ValidationError
+
+	err := controller.ServerUsecase.RevokeServerInvite(ctx, userId, serverIdParam, inviteCode)
+	if err != nil {
+		if errors.As(err, &validationErr) {
+			return util.SendErrorResponseNotFound(ctx, err)
+		}
+
+		return util.SendErrorResponseInternalServer(ctx, controller.Log, err)
+	}
+
+	return util.SendSuccessResponseNoData(ctx)
+}
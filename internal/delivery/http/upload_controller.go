@@ -0,0 +1,73 @@
+package http
+
+import (
+	"errors"
+
+	"github.com/ferdian3456/virdanproject/internal/constant"
+	"github.com/ferdian3456/virdanproject/internal/model"
+	"github.com/ferdian3456/virdanproject/internal/usecase"
+	"github.com/ferdian3456/virdanproject/internal/util"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/knadh/koanf/v2"
+	"go.uber.org/zap"
+)
+
+type UploadController struct {
+	UploadUsecase *usecase.UploadUsecase
+	Log           *zap.Logger
+	Config        *koanf.Koanf
+}
+
+func NewUploadController(uploadUsecase *usecase.UploadUsecase, zap *zap.Logger, koanf *koanf.Koanf) *UploadController {
+	return &UploadController{
+		UploadUsecase: uploadUsecase,
+		Log:           zap,
+		Config:        koanf,
+	}
+}
+
+func (controller *UploadController) CreateUploadIntent(ctx *fiber.Ctx) error {
+	userId := ctx.Locals("userId").(uuid.UUID)
+
+	var payload model.UploadIntentCreateRequest
+	err := util.ReadRequestBody(ctx, &payload)
+	if err != nil {
+		return util.SendErrorResponse(ctx, &model.ValidationError{
+			Code:    constant.ERR_INVALID_REQUEST_BODY_ERROR_CODE,
+			Message: constant.ERR_INVALID_REQUEST_BODY_MESSAGE,
+		})
+	}
+
+	var validationErr *model.ValidationError
+
+	response, err := controller.UploadUsecase.CreateUploadIntent(ctx, userId, payload)
+	if err != nil {
+		if errors.As(err, &validationErr) {
+			return util.SendErrorResponseNotFound(ctx, err)
+		}
+
+		return util.SendErrorResponseInternalServer(ctx, controller.Log, err)
+	}
+
+	return util.SendSuccessResponseWithData(ctx, response)
+}
+
+func (controller *UploadController) FinalizeUpload(ctx *fiber.Ctx) error {
+	userId := ctx.Locals("userId").(uuid.UUID)
+
+	uploadIdParam := ctx.Params("uploadId")
+
+	var validationErr *model.ValidationError
+
+	err := controller.UploadUsecase.FinalizeUpload(ctx, userId, uploadIdParam)
+	if err != nil {
+		if errors.As(err, &validationErr) {
+			return util.SendErrorResponseNotFound(ctx, err)
+		}
+
+		return util.SendErrorResponseInternalServer(ctx, controller.Log, err)
+	}
+
+	return util.SendSuccessResponseNoData(ctx)
+}
@@ -76,6 +76,78 @@ func (controller UserController) Login(ctx *fiber.Ctx) error {
 	return util.SendSuccessResponseWithData(ctx, response)
 }
 
+func (controller UserController) Refresh(ctx *fiber.Ctx) error {
+	var payload model.TokenRefreshRequest
+	err := util.ReadRequestBody(ctx, &payload)
+	if err != nil {
+		return util.SendErrorResponse(ctx, &model.ValidationError{
+			Code:    constant.ERR_INVALID_REQUEST_BODY_ERROR_CODE,
+			Message: constant.ERR_INVALID_REQUEST_BODY_MESSAGE,
+		})
+	}
+
+	var validationErr *model.ValidationError
+
+	response, err := controller.UserUsecase.Refresh(ctx, payload)
+	if err != nil {
+		if errors.As(err, &validationErr) {
+			return util.SendErrorResponse(ctx, err)
+		}
+
+		return util.SendErrorResponseInternalServer(ctx, controller.Log, err)
+	}
+
+	return util.SendSuccessResponseWithData(ctx, response)
+}
+
+func (controller UserController) ForgotPassword(ctx *fiber.Ctx) error {
+	var payload model.UserForgotPasswordRequest
+	err := util.ReadRequestBody(ctx, &payload)
+	if err != nil {
+		return util.SendErrorResponse(ctx, &model.ValidationError{
+			Code:    constant.ERR_INVALID_REQUEST_BODY_ERROR_CODE,
+			Message: constant.ERR_INVALID_REQUEST_BODY_MESSAGE,
+		})
+	}
+
+	var validationErr *model.ValidationError
+
+	response, err := controller.UserUsecase.ForgotPassword(ctx, payload)
+	if err != nil {
+		if errors.As(err, &validationErr) {
+			return util.SendErrorResponse(ctx, err)
+		}
+
+		return util.SendErrorResponseInternalServer(ctx, controller.Log, err)
+	}
+
+	return util.SendSuccessResponseWithData(ctx, response)
+}
+
+func (controller UserController) ResetPassword(ctx *fiber.Ctx) error {
+	var payload model.UserResetPasswordRequest
+	err := util.ReadRequestBody(ctx, &payload)
+	if err != nil {
+		return util.SendErrorResponse(ctx, &model.ValidationError{
+			Code:    constant.ERR_INVALID_REQUEST_BODY_ERROR_CODE,
+			Message: constant.ERR_INVALID_REQUEST_BODY_MESSAGE,
+		})
+	}
+
+	var validationErr *model.ValidationError
+
+	err = controller.UserUsecase.ResetPassword(ctx, payload)
+	if err != nil {
+		if errors.As(err, &validationErr) {
+			return util.SendErrorResponse(ctx, err)
+		}
+
+		return util.SendErrorResponseInternalServer(ctx, controller.Log, err)
+	}
+
+	return util.SendSuccessResponseNoData(ctx)
+}
+
 func (controller UserController) GetUserInfo(ctx *fiber.Ctx) error {
 	userId := ctx.Locals("userId").(uuid.UUID)
 
@@ -95,8 +167,51 @@ func (controller UserController) GetUserInfo(ctx *fiber.Ctx) error {
 
 func (controller UserController) Logout(ctx *fiber.Ctx) error {
 	userId := ctx.Locals("userId").(uuid.UUID)
+	sessionId := ctx.Locals("sessionId").(uuid.UUID)
+
+	err := controller.UserUsecase.Logout(ctx, userId, sessionId)
+	if err != nil {
+		return util.SendErrorResponseInternalServer(ctx, controller.Log, err)
+	}
+
+	return util.SendSuccessResponseNoData(ctx)
+}
+
+func (controller UserController) GetSessions(ctx *fiber.Ctx) error {
+	userId := ctx.Locals("userId").(uuid.UUID)
+	sessionId := ctx.Locals("sessionId").(uuid.UUID)
+
+	response, err := controller.UserUsecase.GetSessions(ctx, userId, sessionId)
+	if err != nil {
+		return util.SendErrorResponseInternalServer(ctx, controller.Log, err)
+	}
+
+	return util.SendSuccessResponseWithData(ctx, response)
+}
+
+func (controller UserController) DeleteSession(ctx *fiber.Ctx) error {
+	userId := ctx.Locals("userId").(uuid.UUID)
+	sessionId := ctx.Params("id")
+
+	var validationErr *model.ValidationError
+
+	err := controller.UserUsecase.DeleteSession(ctx, userId, sessionId)
+	if err != nil {
+		if errors.As(err, &validationErr) {
+			return util.SendErrorResponseNotFound(ctx, err)
+		}
+
+		return util.SendErrorResponseInternalServer(ctx, controller.Log, err)
+	}
+
+	return util.SendSuccessResponseNoData(ctx)
+}
+
+func (controller UserController) LogoutOtherSessions(ctx *fiber.Ctx) error {
+	userId := ctx.Locals("userId").(uuid.UUID)
+	sessionId := ctx.Locals("sessionId").(uuid.UUID)
 
-	err := controller.UserUsecase.Logout(ctx, userId)
+	err := controller.UserUsecase.LogoutOtherSessions(ctx, userId, sessionId)
 	if err != nil {
 		return util.SendErrorResponseInternalServer(ctx, controller.Log, err)
 	}
@@ -121,6 +236,23 @@ func (controller UserController) UpdateAvatar(ctx *fiber.Ctx) error {
 	return util.SendSuccessResponseNoData(ctx)
 }
 
+func (controller UserController) DeleteAvatar(ctx *fiber.Ctx) error {
+	userId := ctx.Locals("userId").(uuid.UUID)
+
+	var validationErr *model.ValidationError
+
+	err := controller.UserUsecase.DeleteAvatar(ctx, userId)
+	if err != nil {
+		if errors.As(err, &validationErr) {
+			return util.SendErrorResponseNotFound(ctx, err)
+		}
+
+		return util.SendErrorResponseInternalServer(ctx, controller.Log, err)
+	}
+
+	return util.SendSuccessResponseNoData(ctx)
+}
+
 func (controller UserController) StartSignup(ctx *fiber.Ctx) error {
 	var payload model.UserSignupStartRequest
 	err := util.ReadRequestBody(ctx, &payload)
@@ -307,3 +439,73 @@ func (controller UserController) UpdateBio(ctx *fiber.Ctx) error {
 
 	return util.SendSuccessResponseNoData(ctx)
 }
+
+func (controller UserController) ChangePassword(ctx *fiber.Ctx) error {
+	userId := ctx.Locals("userId").(uuid.UUID)
+	sessionId := ctx.Locals("sessionId").(uuid.UUID)
+
+	var payload model.PasswordChangeRequest
+	err := util.ReadRequestBody(ctx, &payload)
+	if err != nil {
+		return util.SendErrorResponse(ctx, &model.ValidationError{
+			Code:    constant.ERR_INVALID_REQUEST_BODY_ERROR_CODE,
+			Message: constant.ERR_INVALID_REQUEST_BODY_MESSAGE,
+		})
+	}
+
+	var validationErr *model.ValidationError
+
+	response, err := controller.UserUsecase.ChangePassword(ctx, userId, sessionId, payload)
+	if err != nil {
+		if errors.As(err, &validationErr) {
+			return util.SendErrorResponse(ctx, err)
+		}
+
+		return util.SendErrorResponseInternalServer(ctx, controller.Log, err)
+	}
+
+	return util.SendSuccessResponseWithData(ctx, response)
+}
+
+func (controller UserController) DeleteAccount(ctx *fiber.Ctx) error {
+	userId := ctx.Locals("userId").(uuid.UUID)
+
+	var payload model.AccountDeleteRequest
+	err := util.ReadRequestBody(ctx, &payload)
+	if err != nil {
+		return util.SendErrorResponse(ctx, &model.ValidationError{
+			Code:    constant.ERR_INVALID_REQUEST_BODY_ERROR_CODE,
+			Message: constant.ERR_INVALID_REQUEST_BODY_MESSAGE,
+		})
+	}
+
+	var validationErr *model.ValidationError
+
+	response, err := controller.UserUsecase.DeleteAccount(ctx, userId, payload)
+	if err != nil {
+		if errors.As(err, &validationErr) {
+			return util.SendErrorResponse(ctx, err)
+		}
+
+		return util.SendErrorResponseInternalServer(ctx, controller.Log, err)
+	}
+
+	return util.SendSuccessResponseWithData(ctx, response)
+}
+
+func (controller UserController) ExportAccountData(ctx *fiber.Ctx) error {
+	userId := ctx.Locals("userId").(uuid.UUID)
+
+	var validationErr *model.ValidationError
+
+	response, err := controller.UserUsecase.ExportAccountData(ctx, userId)
+	if err != nil {
+		if errors.As(err, &validationErr) {
+			return util.SendErrorResponseNotFound(ctx, err)
+		}
+
+		return util.SendErrorResponseInternalServer(ctx, controller.Log, err)
+	}
+
+	return util.SendSuccessResponseWithData(ctx, response)
+}
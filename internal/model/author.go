@@ -0,0 +1,86 @@
+package model
+
+import (
+	"github.com/google/uuid"
+)
+
+// AuthorStatus tells whether the author of a post or comment is still around in its server
+type AuthorStatus string
+
+const (
+	AuthorStatusMember  AuthorStatus = "member"
+	AuthorStatusLeft    AuthorStatus = "left"
+	AuthorStatusDeleted AuthorStatus = "deleted"
+)
+
+// Placeholder names shown instead of authors who left the server or deleted their account
+const (
+	LeftAuthorUsername    = "Former member"
+	DeletedAuthorUsername = "Deleted user"
+)
+
+// AuthorResponse summarizes the author of a post or comment as seen in its server. Authors who left or were
+// removed from the server and deleted accounts are placeholders without a profile or role
+type AuthorResponse struct {
+	UserId          *uuid.UUID          `json:"userId"`
+	Username        string              `json:"username"`
+	Fullname        string              `json:"fullname"`
+	AvatarImageUrls map[string]string   `json:"avatarImageUrls"`
+	Role            *AuthorRoleResponse `json:"role"`
+	Status          AuthorStatus        `json:"status"`
+}
+
+type AuthorRoleResponse struct {
+	Id   uuid.UUID `json:"id"`
+	Name string    `json:"name"`
+}
+
+// AuthorRow holds the author columns of a post or comment as read from the database, every column is empty
+// when the account is gone and the member columns are empty when the author never joined the server
+type AuthorRow struct {
+	UserId         *uuid.UUID
+	Username       *string
+	Fullname       *string
+	AvatarVariants ImageVariants
+	MemberStatus   *Status
+	RoleId         *uuid.UUID
+	RoleName       *string
+}
+
+// Summary builds the author summary, with a placeholder for authors who are no longer members
+func (row AuthorRow) Summary(minioFullUrl string) AuthorResponse {
+	if row.UserId == nil || row.Username == nil {
+		return AuthorResponse{
+			Username: DeletedAuthorUsername,
+			Status:   AuthorStatusDeleted,
+		}
+	}
+
+	if row.MemberStatus == nil || *row.MemberStatus != MemberStatusActive {
+		return AuthorResponse{
+			UserId:   row.UserId,
+			Username: LeftAuthorUsername,
+			Status:   AuthorStatusLeft,
+		}
+	}
+
+	author := AuthorResponse{
+		UserId:          row.UserId,
+		Username:        *row.Username,
+		AvatarImageUrls: row.AvatarVariants.Urls(minioFullUrl),
+		Status:          AuthorStatusMember,
+	}
+
+	if row.Fullname != nil {
+		author.Fullname = *row.Fullname
+	}
+
+	if row.RoleId != nil && row.RoleName != nil {
+		author.Role = &AuthorRoleResponse{
+			Id:   *row.RoleId,
+			Name: *row.RoleName,
+		}
+	}
+
+	return author
+}
@@ -0,0 +1,79 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DigestFrequency is how often a user gets the activity digest email, users who never chose get it weekly
+type DigestFrequency string
+
+const (
+	DigestFrequencyDaily  DigestFrequency = "daily"
+	DigestFrequencyWeekly DigestFrequency = "weekly"
+	DigestFrequencyOff    DigestFrequency = "off"
+
+	DefaultDigestFrequency = DigestFrequencyWeekly
+)
+
+// IsValidDigestFrequency reports whether frequency is one of the supported digest frequencies
+func IsValidDigestFrequency(frequency DigestFrequency) bool {
+	switch frequency {
+	case DigestFrequencyDaily, DigestFrequencyWeekly, DigestFrequencyOff:
+		return true
+	}
+
+	return false
+}
+
+// DigestRecipient is a user whose digest is due. The digest covers the activity after SinceDatetime,
+// SentDatetime is the stored end of the previous window and nil before the first digest
+type DigestRecipient struct {
+	UserId        uuid.UUID
+	Username      string
+	Email         string
+	Frequency     DigestFrequency
+	SentDatetime  *time.Time
+	SinceDatetime time.Time
+}
+
+type DigestPost struct {
+	ServerName     string
+	AuthorUsername string
+	Caption        string
+	CreateDatetime time.Time
+}
+
+// DigestActivity is what happened for the recipient within the window, only unread notifications count
+type DigestActivity struct {
+	Posts        []DigestPost
+	PostCount    int
+	LikeCount    int
+	CommentCount int
+	ReplyCount   int
+}
+
+// IsEmpty reports whether there is nothing worth an email
+func (activity DigestActivity) IsEmpty() bool {
+	return activity.PostCount == 0 && activity.LikeCount == 0 && activity.CommentCount == 0 && activity.ReplyCount == 0
+}
+
+type DigestTemplateData struct {
+	Username       string
+	Period         string
+	Posts          []DigestPost
+	MorePostCount  int
+	LikeCount      int
+	CommentCount   int
+	ReplyCount     int
+	UnsubscribeUrl string
+}
+
+// DigestReport is the outcome of one digest run
+type DigestReport struct {
+	Due     int
+	Sent    int
+	Skipped int
+	Failed  int
+}
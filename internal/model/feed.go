@@ -0,0 +1,24 @@
+package model
+
+import (
+	"github.com/google/uuid"
+)
+
+// FeedListResponse is a page of the home feed, it is paginated with ServerPostCursor like the posts of a server
+type FeedListResponse struct {
+	Data []FeedPostResponse `json:"data"`
+	Page Page               `json:"page"`
+}
+
+// FeedPostResponse is a post of the home feed with the server it was posted in
+type FeedPostResponse struct {
+	Server  FeedServerResponse `json:"server"`
+	IsLiked bool               `json:"isLiked"`
+	ServerPostResponse
+}
+
+type FeedServerResponse struct {
+	Id              uuid.UUID         `json:"id"`
+	Name            string            `json:"name"`
+	AvatarImageUrls map[string]string `json:"avatarImageUrls"`
+}
@@ -0,0 +1,75 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// PrivateObjectPrefix marks objects that are not publicly readable, such as the post images of private servers.
+// They are only reachable through presigned urls
+const PrivateObjectPrefix = "private/"
+
+func IsPrivateObjectKey(objectKey string) bool {
+	return strings.HasPrefix(objectKey, PrivateObjectPrefix)
+}
+
+// PlaceObjectKey moves the key under or out of the private prefix
+func PlaceObjectKey(objectKey string, private bool) string {
+	publicKey := strings.TrimPrefix(objectKey, PrivateObjectPrefix)
+	if private {
+		return PrivateObjectPrefix + publicKey
+	}
+
+	return publicKey
+}
+
+// MisplacedPostImage is a post image whose objects sit under the wrong prefix for the privacy of its server
+type MisplacedPostImage struct {
+	Id        uuid.UUID
+	Bucket    string
+	ObjectKey string
+	Variants  ImageVariants
+	Private   bool
+}
+
+// ImageVariants maps a variant name such as thumb, medium or large to its object key
+type ImageVariants map[string]string
+
+// Urls builds the srcset style map of the variant urls, nil when the image has no variant
+func (variants ImageVariants) Urls(minioFullUrl string) map[string]string {
+	if len(variants) == 0 {
+		return nil
+	}
+
+	urls := make(map[string]string, len(variants))
+	for name, objectKey := range variants {
+		urls[name] = fmt.Sprintf("%s/%s", minioFullUrl, objectKey)
+	}
+
+	return urls
+}
+
+// ObjectKeys lists the original object followed by the variant objects, images stored before
+// variants existed use the original as their only variant so it is listed once
+func (variants ImageVariants) ObjectKeys(originalKey string) []string {
+	objectKeys := []string{originalKey}
+	for _, objectKey := range variants {
+		if objectKey != originalKey {
+			objectKeys = append(objectKeys, objectKey)
+		}
+	}
+
+	return objectKeys
+}
+
+// Place returns the variants with every key moved under or out of the private prefix
+func (variants ImageVariants) Place(private bool) ImageVariants {
+	placed := make(ImageVariants, len(variants))
+	for name, objectKey := range variants {
+		placed[name] = PlaceObjectKey(objectKey, private)
+	}
+
+	return placed
+}
@@ -6,6 +6,7 @@ import (
 )
 
 type Claims struct {
-	UserId uuid.UUID `json:"userId"`
+	UserId    uuid.UUID `json:"userId"`
+	SessionId uuid.UUID `json:"sid"`
 	jwt.RegisteredClaims
 }
@@ -0,0 +1,89 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type NotificationType string
+
+const (
+	NotificationPostLiked      NotificationType = "post_liked"
+	NotificationPostCommented  NotificationType = "post_commented"
+	NotificationCommentReplied NotificationType = "comment_replied"
+	NotificationMemberJoined   NotificationType = "member_joined"
+)
+
+// NotificationTypes are the types a user can mute
+var NotificationTypes = []NotificationType{
+	NotificationPostLiked,
+	NotificationPostCommented,
+	NotificationCommentReplied,
+	NotificationMemberJoined,
+}
+
+// IsValidNotificationType reports whether notificationType is one of the supported notification types
+func IsValidNotificationType(notificationType NotificationType) bool {
+	for _, validType := range NotificationTypes {
+		if notificationType == validType {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Notification tells UserId that ActorId did something, the ids that do not apply to the type stay nil
+type Notification struct {
+	Id             uuid.UUID
+	UserId         uuid.UUID
+	ActorId        uuid.UUID
+	Type           NotificationType
+	ServerId       *uuid.UUID
+	PostId         *uuid.UUID
+	CommentId      *uuid.UUID
+	ReadDatetime   *time.Time
+	CreateDatetime time.Time
+	UpdateDatetime time.Time
+	CreateUserId   uuid.UUID
+	UpdateUserId   uuid.UUID
+}
+
+type NotificationCursor struct {
+	Id             uuid.UUID `json:"id"`
+	CreateDatetime time.Time `json:"createDatetime"`
+}
+
+type NotificationListResponse struct {
+	Data []NotificationResponse `json:"data"`
+	Page Page                   `json:"page"`
+}
+
+// NotificationResponse is an inbox entry, the actor is nil once the actor deleted the account
+type NotificationResponse struct {
+	Id             uuid.UUID        `json:"id"`
+	Type           NotificationType `json:"type"`
+	ActorId        *uuid.UUID       `json:"actorId"`
+	ActorUsername  *string          `json:"actorUsername"`
+	ServerId       *uuid.UUID       `json:"serverId"`
+	PostId         *uuid.UUID       `json:"postId"`
+	CommentId      *uuid.UUID       `json:"commentId"`
+	IsRead         bool             `json:"isRead"`
+	CreateDatetime time.Time        `json:"createDatetime"`
+}
+
+type NotificationUnreadCountResponse struct {
+	UnreadCount int `json:"unreadCount"`
+}
+
+// NotificationSettings are the notification preferences kept in users.settings
+type NotificationSettings struct {
+	MutedTypes      []NotificationType `json:"mutedTypes"`
+	DigestFrequency DigestFrequency    `json:"digestFrequency"`
+}
+
+// DigestUnsubscribeResponse lets the unsubscribe page confirm before the digest is turned off
+type DigestUnsubscribeResponse struct {
+	DigestFrequency DigestFrequency `json:"digestFrequency"`
+}
@@ -0,0 +1,68 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Reaction string
+
+const (
+	ReactionThumbsUp Reaction = "thumbs_up"
+	ReactionHeart    Reaction = "heart"
+	ReactionLaugh    Reaction = "laugh"
+	ReactionWow      Reaction = "wow"
+	ReactionSad      Reaction = "sad"
+	ReactionAngry    Reaction = "angry"
+)
+
+// Reactions lists every reaction a user can leave on a post or a comment, in display order
+var Reactions = []Reaction{
+	ReactionThumbsUp,
+	ReactionHeart,
+	ReactionLaugh,
+	ReactionWow,
+	ReactionSad,
+	ReactionAngry,
+}
+
+// IsValidReaction reports whether the reaction is part of the fixed set
+func IsValidReaction(reaction string) bool {
+	for _, allowed := range Reactions {
+		if string(allowed) == reaction {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ServerReaction is the reaction of a user on a post or a comment, TargetId holds the post or comment id
+type ServerReaction struct {
+	Id             uuid.UUID
+	TargetId       uuid.UUID
+	UserId         uuid.UUID
+	Reaction       string
+	CreateDatetime time.Time
+	UpdateDatetime time.Time
+	CreateUserId   uuid.UUID
+	UpdateUserId   uuid.UUID
+}
+
+type ReactionRequest struct {
+	Reaction string `json:"reaction"`
+}
+
+// ReactionSummaryResponse holds the count of every reaction left on a target and the reaction of the caller
+type ReactionSummaryResponse struct {
+	Reactions  map[string]int `json:"reactions"`
+	MyReaction *string        `json:"myReaction"`
+}
+
+// NewReactionSummary returns a summary without any reaction
+func NewReactionSummary() ReactionSummaryResponse {
+	return ReactionSummaryResponse{
+		Reactions: map[string]int{},
+	}
+}
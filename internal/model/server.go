@@ -74,14 +74,14 @@ type ServerDiscoveryCursor struct {
 }
 
 type ServerInfoResponse struct {
-	Id             uuid.UUID `json:"id"`
-	Name           string    `json:"name"`
-	ShortName      string    `json:"shortName"`
-	CategoryName   string    `json:"categoryName"`
-	AvatarImageUrl *string   `json:"avatarImageUrl"`
-	BannerImageUrl *string   `json:"bannerImageUrl"`
-	Description    *string   `json:"description"`
-	CreateDatetime time.Time `json:"-"` // tidak di-serialize ke JSON, hanya untuk cursor
+	Id              uuid.UUID         `json:"id"`
+	Name            string            `json:"name"`
+	ShortName       string            `json:"shortName"`
+	CategoryName    string            `json:"categoryName"`
+	AvatarImageUrls map[string]string `json:"avatarImageUrls"`
+	BannerImageUrls map[string]string `json:"bannerImageUrls"`
+	Description     *string           `json:"description"`
+	CreateDatetime  time.Time         `json:"-"` // tidak di-serialize ke JSON, hanya untuk cursor
 }
 
 type ServerUserListResponse struct {
@@ -95,22 +95,22 @@ type ServerUserCursor struct {
 }
 
 type ServerUserResponse struct {
-	Id             uuid.UUID `json:"id"`
-	Name           string    `json:"name"`
-	ShortName      string    `json:"shortName"`
-	AvatarImageUrl *string   `json:"avatarImageUrl"`
-	JoinedDatetime time.Time `json:"-"` // tidak di-serialize ke JSON, hanya untuk cursor
+	Id              uuid.UUID         `json:"id"`
+	Name            string            `json:"name"`
+	ShortName       string            `json:"shortName"`
+	AvatarImageUrls map[string]string `json:"avatarImageUrls"`
+	JoinedDatetime  time.Time         `json:"-"` // tidak di-serialize ke JSON, hanya untuk cursor
 }
 
 type ServerResponse struct {
-	Id             uuid.UUID `json:"id"`
-	OwnerName      string    `json:"ownerName"`
-	Name           string    `json:"name"`
-	ShortName      string    `json:"shortName"`
-	CategoryName   string    `json:"categoryName"`
-	AvatarImageUrl *string   `json:"avatarImageUrl"`
-	BannerImageUrl *string   `json:"bannerImageUrl"`
-	Description    *string   `json:"description"`
+	Id              uuid.UUID         `json:"id"`
+	OwnerName       string            `json:"ownerName"`
+	Name            string            `json:"name"`
+	ShortName       string            `json:"shortName"`
+	CategoryName    string            `json:"categoryName"`
+	AvatarImageUrls map[string]string `json:"avatarImageUrls"`
+	BannerImageUrls map[string]string `json:"bannerImageUrls"`
+	Description     *string           `json:"description"`
 }
 
 type ServerUpdateNameRequest struct {
@@ -128,3 +128,15 @@ type ServerUpdateCategoryRequest struct {
 type ServerUpdateDescriptionRequest struct {
 	Description *string `json:"description"`
 }
+
+type ServerTransferOwnershipRequest struct {
+	UserId   string `json:"userId"`
+	Password string `json:"password"`
+}
+
+type OwnershipTransferTemplateData struct {
+	Username      string
+	OtherUsername string
+	ServerName    string
+	IsNewOwner    bool
+}
@@ -8,10 +8,11 @@ import (
 
 type ServerAvatarImage struct {
 	Id             uuid.UUID
-	ServerId       uuid.UUID
 	Bucket         string
 	ObjectKey      string
 	MimeType       string
+	Size           int64
+	Variants       ImageVariants
 	CreateDatetime time.Time
 	UpdateDatetime time.Time
 	CreateUserId   uuid.UUID
@@ -8,11 +8,11 @@ import (
 
 type ServerBannerImage struct {
 	Id             uuid.UUID
-	ServerId       uuid.UUID
 	Bucket         string
 	ObjectKey      string
 	MimeType       string
 	Size           int64
+	Variants       ImageVariants
 	CreateDatetime time.Time
 	UpdateDatetime time.Time
 	CreateUserId   uuid.UUID
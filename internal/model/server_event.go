@@ -0,0 +1,48 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type ServerEventType string
+
+const (
+	ServerEventConnected        ServerEventType = "connected"
+	ServerEventPostCreated      ServerEventType = "post.created"
+	ServerEventPostDeleted      ServerEventType = "post.deleted"
+	ServerEventCommentCreated   ServerEventType = "comment.created"
+	ServerEventLikeCountChanged ServerEventType = "post.like_count_changed"
+	ServerEventMemberJoined     ServerEventType = "member.joined"
+	ServerEventMemberLeft       ServerEventType = "member.left"
+)
+
+// ServerEvent is pushed over the WebSocket gateway to the members of the server it happened in
+type ServerEvent struct {
+	Type           ServerEventType `json:"type"`
+	ServerId       uuid.UUID       `json:"serverId"`
+	Data           json.RawMessage `json:"data,omitempty"`
+	CreateDatetime time.Time       `json:"createDatetime"`
+}
+
+type PostDeletedEventData struct {
+	PostId uuid.UUID `json:"postId"`
+}
+
+type CommentCreatedEventData struct {
+	PostId  uuid.UUID             `json:"postId"`
+	Comment ServerCommentResponse `json:"comment"`
+}
+
+type LikeCountChangedEventData struct {
+	PostId    uuid.UUID `json:"postId"`
+	LikeCount int       `json:"likeCount"`
+}
+
+// ServerMemberEventData is the data of member.joined and member.left, the gateway also uses it
+// to start or stop sending the events of the server to that user
+type ServerMemberEventData struct {
+	UserId uuid.UUID `json:"userId"`
+}
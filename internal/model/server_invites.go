@@ -20,6 +20,18 @@ type ServerInvites struct {
 	UpdateUserId    uuid.UUID
 }
 
+type ServerInviteUse struct {
+	Id             uuid.UUID
+	InviteId       uuid.UUID
+	ServerId       uuid.UUID
+	UserId         uuid.UUID
+	UsedDatetime   time.Time
+	CreateDatetime time.Time
+	UpdateDatetime time.Time
+	CreateUserId   uuid.UUID
+	UpdateUserId   uuid.UUID
+}
+
 type ServerInviteLinkRequest struct {
 	ExpiresInMinutes int `json:"expiresInMinutes"`
 	MaxUses          int `json:"maxUses"`
@@ -34,10 +46,41 @@ type ServerInviteLinkResponse struct {
 	ExpiresAt  time.Time `json:"expiresAt"`
 }
 
+type InviteStatus string
+
+const (
+	InviteStatusActive  InviteStatus = "active"
+	InviteStatusExpired InviteStatus = "expired"
+	InviteStatusUsedUp  InviteStatus = "used_up"
+	InviteStatusRevoked InviteStatus = "revoked"
+)
+
 type ServerInfoForInviteResponse struct {
-	OwnerName     string  `json:"ownerName"`
-	ServerName    string  `json:"serverName"`
-	Description   *string `json:"description"`
-	AvatarImageId *string `json:"avatarImageId"`
-	BannerImageId *string `json:"bannerImageId"`
+	ServerId        uuid.UUID         `json:"serverId"`
+	OwnerName       string            `json:"ownerName"`
+	ServerName      string            `json:"serverName"`
+	ShortName       string            `json:"shortName"`
+	CategoryName    *string           `json:"categoryName"`
+	Description     *string           `json:"description"`
+	AvatarImageUrls map[string]string `json:"avatarImageUrls"`
+	BannerImageUrls map[string]string `json:"bannerImageUrls"`
+	MemberCount     int               `json:"memberCount"`
+	Status          InviteStatus      `json:"status"`
+	ExpiresDatetime *time.Time        `json:"expiresDatetime"`
+	IsActive        bool              `json:"-"`
+	MaxUses         int               `json:"-"`
+	UsedCount       int               `json:"-"`
+}
+
+type ServerInviteListResponse struct {
+	Data []ServerInviteResponse `json:"data"`
+}
+
+type ServerInviteResponse struct {
+	Code            string     `json:"code"`
+	MaxUses         int        `json:"maxUses"`
+	UsedCount       int        `json:"usedCount"`
+	ExpiresDatetime *time.Time `json:"expiresDatetime"`
+	CreatorName     string     `json:"creatorName"`
+	CreateDatetime  time.Time  `json:"createDatetime"`
 }
@@ -27,3 +27,24 @@ type ServerMember struct {
 	CreateUserId   uuid.UUID
 	UpdateUserId   uuid.UUID
 }
+
+type ServerMemberListResponse struct {
+	Data []ServerMemberResponse `json:"data"`
+	Page Page                   `json:"page"`
+}
+
+type ServerMemberCursor struct {
+	UserId         string    `json:"userId"`
+	JoinedDatetime time.Time `json:"joinedDatetime"`
+}
+
+type ServerMemberResponse struct {
+	UserId          uuid.UUID         `json:"userId"`
+	Username        string            `json:"username"`
+	Fullname        string            `json:"fullname"`
+	AvatarImageUrls map[string]string `json:"avatarImageUrls"`
+	RoleId          uuid.UUID         `json:"roleId"`
+	RoleName        string            `json:"roleName"`
+	IsOwner         bool              `json:"isOwner"`
+	JoinedDatetime  time.Time         `json:"joinedDatetime"`
+}
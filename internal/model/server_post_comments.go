@@ -11,6 +11,7 @@ type ServerPostComments struct {
 	PostId         uuid.UUID
 	AuthorId       uuid.UUID
 	ParentId       *uuid.UUID
+	Depth          int
 	Content        string
 	CreateDatetime time.Time
 	UpdateDatetime time.Time
@@ -34,10 +35,44 @@ type ServerCommentListResponse struct {
 }
 
 type ServerCommentResponse struct {
-	Id             uuid.UUID  `json:"id"`
-	AuthorId       uuid.UUID  `json:"authorId"`
-	ParentId       *uuid.UUID `json:"parentId"`
-	Content        string     `json:"content"`
-	CreateDatetime time.Time  `json:"createDatetime"`
-	UpdateDatetime time.Time  `json:"updateDatetime"`
+	Id             uuid.UUID               `json:"id"`
+	AuthorId       *uuid.UUID              `json:"authorId"`
+	Author         AuthorResponse          `json:"author"`
+	ParentId       *uuid.UUID              `json:"parentId"`
+	Depth          int                     `json:"depth"`
+	Content        string                  `json:"content"`
+	Edited         bool                    `json:"edited"`
+	ReplyCount     int                     `json:"replyCount"`
+	Reactions      map[string]int          `json:"reactions"`
+	MyReaction     *string                 `json:"myReaction"`
+	Replies        []ServerCommentResponse `json:"replies,omitempty"`
+	CreateDatetime time.Time               `json:"createDatetime"`
+	UpdateDatetime time.Time               `json:"updateDatetime"`
+}
+
+type ServerCommentUpdateRequest struct {
+	Content string `json:"content"`
+}
+
+type ServerPostCommentRevision struct {
+	Id              uuid.UUID
+	CommentId       uuid.UUID
+	Content         string
+	WrittenDatetime time.Time
+	CreateDatetime  time.Time
+	UpdateDatetime  time.Time
+	CreateUserId    uuid.UUID
+	UpdateUserId    uuid.UUID
+}
+
+type ServerCommentRevisionListResponse struct {
+	Data []ServerCommentRevisionResponse `json:"data"`
+}
+
+type ServerCommentRevisionResponse struct {
+	Id              uuid.UUID  `json:"id"`
+	Content         string     `json:"content"`
+	WrittenDatetime time.Time  `json:"writtenDatetime"`
+	EditedBy        *uuid.UUID `json:"editedBy"`
+	EditedDatetime  time.Time  `json:"editedDatetime"`
 }
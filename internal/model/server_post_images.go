@@ -12,6 +12,7 @@ type ServerPostImages struct {
 	ObjectKey      string
 	MimeType       string
 	Size           int64
+	Variants       ImageVariants
 	CreateDatetime time.Time
 	UpdateDatetime time.Time
 	CreateUserId   uuid.UUID
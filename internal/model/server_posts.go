@@ -10,7 +10,6 @@ type ServerPosts struct {
 	Id             uuid.UUID
 	ServerId       uuid.UUID
 	AuthorId       uuid.UUID
-	PostImageId    uuid.UUID
 	Caption        string
 	CreateDatetime time.Time
 	UpdateDatetime time.Time
@@ -33,17 +32,38 @@ type ServerPostListResponse struct {
 }
 
 type ServerPostResponse struct {
-	OwnerId        uuid.UUID  `json:"ownerId"`
-	PostId         uuid.UUID  `json:"postId"`
-	PostImageUrl   string     `json:"postImageUrl"`
-	Caption        string     `json:"caption"`
-	CommentCount   int        `json:"commentCount"`
-	LikeCount      int        `json:"likeCount"`
-	CreateDatetime time.Time  `json:"createDatetime"`
-	UpdateDatetime time.Time  `json:"updateDatetime"`
+	OwnerId        uuid.UUID                 `json:"ownerId"`
+	Author         AuthorResponse            `json:"author"`
+	PostId         uuid.UUID                 `json:"postId"`
+	Media          []ServerPostMediaResponse `json:"media"`
+	Caption        string                    `json:"caption"`
+	CommentCount   int                       `json:"commentCount"`
+	LikeCount      int                       `json:"likeCount"`
+	Reactions      map[string]int            `json:"reactions"`
+	MyReaction     *string                   `json:"myReaction"`
+	CreateDatetime time.Time                 `json:"createDatetime"`
+	UpdateDatetime time.Time                 `json:"updateDatetime"`
 }
 
 // PostLikeResponse represents response after like/unlike operation
 type PostLikeResponse struct {
 	LikeCount int `json:"likeCount"`
 }
+
+// ServerPostMedia places an image of a post at its position in the carousel
+type ServerPostMedia struct {
+	Id             uuid.UUID
+	PostId         uuid.UUID
+	PostImageId    uuid.UUID
+	Position       int
+	CreateDatetime time.Time
+	UpdateDatetime time.Time
+	CreateUserId   uuid.UUID
+	UpdateUserId   uuid.UUID
+}
+
+type ServerPostMediaResponse struct {
+	Id       uuid.UUID         `json:"id"`
+	Urls     map[string]string `json:"urls"`
+	Position int               `json:"position"`
+}
@@ -10,6 +10,37 @@ import (
 const OwnerRole = "Owner"
 const MemberRole = "Member"
 
+type Permission string
+
+const (
+	PermissionAll              Permission = "*"
+	PermissionManageServer     Permission = "manage_server"
+	PermissionManageRoles      Permission = "manage_roles"
+	PermissionCreateInvite     Permission = "create_invite"
+	PermissionManageInvites    Permission = "manage_invites"
+	PermissionCreatePost       Permission = "create_post"
+	PermissionDeleteAnyPost    Permission = "delete_any_post"
+	PermissionDeleteAnyComment Permission = "delete_any_comment"
+	PermissionKickMembers      Permission = "kick_members"
+	PermissionBanMembers       Permission = "ban_members"
+)
+
+// AssignablePermissions lists every permission a role can be granted, in display order
+var AssignablePermissions = []Permission{
+	PermissionManageServer,
+	PermissionManageRoles,
+	PermissionCreateInvite,
+	PermissionManageInvites,
+	PermissionCreatePost,
+	PermissionDeleteAnyPost,
+	PermissionDeleteAnyComment,
+	PermissionKickMembers,
+	PermissionBanMembers,
+}
+
+const OwnerPermissions = `{"*": true}`
+const DefaultMemberPermissions = `{"create_invite": true, "create_post": true}`
+
 type ServerRole struct {
 	Id             uuid.UUID
 	ServerId       uuid.UUID
@@ -20,3 +51,65 @@ type ServerRole struct {
 	CreateUserId   uuid.UUID
 	UpdateUserId   uuid.UUID
 }
+
+// ServerMemberPermission is the role of an active member resolved for permission checks
+type ServerMemberPermission struct {
+	ServerId    uuid.UUID
+	UserId      uuid.UUID
+	RoleId      uuid.UUID
+	IsOwner     bool
+	Permissions map[string]bool
+}
+
+// Has reports whether the member holds the permission, the owner and wildcard roles hold all of them
+func (member ServerMemberPermission) Has(permission Permission) bool {
+	if member.IsOwner || member.Permissions[string(PermissionAll)] {
+		return true
+	}
+
+	return member.Permissions[string(permission)]
+}
+
+// PermissionList flattens a permissions document into the granted keys, wildcard first then in display order
+func PermissionList(permissions map[string]bool) []string {
+	list := []string{}
+	if permissions[string(PermissionAll)] {
+		list = append(list, string(PermissionAll))
+	}
+
+	for _, permission := range AssignablePermissions {
+		if permissions[string(permission)] {
+			list = append(list, string(permission))
+		}
+	}
+
+	return list
+}
+
+type ServerRoleCreateRequest struct {
+	Name        string   `json:"name"`
+	Permissions []string `json:"permissions"`
+}
+
+type ServerRoleUpdateRequest struct {
+	Name        string   `json:"name"`
+	Permissions []string `json:"permissions"`
+}
+
+type ServerRoleResponse struct {
+	Id             uuid.UUID `json:"id"`
+	ServerId       uuid.UUID `json:"serverId"`
+	Name           string    `json:"name"`
+	Permissions    []string  `json:"permissions"`
+	MemberCount    int       `json:"memberCount"`
+	CreateDatetime time.Time `json:"createDatetime"`
+	UpdateDatetime time.Time `json:"updateDatetime"`
+}
+
+type ServerRoleListResponse struct {
+	Data []ServerRoleResponse `json:"data"`
+}
+
+type ServerMemberRoleUpdateRequest struct {
+	RoleId string `json:"roleId"`
+}
@@ -0,0 +1,31 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type AuthSession struct {
+	Id          uuid.UUID
+	UserId      uuid.UUID
+	DeviceLabel string
+	IpAddress   string
+	UserAgent   string
+	CreateAt    int64
+	LastSeenAt  int64
+}
+
+type SessionResponse struct {
+	Id               uuid.UUID `json:"id"`
+	DeviceLabel      string    `json:"deviceLabel"`
+	IpAddress        string    `json:"ipAddress"`
+	UserAgent        string    `json:"userAgent"`
+	Current          bool      `json:"current"`
+	CreateDatetime   time.Time `json:"createDatetime"`
+	LastSeenDatetime time.Time `json:"lastSeenDatetime"`
+}
+
+type SessionListResponse struct {
+	Data []SessionResponse `json:"data"`
+}
@@ -0,0 +1,19 @@
+package model
+
+import "time"
+
+// StoredObject is an object found while listing the bucket
+type StoredObject struct {
+	Key          string
+	Size         int64
+	LastModified time.Time
+}
+
+// OrphanedObjectReport is the outcome of one garbage collector run, removed stays zero in dry run mode
+type OrphanedObjectReport struct {
+	DryRun       bool
+	Scanned      int
+	Orphaned     []StoredObject
+	OrphanedSize int64
+	Removed      int
+}
@@ -7,3 +7,7 @@ type TokenResponse struct {
 	RefreshTokenExpiresIn int    `json:"refreshTokenExpiresIn"`
 	TokenType             string `json:"tokenType"`
 }
+
+type TokenRefreshRequest struct {
+	RefreshToken string `json:"refreshToken"`
+}
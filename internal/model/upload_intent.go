@@ -0,0 +1,61 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// UploadPurpose tells what a direct upload will be attached to once it is finalized
+type UploadPurpose string
+
+const (
+	UploadPurposeUserAvatar   UploadPurpose = "user_avatar"
+	UploadPurposeServerAvatar UploadPurpose = "server_avatar"
+	UploadPurposeServerBanner UploadPurpose = "server_banner"
+	UploadPurposePost         UploadPurpose = "post"
+)
+
+// IsValidUploadPurpose reports whether purpose is one of the supported upload purposes
+func IsValidUploadPurpose(purpose UploadPurpose) bool {
+	switch purpose {
+	case UploadPurposeUserAvatar, UploadPurposeServerAvatar, UploadPurposeServerBanner, UploadPurposePost:
+		return true
+	}
+
+	return false
+}
+
+// UploadIntent is a pending direct upload to object storage. TargetId is the server for
+// server avatars and banners, the post for post images and nil for user avatars
+type UploadIntent struct {
+	Id              uuid.UUID
+	UserId          uuid.UUID
+	Purpose         UploadPurpose
+	TargetId        *uuid.UUID
+	Bucket          string
+	ObjectKey       string
+	ContentType     string
+	ExpiresDatetime time.Time
+	CreateDatetime  time.Time
+	UpdateDatetime  time.Time
+	CreateUserId    uuid.UUID
+	UpdateUserId    uuid.UUID
+}
+
+type UploadIntentCreateRequest struct {
+	Purpose     UploadPurpose `json:"purpose"`
+	TargetId    *uuid.UUID    `json:"targetId"`
+	ContentType string        `json:"contentType"`
+}
+
+// UploadIntentResponse tells the client where to upload, the fields go into the multipart form before the file
+type UploadIntentResponse struct {
+	Id              uuid.UUID         `json:"id"`
+	UploadUrl       string            `json:"uploadUrl"`
+	UploadFields    map[string]string `json:"uploadFields"`
+	Method          string            `json:"method"`
+	ContentType     string            `json:"contentType"`
+	MaxSize         int64             `json:"maxSize"`
+	ExpiresDatetime time.Time         `json:"expiresDatetime"`
+}
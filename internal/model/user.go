@@ -21,8 +21,9 @@ type UserCreateRequest struct {
 }
 
 type UserLoginRequest struct {
-	Username string `json:"username"`
-	Password string `json:"password"`
+	Username    string `json:"username"`
+	Password    string `json:"password"`
+	DeviceLabel string `json:"deviceLabel"`
 }
 
 type UsernameUpdateRequest struct {
@@ -37,6 +38,19 @@ type BioUpdateRequest struct {
 	Bio string `json:"bio"`
 }
 
+type PasswordChangeRequest struct {
+	CurrentPassword string `json:"currentPassword"`
+	NewPassword     string `json:"newPassword"`
+}
+
+type AccountDeleteRequest struct {
+	Password string `json:"password"`
+}
+
+type AccountDeleteResponse struct {
+	DeleteScheduledDatetime time.Time `json:"deleteScheduledDatetime"`
+}
+
 type UserSignupStartRequest struct {
 	Email string `json:"email"`
 }
@@ -65,14 +79,30 @@ type UserSignupStartResponse struct {
 	SessionId    uuid.UUID `json:"sessionId"`
 	OtpExpiresAt int64     `json:"otpExpiresAt"`
 }
+
+type UserForgotPasswordRequest struct {
+	Email string `json:"email"`
+}
+
+type UserForgotPasswordResponse struct {
+	SessionId    uuid.UUID `json:"sessionId"`
+	OtpExpiresAt int64     `json:"otpExpiresAt"`
+}
+
+type UserResetPasswordRequest struct {
+	SessionId string `json:"sessionId"`
+	OTP       string `json:"otp"`
+	Password  string `json:"password"`
+}
+
 type UserResponse struct {
-	Id             string    `json:"id"`
-	Username       string    `json:"username"`
-	Fullname       string    `json:"fullname"`
-	Email          string    `json:"email"`
-	AvatarImage    *string   `json:"avatarImage"`
-	CreateDatetime time.Time `json:"createDatetime"`
-	UpdateDatetime time.Time `json:"updateDatetime"`
+	Id              string            `json:"id"`
+	Username        string            `json:"username"`
+	Fullname        string            `json:"fullname"`
+	Email           string            `json:"email"`
+	AvatarImageUrls map[string]string `json:"avatarImageUrls"`
+	CreateDatetime  time.Time         `json:"createDatetime"`
+	UpdateDatetime  time.Time         `json:"updateDatetime"`
 }
 
 type UserSignupStatus struct {
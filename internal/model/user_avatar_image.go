@@ -13,6 +13,7 @@ type UserAvatarImage struct {
 	ObjectKey      string
 	MimeType       string
 	Size           int64
+	Variants       ImageVariants
 	CreateDatetime time.Time
 	UpdateDatetime time.Time
 	CreateUserId   uuid.UUID
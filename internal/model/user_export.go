@@ -0,0 +1,39 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type UserDataExportResponse struct {
+	Profile        UserResponse           `json:"profile"`
+	Memberships    []UserExportMembership `json:"memberships"`
+	Posts          []UserExportPost       `json:"posts"`
+	Comments       []UserExportComment    `json:"comments"`
+	ExportDatetime time.Time              `json:"exportDatetime"`
+}
+
+type UserExportMembership struct {
+	ServerId       uuid.UUID `json:"serverId"`
+	ServerName     string    `json:"serverName"`
+	RoleName       string    `json:"roleName"`
+	IsOwner        bool      `json:"isOwner"`
+	JoinedDatetime time.Time `json:"joinedDatetime"`
+}
+
+type UserExportPost struct {
+	Id             uuid.UUID `json:"id"`
+	ServerId       uuid.UUID `json:"serverId"`
+	Caption        string    `json:"caption"`
+	ImageUrls      []string  `json:"imageUrls"`
+	CreateDatetime time.Time `json:"createDatetime"`
+}
+
+type UserExportComment struct {
+	Id             uuid.UUID  `json:"id"`
+	PostId         uuid.UUID  `json:"postId"`
+	ParentId       *uuid.UUID `json:"parentId"`
+	Content        string     `json:"content"`
+	CreateDatetime time.Time  `json:"createDatetime"`
+}
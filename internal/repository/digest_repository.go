@@ -0,0 +1,149 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/ferdian3456/virdanproject/internal/model"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/minio/minio-go/v7"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+type DigestRepository struct {
+	Log      *zap.Logger
+	DB       *pgxpool.Pool
+	DBCache  *redis.Client
+	DBObject *minio.Client
+}
+
+func NewDigestRepository(zap *zap.Logger, db *pgxpool.Pool, dbCache *redis.Client, minio *minio.Client) *DigestRepository {
+	return &DigestRepository{
+		Log:      zap,
+		DB:       db,
+		DBCache:  dbCache,
+		DBObject: minio,
+	}
+}
+
+// GetDueDigestRecipients returns the users whose last digest window ended before the cutoff of their frequency,
+// oldest window first. Accounts waiting for deletion and the excluded users are skipped. A user without a previous
+// digest, such as an account older than digests or one that just turned them back on, gets only the last period
+func (repository *DigestRepository) GetDueDigestRecipients(ctx context.Context, dailyCutoff time.Time, weeklyCutoff time.Time, excludedUserIds []uuid.UUID, limit int) ([]model.DigestRecipient, error) {
+	query := `SELECT id, username, email, COALESCE(settings->>'digestFrequency', $3), digest_sent_datetime,
+		COALESCE(digest_sent_datetime, GREATEST(create_datetime, CASE COALESCE(settings->>'digestFrequency', $3) WHEN $5 THEN $1::timestamptz ELSE $2::timestamptz END))
+		FROM users
+		WHERE delete_scheduled_datetime IS NULL
+		AND COALESCE(settings->>'digestFrequency', $3) <> $4
+		AND COALESCE(digest_sent_datetime, create_datetime) <= CASE COALESCE(settings->>'digestFrequency', $3) WHEN $5 THEN $1::timestamptz ELSE $2::timestamptz END
+		AND NOT (id = ANY($6))
+		ORDER BY COALESCE(digest_sent_datetime, create_datetime) ASC, id ASC
+		LIMIT $7`
+
+	rows, err := repository.DB.Query(ctx, query, dailyCutoff, weeklyCutoff, string(model.DefaultDigestFrequency), string(model.DigestFrequencyOff), string(model.DigestFrequencyDaily), excludedUserIds, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	recipients := []model.DigestRecipient{}
+	for rows.Next() {
+		var recipient model.DigestRecipient
+		err = rows.Scan(&recipient.UserId, &recipient.Username, &recipient.Email, &recipient.Frequency, &recipient.SentDatetime, &recipient.SinceDatetime)
+		if err != nil {
+			return nil, err
+		}
+
+		recipients = append(recipients, recipient)
+	}
+
+	return recipients, rows.Err()
+}
+
+// ClaimDigest moves the end of the digest window of the user to sentDatetime. It only succeeds while the window
+// is still the one the recipient was read with, so two workers never send the same digest. The audit columns
+// are left alone, the user did not change anything
+func (repository *DigestRepository) ClaimDigest(ctx context.Context, userId uuid.UUID, previousSentDatetime *time.Time, sentDatetime time.Time) (bool, error) {
+	query := "UPDATE users SET digest_sent_datetime = $1 WHERE id = $2 AND digest_sent_datetime IS NOT DISTINCT FROM $3"
+
+	result, err := repository.DB.Exec(ctx, query, sentDatetime, userId, previousSentDatetime)
+	if err != nil {
+		return false, err
+	}
+
+	return result.RowsAffected() == 1, nil
+}
+
+// ReleaseDigest puts the previous window back after a digest could not be sent, the next run tries again
+func (repository *DigestRepository) ReleaseDigest(ctx context.Context, userId uuid.UUID, sentDatetime time.Time, previousSentDatetime *time.Time) error {
+	query := "UPDATE users SET digest_sent_datetime = $1 WHERE id = $2 AND digest_sent_datetime = $3"
+
+	_, err := repository.DB.Exec(ctx, query, previousSentDatetime, userId, sentDatetime)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// GetDigestPosts returns the newest posts of other members in the servers of the user within the window,
+// together with how many there are in total
+func (repository *DigestRepository) GetDigestPosts(ctx context.Context, userId uuid.UUID, since time.Time, until time.Time, limit int) ([]model.DigestPost, int, error) {
+	query := `SELECT B.name, C.username, A.caption, A.create_datetime, COUNT(*) OVER ()
+		FROM server_posts A
+		INNER JOIN servers B ON B.id = A.server_id
+		INNER JOIN users C ON C.id = A.author_id
+		INNER JOIN server_members D ON D.server_id = A.server_id AND D.user_id = $1 AND D.status = $2
+		WHERE A.author_id <> $1 AND A.create_datetime > $3 AND A.create_datetime <= $4
+		ORDER BY A.create_datetime DESC, A.id DESC
+		LIMIT $5`
+
+	rows, err := repository.DB.Query(ctx, query, userId, model.MemberStatusActive, since, until, limit)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	posts := []model.DigestPost{}
+	total := 0
+	for rows.Next() {
+		var post model.DigestPost
+		err = rows.Scan(&post.ServerName, &post.AuthorUsername, &post.Caption, &post.CreateDatetime, &total)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		posts = append(posts, post)
+	}
+
+	return posts, total, rows.Err()
+}
+
+// GetDigestNotificationCounts counts the unread notifications of the user within the window by type
+func (repository *DigestRepository) GetDigestNotificationCounts(ctx context.Context, userId uuid.UUID, since time.Time, until time.Time) (map[model.NotificationType]int, error) {
+	query := `SELECT type, COUNT(*) FROM notifications
+		WHERE user_id = $1 AND read_datetime IS NULL AND create_datetime > $2 AND create_datetime <= $3
+		GROUP BY type`
+
+	rows, err := repository.DB.Query(ctx, query, userId, since, until)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := map[model.NotificationType]int{}
+	for rows.Next() {
+		var notificationType model.NotificationType
+		var count int
+		err = rows.Scan(&notificationType, &count)
+		if err != nil {
+			return nil, err
+		}
+
+		counts[notificationType] = count
+	}
+
+	return counts, rows.Err()
+}
@@ -0,0 +1,77 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/ferdian3456/virdanproject/internal/constant"
+	"github.com/ferdian3456/virdanproject/internal/model"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/minio/minio-go/v7"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+type EventRepository struct {
+	Log      *zap.Logger
+	DB       *pgxpool.Pool
+	DBCache  *redis.Client
+	DBObject *minio.Client
+}
+
+func NewEventRepository(zap *zap.Logger, db *pgxpool.Pool, dbCache *redis.Client, minio *minio.Client) *EventRepository {
+	return &EventRepository{
+		Log:      zap,
+		DB:       db,
+		DBCache:  dbCache,
+		DBObject: minio,
+	}
+}
+
+// PublishServerEvent hands the event to every API instance through Redis
+func (repository *EventRepository) PublishServerEvent(ctx context.Context, event model.ServerEvent) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	return repository.DBCache.Publish(ctx, constant.SERVER_EVENT_CHANNEL, payload).Err()
+}
+
+// SubscribeServerEvents returns once the subscription is confirmed, so no event published afterwards is missed
+func (repository *EventRepository) SubscribeServerEvents(ctx context.Context) (*redis.PubSub, error) {
+	pubsub := repository.DBCache.Subscribe(ctx, constant.SERVER_EVENT_CHANNEL)
+
+	_, err := pubsub.Receive(ctx)
+	if err != nil {
+		_ = pubsub.Close()
+		return nil, err
+	}
+
+	return pubsub, nil
+}
+
+// GetActiveServerIds lists the servers the user is an active member of
+func (repository *EventRepository) GetActiveServerIds(ctx context.Context, userId uuid.UUID) ([]uuid.UUID, error) {
+	query := "SELECT server_id FROM server_members WHERE user_id = $1 AND status = $2"
+
+	rows, err := repository.DB.Query(ctx, query, userId, model.MemberStatusActive)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	serverIds := []uuid.UUID{}
+	for rows.Next() {
+		var serverId uuid.UUID
+		err = rows.Scan(&serverId)
+		if err != nil {
+			return nil, err
+		}
+
+		serverIds = append(serverIds, serverId)
+	}
+
+	return serverIds, rows.Err()
+}
@@ -0,0 +1,177 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/ferdian3456/virdanproject/internal/model"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/minio/minio-go/v7"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+type NotificationRepository struct {
+	Log      *zap.Logger
+	DB       *pgxpool.Pool
+	DBCache  *redis.Client
+	DBObject *minio.Client
+}
+
+func NewNotificationRepository(zap *zap.Logger, db *pgxpool.Pool, dbCache *redis.Client, minio *minio.Client) *NotificationRepository {
+	return &NotificationRepository{
+		Log:      zap,
+		DB:       db,
+		DBCache:  dbCache,
+		DBObject: minio,
+	}
+}
+
+func (repository *NotificationRepository) CreateNotification(ctx context.Context, notification model.Notification) error {
+	query := `INSERT INTO notifications (id, user_id, actor_id, type, server_id, post_id, comment_id, read_datetime, create_user_id, update_user_id, create_datetime, update_datetime)
+		VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12)`
+
+	_, err := repository.DB.Exec(ctx, query, notification.Id, notification.UserId, notification.ActorId, notification.Type, notification.ServerId, notification.PostId, notification.CommentId, notification.ReadDatetime, notification.CreateUserId, notification.UpdateUserId, notification.CreateDatetime, notification.UpdateDatetime)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (repository *NotificationRepository) GetNotifications(ctx context.Context, limit int, userId uuid.UUID, cursor *model.NotificationCursor) ([]model.NotificationResponse, error) {
+	query := `SELECT A.id, A.type, A.actor_id, B.username, A.server_id, A.post_id, A.comment_id, A.read_datetime IS NOT NULL, A.create_datetime
+		FROM notifications A
+		LEFT JOIN users B ON B.id = A.actor_id
+		WHERE A.user_id = $1`
+	args := []interface{}{userId, limit}
+
+	if cursor.Id != uuid.Nil && !cursor.CreateDatetime.IsZero() {
+		query += " AND (A.create_datetime < $3 OR (A.create_datetime = $3 AND A.id < $4))"
+		args = append(args, cursor.CreateDatetime, cursor.Id)
+	}
+
+	query += " ORDER BY A.create_datetime DESC, A.id DESC LIMIT $2"
+
+	rows, err := repository.DB.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	notifications := []model.NotificationResponse{}
+	for rows.Next() {
+		var notification model.NotificationResponse
+		err = rows.Scan(&notification.Id, &notification.Type, &notification.ActorId, &notification.ActorUsername, &notification.ServerId, &notification.PostId, &notification.CommentId, &notification.IsRead, &notification.CreateDatetime)
+		if err != nil {
+			return nil, err
+		}
+
+		notifications = append(notifications, notification)
+	}
+
+	return notifications, rows.Err()
+}
+
+func (repository *NotificationRepository) GetUnreadNotificationCount(ctx context.Context, userId uuid.UUID) (int, error) {
+	query := "SELECT COUNT(*) FROM notifications WHERE user_id = $1 AND read_datetime IS NULL"
+
+	var count int
+	err := repository.DB.QueryRow(ctx, query, userId).Scan(&count)
+	if err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// MarkNotificationRead keeps the first read time of an already read notification, false means the user
+// has no notification with that id
+func (repository *NotificationRepository) MarkNotificationRead(ctx context.Context, notificationId uuid.UUID, userId uuid.UUID, readDatetime time.Time) (bool, error) {
+	query := "UPDATE notifications SET read_datetime = COALESCE(read_datetime, $1), update_datetime = $1, update_user_id = $2 WHERE id = $3 AND user_id = $2"
+
+	result, err := repository.DB.Exec(ctx, query, readDatetime, userId, notificationId)
+	if err != nil {
+		return false, err
+	}
+
+	return result.RowsAffected() == 1, nil
+}
+
+func (repository *NotificationRepository) MarkAllNotificationsRead(ctx context.Context, userId uuid.UUID, readDatetime time.Time) error {
+	query := "UPDATE notifications SET read_datetime = $1, update_datetime = $1, update_user_id = $2 WHERE user_id = $2 AND read_datetime IS NULL"
+
+	_, err := repository.DB.Exec(ctx, query, readDatetime, userId)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// GetMutedNotificationTypes reads the muted types from users.settings, a user without the setting mutes nothing
+func (repository *NotificationRepository) GetMutedNotificationTypes(ctx context.Context, userId uuid.UUID) ([]model.NotificationType, error) {
+	query := "SELECT COALESCE(settings->'mutedNotificationTypes', '[]'::jsonb) FROM users WHERE id = $1"
+
+	mutedTypes := []model.NotificationType{}
+	err := repository.DB.QueryRow(ctx, query, userId).Scan(&mutedTypes)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return []model.NotificationType{}, nil
+		}
+		return nil, err
+	}
+
+	return mutedTypes, nil
+}
+
+// GetNotificationSettings reads the notification preferences from users.settings, missing keys get their defaults
+func (repository *NotificationRepository) GetNotificationSettings(ctx context.Context, userId uuid.UUID) (model.NotificationSettings, error) {
+	query := `SELECT COALESCE(settings->'mutedNotificationTypes', '[]'::jsonb), COALESCE(settings->>'digestFrequency', $2)
+		FROM users WHERE id = $1`
+
+	settings := model.NotificationSettings{
+		MutedTypes:      []model.NotificationType{},
+		DigestFrequency: model.DefaultDigestFrequency,
+	}
+	err := repository.DB.QueryRow(ctx, query, userId, string(model.DefaultDigestFrequency)).Scan(&settings.MutedTypes, &settings.DigestFrequency)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return settings, nil
+		}
+		return settings, err
+	}
+
+	return settings, nil
+}
+
+// UpdateNotificationSettings replaces the notification preferences and leaves the other keys of users.settings alone.
+// Turning the digest back on forgets the old window, the next digest only covers its own period
+func (repository *NotificationRepository) UpdateNotificationSettings(ctx context.Context, userId uuid.UUID, settings model.NotificationSettings, updateDatetime time.Time) error {
+	query := `UPDATE users SET settings = settings || jsonb_build_object('mutedNotificationTypes', $1::jsonb, 'digestFrequency', $2::text),
+		digest_sent_datetime = CASE WHEN COALESCE(settings->>'digestFrequency', $5) = $6 AND $2::text <> $6 THEN NULL ELSE digest_sent_datetime END,
+		update_datetime = $3, update_user_id = $4 WHERE id = $4`
+
+	_, err := repository.DB.Exec(ctx, query, settings.MutedTypes, string(settings.DigestFrequency), updateDatetime, userId, string(model.DefaultDigestFrequency), string(model.DigestFrequencyOff))
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// UpdateDigestFrequency changes only the digest frequency, false means the user does not exist
+func (repository *NotificationRepository) UpdateDigestFrequency(ctx context.Context, userId uuid.UUID, frequency model.DigestFrequency, updateDatetime time.Time) (bool, error) {
+	query := `UPDATE users SET settings = settings || jsonb_build_object('digestFrequency', $1::text),
+		update_datetime = $2, update_user_id = $3 WHERE id = $3`
+
+	result, err := repository.DB.Exec(ctx, query, string(frequency), updateDatetime, userId)
+	if err != nil {
+		return false, err
+	}
+
+	return result.RowsAffected() == 1, nil
+}
@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/ferdian3456/virdanproject/internal/model"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/minio/minio-go/v7"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+type ObjectRepository struct {
+	Log      *zap.Logger
+	DB       *pgxpool.Pool
+	DBCache  *redis.Client
+	DBObject *minio.Client
+}
+
+func NewObjectRepository(zap *zap.Logger, db *pgxpool.Pool, dbCache *redis.Client, minio *minio.Client) *ObjectRepository {
+	return &ObjectRepository{
+		Log:      zap,
+		DB:       db,
+		DBCache:  dbCache,
+		DBObject: minio,
+	}
+}
+
+// ListObjects lists every object under the prefix, including nested ones
+func (repository *ObjectRepository) ListObjects(ctx context.Context, bucketName string, prefix string) ([]model.StoredObject, error) {
+	objects := []model.StoredObject{}
+
+	for info := range repository.DBObject.ListObjects(ctx, bucketName, minio.ListObjectsOptions{Prefix: prefix, Recursive: true}) {
+		if info.Err != nil {
+			return nil, info.Err
+		}
+
+		objects = append(objects, model.StoredObject{
+			Key:          info.Key,
+			Size:         info.Size,
+			LastModified: info.LastModified,
+		})
+	}
+
+	return objects, nil
+}
+
+// GetReferencedObjectKeys collects the key of every object a row still points to, the originals and variants
+// of every image table and the staging objects of pending upload intents
+func (repository *ObjectRepository) GetReferencedObjectKeys(ctx context.Context) (map[string]bool, error) {
+	query := `SELECT object_key, variants FROM server_post_images
+		UNION ALL SELECT object_key, variants FROM server_avatar_images
+		UNION ALL SELECT object_key, variants FROM server_banner_images
+		UNION ALL SELECT object_key, variants FROM user_avatar_images
+		UNION ALL SELECT object_key, '{}'::jsonb FROM upload_intents`
+
+	rows, err := repository.DB.Query(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	objectKeys := map[string]bool{}
+	for rows.Next() {
+		var objectKey string
+		var variants model.ImageVariants
+		err = rows.Scan(&objectKey, &variants)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, key := range variants.ObjectKeys(objectKey) {
+			objectKeys[key] = true
+		}
+	}
+
+	return objectKeys, rows.Err()
+}
+
+func (repository *ObjectRepository) RemoveObject(ctx context.Context, bucketName string, objectKey string) error {
+	err := repository.DBObject.RemoveObject(ctx, bucketName, objectKey, minio.RemoveObjectOptions{})
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,53 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ferdian3456/virdanproject/internal/constant"
+	"github.com/ferdian3456/virdanproject/internal/model"
+	"github.com/minio/minio-go/v7"
+)
+
+// objectCacheControl keeps public objects cacheable by a CDN forever, private ones are served
+// through short lived presigned urls so nothing in between may keep a copy
+func objectCacheControl(objectKey string) string {
+	if model.IsPrivateObjectKey(objectKey) {
+		return "private, no-store"
+	}
+
+	return "public, max-age=31536000, immutable"
+}
+
+// objectUrl builds the plain url of a public object and a presigned url of a private one
+func objectUrl(ctx context.Context, client *minio.Client, bucketName string, minioFullUrl string, objectKey string) (string, error) {
+	if !model.IsPrivateObjectKey(objectKey) {
+		return fmt.Sprintf("%s/%s", minioFullUrl, objectKey), nil
+	}
+
+	presignedUrl, err := client.PresignedGetObject(ctx, bucketName, objectKey, constant.PRIVATE_MEDIA_URL_EXPIRY, nil)
+	if err != nil {
+		return "", err
+	}
+
+	return presignedUrl.String(), nil
+}
+
+// variantUrls is Urls of the variants with the private ones presigned
+func variantUrls(ctx context.Context, client *minio.Client, bucketName string, minioFullUrl string, variants model.ImageVariants) (map[string]string, error) {
+	if len(variants) == 0 {
+		return nil, nil
+	}
+
+	urls := make(map[string]string, len(variants))
+	for name, objectKey := range variants {
+		url, err := objectUrl(ctx, client, bucketName, minioFullUrl, objectKey)
+		if err != nil {
+			return nil, err
+		}
+
+		urls[name] = url
+	}
+
+	return urls, nil
+}
@@ -32,27 +32,11 @@ func NewPostRepository(zap *zap.Logger, db *pgxpool.Pool, dbCache *redis.Client,
 	}
 }
 
-func (repository *PostRepository) CheckServerMember(ctx context.Context, serverId uuid.UUID, userId uuid.UUID) (int, error) {
-	query := "SELECT 1 FROM server_members WHERE server_id = $1 AND user_id = $2 AND status = $3"
-
-	var exists int
-	err := repository.DB.QueryRow(ctx, query, serverId, userId, model.MemberStatusActive).Scan(&exists)
-	if err != nil {
-		if errors.Is(err, pgx.ErrNoRows) {
-			return exists, nil
-		}
-
-		return exists, err
-	}
-
-	return exists, nil
-}
-
-func (repository *PostRepository) UploadPostObject(ctx context.Context, bucketName string, imageName string, imageFile *bytes.Reader, imageSize int64) error {
+func (repository *PostRepository) UploadPostObject(ctx context.Context, bucketName string, imageName string, imageFile *bytes.Reader, imageSize int64, contentType string) error {
 	_, err := repository.DBObject.PutObject(ctx, bucketName, imageName, imageFile, imageSize,
 		minio.PutObjectOptions{
-			ContentType:  "image/webp",
-			CacheControl: "public, max-age=31536000, immutable",
+			ContentType:  contentType,
+			CacheControl: objectCacheControl(imageName),
 		})
 	if err != nil {
 		return err
@@ -62,9 +46,9 @@ func (repository *PostRepository) UploadPostObject(ctx context.Context, bucketNa
 }
 
 func (repository *PostRepository) CreateServerPostImage(ctx context.Context, tx pgx.Tx, serverPostImage model.ServerPostImages) error {
-	query := "INSERT INTO server_post_images (id, bucket, object_key, mime_type, size, create_datetime, update_datetime, create_user_id, update_user_id) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)"
+	query := "INSERT INTO server_post_images (id, bucket, object_key, mime_type, size, variants, create_datetime, update_datetime, create_user_id, update_user_id) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)"
 
-	_, err := tx.Exec(ctx, query, serverPostImage.Id, serverPostImage.Bucket, serverPostImage.ObjectKey, serverPostImage.MimeType, serverPostImage.Size, serverPostImage.CreateDatetime, serverPostImage.UpdateDatetime, serverPostImage.CreateUserId, serverPostImage.UpdateUserId)
+	_, err := tx.Exec(ctx, query, serverPostImage.Id, serverPostImage.Bucket, serverPostImage.ObjectKey, serverPostImage.MimeType, serverPostImage.Size, serverPostImage.Variants, serverPostImage.CreateDatetime, serverPostImage.UpdateDatetime, serverPostImage.CreateUserId, serverPostImage.UpdateUserId)
 	if err != nil {
 		return err
 	}
@@ -73,9 +57,9 @@ func (repository *PostRepository) CreateServerPostImage(ctx context.Context, tx
 }
 
 func (repository *PostRepository) CreateServerPost(ctx context.Context, tx pgx.Tx, serverPost model.ServerPosts) error {
-	query := "INSERT INTO server_posts (id, server_id, author_id, post_image_id, caption, create_datetime, update_datetime, create_user_id, update_user_id) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)"
+	query := "INSERT INTO server_posts (id, server_id, author_id, caption, create_datetime, update_datetime, create_user_id, update_user_id) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)"
 
-	_, err := tx.Exec(ctx, query, serverPost.Id, serverPost.ServerId, serverPost.AuthorId, serverPost.PostImageId, serverPost.Caption, serverPost.CreateDatetime, serverPost.UpdateDatetime, serverPost.CreateUserId, serverPost.UpdateUserId)
+	_, err := tx.Exec(ctx, query, serverPost.Id, serverPost.ServerId, serverPost.AuthorId, serverPost.Caption, serverPost.CreateDatetime, serverPost.UpdateDatetime, serverPost.CreateUserId, serverPost.UpdateUserId)
 	if err != nil {
 		return err
 	}
@@ -83,6 +67,76 @@ func (repository *PostRepository) CreateServerPost(ctx context.Context, tx pgx.T
 	return nil
 }
 
+func (repository *PostRepository) CreateServerPostMedia(ctx context.Context, tx pgx.Tx, media model.ServerPostMedia) error {
+	query := "INSERT INTO server_post_media (id, post_id, post_image_id, position, create_datetime, update_datetime, create_user_id, update_user_id) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)"
+
+	_, err := tx.Exec(ctx, query, media.Id, media.PostId, media.PostImageId, media.Position, media.CreateDatetime, media.UpdateDatetime, media.CreateUserId, media.UpdateUserId)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// GetNextMediaPosition locks the post and returns how many media it holds with the position after the last one
+func (repository *PostRepository) GetNextMediaPosition(ctx context.Context, tx pgx.Tx, postId uuid.UUID) (int, int, error) {
+	query := "SELECT id FROM server_posts WHERE id = $1 FOR UPDATE"
+
+	_, err := tx.Exec(ctx, query, postId)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	query = "SELECT COUNT(*), COALESCE(MAX(position) + 1, 0) FROM server_post_media WHERE post_id = $1"
+
+	var total, position int
+	err = tx.QueryRow(ctx, query, postId).Scan(&total, &position)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return total, position, nil
+}
+
+// GetPostMedia lists the media of every post in carousel order, media of private servers get presigned urls
+func (repository *PostRepository) GetPostMedia(ctx context.Context, postIds []uuid.UUID, minioFullUrl string) (map[uuid.UUID][]model.ServerPostMediaResponse, error) {
+	query := `
+		SELECT spm.post_id, spm.id, spi.bucket, spi.variants, spm.position
+		FROM server_post_media spm
+		INNER JOIN server_post_images spi ON spm.post_image_id = spi.id
+		WHERE spm.post_id = ANY($1)
+		ORDER BY spm.post_id, spm.position
+	`
+
+	rows, err := repository.DB.Query(ctx, query, postIds)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	mediaByPost := make(map[uuid.UUID][]model.ServerPostMediaResponse, len(postIds))
+
+	for rows.Next() {
+		var postId uuid.UUID
+		var bucketName string
+		var variants model.ImageVariants
+		var media model.ServerPostMediaResponse
+		err := rows.Scan(&postId, &media.Id, &bucketName, &variants, &media.Position)
+		if err != nil {
+			return nil, err
+		}
+
+		media.Urls, err = variantUrls(ctx, repository.DBObject, bucketName, minioFullUrl, variants)
+		if err != nil {
+			return nil, err
+		}
+
+		mediaByPost[postId] = append(mediaByPost[postId], media)
+	}
+
+	return mediaByPost, rows.Err()
+}
+
 func (repository *PostRepository) CheckPostOwnership(ctx context.Context, postId uuid.UUID, userId uuid.UUID) (int, error) {
 	query := "SELECT 1 FROM server_posts WHERE id = $1 AND author_id = $2"
 
@@ -110,10 +164,10 @@ func (repository *PostRepository) UpdatePostCaption(ctx context.Context, postId
 	return nil
 }
 
-func (repository *PostRepository) DeletePost(ctx context.Context, postId uuid.UUID) error {
+func (repository *PostRepository) DeletePost(ctx context.Context, tx pgx.Tx, postId uuid.UUID) error {
 	query := "DELETE FROM server_posts WHERE id = $1"
 
-	_, err := repository.DB.Exec(ctx, query, postId)
+	_, err := tx.Exec(ctx, query, postId)
 	if err != nil {
 		return err
 	}
@@ -121,36 +175,30 @@ func (repository *PostRepository) DeletePost(ctx context.Context, postId uuid.UU
 	return nil
 }
 
-func (repository *PostRepository) GetPostImage(ctx context.Context, tx pgx.Tx, postId uuid.UUID) (uuid.UUID, string, error) {
-	query := `
-		SELECT sp.post_image_id, spi.object_key
-		FROM server_posts sp
-		INNER JOIN server_post_images spi ON sp.post_image_id = spi.id
-		WHERE sp.id = $1
-	`
+// DeletePostImages removes the image rows of the post and returns the object keys of their originals
+// and variants so the caller can remove them from storage after commit
+func (repository *PostRepository) DeletePostImages(ctx context.Context, tx pgx.Tx, postId uuid.UUID) ([]string, error) {
+	query := "DELETE FROM server_post_images WHERE id IN (SELECT post_image_id FROM server_post_media WHERE post_id = $1) RETURNING object_key, variants"
 
-	var postImageId uuid.UUID
-	var objectKey string
-	err := tx.QueryRow(ctx, query, postId).Scan(&postImageId, &objectKey)
+	rows, err := tx.Query(ctx, query, postId)
 	if err != nil {
-		if errors.Is(err, pgx.ErrNoRows) {
-			return uuid.Nil, "", nil
-		}
-		return uuid.Nil, "", err
+		return nil, err
 	}
+	defer rows.Close()
 
-	return postImageId, objectKey, nil
-}
-
-func (repository *PostRepository) DeletePostImage(ctx context.Context, tx pgx.Tx, postImageId uuid.UUID) error {
-	query := "DELETE FROM server_post_images WHERE id = $1"
+	objectKeys := []string{}
+	for rows.Next() {
+		var objectKey string
+		var variants model.ImageVariants
+		err = rows.Scan(&objectKey, &variants)
+		if err != nil {
+			return nil, err
+		}
 
-	_, err := tx.Exec(ctx, query, postImageId)
-	if err != nil {
-		return err
+		objectKeys = append(objectKeys, variants.ObjectKeys(objectKey)...)
 	}
 
-	return nil
+	return objectKeys, rows.Err()
 }
 
 func (repository *PostRepository) DeletePostObject(ctx context.Context, bucketName string, objectKey string) error {
@@ -162,6 +210,16 @@ func (repository *PostRepository) DeletePostObject(ctx context.Context, bucketNa
 	return nil
 }
 
+// postAuthorColumns selects the author of a post in the order of model.AuthorRow, the author is joined through
+// postAuthorJoins where sp is the post table
+const postAuthorColumns = `sp.author_id, pu.username, pu.fullname, pua.variants, pm.status, pr.id, pr.name`
+
+const postAuthorJoins = `
+	LEFT JOIN users pu ON pu.id = sp.author_id
+	LEFT JOIN user_avatar_images pua ON pua.id = pu.avatar_image_id
+	LEFT JOIN server_members pm ON pm.server_id = sp.server_id AND pm.user_id = sp.author_id
+	LEFT JOIN server_roles pr ON pr.id = pm.server_role_id`
+
 func (repository *PostRepository) GetServerPosts(ctx context.Context, limit int, serverId uuid.UUID, cursor *model.ServerPostCursor, minioFullUrl string) ([]model.ServerPostResponse, error) {
 	var rows pgx.Rows
 	var err error
@@ -170,11 +228,11 @@ func (repository *PostRepository) GetServerPosts(ctx context.Context, limit int,
 	if cursor.Id != uuid.Nil && !cursor.CreateDatetime.IsZero() {
 		// Query with cursor for pagination
 		queryWithCursor := `
-			SELECT sp.author_id, sp.id, spi.object_key, sp.caption, sp.create_datetime, sp.update_datetime,
+			SELECT sp.id, sp.caption, sp.create_datetime, sp.update_datetime,
 			       COALESCE(comment_counts.comment_count, 0) as comment_count,
-			       COALESCE(like_counts.like_count, 0) as like_count
+			       COALESCE(like_counts.like_count, 0) as like_count,
+			       ` + postAuthorColumns + `
 			FROM server_posts sp
-			INNER JOIN server_post_images spi ON sp.post_image_id = spi.id
 			LEFT JOIN (
 				SELECT post_id, COUNT(*) as comment_count
 				FROM server_post_comments
@@ -184,7 +242,7 @@ func (repository *PostRepository) GetServerPosts(ctx context.Context, limit int,
 				SELECT post_id, COUNT(*) as like_count
 				FROM server_post_likes
 				GROUP BY post_id
-			) like_counts ON sp.id = like_counts.post_id
+			) like_counts ON sp.id = like_counts.post_id` + postAuthorJoins + `
 			WHERE sp.server_id = $1
 			AND (sp.create_datetime < $2 OR (sp.create_datetime = $2 AND sp.id < $3))
 			ORDER BY sp.create_datetime DESC, sp.id DESC
@@ -194,11 +252,11 @@ func (repository *PostRepository) GetServerPosts(ctx context.Context, limit int,
 	} else {
 		// Query without cursor for first page
 		query := `
-			SELECT sp.author_id, sp.id, spi.object_key, sp.caption, sp.create_datetime, sp.update_datetime,
+			SELECT sp.id, sp.caption, sp.create_datetime, sp.update_datetime,
 			       COALESCE(comment_counts.comment_count, 0) as comment_count,
-			       COALESCE(like_counts.like_count, 0) as like_count
+			       COALESCE(like_counts.like_count, 0) as like_count,
+			       ` + postAuthorColumns + `
 			FROM server_posts sp
-			INNER JOIN server_post_images spi ON sp.post_image_id = spi.id
 			LEFT JOIN (
 				SELECT post_id, COUNT(*) as comment_count
 				FROM server_post_comments
@@ -208,7 +266,7 @@ func (repository *PostRepository) GetServerPosts(ctx context.Context, limit int,
 				SELECT post_id, COUNT(*) as like_count
 				FROM server_post_likes
 				GROUP BY post_id
-			) like_counts ON sp.id = like_counts.post_id
+			) like_counts ON sp.id = like_counts.post_id` + postAuthorJoins + `
 			WHERE sp.server_id = $1
 			ORDER BY sp.create_datetime DESC, sp.id DESC
 			LIMIT $2
@@ -224,27 +282,96 @@ func (repository *PostRepository) GetServerPosts(ctx context.Context, limit int,
 	posts := []model.ServerPostResponse{}
 
 	for rows.Next() {
-		var post model.ServerPostResponse
-		err := rows.Scan(&post.OwnerId, &post.PostId, &post.PostImageUrl, &post.Caption, &post.CreateDatetime, &post.UpdateDatetime, &post.CommentCount, &post.LikeCount)
+		post, err := scanPost(rows, minioFullUrl)
 		if err != nil {
 			return nil, err
 		}
 
-		post.PostImageUrl = fmt.Sprintf("%s/%s.webp", minioFullUrl, post.PostImageUrl)
+		posts = append(posts, post)
+	}
+
+	return posts, rows.Err()
+}
+
+// GetFeedPosts merges the newest posts of every server the user is an active member of. Each server reads at most
+// limit posts through its own index range before the merge, so the cost grows with the number of servers and not
+// with the number of posts in them. Counts and authors are only looked up for the page that is returned
+func (repository *PostRepository) GetFeedPosts(ctx context.Context, limit int, userId uuid.UUID, cursor *model.ServerPostCursor, minioFullUrl string) ([]model.FeedPostResponse, error) {
+	var cursorDatetime *time.Time
+	var cursorId *uuid.UUID
+	if cursor.Id != uuid.Nil && !cursor.CreateDatetime.IsZero() {
+		cursorDatetime = &cursor.CreateDatetime
+		cursorId = &cursor.Id
+	}
+
+	query := `
+		WITH feed AS (
+			SELECT sp.id, sp.server_id, sp.author_id, sp.caption, sp.create_datetime, sp.update_datetime
+			FROM server_members sm
+			CROSS JOIN LATERAL (
+				SELECT id, server_id, author_id, caption, create_datetime, update_datetime
+				FROM server_posts
+				WHERE server_id = sm.server_id
+				AND ($2::timestamptz IS NULL OR create_datetime < $2 OR (create_datetime = $2 AND id < $3))
+				ORDER BY create_datetime DESC, id DESC
+				LIMIT $4
+			) sp
+			WHERE sm.user_id = $1 AND sm.status = $5
+			ORDER BY sp.create_datetime DESC, sp.id DESC
+			LIMIT $4
+		)
+		SELECT sp.server_id, s.name, sa.variants,
+		       EXISTS (SELECT 1 FROM server_post_likes WHERE post_id = sp.id AND user_id = $1) as is_liked,
+		       sp.id, sp.caption, sp.create_datetime, sp.update_datetime,
+		       (SELECT COUNT(*) FROM server_post_comments WHERE post_id = sp.id) as comment_count,
+		       (SELECT COUNT(*) FROM server_post_likes WHERE post_id = sp.id) as like_count,
+		       ` + postAuthorColumns + `
+		FROM feed sp
+		INNER JOIN servers s ON s.id = sp.server_id
+		LEFT JOIN server_avatar_images sa ON sa.id = s.avatar_image_id` + postAuthorJoins + `
+		ORDER BY sp.create_datetime DESC, sp.id DESC
+	`
+
+	rows, err := repository.DB.Query(ctx, query, userId, cursorDatetime, cursorId, limit, model.MemberStatusActive)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	posts := []model.FeedPostResponse{}
+
+	for rows.Next() {
+		var post model.FeedPostResponse
+		var serverAvatarVariants model.ImageVariants
+		var author model.AuthorRow
+		err := rows.Scan(
+			&post.Server.Id, &post.Server.Name, &serverAvatarVariants, &post.IsLiked,
+			&post.PostId, &post.Caption, &post.CreateDatetime, &post.UpdateDatetime, &post.CommentCount, &post.LikeCount,
+			&author.UserId, &author.Username, &author.Fullname, &author.AvatarVariants, &author.MemberStatus, &author.RoleId, &author.RoleName,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		post.Server.AvatarImageUrls = serverAvatarVariants.Urls(minioFullUrl)
+		if author.UserId != nil {
+			post.OwnerId = *author.UserId
+		}
+		post.Author = author.Summary(minioFullUrl)
 
 		posts = append(posts, post)
 	}
 
-	return posts, nil
+	return posts, rows.Err()
 }
 
 func (repository *PostRepository) GetPost(ctx context.Context, postId uuid.UUID, minioFullUrl string) (model.ServerPostResponse, error) {
 	query := `
-		SELECT sp.author_id, sp.id, spi.object_key, sp.caption, sp.create_datetime, sp.update_datetime,
+		SELECT sp.id, sp.caption, sp.create_datetime, sp.update_datetime,
 		       COALESCE(comment_counts.comment_count, 0) as comment_count,
-		       COALESCE(like_counts.like_count, 0) as like_count
+		       COALESCE(like_counts.like_count, 0) as like_count,
+		       ` + postAuthorColumns + `
 		FROM server_posts sp
-		INNER JOIN server_post_images spi ON sp.post_image_id = spi.id
 		LEFT JOIN (
 			SELECT post_id, COUNT(*) as comment_count
 			FROM server_post_comments
@@ -254,15 +381,11 @@ func (repository *PostRepository) GetPost(ctx context.Context, postId uuid.UUID,
 			SELECT post_id, COUNT(*) as like_count
 			FROM server_post_likes
 			GROUP BY post_id
-		) like_counts ON sp.id = like_counts.post_id
+		) like_counts ON sp.id = like_counts.post_id` + postAuthorJoins + `
 		WHERE sp.id = $1
 	`
 
-	var post model.ServerPostResponse
-	err := repository.DB.QueryRow(ctx, query, postId).Scan(
-		&post.OwnerId, &post.PostId, &post.PostImageUrl, &post.Caption,
-		&post.CreateDatetime, &post.UpdateDatetime, &post.CommentCount, &post.LikeCount,
-	)
+	post, err := scanPost(repository.DB.QueryRow(ctx, query, postId), minioFullUrl)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return post, nil
@@ -271,7 +394,25 @@ func (repository *PostRepository) GetPost(ctx context.Context, postId uuid.UUID,
 		return post, err
 	}
 
-	post.PostImageUrl = fmt.Sprintf("%s/%s.webp", minioFullUrl, post.PostImageUrl)
+	return post, nil
+}
+
+// scanPost reads a post selected with its counts followed by postAuthorColumns
+func scanPost(row pgx.Row, minioFullUrl string) (model.ServerPostResponse, error) {
+	var post model.ServerPostResponse
+	var author model.AuthorRow
+	err := row.Scan(
+		&post.PostId, &post.Caption, &post.CreateDatetime, &post.UpdateDatetime, &post.CommentCount, &post.LikeCount,
+		&author.UserId, &author.Username, &author.Fullname, &author.AvatarVariants, &author.MemberStatus, &author.RoleId, &author.RoleName,
+	)
+	if err != nil {
+		return model.ServerPostResponse{}, err
+	}
+
+	if author.UserId != nil {
+		post.OwnerId = *author.UserId
+	}
+	post.Author = author.Summary(minioFullUrl)
 
 	return post, nil
 }
@@ -318,27 +459,6 @@ func (repository *PostRepository) GetPostServerId(ctx context.Context, postId uu
 	return serverId, nil
 }
 
-func (repository *PostRepository) CheckPostServerMember(ctx context.Context, postId uuid.UUID, userId uuid.UUID) (int, error) {
-	query := `
-		SELECT 1
-		FROM server_posts sp
-		INNER JOIN server_members sm ON sp.server_id = sm.server_id
-		WHERE sp.id = $1 AND sm.user_id = $2 AND sm.status = $3
-	`
-
-	var exists int
-	err := repository.DB.QueryRow(ctx, query, postId, userId, model.MemberStatusActive).Scan(&exists)
-	if err != nil {
-		if errors.Is(err, pgx.ErrNoRows) {
-			return exists, nil
-		}
-
-		return exists, err
-	}
-
-	return exists, nil
-}
-
 func (repository *PostRepository) DeletePostLike(ctx context.Context, postId uuid.UUID, userId uuid.UUID) error {
 	query := "DELETE FROM server_post_likes WHERE post_id = $1 AND user_id = $2"
 
@@ -367,9 +487,9 @@ func (repository *PostRepository) CheckCommentExists(ctx context.Context, commen
 }
 
 func (repository *PostRepository) CreateComment(ctx context.Context, comment model.ServerPostComments) error {
-	query := "INSERT INTO server_post_comments (id, post_id, author_id, parent_id, content, create_datetime, update_datetime, create_user_id, update_user_id) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)"
+	query := "INSERT INTO server_post_comments (id, post_id, author_id, parent_id, depth, content, create_datetime, update_datetime, create_user_id, update_user_id) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)"
 
-	_, err := repository.DB.Exec(ctx, query, comment.Id, comment.PostId, comment.AuthorId, comment.ParentId, comment.Content, comment.CreateDatetime, comment.UpdateDatetime, comment.CreateUserId, comment.UpdateUserId)
+	_, err := repository.DB.Exec(ctx, query, comment.Id, comment.PostId, comment.AuthorId, comment.ParentId, comment.Depth, comment.Content, comment.CreateDatetime, comment.UpdateDatetime, comment.CreateUserId, comment.UpdateUserId)
 	if err != nil {
 		return err
 	}
@@ -377,7 +497,46 @@ func (repository *PostRepository) CreateComment(ctx context.Context, comment mod
 	return nil
 }
 
-func (repository *PostRepository) GetComments(ctx context.Context, limit int, postId uuid.UUID, cursor *model.ServerCommentCursor) ([]model.ServerCommentResponse, error) {
+// commentColumns selects a comment in the order scanComments reads it, A is the comment table and the author
+// is joined through commentAuthorJoins
+const commentColumns = `A.id, A.author_id, A.parent_id, A.depth, A.content, A.edited_datetime IS NOT NULL,
+	(SELECT COUNT(*) FROM server_post_comments B WHERE B.parent_id = A.id),
+	A.create_datetime, A.update_datetime,
+	cu.username, cu.fullname, cua.variants, cm.status, cr.id, cr.name`
+
+// commentAuthorJoins reaches the membership of the author through the server of the post
+const commentAuthorJoins = `
+	INNER JOIN server_posts cp ON cp.id = A.post_id
+	LEFT JOIN users cu ON cu.id = A.author_id
+	LEFT JOIN user_avatar_images cua ON cua.id = cu.avatar_image_id
+	LEFT JOIN server_members cm ON cm.server_id = cp.server_id AND cm.user_id = A.author_id
+	LEFT JOIN server_roles cr ON cr.id = cm.server_role_id`
+
+func scanComments(rows pgx.Rows, minioFullUrl string) ([]model.ServerCommentResponse, error) {
+	comments := []model.ServerCommentResponse{}
+
+	for rows.Next() {
+		var comment model.ServerCommentResponse
+		var author model.AuthorRow
+		err := rows.Scan(
+			&comment.Id, &comment.AuthorId, &comment.ParentId, &comment.Depth, &comment.Content, &comment.Edited, &comment.ReplyCount, &comment.CreateDatetime, &comment.UpdateDatetime,
+			&author.Username, &author.Fullname, &author.AvatarVariants, &author.MemberStatus, &author.RoleId, &author.RoleName,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		author.UserId = comment.AuthorId
+		comment.Author = author.Summary(minioFullUrl)
+
+		comments = append(comments, comment)
+	}
+
+	return comments, rows.Err()
+}
+
+// GetComments lists the comments of a post newest first, topLevelOnly leaves out every reply
+func (repository *PostRepository) GetComments(ctx context.Context, limit int, postId uuid.UUID, cursor *model.ServerCommentCursor, topLevelOnly bool, minioFullUrl string) ([]model.ServerCommentResponse, error) {
 	var rows pgx.Rows
 	var err error
 
@@ -385,24 +544,58 @@ func (repository *PostRepository) GetComments(ctx context.Context, limit int, po
 	if cursor.Id != uuid.Nil && !cursor.CreateDatetime.IsZero() {
 		// Query with cursor for pagination
 		queryWithCursor := `
-			SELECT id, author_id, parent_id, content, create_datetime, update_datetime
-			FROM server_post_comments
-			WHERE post_id = $1
-			AND (create_datetime < $2 OR (create_datetime = $2 AND id < $3))
-			ORDER BY create_datetime DESC, id DESC
-			LIMIT $4
+			SELECT ` + commentColumns + `
+			FROM server_post_comments A` + commentAuthorJoins + `
+			WHERE A.post_id = $1 AND (NOT $2 OR A.parent_id IS NULL)
+			AND (A.create_datetime < $3 OR (A.create_datetime = $3 AND A.id < $4))
+			ORDER BY A.create_datetime DESC, A.id DESC
+			LIMIT $5
 		`
-		rows, err = repository.DB.Query(ctx, queryWithCursor, postId, cursor.CreateDatetime, cursor.Id, limit)
+		rows, err = repository.DB.Query(ctx, queryWithCursor, postId, topLevelOnly, cursor.CreateDatetime, cursor.Id, limit)
 	} else {
 		// Query without cursor for first page
 		query := `
-			SELECT id, author_id, parent_id, content, create_datetime, update_datetime
-			FROM server_post_comments
-			WHERE post_id = $1
-			ORDER BY create_datetime DESC, id DESC
+			SELECT ` + commentColumns + `
+			FROM server_post_comments A` + commentAuthorJoins + `
+			WHERE A.post_id = $1 AND (NOT $2 OR A.parent_id IS NULL)
+			ORDER BY A.create_datetime DESC, A.id DESC
+			LIMIT $3
+		`
+		rows, err = repository.DB.Query(ctx, query, postId, topLevelOnly, limit)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanComments(rows, minioFullUrl)
+}
+
+// GetCommentReplies lists the direct replies of a comment oldest first, so a thread reads top to bottom
+func (repository *PostRepository) GetCommentReplies(ctx context.Context, limit int, parentId uuid.UUID, cursor *model.ServerCommentCursor, minioFullUrl string) ([]model.ServerCommentResponse, error) {
+	var rows pgx.Rows
+	var err error
+
+	if cursor.Id != uuid.Nil && !cursor.CreateDatetime.IsZero() {
+		queryWithCursor := `
+			SELECT ` + commentColumns + `
+			FROM server_post_comments A` + commentAuthorJoins + `
+			WHERE A.parent_id = $1
+			AND (A.create_datetime > $2 OR (A.create_datetime = $2 AND A.id > $3))
+			ORDER BY A.create_datetime ASC, A.id ASC
+			LIMIT $4
+		`
+		rows, err = repository.DB.Query(ctx, queryWithCursor, parentId, cursor.CreateDatetime, cursor.Id, limit)
+	} else {
+		query := `
+			SELECT ` + commentColumns + `
+			FROM server_post_comments A` + commentAuthorJoins + `
+			WHERE A.parent_id = $1
+			ORDER BY A.create_datetime ASC, A.id ASC
 			LIMIT $2
 		`
-		rows, err = repository.DB.Query(ctx, query, postId, limit)
+		rows, err = repository.DB.Query(ctx, query, parentId, limit)
 	}
 
 	if err != nil {
@@ -410,19 +603,48 @@ func (repository *PostRepository) GetComments(ctx context.Context, limit int, po
 	}
 	defer rows.Close()
 
-	comments := []model.ServerCommentResponse{}
+	return scanComments(rows, minioFullUrl)
+}
 
-	for rows.Next() {
-		var comment model.ServerCommentResponse
-		err := rows.Scan(&comment.Id, &comment.AuthorId, &comment.ParentId, &comment.Content, &comment.CreateDatetime, &comment.UpdateDatetime)
-		if err != nil {
-			return nil, err
+// GetCommentReplyPreviews loads the first replies of every given comment in one round trip
+func (repository *PostRepository) GetCommentReplyPreviews(ctx context.Context, parentIds []uuid.UUID, limit int, minioFullUrl string) ([]model.ServerCommentResponse, error) {
+	query := `
+		SELECT ` + commentColumns + `
+		FROM unnest($1::uuid[]) AS P(id)
+		CROSS JOIN LATERAL (
+			SELECT * FROM server_post_comments C
+			WHERE C.parent_id = P.id
+			ORDER BY C.create_datetime ASC, C.id ASC
+			LIMIT $2
+		) A` + commentAuthorJoins + `
+		ORDER BY A.create_datetime ASC, A.id ASC
+	`
+
+	rows, err := repository.DB.Query(ctx, query, parentIds, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanComments(rows, minioFullUrl)
+}
+
+// GetCommentPostAndDepth returns the post and depth of a comment, the post id is Nil when the comment does not exist
+func (repository *PostRepository) GetCommentPostAndDepth(ctx context.Context, commentId uuid.UUID) (uuid.UUID, int, error) {
+	query := "SELECT post_id, depth FROM server_post_comments WHERE id = $1"
+
+	var postId uuid.UUID
+	var depth int
+	err := repository.DB.QueryRow(ctx, query, commentId).Scan(&postId, &depth)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return uuid.Nil, 0, nil
 		}
 
-		comments = append(comments, comment)
+		return uuid.Nil, 0, err
 	}
 
-	return comments, nil
+	return postId, depth, nil
 }
 
 func (repository *PostRepository) CheckCommentOwnership(ctx context.Context, commentId uuid.UUID, userId uuid.UUID) (int, error) {
@@ -451,3 +673,189 @@ func (repository *PostRepository) DeleteComment(ctx context.Context, commentId u
 
 	return nil
 }
+
+func (repository *PostRepository) GetComment(ctx context.Context, commentId uuid.UUID, minioFullUrl string) (model.ServerCommentResponse, error) {
+	query := "SELECT " + commentColumns + " FROM server_post_comments A" + commentAuthorJoins + " WHERE A.id = $1"
+
+	rows, err := repository.DB.Query(ctx, query, commentId)
+	if err != nil {
+		return model.ServerCommentResponse{}, err
+	}
+	defer rows.Close()
+
+	comments, err := scanComments(rows, minioFullUrl)
+	if err != nil {
+		return model.ServerCommentResponse{}, err
+	}
+
+	if len(comments) == 0 {
+		return model.ServerCommentResponse{}, nil
+	}
+
+	return comments[0], nil
+}
+
+// LockCommentContent locks the comment for an edit and returns its content and when that content was written
+func (repository *PostRepository) LockCommentContent(ctx context.Context, tx pgx.Tx, commentId uuid.UUID) (string, time.Time, error) {
+	query := "SELECT content, update_datetime FROM server_post_comments WHERE id = $1 FOR UPDATE"
+
+	var content string
+	var writtenDatetime time.Time
+	err := tx.QueryRow(ctx, query, commentId).Scan(&content, &writtenDatetime)
+	if err != nil {
+		return content, writtenDatetime, err
+	}
+
+	return content, writtenDatetime, nil
+}
+
+func (repository *PostRepository) UpdateCommentContent(ctx context.Context, tx pgx.Tx, commentId uuid.UUID, content string, updateUserId uuid.UUID, updateDatetime time.Time) error {
+	query := "UPDATE server_post_comments SET content = $1, edited_datetime = $2, update_datetime = $2, update_user_id = $3 WHERE id = $4"
+
+	_, err := tx.Exec(ctx, query, content, updateDatetime, updateUserId, commentId)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (repository *PostRepository) CreateCommentRevision(ctx context.Context, tx pgx.Tx, revision model.ServerPostCommentRevision) error {
+	query := "INSERT INTO server_post_comment_revisions (id, comment_id, content, written_datetime, create_user_id, update_user_id, create_datetime, update_datetime) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)"
+
+	_, err := tx.Exec(ctx, query, revision.Id, revision.CommentId, revision.Content, revision.WrittenDatetime, revision.CreateUserId, revision.UpdateUserId, revision.CreateDatetime, revision.UpdateDatetime)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// GetCommentRevisions lists the previous versions of a comment, the latest replaced version first
+func (repository *PostRepository) GetCommentRevisions(ctx context.Context, commentId uuid.UUID) ([]model.ServerCommentRevisionResponse, error) {
+	query := `
+		SELECT A.id, A.content, A.written_datetime, B.id, A.create_datetime
+		FROM server_post_comment_revisions A
+		LEFT JOIN users B ON B.id = A.create_user_id
+		WHERE A.comment_id = $1
+		ORDER BY A.create_datetime DESC, A.id DESC
+	`
+
+	rows, err := repository.DB.Query(ctx, query, commentId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	revisions := []model.ServerCommentRevisionResponse{}
+
+	for rows.Next() {
+		var revision model.ServerCommentRevisionResponse
+		err := rows.Scan(&revision.Id, &revision.Content, &revision.WrittenDatetime, &revision.EditedBy, &revision.EditedDatetime)
+		if err != nil {
+			return nil, err
+		}
+
+		revisions = append(revisions, revision)
+	}
+
+	return revisions, rows.Err()
+}
+
+// upsertReaction sets the reaction of a user on a target, the unique key on (target, user) keeps
+// concurrent requests from leaving more than one reaction behind
+func (repository *PostRepository) upsertReaction(ctx context.Context, table string, targetColumn string, reaction model.ServerReaction) error {
+	query := fmt.Sprintf(`
+		INSERT INTO %[1]s (id, %[2]s, user_id, reaction, create_datetime, update_datetime, create_user_id, update_user_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (%[2]s, user_id) DO UPDATE
+		SET reaction = EXCLUDED.reaction, update_datetime = EXCLUDED.update_datetime, update_user_id = EXCLUDED.update_user_id
+		WHERE %[1]s.reaction <> EXCLUDED.reaction
+	`, table, targetColumn)
+
+	_, err := repository.DB.Exec(ctx, query, reaction.Id, reaction.TargetId, reaction.UserId, reaction.Reaction, reaction.CreateDatetime, reaction.UpdateDatetime, reaction.CreateUserId, reaction.UpdateUserId)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (repository *PostRepository) deleteReaction(ctx context.Context, table string, targetColumn string, targetId uuid.UUID, userId uuid.UUID) error {
+	query := fmt.Sprintf("DELETE FROM %s WHERE %s = $1 AND user_id = $2", table, targetColumn)
+
+	_, err := repository.DB.Exec(ctx, query, targetId, userId)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// getReactionSummaries counts the reactions of every target and marks the one left by the user,
+// targets without any reaction are left out of the map
+func (repository *PostRepository) getReactionSummaries(ctx context.Context, table string, targetColumn string, targetIds []uuid.UUID, userId uuid.UUID) (map[uuid.UUID]model.ReactionSummaryResponse, error) {
+	query := fmt.Sprintf(`
+		SELECT %[2]s, reaction, COUNT(*), BOOL_OR(user_id = $2)
+		FROM %[1]s
+		WHERE %[2]s = ANY($1)
+		GROUP BY %[2]s, reaction
+	`, table, targetColumn)
+
+	rows, err := repository.DB.Query(ctx, query, targetIds, userId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	summaries := make(map[uuid.UUID]model.ReactionSummaryResponse, len(targetIds))
+
+	for rows.Next() {
+		var targetId uuid.UUID
+		var reaction string
+		var count int
+		var mine bool
+		err := rows.Scan(&targetId, &reaction, &count, &mine)
+		if err != nil {
+			return nil, err
+		}
+
+		summary, ok := summaries[targetId]
+		if !ok {
+			summary = model.NewReactionSummary()
+		}
+
+		summary.Reactions[reaction] = count
+		if mine {
+			summary.MyReaction = &reaction
+		}
+
+		summaries[targetId] = summary
+	}
+
+	return summaries, rows.Err()
+}
+
+func (repository *PostRepository) UpsertPostReaction(ctx context.Context, reaction model.ServerReaction) error {
+	return repository.upsertReaction(ctx, "server_post_reactions", "post_id", reaction)
+}
+
+func (repository *PostRepository) DeletePostReaction(ctx context.Context, postId uuid.UUID, userId uuid.UUID) error {
+	return repository.deleteReaction(ctx, "server_post_reactions", "post_id", postId, userId)
+}
+
+func (repository *PostRepository) GetPostReactionSummaries(ctx context.Context, postIds []uuid.UUID, userId uuid.UUID) (map[uuid.UUID]model.ReactionSummaryResponse, error) {
+	return repository.getReactionSummaries(ctx, "server_post_reactions", "post_id", postIds, userId)
+}
+
+func (repository *PostRepository) UpsertCommentReaction(ctx context.Context, reaction model.ServerReaction) error {
+	return repository.upsertReaction(ctx, "server_post_comment_reactions", "comment_id", reaction)
+}
+
+func (repository *PostRepository) DeleteCommentReaction(ctx context.Context, commentId uuid.UUID, userId uuid.UUID) error {
+	return repository.deleteReaction(ctx, "server_post_comment_reactions", "comment_id", commentId, userId)
+}
+
+func (repository *PostRepository) GetCommentReactionSummaries(ctx context.Context, commentIds []uuid.UUID, userId uuid.UUID) (map[uuid.UUID]model.ReactionSummaryResponse, error) {
+	return repository.getReactionSummaries(ctx, "server_post_comment_reactions", "comment_id", commentIds, userId)
+}
@@ -4,7 +4,6 @@ import (
 	"bytes"
 	"context"
 	"errors"
-	"fmt"
 	"time"
 
 	"github.com/ferdian3456/virdanproject/internal/model"
@@ -81,11 +80,11 @@ func (repository *ServerRepository) CheckInviteCodes(ctx context.Context, code s
 	return exists, nil
 }
 
-func (repository *ServerRepository) CheckInviteCodesAndRetrieveServerId(ctx context.Context, code string) (uuid.UUID, error) {
-	query := "SELECT server_id FROM server_invites WHERE code = $1 AND is_active = true AND used_count < max_uses"
+func (repository *ServerRepository) CheckInviteCodesAndRetrieveServerId(ctx context.Context, code string, now time.Time) (uuid.UUID, error) {
+	query := "SELECT server_id FROM server_invites WHERE code = $1 AND is_active = true AND used_count < max_uses AND (expires_datetime IS NULL OR expires_datetime > $2)"
 
 	var serverId uuid.UUID
-	err := repository.DB.QueryRow(ctx, query, code).Scan(&serverId)
+	err := repository.DB.QueryRow(ctx, query, code, now).Scan(&serverId)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return serverId, nil
@@ -108,19 +107,101 @@ func (repository *ServerRepository) CreateServerInvites(ctx context.Context, ser
 	return nil
 }
 
-func (repository *ServerRepository) GetServerInfoForInvite(ctx context.Context, inviteCode string) (model.ServerInfoForInviteResponse, error) {
+// ConsumeServerInvite takes one use of the invite, the row lock of the update keeps concurrent joins
+// from going over max_uses. The invite id is Nil when the invite is revoked, expired or used up
+func (repository *ServerRepository) ConsumeServerInvite(ctx context.Context, tx pgx.Tx, code string, serverId uuid.UUID, updateUserId uuid.UUID, now time.Time) (uuid.UUID, error) {
+	query := `UPDATE server_invites SET used_count = used_count + 1, update_datetime = $1, update_user_id = $2
+			  WHERE code = $3 AND server_id = $4 AND is_active = true AND used_count < max_uses AND (expires_datetime IS NULL OR expires_datetime > $1)
+			  RETURNING id`
+
+	var inviteId uuid.UUID
+	err := tx.QueryRow(ctx, query, now, updateUserId, code, serverId).Scan(&inviteId)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return uuid.Nil, nil
+		}
+
+		return uuid.Nil, err
+	}
+
+	return inviteId, nil
+}
+
+func (repository *ServerRepository) CreateServerInviteUse(ctx context.Context, tx pgx.Tx, inviteUse model.ServerInviteUse) error {
+	query := "INSERT INTO server_invite_uses (id, invite_id, server_id, user_id, used_datetime, create_user_id, update_user_id, create_datetime, update_datetime) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)"
+
+	_, err := tx.Exec(ctx, query, inviteUse.Id, inviteUse.InviteId, inviteUse.ServerId, inviteUse.UserId, inviteUse.UsedDatetime, inviteUse.CreateUserId, inviteUse.UpdateUserId, inviteUse.CreateDatetime, inviteUse.UpdateDatetime)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// GetServerInvites lists the invites of the server that can still be used
+func (repository *ServerRepository) GetServerInvites(ctx context.Context, serverId uuid.UUID, now time.Time) ([]model.ServerInviteResponse, error) {
+	query := `
+		SELECT A.code, A.max_uses, A.used_count, A.expires_datetime, COALESCE(B.username, ''), A.create_datetime
+		FROM server_invites A
+		LEFT JOIN users B ON B.id = A.create_user_id
+		WHERE A.server_id = $1 AND A.is_active = true AND A.used_count < A.max_uses AND (A.expires_datetime IS NULL OR A.expires_datetime > $2)
+		ORDER BY A.create_datetime DESC
+	`
+
+	rows, err := repository.DB.Query(ctx, query, serverId, now)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	invites := []model.ServerInviteResponse{}
+
+	for rows.Next() {
+		var invite model.ServerInviteResponse
+		err := rows.Scan(&invite.Code, &invite.MaxUses, &invite.UsedCount, &invite.ExpiresDatetime, &invite.CreatorName, &invite.CreateDatetime)
+		if err != nil {
+			return nil, err
+		}
+
+		invites = append(invites, invite)
+	}
+
+	return invites, rows.Err()
+}
+
+// RevokeServerInvite deactivates the invite, false means no active invite of the server has that code
+func (repository *ServerRepository) RevokeServerInvite(ctx context.Context, serverId uuid.UUID, code string, updateUserId uuid.UUID, updateDatetime time.Time) (bool, error) {
+	query := "UPDATE server_invites SET is_active = false, update_datetime = $1, update_user_id = $2 WHERE server_id = $3 AND code = $4 AND is_active = true"
+
+	result, err := repository.DB.Exec(ctx, query, updateDatetime, updateUserId, serverId, code)
+	if err != nil {
+		return false, err
+	}
+
+	return result.RowsAffected() == 1, nil
+}
+
+// GetServerInfoForInvite loads the public preview of the server behind an invite code, whatever the state
+// of the invite. The server id is Nil when the code does not exist
+func (repository *ServerRepository) GetServerInfoForInvite(ctx context.Context, inviteCode string, minioFullUrl string) (model.ServerInfoForInviteResponse, error) {
 	query := `
-		SELECT C.username, A.name, A.description, D.object_key,E.object_key  FROM servers A
+		SELECT A.id, C.username, A.name, A.short_name, F.name, A.description, D.variants, E.variants,
+		(SELECT COUNT(*) FROM server_members G WHERE G.server_id = A.id AND G.status = $2),
+		B.is_active, B.max_uses, B.used_count, B.expires_datetime
+		FROM servers A
 		INNER JOIN server_invites B ON A.id = B.server_id
 		INNER JOIN users C ON C.id = A.owner_id
-		LEFT JOIN server_avatar_images D ON A.id = D.server_id
-		LEFT JOIN server_banner_images E ON A.id = E.server_id
+		LEFT JOIN server_avatar_images D ON D.id = A.avatar_image_id
+		LEFT JOIN server_banner_images E ON E.id = A.banner_image_id
+		LEFT JOIN server_categories F ON F.id = A.category_id
 		WHERE B.code = $1
 	`
 
 	server := model.ServerInfoForInviteResponse{}
+	var avatarVariants, bannerVariants model.ImageVariants
 
-	err := repository.DB.QueryRow(ctx, query, inviteCode).Scan(&server.OwnerName, &server.ServerName, &server.Description, &server.AvatarImageId, &server.BannerImageId)
+	err := repository.DB.QueryRow(ctx, query, inviteCode, model.MemberStatusActive).Scan(&server.ServerId, &server.OwnerName, &server.ServerName, &server.ShortName, &server.CategoryName, &server.Description,
+		&avatarVariants, &bannerVariants, &server.MemberCount, &server.IsActive, &server.MaxUses, &server.UsedCount, &server.ExpiresDatetime)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return server, nil
@@ -129,6 +210,9 @@ func (repository *ServerRepository) GetServerInfoForInvite(ctx context.Context,
 		return server, err
 	}
 
+	server.AvatarImageUrls = avatarVariants.Urls(minioFullUrl)
+	server.BannerImageUrls = bannerVariants.Urls(minioFullUrl)
+
 	return server, nil
 }
 
@@ -149,9 +233,9 @@ func (repository *ServerRepository) CheckServerCategories(ctx context.Context, c
 }
 
 func (repository *ServerRepository) CreateServerAvatarImage(ctx context.Context, tx pgx.Tx, serverAvatarImage model.ServerAvatarImage) error {
-	query := "INSERT INTO server_avatar_images (id, server_id, bucket, object_key, mime_type, size, create_datetime, update_datetime, create_user_id, update_user_id) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)"
+	query := "INSERT INTO server_avatar_images (id, bucket, object_key, mime_type, size, variants, create_datetime, update_datetime, create_user_id, update_user_id) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)"
 
-	_, err := tx.Exec(ctx, query, serverAvatarImage.Id, serverAvatarImage.ServerId, serverAvatarImage.Bucket, serverAvatarImage.ObjectKey, serverAvatarImage.MimeType, 0, serverAvatarImage.CreateDatetime, serverAvatarImage.UpdateDatetime, serverAvatarImage.CreateUserId, serverAvatarImage.UpdateUserId)
+	_, err := tx.Exec(ctx, query, serverAvatarImage.Id, serverAvatarImage.Bucket, serverAvatarImage.ObjectKey, serverAvatarImage.MimeType, serverAvatarImage.Size, serverAvatarImage.Variants, serverAvatarImage.CreateDatetime, serverAvatarImage.UpdateDatetime, serverAvatarImage.CreateUserId, serverAvatarImage.UpdateUserId)
 	if err != nil {
 		return err
 	}
@@ -160,9 +244,9 @@ func (repository *ServerRepository) CreateServerAvatarImage(ctx context.Context,
 }
 
 func (repository *ServerRepository) CreateServerBannerImage(ctx context.Context, tx pgx.Tx, serverBannerImage model.ServerBannerImage) error {
-	query := "INSERT INTO server_banner_images (id, server_id, bucket, object_key, mime_type, size, create_datetime, update_datetime, create_user_id, update_user_id) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)"
+	query := "INSERT INTO server_banner_images (id, bucket, object_key, mime_type, size, variants, create_datetime, update_datetime, create_user_id, update_user_id) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)"
 
-	_, err := tx.Exec(ctx, query, serverBannerImage.Id, serverBannerImage.ServerId, serverBannerImage.Bucket, serverBannerImage.ObjectKey, serverBannerImage.MimeType, serverBannerImage.Size, serverBannerImage.CreateDatetime, serverBannerImage.UpdateDatetime, serverBannerImage.CreateUserId, serverBannerImage.UpdateUserId)
+	_, err := tx.Exec(ctx, query, serverBannerImage.Id, serverBannerImage.Bucket, serverBannerImage.ObjectKey, serverBannerImage.MimeType, serverBannerImage.Size, serverBannerImage.Variants, serverBannerImage.CreateDatetime, serverBannerImage.UpdateDatetime, serverBannerImage.CreateUserId, serverBannerImage.UpdateUserId)
 	if err != nil {
 		return err
 	}
@@ -170,10 +254,10 @@ func (repository *ServerRepository) CreateServerBannerImage(ctx context.Context,
 	return nil
 }
 
-func (repository *ServerRepository) UploadObject(ctx context.Context, bucketName string, imageName string, imageFile *bytes.Reader, imageSize int64) error {
+func (repository *ServerRepository) UploadObject(ctx context.Context, bucketName string, imageName string, imageFile *bytes.Reader, imageSize int64, contentType string) error {
 	_, err := repository.DBObject.PutObject(ctx, bucketName, imageName, imageFile, imageSize,
 		minio.PutObjectOptions{
-			ContentType:  "image/webp",
+			ContentType:  contentType,
 			CacheControl: "public, max-age=31536000, immutable",
 		})
 	if err != nil {
@@ -191,7 +275,7 @@ func (repository *ServerRepository) GetServerDiscovery(ctx context.Context, limi
 	if cursor.Id != "" && !cursor.CreateDatetime.IsZero() {
 		// Query with cursor for pagination
 		queryWithCursor := `
-		SELECT A.id,A.name,A.short_name,B.name,C.object_key,D.object_key,A.description,A.create_datetime FROM servers A
+		SELECT A.id,A.name,A.short_name,B.name,C.variants,D.variants,A.description,A.create_datetime FROM servers A
 		LEFT JOIN server_categories B ON A.category_id = B.id
 		LEFT JOIN server_avatar_images C ON A.avatar_image_id = C.id
 		LEFT JOIN server_banner_images D ON A.banner_image_id = D.id
@@ -205,7 +289,7 @@ func (repository *ServerRepository) GetServerDiscovery(ctx context.Context, limi
 	} else {
 		// Query without cursor for first page
 		query := `
-		SELECT A.id,A.name,A.short_name,B.name,C.object_key,D.object_key,A.description,A.create_datetime FROM servers A
+		SELECT A.id,A.name,A.short_name,B.name,C.variants,D.variants,A.description,A.create_datetime FROM servers A
 		LEFT JOIN server_categories B ON A.category_id = B.id
 		LEFT JOIN server_avatar_images C ON A.avatar_image_id = C.id
 		LEFT JOIN server_banner_images D ON A.banner_image_id = D.id
@@ -225,17 +309,14 @@ func (repository *ServerRepository) GetServerDiscovery(ctx context.Context, limi
 
 	for rows.Next() {
 		var server model.ServerInfoResponse
-		err := rows.Scan(&server.Id, &server.Name, &server.ShortName, &server.CategoryName, &server.AvatarImageUrl, &server.BannerImageUrl, &server.Description, &server.CreateDatetime)
+		var avatarVariants, bannerVariants model.ImageVariants
+		err := rows.Scan(&server.Id, &server.Name, &server.ShortName, &server.CategoryName, &avatarVariants, &bannerVariants, &server.Description, &server.CreateDatetime)
 		if err != nil {
 			return nil, err
 		}
 
-		if server.AvatarImageUrl != nil {
-			*server.AvatarImageUrl = fmt.Sprintf("%s/%s.webp", minioFullUrl, *server.AvatarImageUrl)
-		}
-		if server.BannerImageUrl != nil {
-			*server.BannerImageUrl = fmt.Sprintf("%s/%s.webp", minioFullUrl, *server.BannerImageUrl)
-		}
+		server.AvatarImageUrls = avatarVariants.Urls(minioFullUrl)
+		server.BannerImageUrls = bannerVariants.Urls(minioFullUrl)
 
 		servers = append(servers, server)
 	}
@@ -251,25 +332,25 @@ func (repository *ServerRepository) GetUserServer(ctx context.Context, limit int
 	if cursor.ServerId != "" && !cursor.JoinedDatetime.IsZero() {
 		// Query with cursor for pagination
 		queryWithCursor := `
-		SELECT B.id, B.name, B.short_name, C.object_key, A.joined_datetime FROM server_members A
+		SELECT B.id, B.name, B.short_name, C.variants, A.joined_datetime FROM server_members A
 		INNER JOIN servers B ON A.server_id = B.id
 		LEFT JOIN server_avatar_images C ON C.id = B.avatar_image_id
-		WHERE (A.joined_datetime < $1 OR (A.joined_datetime = $1 AND A.server_id < $2)) AND A.user_id = $3
+		WHERE (A.joined_datetime < $1 OR (A.joined_datetime = $1 AND A.server_id < $2)) AND A.user_id = $3 AND A.status = $4
 		ORDER BY A.joined_datetime DESC, A.server_id DESC
-		LIMIT $4
+		LIMIT $5
 		`
-		rows, err = repository.DB.Query(ctx, queryWithCursor, cursor.JoinedDatetime, cursor.ServerId, userId, limit)
+		rows, err = repository.DB.Query(ctx, queryWithCursor, cursor.JoinedDatetime, cursor.ServerId, userId, model.MemberStatusActive, limit)
 	} else {
 		// Query without cursor for first page
 		query := `
-		SELECT B.id, B.name, B.short_name, C.object_key, A.joined_datetime FROM server_members A
+		SELECT B.id, B.name, B.short_name, C.variants, A.joined_datetime FROM server_members A
 		INNER JOIN servers B ON A.server_id = B.id
 		LEFT JOIN server_avatar_images C ON C.id = B.avatar_image_id
-		WHERE A.user_id = $1
+		WHERE A.user_id = $1 AND A.status = $2
 		ORDER BY A.joined_datetime DESC, A.server_id DESC
-		LIMIT $2
+		LIMIT $3
 		`
-		rows, err = repository.DB.Query(ctx, query, userId, limit)
+		rows, err = repository.DB.Query(ctx, query, userId, model.MemberStatusActive, limit)
 	}
 	if err != nil {
 		return nil, err
@@ -280,14 +361,13 @@ func (repository *ServerRepository) GetUserServer(ctx context.Context, limit int
 
 	for rows.Next() {
 		var server model.ServerUserResponse
-		err := rows.Scan(&server.Id, &server.Name, &server.ShortName, &server.AvatarImageUrl, &server.JoinedDatetime)
+		var avatarVariants model.ImageVariants
+		err := rows.Scan(&server.Id, &server.Name, &server.ShortName, &avatarVariants, &server.JoinedDatetime)
 		if err != nil {
 			return nil, err
 		}
 
-		if server.AvatarImageUrl != nil {
-			*server.AvatarImageUrl = fmt.Sprintf("%s%s.webp", minioFullUrl, *server.AvatarImageUrl)
-		}
+		server.AvatarImageUrls = avatarVariants.Urls(minioFullUrl)
 
 		servers = append(servers, server)
 	}
@@ -312,14 +392,13 @@ func (repository *ServerRepository) CheckServerEligible(ctx context.Context, ser
 	return exists, nil
 }
 
-func (repository *ServerRepository) CheckServerMember(ctx context.Context, serverId uuid.UUID, userId uuid.UUID) (int, error) {
+func (repository *ServerRepository) CheckServerPrivate(ctx context.Context, serverId uuid.UUID) (int, error) {
 	query := `
-	SELECT 1 FROM server_members WHERE server_id = $1 AND user_id = $2 AND status = 'ACTIVE'
+	SELECT 1 FROM servers WHERE id = $1 AND COALESCE((settings->>'isPrivate')::boolean, false) = true
 	`
 
 	var exists int
-	repository.Log.Debug("checking", zap.String("serverId", serverId.String()), zap.String("userId", userId.String()))
-	err := repository.DB.QueryRow(ctx, query, serverId, userId).Scan(&exists)
+	err := repository.DB.QueryRow(ctx, query, serverId).Scan(&exists)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return exists, nil
@@ -330,20 +409,138 @@ func (repository *ServerRepository) CheckServerMember(ctx context.Context, serve
 	return exists, nil
 }
 
-func (repository *ServerRepository) CheckServerOwnership(ctx context.Context, serverId uuid.UUID, userId uuid.UUID) (int, error) {
-	query := "SELECT 1 FROM servers WHERE id = $1 AND owner_id = $2"
+func (repository *ServerRepository) CountOwnedServersWithOtherMembers(ctx context.Context, userId uuid.UUID) (int, error) {
+	query := `
+	SELECT COUNT(*) FROM servers A
+	WHERE A.owner_id = $1
+	AND EXISTS (SELECT 1 FROM server_members B WHERE B.server_id = A.id AND B.user_id <> $1 AND B.status = $2)
+	`
 
-	var exists int
-	err := repository.DB.QueryRow(ctx, query, serverId, userId).Scan(&exists)
+	var total int
+	err := repository.DB.QueryRow(ctx, query, userId, model.MemberStatusActive).Scan(&total)
+	if err != nil {
+		return total, err
+	}
+
+	return total, nil
+}
+
+func (repository *ServerRepository) GetOwnedServerIds(ctx context.Context, tx pgx.Tx, userId uuid.UUID) ([]uuid.UUID, error) {
+	query := "SELECT id FROM servers WHERE owner_id = $1 FOR UPDATE"
+
+	rows, err := tx.Query(ctx, query, userId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	serverIds := []uuid.UUID{}
+	for rows.Next() {
+		var serverId uuid.UUID
+		err = rows.Scan(&serverId)
+		if err != nil {
+			return nil, err
+		}
+
+		serverIds = append(serverIds, serverId)
+	}
+
+	return serverIds, rows.Err()
+}
+
+// GetServerSuccessor returns the longest standing active member other than the owner, or uuid.Nil
+func (repository *ServerRepository) GetServerSuccessor(ctx context.Context, tx pgx.Tx, serverId uuid.UUID, ownerId uuid.UUID) (uuid.UUID, error) {
+	query := `
+	SELECT user_id FROM server_members
+	WHERE server_id = $1 AND user_id <> $2 AND status = $3
+	ORDER BY joined_datetime ASC, id ASC
+	LIMIT 1
+	`
+
+	var userId uuid.UUID
+	err := tx.QueryRow(ctx, query, serverId, ownerId, model.MemberStatusActive).Scan(&userId)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			return exists, nil
+			return uuid.Nil, nil
 		}
+		return userId, err
+	}
 
-		return exists, err
+	return userId, nil
+}
+
+// DeleteServerWithContent deletes the server together with its post, avatar and banner image rows and returns
+// the object keys of their originals and variants so the caller can remove them from storage after commit
+func (repository *ServerRepository) DeleteServerWithContent(ctx context.Context, tx pgx.Tx, serverId uuid.UUID) ([]string, error) {
+	objectKeys := []string{}
+
+	query := `DELETE FROM server_post_images WHERE id IN (
+			SELECT B.post_image_id FROM server_posts A
+			INNER JOIN server_post_media B ON B.post_id = A.id
+			WHERE A.server_id = $1
+		) RETURNING object_key, variants`
+
+	rows, err := tx.Query(ctx, query, serverId)
+	if err != nil {
+		return nil, err
 	}
 
-	return exists, nil
+	for rows.Next() {
+		var objectKey string
+		var variants model.ImageVariants
+		err = rows.Scan(&objectKey, &variants)
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+
+		objectKeys = append(objectKeys, variants.ObjectKeys(objectKey)...)
+	}
+	rows.Close()
+
+	if rows.Err() != nil {
+		return nil, rows.Err()
+	}
+
+	var avatarImageId *uuid.UUID
+	var bannerImageId *uuid.UUID
+
+	query = "DELETE FROM servers WHERE id = $1 RETURNING avatar_image_id, banner_image_id"
+
+	err = tx.QueryRow(ctx, query, serverId).Scan(&avatarImageId, &bannerImageId)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return objectKeys, nil
+		}
+		return nil, err
+	}
+
+	// Image rows are removed after the server because servers cascade from them
+	var objectKey string
+	var variants model.ImageVariants
+	if avatarImageId != nil {
+		query = "DELETE FROM server_avatar_images WHERE id = $1 RETURNING object_key, variants"
+
+		err = tx.QueryRow(ctx, query, *avatarImageId).Scan(&objectKey, &variants)
+		if err == nil {
+			objectKeys = append(objectKeys, variants.ObjectKeys(objectKey)...)
+		} else if !errors.Is(err, pgx.ErrNoRows) {
+			return nil, err
+		}
+	}
+
+	if bannerImageId != nil {
+		query = "DELETE FROM server_banner_images WHERE id = $1 RETURNING object_key, variants"
+
+		err = tx.QueryRow(ctx, query, *bannerImageId).Scan(&objectKey, &variants)
+		if err == nil {
+			objectKeys = append(objectKeys, variants.ObjectKeys(objectKey)...)
+		} else if !errors.Is(err, pgx.ErrNoRows) {
+			return nil, err
+		}
+	}
+
+	return objectKeys, nil
 }
 
 func (repository *ServerRepository) UpdateServerName(ctx context.Context, serverId uuid.UUID, name string, updateUserId uuid.UUID, updateDatetime time.Time) error {
@@ -390,21 +587,11 @@ func (repository *ServerRepository) UpdateServerDescription(ctx context.Context,
 	return nil
 }
 
-func (repository *ServerRepository) DeleteServer(ctx context.Context, serverId uuid.UUID) error {
-	query := "DELETE FROM servers WHERE id = $1"
+// DeleteServerAvatarImage removes a avatar image row, the server must no longer point to it because servers cascade from their images
+func (repository *ServerRepository) DeleteServerAvatarImage(ctx context.Context, tx pgx.Tx, avatarImageId uuid.UUID) error {
+	query := "DELETE FROM server_avatar_images WHERE id = $1"
 
-	_, err := repository.DB.Exec(ctx, query, serverId)
-	if err != nil {
-		return err
-	}
-
-	return nil
-}
-
-func (repository *ServerRepository) DeleteServerAvatarImage(ctx context.Context, tx pgx.Tx, serverId uuid.UUID) error {
-	query := "DELETE FROM server_avatar_images WHERE server_id = $1"
-
-	_, err := tx.Exec(ctx, query, serverId)
+	_, err := tx.Exec(ctx, query, avatarImageId)
 	if err != nil {
 		return err
 	}
@@ -423,19 +610,30 @@ func (repository *ServerRepository) UpdateServerAvatarImage(ctx context.Context,
 	return nil
 }
 
-func (repository *ServerRepository) GetServerAvatar(ctx context.Context, tx pgx.Tx, serverId uuid.UUID) (string, error) {
-	query := "SELECT object_key FROM server_avatar_images WHERE server_id=$1 LIMIT 1"
-
-	var objectKey string
-	err := tx.QueryRow(ctx, query, serverId).Scan(&objectKey)
+// GetServerAvatar locks the server and returns its current avatar image id with the object keys of the original
+// and its variants, the id is nil when the server has no avatar
+func (repository *ServerRepository) GetServerAvatar(ctx context.Context, tx pgx.Tx, serverId uuid.UUID) (*uuid.UUID, []string, error) {
+	query := `SELECT B.id, B.object_key, B.variants FROM servers A
+		LEFT JOIN server_avatar_images B ON B.id = A.avatar_image_id
+		WHERE A.id = $1
+		FOR UPDATE OF A`
+
+	var imageId *uuid.UUID
+	var objectKey *string
+	var variants model.ImageVariants
+	err := tx.QueryRow(ctx, query, serverId).Scan(&imageId, &objectKey, &variants)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			return "", nil
+			return nil, []string{}, nil
 		}
-		return objectKey, err
+		return nil, nil, err
 	}
 
-	return objectKey, nil
+	if imageId == nil {
+		return nil, []string{}, nil
+	}
+
+	return imageId, variants.ObjectKeys(*objectKey), nil
 }
 
 func (repository *ServerRepository) RemoveServerAvatarObject(ctx context.Context, bucketName string, fileName string) error {
@@ -447,10 +645,11 @@ func (repository *ServerRepository) RemoveServerAvatarObject(ctx context.Context
 	return nil
 }
 
-func (repository *ServerRepository) DeleteServerBannerImage(ctx context.Context, tx pgx.Tx, serverId uuid.UUID) error {
-	query := "DELETE FROM server_banner_images WHERE server_id = $1"
+// DeleteServerBannerImage removes a banner image row, the server must no longer point to it because servers cascade from their images
+func (repository *ServerRepository) DeleteServerBannerImage(ctx context.Context, tx pgx.Tx, bannerImageId uuid.UUID) error {
+	query := "DELETE FROM server_banner_images WHERE id = $1"
 
-	_, err := tx.Exec(ctx, query, serverId)
+	_, err := tx.Exec(ctx, query, bannerImageId)
 	if err != nil {
 		return err
 	}
@@ -469,19 +668,30 @@ func (repository *ServerRepository) UpdateServerBannerImage(ctx context.Context,
 	return nil
 }
 
-func (repository *ServerRepository) GetServerBanner(ctx context.Context, tx pgx.Tx, serverId uuid.UUID) (string, error) {
-	query := "SELECT object_key FROM server_banner_images WHERE server_id=$1 LIMIT 1"
-
-	var objectKey string
-	err := tx.QueryRow(ctx, query, serverId).Scan(&objectKey)
+// GetServerBanner locks the server and returns its current banner image id with the object keys of the original
+// and its variants, the id is nil when the server has no banner
+func (repository *ServerRepository) GetServerBanner(ctx context.Context, tx pgx.Tx, serverId uuid.UUID) (*uuid.UUID, []string, error) {
+	query := `SELECT B.id, B.object_key, B.variants FROM servers A
+		LEFT JOIN server_banner_images B ON B.id = A.banner_image_id
+		WHERE A.id = $1
+		FOR UPDATE OF A`
+
+	var imageId *uuid.UUID
+	var objectKey *string
+	var variants model.ImageVariants
+	err := tx.QueryRow(ctx, query, serverId).Scan(&imageId, &objectKey, &variants)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			return "", nil
+			return nil, []string{}, nil
 		}
-		return objectKey, err
+		return nil, nil, err
+	}
+
+	if imageId == nil {
+		return nil, []string{}, nil
 	}
 
-	return objectKey, nil
+	return imageId, variants.ObjectKeys(*objectKey), nil
 }
 
 func (repository *ServerRepository) RemoveServerBannerObject(ctx context.Context, bucketName string, fileName string) error {
@@ -493,6 +703,84 @@ func (repository *ServerRepository) RemoveServerBannerObject(ctx context.Context
 	return nil
 }
 
+// GetMisplacedPostImages lists up to limit post images stored under the wrong prefix for the privacy of their server,
+// a nil server id looks through every server
+func (repository *ServerRepository) GetMisplacedPostImages(ctx context.Context, serverId *uuid.UUID, limit int) ([]model.MisplacedPostImage, error) {
+	query := `SELECT C.id, C.bucket, C.object_key, C.variants, COALESCE((A.settings->>'isPrivate')::boolean, false)
+		FROM servers A
+		INNER JOIN server_posts B ON B.server_id = A.id
+		INNER JOIN server_post_media D ON D.post_id = B.id
+		INNER JOIN server_post_images C ON C.id = D.post_image_id
+		WHERE ($1::uuid IS NULL OR A.id = $1)
+		AND COALESCE((A.settings->>'isPrivate')::boolean, false) <> (C.object_key LIKE $2)
+		LIMIT $3`
+
+	rows, err := repository.DB.Query(ctx, query, serverId, model.PrivateObjectPrefix+"%", limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	images := []model.MisplacedPostImage{}
+	for rows.Next() {
+		var image model.MisplacedPostImage
+		err = rows.Scan(&image.Id, &image.Bucket, &image.ObjectKey, &image.Variants, &image.Private)
+		if err != nil {
+			return nil, err
+		}
+
+		images = append(images, image)
+	}
+
+	return images, rows.Err()
+}
+
+// CopyPostImageObject copies the object to the new key with the cache policy of that key
+func (repository *ServerRepository) CopyPostImageObject(ctx context.Context, bucketName string, objectKey string, newObjectKey string) error {
+	info, err := repository.DBObject.StatObject(ctx, bucketName, objectKey, minio.StatObjectOptions{})
+	if err != nil {
+		return err
+	}
+
+	_, err = repository.DBObject.CopyObject(ctx,
+		minio.CopyDestOptions{
+			Bucket:          bucketName,
+			Object:          newObjectKey,
+			ReplaceMetadata: true,
+			ContentType:     info.ContentType,
+			CacheControl:    objectCacheControl(newObjectKey),
+		},
+		minio.CopySrcOptions{
+			Bucket: bucketName,
+			Object: objectKey,
+		})
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (repository *ServerRepository) UpdatePostImageObjects(ctx context.Context, imageId uuid.UUID, objectKey string, variants model.ImageVariants, updateDatetime time.Time) error {
+	query := "UPDATE server_post_images SET object_key = $1, variants = $2, update_datetime = $3 WHERE id = $4"
+
+	_, err := repository.DB.Exec(ctx, query, objectKey, variants, updateDatetime, imageId)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (repository *ServerRepository) RemoveObject(ctx context.Context, bucketName string, objectKey string) error {
+	err := repository.DBObject.RemoveObject(ctx, bucketName, objectKey, minio.RemoveObjectOptions{})
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
 func (repository *ServerRepository) UpdateServerSettings(ctx context.Context, serverId uuid.UUID, settings []byte, updateUserId uuid.UUID, updateDatetime time.Time) error {
 	query := "UPDATE servers SET settings = $1, update_datetime = $2, update_user_id = $3 WHERE id = $4"
 
@@ -519,3 +807,290 @@ func (repository *ServerRepository) GetServerDetail(ctx context.Context, serverI
 
 	return response, nil
 }
+
+// GetMemberPermission resolves the role of an active member, RoleId is uuid.Nil when the user is not an active member
+func (repository *ServerRepository) GetMemberPermission(ctx context.Context, serverId uuid.UUID, userId uuid.UUID) (model.ServerMemberPermission, error) {
+	query := `SELECT B.server_role_id, A.owner_id = B.user_id, C.permissions
+			  FROM servers A
+			  INNER JOIN server_members B ON B.server_id = A.id
+			  INNER JOIN server_roles C ON C.id = B.server_role_id
+			  WHERE A.id = $1 AND B.user_id = $2 AND B.status = $3`
+
+	member := model.ServerMemberPermission{
+		ServerId: serverId,
+		UserId:   userId,
+	}
+	err := repository.DB.QueryRow(ctx, query, serverId, userId, model.MemberStatusActive).Scan(&member.RoleId, &member.IsOwner, &member.Permissions)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return member, nil
+		}
+		return member, err
+	}
+
+	return member, nil
+}
+
+func (repository *ServerRepository) GetServerRoles(ctx context.Context, serverId uuid.UUID) ([]model.ServerRoleResponse, error) {
+	query := `SELECT A.id, A.server_id, A.name, A.permissions, A.create_datetime, A.update_datetime,
+				(SELECT COUNT(*) FROM server_members B WHERE B.server_role_id = A.id AND B.status = $2)
+			  FROM server_roles A
+			  WHERE A.server_id = $1
+			  ORDER BY A.create_datetime ASC, A.id ASC`
+
+	rows, err := repository.DB.Query(ctx, query, serverId, model.MemberStatusActive)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	roles := []model.ServerRoleResponse{}
+	for rows.Next() {
+		var role model.ServerRoleResponse
+		var permissions map[string]bool
+		err = rows.Scan(&role.Id, &role.ServerId, &role.Name, &permissions, &role.CreateDatetime, &role.UpdateDatetime, &role.MemberCount)
+		if err != nil {
+			return nil, err
+		}
+
+		role.Permissions = model.PermissionList(permissions)
+		roles = append(roles, role)
+	}
+
+	return roles, rows.Err()
+}
+
+// GetServerRole returns the role of the server, Id is uuid.Nil when not found
+func (repository *ServerRepository) GetServerRole(ctx context.Context, serverId uuid.UUID, roleId uuid.UUID) (model.ServerRoleResponse, error) {
+	query := `SELECT A.id, A.server_id, A.name, A.permissions, A.create_datetime, A.update_datetime,
+				(SELECT COUNT(*) FROM server_members B WHERE B.server_role_id = A.id AND B.status = $3)
+			  FROM server_roles A
+			  WHERE A.server_id = $1 AND A.id = $2`
+
+	var role model.ServerRoleResponse
+	var permissions map[string]bool
+	err := repository.DB.QueryRow(ctx, query, serverId, roleId, model.MemberStatusActive).Scan(&role.Id, &role.ServerId, &role.Name, &permissions, &role.CreateDatetime, &role.UpdateDatetime, &role.MemberCount)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return model.ServerRoleResponse{}, nil
+		}
+		return role, err
+	}
+
+	role.Permissions = model.PermissionList(permissions)
+
+	return role, nil
+}
+
+func (repository *ServerRepository) CheckServerRoleName(ctx context.Context, serverId uuid.UUID, name string, exceptRoleId uuid.UUID) (int, error) {
+	query := "SELECT 1 FROM server_roles WHERE server_id = $1 AND LOWER(name) = LOWER($2) AND id <> $3"
+
+	var exists int
+	err := repository.DB.QueryRow(ctx, query, serverId, name, exceptRoleId).Scan(&exists)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return exists, nil
+		}
+		return exists, err
+	}
+
+	return exists, nil
+}
+
+func (repository *ServerRepository) UpdateServerRole(ctx context.Context, roleId uuid.UUID, name string, permissions []byte, updateUserId uuid.UUID, updateDatetime time.Time) error {
+	query := "UPDATE server_roles SET name = $1, permissions = $2, update_datetime = $3, update_user_id = $4 WHERE id = $5"
+
+	_, err := repository.DB.Exec(ctx, query, name, permissions, updateDatetime, updateUserId, roleId)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// GetServerRoleIdByName returns the id of the role with the given name, uuid.Nil when not found
+func (repository *ServerRepository) GetServerRoleIdByName(ctx context.Context, tx pgx.Tx, serverId uuid.UUID, name string) (uuid.UUID, error) {
+	query := "SELECT id FROM server_roles WHERE server_id = $1 AND name = $2"
+
+	var roleId uuid.UUID
+	err := tx.QueryRow(ctx, query, serverId, name).Scan(&roleId)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return uuid.Nil, nil
+		}
+		return uuid.Nil, err
+	}
+
+	return roleId, nil
+}
+
+// ReassignServerRoleMembers moves every member row of a role, whatever its status, to another role
+func (repository *ServerRepository) ReassignServerRoleMembers(ctx context.Context, tx pgx.Tx, fromRoleId uuid.UUID, toRoleId uuid.UUID, updateUserId uuid.UUID, updateDatetime time.Time) error {
+	query := "UPDATE server_members SET server_role_id = $1, update_datetime = $2, update_user_id = $3 WHERE server_role_id = $4"
+
+	_, err := tx.Exec(ctx, query, toRoleId, updateDatetime, updateUserId, fromRoleId)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (repository *ServerRepository) DeleteServerRole(ctx context.Context, tx pgx.Tx, roleId uuid.UUID) error {
+	query := "DELETE FROM server_roles WHERE id = $1"
+
+	_, err := tx.Exec(ctx, query, roleId)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (repository *ServerRepository) UpdateServerMemberRole(ctx context.Context, serverId uuid.UUID, userId uuid.UUID, roleId uuid.UUID, updateUserId uuid.UUID, updateDatetime time.Time) error {
+	query := "UPDATE server_members SET server_role_id = $1, update_datetime = $2, update_user_id = $3 WHERE server_id = $4 AND user_id = $5"
+
+	_, err := repository.DB.Exec(ctx, query, roleId, updateDatetime, updateUserId, serverId, userId)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (repository *ServerRepository) GetServerMembers(ctx context.Context, limit int, cursor *model.ServerMemberCursor, serverId uuid.UUID, minioFullUrl string) ([]model.ServerMemberResponse, error) {
+	var rows pgx.Rows
+	var err error
+
+	// Check if cursor is provided (not first page)
+	if cursor.UserId != "" && !cursor.JoinedDatetime.IsZero() {
+		queryWithCursor := `
+		SELECT A.user_id, B.username, B.fullname, D.variants, A.server_role_id, C.name, E.owner_id = A.user_id, A.joined_datetime
+		FROM server_members A
+		INNER JOIN users B ON B.id = A.user_id
+		INNER JOIN server_roles C ON C.id = A.server_role_id
+		INNER JOIN servers E ON E.id = A.server_id
+		LEFT JOIN user_avatar_images D ON D.id = B.avatar_image_id
+		WHERE A.server_id = $1 AND A.status = $2 AND (A.joined_datetime > $3 OR (A.joined_datetime = $3 AND A.user_id > $4))
+		ORDER BY A.joined_datetime ASC, A.user_id ASC
+		LIMIT $5
+		`
+		rows, err = repository.DB.Query(ctx, queryWithCursor, serverId, model.MemberStatusActive, cursor.JoinedDatetime, cursor.UserId, limit)
+	} else {
+		query := `
+		SELECT A.user_id, B.username, B.fullname, D.variants, A.server_role_id, C.name, E.owner_id = A.user_id, A.joined_datetime
+		FROM server_members A
+		INNER JOIN users B ON B.id = A.user_id
+		INNER JOIN server_roles C ON C.id = A.server_role_id
+		INNER JOIN servers E ON E.id = A.server_id
+		LEFT JOIN user_avatar_images D ON D.id = B.avatar_image_id
+		WHERE A.server_id = $1 AND A.status = $2
+		ORDER BY A.joined_datetime ASC, A.user_id ASC
+		LIMIT $3
+		`
+		rows, err = repository.DB.Query(ctx, query, serverId, model.MemberStatusActive, limit)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	members := []model.ServerMemberResponse{}
+
+	for rows.Next() {
+		var member model.ServerMemberResponse
+		var avatarVariants model.ImageVariants
+		err := rows.Scan(&member.UserId, &member.Username, &member.Fullname, &avatarVariants, &member.RoleId, &member.RoleName, &member.IsOwner, &member.JoinedDatetime)
+		if err != nil {
+			return nil, err
+		}
+
+		member.AvatarImageUrls = avatarVariants.Urls(minioFullUrl)
+
+		members = append(members, member)
+	}
+
+	return members, rows.Err()
+}
+
+// GetServerMemberStatus returns the membership status of the user, 0 when they never joined
+func (repository *ServerRepository) GetServerMemberStatus(ctx context.Context, serverId uuid.UUID, userId uuid.UUID) (model.Status, error) {
+	query := "SELECT status FROM server_members WHERE server_id = $1 AND user_id = $2"
+
+	var status model.Status
+	err := repository.DB.QueryRow(ctx, query, serverId, userId).Scan(&status)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, nil
+		}
+		return status, err
+	}
+
+	return status, nil
+}
+
+// RejoinServerMember reactivates the membership of a user who left, with a fresh role and join date.
+// It returns false when the membership is no longer left, such as after a ban
+func (repository *ServerRepository) RejoinServerMember(ctx context.Context, tx pgx.Tx, serverId uuid.UUID, userId uuid.UUID, roleId uuid.UUID, updateDatetime time.Time) (bool, error) {
+	query := `UPDATE server_members SET server_role_id = $1, status = $2, joined_datetime = $3, left_datetime = NULL, update_datetime = $3, update_user_id = $4
+			  WHERE server_id = $5 AND user_id = $4 AND status = $6`
+
+	result, err := tx.Exec(ctx, query, roleId, model.MemberStatusActive, updateDatetime, userId, serverId, model.MemberStatusLeft)
+	if err != nil {
+		return false, err
+	}
+
+	return result.RowsAffected() == 1, nil
+}
+
+func (repository *ServerRepository) UpdateServerMemberStatus(ctx context.Context, serverId uuid.UUID, userId uuid.UUID, status model.Status, leftDatetime *time.Time, updateUserId uuid.UUID, updateDatetime time.Time) error {
+	query := "UPDATE server_members SET status = $1, left_datetime = $2, update_datetime = $3, update_user_id = $4 WHERE server_id = $5 AND user_id = $6"
+
+	_, err := repository.DB.Exec(ctx, query, status, leftDatetime, updateDatetime, updateUserId, serverId, userId)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// LockServer locks the server row for the rest of the transaction, the owner id is Nil when the server does not exist
+func (repository *ServerRepository) LockServer(ctx context.Context, tx pgx.Tx, serverId uuid.UUID) (uuid.UUID, string, error) {
+	query := "SELECT owner_id, name FROM servers WHERE id = $1 FOR UPDATE"
+
+	var ownerId uuid.UUID
+	var name string
+	err := tx.QueryRow(ctx, query, serverId).Scan(&ownerId, &name)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return uuid.Nil, name, nil
+		}
+		return uuid.Nil, name, err
+	}
+
+	return ownerId, name, nil
+}
+
+// UpdateServerOwner points the server at its new owner, the roles are assigned by AssignActiveMemberRole
+func (repository *ServerRepository) UpdateServerOwner(ctx context.Context, tx pgx.Tx, serverId uuid.UUID, ownerId uuid.UUID, updateUserId uuid.UUID, updateDatetime time.Time) error {
+	query := "UPDATE servers SET owner_id = $1, update_datetime = $2, update_user_id = $3 WHERE id = $4"
+
+	_, err := tx.Exec(ctx, query, ownerId, updateDatetime, updateUserId, serverId)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// AssignActiveMemberRole changes the role of an active member, false means the user is not an active member
+func (repository *ServerRepository) AssignActiveMemberRole(ctx context.Context, tx pgx.Tx, serverId uuid.UUID, userId uuid.UUID, roleId uuid.UUID, updateUserId uuid.UUID, updateDatetime time.Time) (bool, error) {
+	query := "UPDATE server_members SET server_role_id = $1, update_datetime = $2, update_user_id = $3 WHERE server_id = $4 AND user_id = $5 AND status = $6"
+
+	result, err := tx.Exec(ctx, query, roleId, updateDatetime, updateUserId, serverId, userId, model.MemberStatusActive)
+	if err != nil {
+		return false, err
+	}
+
+	return result.RowsAffected() == 1, nil
+}
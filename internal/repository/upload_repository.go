@@ -0,0 +1,199 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+
+	"github.com/ferdian3456/virdanproject/internal/model"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/minio/minio-go/v7"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+type UploadRepository struct {
+	Log      *zap.Logger
+	DB       *pgxpool.Pool
+	DBCache  *redis.Client
+	DBObject *minio.Client
+}
+
+func NewUploadRepository(zap *zap.Logger, db *pgxpool.Pool, dbCache *redis.Client, minio *minio.Client) *UploadRepository {
+	return &UploadRepository{
+		Log:      zap,
+		DB:       db,
+		DBCache:  dbCache,
+		DBObject: minio,
+	}
+}
+
+func (repository *UploadRepository) CreateUploadIntent(ctx context.Context, intent model.UploadIntent) error {
+	query := `INSERT INTO upload_intents (id, user_id, purpose, target_id, bucket, object_key, content_type, expires_datetime, create_datetime, update_datetime, create_user_id, update_user_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)`
+
+	_, err := repository.DB.Exec(ctx, query, intent.Id, intent.UserId, intent.Purpose, intent.TargetId, intent.Bucket, intent.ObjectKey, intent.ContentType, intent.ExpiresDatetime,
+		intent.CreateDatetime, intent.UpdateDatetime, intent.CreateUserId, intent.UpdateUserId)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// CountPendingUploadIntents counts the intents of the user that are not expired yet
+func (repository *UploadRepository) CountPendingUploadIntents(ctx context.Context, userId uuid.UUID, now time.Time) (int, error) {
+	query := "SELECT COUNT(*) FROM upload_intents WHERE user_id = $1 AND expires_datetime > $2"
+
+	var total int
+	err := repository.DB.QueryRow(ctx, query, userId, now).Scan(&total)
+	if err != nil {
+		return total, err
+	}
+
+	return total, nil
+}
+
+// GetUploadIntent returns the intent, its id is Nil when it does not exist
+func (repository *UploadRepository) GetUploadIntent(ctx context.Context, uploadId uuid.UUID) (model.UploadIntent, error) {
+	query := `SELECT id, user_id, purpose, target_id, bucket, object_key, content_type, expires_datetime, create_datetime, update_datetime, create_user_id, update_user_id
+		FROM upload_intents WHERE id = $1`
+
+	intent := model.UploadIntent{}
+	err := repository.DB.QueryRow(ctx, query, uploadId).Scan(&intent.Id, &intent.UserId, &intent.Purpose, &intent.TargetId, &intent.Bucket, &intent.ObjectKey, &intent.ContentType,
+		&intent.ExpiresDatetime, &intent.CreateDatetime, &intent.UpdateDatetime, &intent.CreateUserId, &intent.UpdateUserId)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return intent, nil
+		}
+
+		return intent, err
+	}
+
+	return intent, nil
+}
+
+// DeleteUploadIntent removes the intent and reports whether this call removed it,
+// finalizing claims the intent this way so it is only ever attached once
+func (repository *UploadRepository) DeleteUploadIntent(ctx context.Context, uploadId uuid.UUID) (bool, error) {
+	query := "DELETE FROM upload_intents WHERE id = $1"
+
+	result, err := repository.DB.Exec(ctx, query, uploadId)
+	if err != nil {
+		return false, err
+	}
+
+	return result.RowsAffected() == 1, nil
+}
+
+// GetExpiredUploadIntents lists up to limit intents that expired before now, oldest first
+func (repository *UploadRepository) GetExpiredUploadIntents(ctx context.Context, now time.Time, limit int) ([]model.UploadIntent, error) {
+	query := `SELECT id, user_id, purpose, target_id, bucket, object_key, content_type, expires_datetime, create_datetime, update_datetime, create_user_id, update_user_id
+		FROM upload_intents WHERE expires_datetime <= $1
+		ORDER BY expires_datetime
+		LIMIT $2`
+
+	rows, err := repository.DB.Query(ctx, query, now, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	intents := []model.UploadIntent{}
+	for rows.Next() {
+		var intent model.UploadIntent
+		err = rows.Scan(&intent.Id, &intent.UserId, &intent.Purpose, &intent.TargetId, &intent.Bucket, &intent.ObjectKey, &intent.ContentType,
+			&intent.ExpiresDatetime, &intent.CreateDatetime, &intent.UpdateDatetime, &intent.CreateUserId, &intent.UpdateUserId)
+		if err != nil {
+			return nil, err
+		}
+
+		intents = append(intents, intent)
+	}
+
+	return intents, rows.Err()
+}
+
+// PresignUpload returns an url and the form fields the client can POST the object with without credentials
+// until expiry. The policy pins the key and content type and lets MinIO refuse objects over maxSize
+func (repository *UploadRepository) PresignUpload(ctx context.Context, bucketName string, objectKey string, contentType string, maxSize int64, expiry time.Duration) (string, map[string]string, error) {
+	policy := minio.NewPostPolicy()
+
+	err := policy.SetBucket(bucketName)
+	if err != nil {
+		return "", nil, err
+	}
+
+	err = policy.SetKey(objectKey)
+	if err != nil {
+		return "", nil, err
+	}
+
+	err = policy.SetContentType(contentType)
+	if err != nil {
+		return "", nil, err
+	}
+
+	err = policy.SetContentLengthRange(1, maxSize)
+	if err != nil {
+		return "", nil, err
+	}
+
+	err = policy.SetExpires(time.Now().UTC().Add(expiry))
+	if err != nil {
+		return "", nil, err
+	}
+
+	presignedUrl, fields, err := repository.DBObject.PresignedPostPolicy(ctx, policy)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return presignedUrl.String(), fields, nil
+}
+
+// GetUploadObject reads an uploaded object, the returned size is -1 when nothing was uploaded
+// and the data is nil when the object is bigger than maxSize
+func (repository *UploadRepository) GetUploadObject(ctx context.Context, bucketName string, objectKey string, maxSize int64) ([]byte, int64, error) {
+	info, err := repository.DBObject.StatObject(ctx, bucketName, objectKey, minio.StatObjectOptions{})
+	if err != nil {
+		if minio.ToErrorResponse(err).Code == "NoSuchKey" {
+			return nil, -1, nil
+		}
+
+		return nil, 0, err
+	}
+
+	if info.Size > maxSize {
+		return nil, info.Size, nil
+	}
+
+	object, err := repository.DBObject.GetObject(ctx, bucketName, objectKey, minio.GetObjectOptions{})
+	if err != nil {
+		return nil, 0, err
+	}
+	defer func() { _ = object.Close() }()
+
+	data, err := io.ReadAll(io.LimitReader(object, maxSize+1))
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if int64(len(data)) > maxSize {
+		return nil, int64(len(data)), nil
+	}
+
+	return data, int64(len(data)), nil
+}
+
+func (repository *UploadRepository) RemoveObject(ctx context.Context, bucketName string, objectKey string) error {
+	err := repository.DBObject.RemoveObject(ctx, bucketName, objectKey, minio.RemoveObjectOptions{})
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
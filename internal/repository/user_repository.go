@@ -96,15 +96,254 @@ func (repository *UserRepository) GetUserAuth(ctx context.Context, username stri
 	return id, passwordHash, nil
 }
 
-func (repository *UserRepository) GetUserInfo(ctx context.Context, id uuid.UUID) (model.UserResponse, error) {
-	query := `SELECT A.id,A.username,A.fullname,A.email,B.object_key,A.create_datetime,A.update_datetime
+func (repository *UserRepository) GetUserIdByEmail(ctx context.Context, email string) (uuid.UUID, error) {
+	query := "SELECT id FROM users WHERE email=$1 LIMIT 1"
+
+	var id uuid.UUID
+	err := repository.DB.QueryRow(ctx, query, email).Scan(&id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return uuid.Nil, nil
+		}
+		return id, err
+	}
+
+	return id, nil
+}
+
+func (repository *UserRepository) GetUserPassword(ctx context.Context, userId uuid.UUID) (string, error) {
+	query := "SELECT password FROM users WHERE id=$1 LIMIT 1"
+
+	var passwordHash string
+	err := repository.DB.QueryRow(ctx, query, userId).Scan(&passwordHash)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return passwordHash, &model.ValidationError{
+				Code:    constant.ERR_NOT_FOUND_ERROR,
+				Message: "User not found",
+				Param:   "userId",
+			}
+		}
+		return passwordHash, err
+	}
+
+	return passwordHash, nil
+}
+
+func (repository *UserRepository) UpdatePassword(ctx context.Context, userId uuid.UUID, password string, updateUserId uuid.UUID, updateDatetime time.Time) error {
+	query := "UPDATE users SET password = $1, update_datetime = $2, update_user_id = $3 WHERE id = $4"
+
+	_, err := repository.DB.Exec(ctx, query, password, updateDatetime, updateUserId, userId)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (repository *UserRepository) ScheduleAccountDeletion(ctx context.Context, userId uuid.UUID, requestedDatetime time.Time, scheduledDatetime time.Time) error {
+	query := "UPDATE users SET delete_requested_datetime = $1, delete_scheduled_datetime = $2, update_datetime = $1, update_user_id = $3 WHERE id = $3"
+
+	_, err := repository.DB.Exec(ctx, query, requestedDatetime, scheduledDatetime, userId)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// RestoreAccount cancels a pending deletion, it reports whether the account was actually pending
+func (repository *UserRepository) RestoreAccount(ctx context.Context, userId uuid.UUID, updateDatetime time.Time) (bool, error) {
+	query := "UPDATE users SET delete_requested_datetime = NULL, delete_scheduled_datetime = NULL, update_datetime = $1, update_user_id = $2 WHERE id = $2 AND delete_scheduled_datetime IS NOT NULL"
+
+	result, err := repository.DB.Exec(ctx, query, updateDatetime, userId)
+	if err != nil {
+		return false, err
+	}
+
+	return result.RowsAffected() == 1, nil
+}
+
+func (repository *UserRepository) GetAccountsDueForDeletion(ctx context.Context, now time.Time, limit int) ([]uuid.UUID, error) {
+	query := "SELECT id FROM users WHERE delete_scheduled_datetime IS NOT NULL AND delete_scheduled_datetime <= $1 ORDER BY delete_scheduled_datetime LIMIT $2"
+
+	rows, err := repository.DB.Query(ctx, query, now, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	userIds := []uuid.UUID{}
+	for rows.Next() {
+		var userId uuid.UUID
+		err = rows.Scan(&userId)
+		if err != nil {
+			return nil, err
+		}
+
+		userIds = append(userIds, userId)
+	}
+
+	return userIds, rows.Err()
+}
+
+// LockAccountDueForDeletion locks the user row for the purge, it returns 0 when the account
+// has been restored in the meantime
+func (repository *UserRepository) LockAccountDueForDeletion(ctx context.Context, tx pgx.Tx, userId uuid.UUID, now time.Time) (int, error) {
+	query := "SELECT 1 FROM users WHERE id = $1 AND delete_scheduled_datetime IS NOT NULL AND delete_scheduled_datetime <= $2 FOR UPDATE"
+
+	var exists int
+	err := tx.QueryRow(ctx, query, userId, now).Scan(&exists)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return exists, nil
+		}
+		return exists, err
+	}
+
+	return exists, nil
+}
+
+// DeleteUserPostImages removes the image rows of every post written by the user and returns the object keys
+// of their originals and variants, the posts themselves cascade from the user
+func (repository *UserRepository) DeleteUserPostImages(ctx context.Context, tx pgx.Tx, userId uuid.UUID) ([]string, error) {
+	query := `DELETE FROM server_post_images WHERE id IN (
+			SELECT B.post_image_id FROM server_posts A
+			INNER JOIN server_post_media B ON B.post_id = A.id
+			WHERE A.author_id = $1
+		) RETURNING object_key, variants`
+
+	rows, err := tx.Query(ctx, query, userId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	objectKeys := []string{}
+	for rows.Next() {
+		var objectKey string
+		var variants model.ImageVariants
+		err = rows.Scan(&objectKey, &variants)
+		if err != nil {
+			return nil, err
+		}
+
+		objectKeys = append(objectKeys, variants.ObjectKeys(objectKey)...)
+	}
+
+	return objectKeys, rows.Err()
+}
+
+func (repository *UserRepository) DeleteUser(ctx context.Context, tx pgx.Tx, userId uuid.UUID) error {
+	query := "DELETE FROM users WHERE id = $1"
+
+	_, err := tx.Exec(ctx, query, userId)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (repository *UserRepository) GetUserMembershipsForExport(ctx context.Context, userId uuid.UUID) ([]model.UserExportMembership, error) {
+	query := `SELECT A.server_id, B.name, C.name, B.owner_id = A.user_id, A.joined_datetime
+			FROM server_members A
+			INNER JOIN servers B ON A.server_id = B.id
+			INNER JOIN server_roles C ON A.server_role_id = C.id
+			WHERE A.user_id = $1
+			ORDER BY A.joined_datetime`
+
+	rows, err := repository.DB.Query(ctx, query, userId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	memberships := []model.UserExportMembership{}
+	for rows.Next() {
+		var membership model.UserExportMembership
+		err = rows.Scan(&membership.ServerId, &membership.ServerName, &membership.RoleName, &membership.IsOwner, &membership.JoinedDatetime)
+		if err != nil {
+			return nil, err
+		}
+
+		memberships = append(memberships, membership)
+	}
+
+	return memberships, rows.Err()
+}
+
+func (repository *UserRepository) GetUserPostsForExport(ctx context.Context, userId uuid.UUID, bucketName string, minioFullUrl string) ([]model.UserExportPost, error) {
+	query := `SELECT A.id, A.server_id, A.caption,
+			ARRAY(SELECT C.object_key FROM server_post_media B
+				INNER JOIN server_post_images C ON B.post_image_id = C.id
+				WHERE B.post_id = A.id
+				ORDER BY B.position),
+			A.create_datetime
+			FROM server_posts A
+			WHERE A.author_id = $1
+			ORDER BY A.create_datetime`
+
+	rows, err := repository.DB.Query(ctx, query, userId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	posts := []model.UserExportPost{}
+	for rows.Next() {
+		var post model.UserExportPost
+		err = rows.Scan(&post.Id, &post.ServerId, &post.Caption, &post.ImageUrls, &post.CreateDatetime)
+		if err != nil {
+			return nil, err
+		}
+
+		for i := range post.ImageUrls {
+			post.ImageUrls[i], err = objectUrl(ctx, repository.DBObject, bucketName, minioFullUrl, post.ImageUrls[i])
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		posts = append(posts, post)
+	}
+
+	return posts, rows.Err()
+}
+
+func (repository *UserRepository) GetUserCommentsForExport(ctx context.Context, userId uuid.UUID) ([]model.UserExportComment, error) {
+	query := "SELECT id, post_id, parent_id, content, create_datetime FROM server_post_comments WHERE author_id = $1 ORDER BY create_datetime"
+
+	rows, err := repository.DB.Query(ctx, query, userId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	comments := []model.UserExportComment{}
+	for rows.Next() {
+		var comment model.UserExportComment
+		err = rows.Scan(&comment.Id, &comment.PostId, &comment.ParentId, &comment.Content, &comment.CreateDatetime)
+		if err != nil {
+			return nil, err
+		}
+
+		comments = append(comments, comment)
+	}
+
+	return comments, rows.Err()
+}
+
+func (repository *UserRepository) GetUserInfo(ctx context.Context, id uuid.UUID, minioFullUrl string) (model.UserResponse, error) {
+	query := `SELECT A.id,A.username,A.fullname,A.email,B.variants,A.create_datetime,A.update_datetime
 			FROM users A
 			LEFT JOIN user_avatar_images B ON A.id = B.user_id
 			WHERE A.id=$1
 			LIMIT 1`
 
 	user := model.UserResponse{}
-	err := repository.DB.QueryRow(ctx, query, id).Scan(&user.Id, &user.Username, &user.Fullname, &user.Email, &user.AvatarImage, &user.CreateDatetime, &user.UpdateDatetime)
+	var avatarVariants model.ImageVariants
+	err := repository.DB.QueryRow(ctx, query, id).Scan(&user.Id, &user.Username, &user.Fullname, &user.Email, &avatarVariants, &user.CreateDatetime, &user.UpdateDatetime)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return user, &model.ValidationError{
@@ -116,24 +355,102 @@ func (repository *UserRepository) GetUserInfo(ctx context.Context, id uuid.UUID)
 		return user, err
 	}
 
+	user.AvatarImageUrls = avatarVariants.Urls(minioFullUrl)
+
 	return user, nil
 }
 
+// touchAuthSessionScript only updates last_seen_at while the session still exists,
+// so a concurrently revoked session is never brought back as a key without TTL
+var touchAuthSessionScript = redis.NewScript(`
+if redis.call("EXISTS", KEYS[1]) == 1 then
+	return redis.call("HSET", KEYS[1], "last_seen_at", ARGV[1])
+end
+return 0
+`)
+
+// incrementIfExistsScript increments a hash field without recreating a hash that has already expired
+var incrementIfExistsScript = redis.NewScript(`
+if redis.call("EXISTS", KEYS[1]) == 1 then
+	return redis.call("HINCRBY", KEYS[1], ARGV[1], 1)
+end
+return 0
+`)
+
 // Redis - Cache
-func (repository *UserRepository) SetAuthTokenInCache(ctx context.Context, accessToken string, refreeshToken string, userId uuid.UUID) error {
-	accessTokenKey := fmt.Sprintf("auth:acccessToken:%s", userId)
-	refreshTokenKey := fmt.Sprintf("auth:refreshToken:%s", userId)
+func (repository *UserRepository) CreateAuthSession(ctx context.Context, session model.AuthSession) error {
+	sessionKey := fmt.Sprintf("auth:session:%s", session.Id)
+	userSessionsKey := fmt.Sprintf("auth:userSessions:%s", session.UserId)
+
+	err := repository.DBCache.HSet(ctx, sessionKey, map[string]interface{}{
+		"user_id":      session.UserId.String(),
+		"device_label": session.DeviceLabel,
+		"ip_address":   session.IpAddress,
+		"user_agent":   session.UserAgent,
+		"create_at":    session.CreateAt,
+		"last_seen_at": session.LastSeenAt,
+	}).Err()
+	if err != nil {
+		return err
+	}
+
+	err = repository.DBCache.Expire(ctx, sessionKey, util.RefreshTokenDuration).Err()
+	if err != nil {
+		return err
+	}
+
+	err = repository.DBCache.SAdd(ctx, userSessionsKey, session.Id.String()).Err()
+	if err != nil {
+		return err
+	}
+
+	err = repository.DBCache.Expire(ctx, userSessionsKey, util.RefreshTokenDuration).Err()
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (repository *UserRepository) SetAuthTokenInCache(ctx context.Context, accessToken string, refreeshToken string, userId uuid.UUID, sessionId uuid.UUID) error {
+	sessionKey := fmt.Sprintf("auth:session:%s", sessionId)
+	userSessionsKey := fmt.Sprintf("auth:userSessions:%s", userId)
 
 	// Hash tokens before storing in Redis for security
 	hashedAccessToken := util.HashToken(accessToken)
 	hashedRefreshToken := util.HashToken(refreeshToken)
+	refreshTokenDataKey := fmt.Sprintf("auth:refreshTokenData:%s", hashedRefreshToken)
+
+	err := repository.DBCache.HSet(ctx, sessionKey, map[string]interface{}{
+		"access_token":  hashedAccessToken,
+		"refresh_token": hashedRefreshToken,
+	}).Err()
+	if err != nil {
+		return err
+	}
 
-	err := repository.DBCache.Set(ctx, accessTokenKey, hashedAccessToken, 15*time.Minute).Err()
+	err = repository.DBCache.Expire(ctx, sessionKey, util.RefreshTokenDuration).Err()
+	if err != nil {
+		return err
+	}
+
+	err = repository.DBCache.Expire(ctx, userSessionsKey, util.RefreshTokenDuration).Err()
+	if err != nil {
+		return err
+	}
+
+	// Every refresh token keeps a record of its owner and session until it expires,
+	// so a rotated token that is presented again can still be recognized as reuse
+	err = repository.DBCache.HSet(ctx, refreshTokenDataKey, map[string]interface{}{
+		"user_id":    userId.String(),
+		"session_id": sessionId.String(),
+		"used":       0,
+	}).Err()
 	if err != nil {
 		return err
 	}
 
-	err = repository.DBCache.Set(ctx, refreshTokenKey, hashedRefreshToken, 15*time.Minute).Err()
+	err = repository.DBCache.Expire(ctx, refreshTokenDataKey, util.RefreshTokenDuration).Err()
 	if err != nil {
 		return err
 	}
@@ -141,43 +458,128 @@ func (repository *UserRepository) SetAuthTokenInCache(ctx context.Context, acces
 	return nil
 }
 
-func (repository *UserRepository) GetAccessTokenInCache(ctx context.Context, userId uuid.UUID) (string, error) {
-	accessTokenKey := fmt.Sprintf("auth:acccessToken:%s", userId)
-	hashedToken, err := repository.DBCache.Get(ctx, accessTokenKey).Result()
-	if err == redis.Nil {
-		return hashedToken, &model.ValidationError{
+func (repository *UserRepository) GetAccessTokenInCache(ctx context.Context, userId uuid.UUID, sessionId uuid.UUID) (string, error) {
+	sessionKey := fmt.Sprintf("auth:session:%s", sessionId)
+
+	vals, err := repository.DBCache.HMGet(ctx, sessionKey, "user_id", "access_token").Result()
+	if err != nil {
+		return "", err
+	}
+
+	sessionUserId, _ := vals[0].(string)
+	hashedToken, _ := vals[1].(string)
+	if sessionUserId != userId.String() || hashedToken == "" {
+		return "", &model.ValidationError{
 			Code:    constant.ERR_NOT_FOUND_ERROR,
 			Message: "Authorization token not found or expired",
 			Param:   "accessToken",
 		}
-	} else if err != nil {
-		return hashedToken, err
 	}
 
 	return hashedToken, nil
 }
 
-func (repository *UserRepository) RemoveAuthToken(ctx context.Context, userId uuid.UUID) error {
-	accessTokenKey := fmt.Sprintf("auth:acccessToken:%s", userId)
-	refreshTokenKey := fmt.Sprintf("auth:refreshToken:%s", userId)
+func (repository *UserRepository) TouchAuthSession(ctx context.Context, sessionId uuid.UUID, lastSeenAt int64) error {
+	sessionKey := fmt.Sprintf("auth:session:%s", sessionId)
 
-	err := repository.DBCache.Del(ctx, accessTokenKey).Err()
+	err := touchAuthSessionScript.Run(ctx, repository.DBCache, []string{sessionKey}, lastSeenAt).Err()
 	if err != nil {
 		return err
 	}
 
-	err = repository.DBCache.Del(ctx, refreshTokenKey).Err()
+	return nil
+}
+
+func (repository *UserRepository) GetAuthSession(ctx context.Context, sessionId uuid.UUID) (map[string]string, error) {
+	sessionKey := fmt.Sprintf("auth:session:%s", sessionId)
+
+	vals, err := repository.DBCache.HGetAll(ctx, sessionKey).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	return vals, nil
+}
+
+func (repository *UserRepository) GetUserSessionIds(ctx context.Context, userId uuid.UUID) ([]string, error) {
+	userSessionsKey := fmt.Sprintf("auth:userSessions:%s", userId)
+
+	sessionIds, err := repository.DBCache.SMembers(ctx, userSessionsKey).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	return sessionIds, nil
+}
+
+func (repository *UserRepository) GetRefreshTokenData(ctx context.Context, hashedRefreshToken string) (map[string]string, error) {
+	key := fmt.Sprintf("auth:refreshTokenData:%s", hashedRefreshToken)
+
+	vals, err := repository.DBCache.HGetAll(ctx, key).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	return vals, nil
+}
+
+// MarkRefreshTokenUsed atomically increments the use counter of a refresh token,
+// a result greater than 1 means the token has already been rotated and 0 means it has expired
+func (repository *UserRepository) MarkRefreshTokenUsed(ctx context.Context, hashedRefreshToken string) (int64, error) {
+	key := fmt.Sprintf("auth:refreshTokenData:%s", hashedRefreshToken)
+
+	used, err := incrementIfExistsScript.Run(ctx, repository.DBCache, []string{key}, "used").Int64()
+	if err != nil {
+		return used, err
+	}
+
+	return used, nil
+}
+
+func (repository *UserRepository) RemoveAuthSession(ctx context.Context, userId uuid.UUID, sessionId uuid.UUID) error {
+	sessionKey := fmt.Sprintf("auth:session:%s", sessionId)
+	userSessionsKey := fmt.Sprintf("auth:userSessions:%s", userId)
+
+	err := repository.DBCache.Del(ctx, sessionKey).Err()
 	if err != nil {
 		return err
 	}
 
+	err = repository.DBCache.SRem(ctx, userSessionsKey, sessionId.String()).Err()
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// RemoveAllAuthSessions ends every session of the user except exceptSessionId,
+// pass uuid.Nil to end all of them
+func (repository *UserRepository) RemoveAllAuthSessions(ctx context.Context, userId uuid.UUID, exceptSessionId uuid.UUID) error {
+	sessionIds, err := repository.GetUserSessionIds(ctx, userId)
+	if err != nil {
+		return err
+	}
+
+	for _, id := range sessionIds {
+		sessionId, err := uuid.Parse(id)
+		if err != nil || sessionId == exceptSessionId {
+			continue
+		}
+
+		err = repository.RemoveAuthSession(ctx, userId, sessionId)
+		if err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
-func (repository *UserRepository) UploadUserAvatar(ctx context.Context, bucketName string, imageName string, imageFile *bytes.Reader, imageSize int64) error {
+func (repository *UserRepository) UploadUserAvatar(ctx context.Context, bucketName string, imageName string, imageFile *bytes.Reader, imageSize int64, contentType string) error {
 	_, err := repository.DBObject.PutObject(ctx, bucketName, imageName, imageFile, imageSize,
 		minio.PutObjectOptions{
-			ContentType:  "image/webp",
+			ContentType:  contentType,
 			CacheControl: "public, max-age=31536000, immutable",
 		})
 	if err != nil {
@@ -187,19 +589,51 @@ func (repository *UserRepository) UploadUserAvatar(ctx context.Context, bucketNa
 	return nil
 }
 
-func (repository *UserRepository) GetUserAvatar(ctx context.Context, tx pgx.Tx, userId uuid.UUID) (string, error) {
-	query := "SELECT object_key FROM user_avatar_images WHERE user_id=$1 LIMIT 1"
+// GetUserAvatar returns the object keys of the avatar original and its variants, empty when the user has no avatar
+func (repository *UserRepository) GetUserAvatar(ctx context.Context, tx pgx.Tx, userId uuid.UUID) ([]string, error) {
+	query := "SELECT object_key, variants FROM user_avatar_images WHERE user_id=$1 LIMIT 1"
 
 	var objectKey string
-	err := tx.QueryRow(ctx, query, userId).Scan(&objectKey)
+	var variants model.ImageVariants
+	err := tx.QueryRow(ctx, query, userId).Scan(&objectKey, &variants)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			return "", nil
+			return []string{}, nil
 		}
-		return objectKey, err
+		return nil, err
+	}
+
+	return variants.ObjectKeys(objectKey), nil
+}
+
+func (repository *UserRepository) LockUser(ctx context.Context, tx pgx.Tx, userId uuid.UUID) error {
+	query := "SELECT id FROM users WHERE id = $1 FOR UPDATE"
+
+	var id uuid.UUID
+	err := tx.QueryRow(ctx, query, userId).Scan(&id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return &model.ValidationError{
+				Code:    constant.ERR_NOT_FOUND_ERROR,
+				Message: "User not found",
+				Param:   "userId",
+			}
+		}
+		return err
+	}
+
+	return nil
+}
+
+func (repository *UserRepository) UpdateUserAvatarImage(ctx context.Context, tx pgx.Tx, userId uuid.UUID, avatarImageId *uuid.UUID, updateUserId uuid.UUID, updateDatetime time.Time) error {
+	query := "UPDATE users SET avatar_image_id = $1, update_datetime = $2, update_user_id = $3 WHERE id = $4"
+
+	_, err := tx.Exec(ctx, query, avatarImageId, updateDatetime, updateUserId, userId)
+	if err != nil {
+		return err
 	}
 
-	return objectKey, nil
+	return nil
 }
 
 func (repository *UserRepository) DeleteUserAvatar(ctx context.Context, bucketName string, fileName string) error {
@@ -211,6 +645,15 @@ func (repository *UserRepository) DeleteUserAvatar(ctx context.Context, bucketNa
 	return nil
 }
 
+func (repository *UserRepository) RemoveObject(ctx context.Context, bucketName string, objectKey string) error {
+	err := repository.DBObject.RemoveObject(ctx, bucketName, objectKey, minio.RemoveObjectOptions{})
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
 func (repository *UserRepository) DeleteAvatarImage(ctx context.Context, tx pgx.Tx, userId uuid.UUID) error {
 	query := "DELETE FROM user_avatar_images WHERE user_id=$1"
 
@@ -223,9 +666,9 @@ func (repository *UserRepository) DeleteAvatarImage(ctx context.Context, tx pgx.
 }
 
 func (repository *UserRepository) AddUserAvatar(ctx context.Context, tx pgx.Tx, avatar model.UserAvatarImage) error {
-	query := "INSERT INTO user_avatar_images (id, user_id, bucket, object_key, mime_type, size, create_datetime, update_datetime, create_user_id, update_user_id) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)"
+	query := "INSERT INTO user_avatar_images (id, user_id, bucket, object_key, mime_type, size, variants, create_datetime, update_datetime, create_user_id, update_user_id) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)"
 
-	_, err := tx.Exec(ctx, query, avatar.Id, avatar.UserId, avatar.Bucket, avatar.ObjectKey, avatar.MimeType, avatar.Size, avatar.CreateDatetime, avatar.UpdateDatetime, avatar.CreateUserId, avatar.UpdateUserId)
+	_, err := tx.Exec(ctx, query, avatar.Id, avatar.UserId, avatar.Bucket, avatar.ObjectKey, avatar.MimeType, avatar.Size, avatar.Variants, avatar.CreateDatetime, avatar.UpdateDatetime, avatar.CreateUserId, avatar.UpdateUserId)
 	if err != nil {
 		return err
 	}
@@ -435,3 +878,95 @@ func (repository *UserRepository) UpdateBio(ctx context.Context, userId uuid.UUI
 
 	return nil
 }
+
+func (repository *UserRepository) SetPasswordResetSession(ctx context.Context, sessionId uuid.UUID, userId uuid.UUID, email string, otp string, otpExpiresAt int64) error {
+	key := fmt.Sprintf("password_reset:%s", sessionId)
+
+	err := repository.DBCache.HSet(ctx, key, map[string]interface{}{
+		"user_id":        userId.String(),
+		"email":          email,
+		"otp":            otp,
+		"otp_expires_at": otpExpiresAt,
+		"attempts":       0,
+		"create_at":      time.Now().Unix(),
+	}).Err()
+	if err != nil {
+		return err
+	}
+
+	err = repository.DBCache.Expire(ctx, key, 30*time.Minute).Err()
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (repository *UserRepository) SetPasswordResetEmailSession(ctx context.Context, sessionId string, email string) error {
+	key := fmt.Sprintf("password_reset_email:%s", email)
+
+	err := repository.DBCache.Set(ctx, key, sessionId, 30*time.Minute).Err()
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (repository *UserRepository) CheckPasswordResetEmailSession(ctx context.Context, email string) (bool, string, error) {
+	key := fmt.Sprintf("password_reset_email:%s", email)
+	sessionId, err := repository.DBCache.Get(ctx, key).Result()
+	if err == redis.Nil {
+		return false, sessionId, nil
+	} else if err != nil {
+		return false, sessionId, err
+	}
+
+	return true, sessionId, nil
+}
+
+func (repository *UserRepository) GetPasswordResetSession(ctx context.Context, sessionId uuid.UUID) (map[string]string, error) {
+	key := fmt.Sprintf("password_reset:%s", sessionId)
+
+	vals, err := repository.DBCache.HGetAll(ctx, key).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	return vals, nil
+}
+
+// IncrementPasswordResetAttempts returns the attempt count after this one,
+// or 0 when the session has expired in the meantime
+func (repository *UserRepository) IncrementPasswordResetAttempts(ctx context.Context, sessionId uuid.UUID) (int64, error) {
+	key := fmt.Sprintf("password_reset:%s", sessionId)
+
+	attempts, err := incrementIfExistsScript.Run(ctx, repository.DBCache, []string{key}, "attempts").Int64()
+	if err != nil {
+		return attempts, err
+	}
+
+	return attempts, nil
+}
+
+func (repository *UserRepository) DeletePasswordResetSession(ctx context.Context, sessionId string) error {
+	key := fmt.Sprintf("password_reset:%s", sessionId)
+
+	err := repository.DBCache.Del(ctx, key).Err()
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (repository *UserRepository) DeletePasswordResetEmailSession(ctx context.Context, email string) error {
+	key := fmt.Sprintf("password_reset_email:%s", email)
+
+	err := repository.DBCache.Del(ctx, key).Err()
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,197 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"html/template"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/ferdian3456/virdanproject/internal/model"
+	"github.com/ferdian3456/virdanproject/internal/repository"
+	"github.com/ferdian3456/virdanproject/internal/util"
+	"github.com/google/uuid"
+	"github.com/knadh/koanf/v2"
+	"go.uber.org/zap"
+)
+
+const (
+	// digestBatchSize is how many due recipients one query returns
+	digestBatchSize = 100
+	// digestPostLimit is how many posts a digest lists, the rest is only counted
+	digestPostLimit = 5
+	// digestCaptionLength is where a listed caption gets cut off, in characters
+	digestCaptionLength = 140
+)
+
+// DigestUsecase sends the daily and weekly activity emails
+type DigestUsecase struct {
+	DigestRepository *repository.DigestRepository
+	Log              *zap.Logger
+	Config           *koanf.Koanf
+}
+
+func NewDigestUsecase(digestRepository *repository.DigestRepository, zap *zap.Logger, koanf *koanf.Koanf) *DigestUsecase {
+	return &DigestUsecase{
+		DigestRepository: digestRepository,
+		Log:              zap,
+		Config:           koanf,
+	}
+}
+
+// SendDigests emails every user whose digest is due at now. The window of a recipient is claimed before the
+// email goes out so concurrent workers never send twice, a recipient without activity gets no email but its
+// window still moves on. A failed send puts the window back for the next run
+func (usecase *DigestUsecase) SendDigests(ctx context.Context, now time.Time) (model.DigestReport, error) {
+	report := model.DigestReport{}
+
+	dailyCutoff := now.Add(-24 * time.Hour)
+	weeklyCutoff := now.Add(-7 * 24 * time.Hour)
+
+	// Failed recipients stay due, they are left out for the rest of this run
+	failedUserIds := []uuid.UUID{}
+
+	for {
+		recipients, err := usecase.DigestRepository.GetDueDigestRecipients(ctx, dailyCutoff, weeklyCutoff, failedUserIds, digestBatchSize)
+		if err != nil {
+			return report, err
+		}
+
+		for _, recipient := range recipients {
+			report.Due++
+
+			sent, err := usecase.sendDigest(ctx, recipient, now)
+			if err != nil {
+				usecase.Log.Warn("failed to send digest", zap.String("userId", recipient.UserId.String()), zap.Error(err))
+				failedUserIds = append(failedUserIds, recipient.UserId)
+				report.Failed++
+				continue
+			}
+
+			if sent {
+				report.Sent++
+			} else {
+				report.Skipped++
+			}
+		}
+
+		if len(recipients) < digestBatchSize {
+			break
+		}
+	}
+
+	usecase.Log.Info("digests sent", zap.Int("due", report.Due), zap.Int("sent", report.Sent), zap.Int("skipped", report.Skipped), zap.Int("failed", report.Failed))
+
+	return report, nil
+}
+
+// sendDigest reports whether an email went out, false without an error means another worker claimed the
+// recipient or there was nothing to tell
+func (usecase *DigestUsecase) sendDigest(ctx context.Context, recipient model.DigestRecipient, now time.Time) (bool, error) {
+	claimed, err := usecase.DigestRepository.ClaimDigest(ctx, recipient.UserId, recipient.SentDatetime, now)
+	if err != nil {
+		return false, err
+	}
+
+	if !claimed {
+		return false, nil
+	}
+
+	activity, err := usecase.getDigestActivity(ctx, recipient.UserId, recipient.SinceDatetime, now)
+	if err == nil {
+		if activity.IsEmpty() {
+			return false, nil
+		}
+
+		err = usecase.sendDigestEmail(recipient, activity)
+	}
+
+	if err != nil {
+		releaseErr := usecase.DigestRepository.ReleaseDigest(ctx, recipient.UserId, now, recipient.SentDatetime)
+		if releaseErr != nil {
+			usecase.Log.Warn("failed to release digest", zap.String("userId", recipient.UserId.String()), zap.Error(releaseErr))
+		}
+
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (usecase *DigestUsecase) getDigestActivity(ctx context.Context, userId uuid.UUID, since time.Time, until time.Time) (model.DigestActivity, error) {
+	posts, postCount, err := usecase.DigestRepository.GetDigestPosts(ctx, userId, since, until, digestPostLimit)
+	if err != nil {
+		return model.DigestActivity{}, err
+	}
+
+	counts, err := usecase.DigestRepository.GetDigestNotificationCounts(ctx, userId, since, until)
+	if err != nil {
+		return model.DigestActivity{}, err
+	}
+
+	for i := range posts {
+		caption := []rune(posts[i].Caption)
+		if len(caption) > digestCaptionLength {
+			posts[i].Caption = strings.TrimSpace(string(caption[:digestCaptionLength])) + "…"
+		}
+	}
+
+	return model.DigestActivity{
+		Posts:        posts,
+		PostCount:    postCount,
+		LikeCount:    counts[model.NotificationPostLiked],
+		CommentCount: counts[model.NotificationPostCommented],
+		ReplyCount:   counts[model.NotificationCommentReplied],
+	}, nil
+}
+
+func (usecase *DigestUsecase) sendDigestEmail(recipient model.DigestRecipient, activity model.DigestActivity) error {
+	token, err := util.GenerateUnsubscribeToken(recipient.UserId, usecase.Config.String("JWT_SECRET_KEY"))
+	if err != nil {
+		return err
+	}
+
+	unsubscribeUrl := fmt.Sprintf("%s/api/notifications/digest/unsubscribe?token=%s", strings.TrimRight(usecase.Config.String("APP_URL"), "/"), url.QueryEscape(token))
+
+	period := "week"
+	if recipient.Frequency == model.DigestFrequencyDaily {
+		period = "day"
+	}
+
+	template, err := template.ParseFS(util.TemplateFS, "template/digest.html")
+	if err != nil {
+		return err
+	}
+
+	var tmpl bytes.Buffer
+	err = template.Execute(&tmpl, model.DigestTemplateData{
+		Username:       recipient.Username,
+		Period:         period,
+		Posts:          activity.Posts,
+		MorePostCount:  activity.PostCount - len(activity.Posts),
+		LikeCount:      activity.LikeCount,
+		CommentCount:   activity.CommentCount,
+		ReplyCount:     activity.ReplyCount,
+		UnsubscribeUrl: unsubscribeUrl,
+	})
+	if err != nil {
+		return err
+	}
+
+	smtpHost := usecase.Config.String("SMTP_HOST")
+	smtpPort := usecase.Config.Int("SMTP_PORT")
+	senderName := usecase.Config.String("SENDER_NAME")
+	senderEmail := usecase.Config.String("SENDER_EMAIL")
+	senderPassword := usecase.Config.String("SENDER_PASSWORD")
+
+	// List-Unsubscribe lets mail clients offer the unsubscribe button, the POST variant needs no confirmation page
+	headers := map[string]string{
+		"List-Unsubscribe":      "<" + unsubscribeUrl + ">",
+		"List-Unsubscribe-Post": "List-Unsubscribe=One-Click",
+	}
+
+	subject := fmt.Sprintf("Your %s Virdan digest", recipient.Frequency)
+	return util.SendEmailWithHeaders(smtpHost, smtpPort, senderName, senderEmail, senderPassword, recipient.Email, subject, tmpl.String(), headers)
+}
@@ -0,0 +1,185 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/ferdian3456/virdanproject/internal/model"
+	"github.com/ferdian3456/virdanproject/internal/repository"
+	"github.com/google/uuid"
+	"github.com/knadh/koanf/v2"
+	"go.uber.org/zap"
+)
+
+// eventClientBuffer is how many events may wait for a slow connection before new ones are dropped
+const eventClientBuffer = 64
+
+// EventClient is one WebSocket connection of a user, Events carries the encoded events to write to it
+type EventClient struct {
+	UserId    uuid.UUID
+	Events    chan []byte
+	serverIds map[uuid.UUID]bool
+}
+
+// EventUsecase fans the server events published by any API instance out to the WebSocket
+// connections of this instance
+type EventUsecase struct {
+	EventRepository *repository.EventRepository
+	Log             *zap.Logger
+	Config          *koanf.Koanf
+
+	mu      sync.Mutex
+	clients map[*EventClient]bool
+}
+
+func NewEventUsecase(eventRepository *repository.EventRepository, zap *zap.Logger, koanf *koanf.Koanf) *EventUsecase {
+	return &EventUsecase{
+		EventRepository: eventRepository,
+		Log:             zap,
+		Config:          koanf,
+		clients:         map[*EventClient]bool{},
+	}
+}
+
+// Start subscribes to the server events and dispatches them until ctx is cancelled
+func (usecase *EventUsecase) Start(ctx context.Context) error {
+	pubsub, err := usecase.EventRepository.SubscribeServerEvents(ctx)
+	if err != nil {
+		return err
+	}
+
+	go func() {
+		defer func() { _ = pubsub.Close() }()
+
+		messages := pubsub.Channel()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case message, ok := <-messages:
+				if !ok {
+					return
+				}
+
+				usecase.dispatch([]byte(message.Payload))
+			}
+		}
+	}()
+
+	return nil
+}
+
+// Register adds a connection of the user, it receives the events of every server the user is a member of
+func (usecase *EventUsecase) Register(ctx context.Context, userId uuid.UUID) (*EventClient, error) {
+	serverIds, err := usecase.EventRepository.GetActiveServerIds(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	client := &EventClient{
+		UserId:    userId,
+		Events:    make(chan []byte, eventClientBuffer),
+		serverIds: make(map[uuid.UUID]bool, len(serverIds)),
+	}
+
+	for _, serverId := range serverIds {
+		client.serverIds[serverId] = true
+	}
+
+	usecase.mu.Lock()
+	usecase.clients[client] = true
+	usecase.mu.Unlock()
+
+	return client, nil
+}
+
+// Unregister removes the connection and closes its channel
+func (usecase *EventUsecase) Unregister(client *EventClient) {
+	usecase.mu.Lock()
+	defer usecase.mu.Unlock()
+
+	if usecase.clients[client] {
+		delete(usecase.clients, client)
+		close(client.Events)
+	}
+}
+
+func (usecase *EventUsecase) dispatch(payload []byte) {
+	var event model.ServerEvent
+	err := json.Unmarshal(payload, &event)
+	if err != nil {
+		usecase.Log.Warn("failed to decode server event", zap.Error(err))
+		return
+	}
+
+	// Member events also change which servers the connections of that user follow
+	var member model.ServerMemberEventData
+	if event.Type == model.ServerEventMemberJoined || event.Type == model.ServerEventMemberLeft {
+		err = json.Unmarshal(event.Data, &member)
+		if err != nil {
+			usecase.Log.Warn("failed to decode server member event", zap.Error(err))
+			return
+		}
+	}
+
+	usecase.mu.Lock()
+	defer usecase.mu.Unlock()
+
+	for client := range usecase.clients {
+		if client.UserId == member.UserId {
+			if event.Type == model.ServerEventMemberJoined {
+				client.serverIds[event.ServerId] = true
+			} else if event.Type == model.ServerEventMemberLeft {
+				// The member still learns it was removed, nothing of the server reaches it afterwards
+				if client.serverIds[event.ServerId] {
+					usecase.send(client, payload)
+				}
+				delete(client.serverIds, event.ServerId)
+				continue
+			}
+		}
+
+		if client.serverIds[event.ServerId] {
+			usecase.send(client, payload)
+		}
+	}
+}
+
+// send never blocks the dispatcher, a connection that stopped reading misses events instead
+func (usecase *EventUsecase) send(client *EventClient, payload []byte) {
+	select {
+	case client.Events <- payload:
+	default:
+		usecase.Log.Warn("dropped server event for slow connection", zap.String("userId", client.UserId.String()))
+	}
+}
+
+// ConnectedEvent is the first message of every connection, it tells the client that events now flow
+func (usecase *EventUsecase) ConnectedEvent() ([]byte, error) {
+	return json.Marshal(model.ServerEvent{
+		Type:           model.ServerEventConnected,
+		CreateDatetime: time.Now().UTC(),
+	})
+}
+
+// publishServerEvent sends the event to the members of the server on every instance. Events are best effort,
+// the change they announce is already committed so a failure is only logged
+func publishServerEvent(ctx context.Context, eventRepository *repository.EventRepository, log *zap.Logger, eventType model.ServerEventType, serverId uuid.UUID, data interface{}) {
+	event := model.ServerEvent{
+		Type:           eventType,
+		ServerId:       serverId,
+		CreateDatetime: time.Now().UTC(),
+	}
+
+	var err error
+	event.Data, err = json.Marshal(data)
+	if err == nil {
+		err = eventRepository.PublishServerEvent(ctx, event)
+	}
+
+	if err != nil {
+		log.Warn("failed to publish server event", zap.String("type", string(eventType)), zap.String("serverId", serverId.String()), zap.Error(err))
+	}
+}
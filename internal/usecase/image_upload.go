@@ -0,0 +1,68 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	"github.com/ferdian3456/virdanproject/internal/model"
+	"github.com/ferdian3456/virdanproject/internal/util"
+	"github.com/google/uuid"
+)
+
+// objectUploader is the upload method of the repository owning the image
+type objectUploader func(ctx context.Context, bucketName string, objectKey string, file *bytes.Reader, size int64, contentType string) error
+
+type imageObject struct {
+	Key         string
+	Data        []byte
+	ContentType string
+}
+
+// storedImage lays a processed upload out as storage objects. Variants are stored under the image id,
+// the original under a random key of its own so it can not be derived from the public variant urls
+type storedImage struct {
+	Id        uuid.UUID
+	ObjectKey string
+	MimeType  string
+	Size      int64
+	Variants  model.ImageVariants
+	objects   []imageObject
+}
+
+func newStoredImage(prefix string, processed *util.ProcessedImage) storedImage {
+	image := storedImage{
+		Id:        uuid.New(),
+		ObjectKey: fmt.Sprintf("%s/original/%s%s", prefix, uuid.New(), processed.Extension),
+		MimeType:  processed.ContentType,
+		Size:      int64(len(processed.Original)),
+		Variants:  make(model.ImageVariants, len(processed.Variants)),
+	}
+
+	image.objects = append(image.objects, imageObject{Key: image.ObjectKey, Data: processed.Original, ContentType: processed.ContentType})
+
+	for name, data := range processed.Variants {
+		objectKey := fmt.Sprintf("%s/%s/%s.webp", prefix, image.Id, name)
+		image.Variants[name] = objectKey
+		image.objects = append(image.objects, imageObject{Key: objectKey, Data: data, ContentType: "image/webp"})
+	}
+
+	return image
+}
+
+// ObjectKeys lists every object of the image, removing a key that was never uploaded is harmless
+func (image storedImage) ObjectKeys() []string {
+	return image.Variants.ObjectKeys(image.ObjectKey)
+}
+
+// upload puts the original and every variant, it stops at the first failure
+func (image storedImage) upload(ctx context.Context, bucketName string, put objectUploader) error {
+	for _, object := range image.objects {
+		err := put(ctx, bucketName, object.Key, bytes.NewReader(object.Data), int64(len(object.Data)), object.ContentType)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,246 @@
+package usecase
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/bytedance/sonic"
+	"github.com/ferdian3456/virdanproject/internal/constant"
+	"github.com/ferdian3456/virdanproject/internal/model"
+	"github.com/ferdian3456/virdanproject/internal/repository"
+	"github.com/ferdian3456/virdanproject/internal/util"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/knadh/koanf/v2"
+	"go.uber.org/zap"
+)
+
+type NotificationUsecase struct {
+	NotificationRepository *repository.NotificationRepository
+	Log                    *zap.Logger
+	Config                 *koanf.Koanf
+}
+
+func NewNotificationUsecase(notificationRepository *repository.NotificationRepository, zap *zap.Logger, koanf *koanf.Koanf) *NotificationUsecase {
+	return &NotificationUsecase{
+		NotificationRepository: notificationRepository,
+		Log:                    zap,
+		Config:                 koanf,
+	}
+}
+
+func (usecase *NotificationUsecase) GetNotifications(ctx *fiber.Ctx, userId uuid.UUID) (model.NotificationListResponse, error) {
+	response := model.NotificationListResponse{}
+
+	limit := ctx.QueryInt("limit", constant.DEFAULT_LIMIT)
+	cursor := ctx.Query("cursor", "")
+
+	if limit < 1 {
+		return response, &model.ValidationError{
+			Code:    constant.ERR_VALIDATION_CODE,
+			Message: "Limit must be greater or equal than 1",
+			Param:   "limit",
+		}
+	} else if limit > constant.MAX_LIMIT {
+		return response, &model.ValidationError{
+			Code:    constant.ERR_VALIDATION_CODE,
+			Message: fmt.Sprintf("Limit is exceeded max limit: %d", constant.MAX_LIMIT),
+			Param:   "limit",
+		}
+	}
+
+	var notificationCursor model.NotificationCursor
+	if cursor != "" {
+		b, err := base64.RawURLEncoding.DecodeString(cursor)
+		if err != nil {
+			return response, err
+		}
+
+		err = sonic.Unmarshal(b, &notificationCursor)
+		if err != nil {
+			return response, err
+		}
+	}
+
+	notifications, err := usecase.NotificationRepository.GetNotifications(ctx.Context(), limit+1, userId, &notificationCursor)
+	if err != nil {
+		return response, err
+	}
+
+	response.Data = notifications
+
+	if len(notifications) > limit {
+		response.Data = notifications[:limit]
+
+		last := notifications[limit-1]
+
+		b, err := sonic.Marshal(model.NotificationCursor{
+			Id:             last.Id,
+			CreateDatetime: last.CreateDatetime,
+		})
+		if err != nil {
+			return response, err
+		}
+
+		response.Page.NextCursor = base64.RawURLEncoding.EncodeToString(b)
+	}
+
+	return response, nil
+}
+
+func (usecase *NotificationUsecase) GetUnreadCount(ctx *fiber.Ctx, userId uuid.UUID) (model.NotificationUnreadCountResponse, error) {
+	response := model.NotificationUnreadCountResponse{}
+
+	count, err := usecase.NotificationRepository.GetUnreadNotificationCount(ctx.Context(), userId)
+	if err != nil {
+		return response, err
+	}
+
+	response.UnreadCount = count
+	return response, nil
+}
+
+func (usecase *NotificationUsecase) MarkNotificationRead(ctx *fiber.Ctx, userId uuid.UUID, notificationIdParam string) error {
+	notificationId, err := uuid.Parse(notificationIdParam)
+	if err != nil {
+		return &model.ValidationError{
+			Code:    constant.ERR_VALIDATION_CODE,
+			Message: "Invalid notification id",
+			Param:   "notificationId",
+		}
+	}
+
+	found, err := usecase.NotificationRepository.MarkNotificationRead(ctx.Context(), notificationId, userId, time.Now().UTC())
+	if err != nil {
+		return err
+	}
+
+	if !found {
+		return &model.ValidationError{
+			Code:    constant.ERR_NOT_FOUND_ERROR,
+			Message: "Notification not found",
+			Param:   "notificationId",
+		}
+	}
+
+	return nil
+}
+
+func (usecase *NotificationUsecase) MarkAllNotificationsRead(ctx *fiber.Ctx, userId uuid.UUID) error {
+	return usecase.NotificationRepository.MarkAllNotificationsRead(ctx.Context(), userId, time.Now().UTC())
+}
+
+func (usecase *NotificationUsecase) GetNotificationSettings(ctx *fiber.Ctx, userId uuid.UUID) (model.NotificationSettings, error) {
+	return usecase.NotificationRepository.GetNotificationSettings(ctx.Context(), userId)
+}
+
+// UpdateNotificationSettings replaces every preference, an empty digest frequency goes back to the default
+func (usecase *NotificationUsecase) UpdateNotificationSettings(ctx *fiber.Ctx, userId uuid.UUID, payload model.NotificationSettings) (model.NotificationSettings, error) {
+	settings := model.NotificationSettings{
+		MutedTypes:      []model.NotificationType{},
+		DigestFrequency: payload.DigestFrequency,
+	}
+
+	for _, mutedType := range payload.MutedTypes {
+		if !model.IsValidNotificationType(mutedType) {
+			return model.NotificationSettings{}, &model.ValidationError{
+				Code:    constant.ERR_VALIDATION_CODE,
+				Message: fmt.Sprintf("Unknown notification type: %s", mutedType),
+				Param:   "mutedTypes",
+			}
+		}
+
+		if !slices.Contains(settings.MutedTypes, mutedType) {
+			settings.MutedTypes = append(settings.MutedTypes, mutedType)
+		}
+	}
+
+	if settings.DigestFrequency == "" {
+		settings.DigestFrequency = model.DefaultDigestFrequency
+	} else if !model.IsValidDigestFrequency(settings.DigestFrequency) {
+		return model.NotificationSettings{}, &model.ValidationError{
+			Code:    constant.ERR_VALIDATION_CODE,
+			Message: "Invalid digest frequency. allowed frequencies: daily, weekly, off",
+			Param:   "digestFrequency",
+		}
+	}
+
+	err := usecase.NotificationRepository.UpdateNotificationSettings(ctx.Context(), userId, settings, time.Now().UTC())
+	if err != nil {
+		return model.NotificationSettings{}, err
+	}
+
+	return settings, nil
+}
+
+// GetDigestUnsubscribe checks a signed unsubscribe link and returns the current frequency without changing it,
+// link scanners of mail providers follow it before the user does
+func (usecase *NotificationUsecase) GetDigestUnsubscribe(ctx *fiber.Ctx, token string) (model.DigestUnsubscribeResponse, error) {
+	userId, err := util.ParseUnsubscribeToken(token, usecase.Config.String("JWT_SECRET_KEY"))
+	if err != nil {
+		return model.DigestUnsubscribeResponse{}, err
+	}
+
+	settings, err := usecase.NotificationRepository.GetNotificationSettings(ctx.Context(), userId)
+	if err != nil {
+		return model.DigestUnsubscribeResponse{}, err
+	}
+
+	return model.DigestUnsubscribeResponse{DigestFrequency: settings.DigestFrequency}, nil
+}
+
+// UnsubscribeDigest turns the digest off for the user of a signed unsubscribe link, no login is needed
+func (usecase *NotificationUsecase) UnsubscribeDigest(ctx *fiber.Ctx, token string) error {
+	userId, err := util.ParseUnsubscribeToken(token, usecase.Config.String("JWT_SECRET_KEY"))
+	if err != nil {
+		return err
+	}
+
+	found, err := usecase.NotificationRepository.UpdateDigestFrequency(ctx.Context(), userId, model.DigestFrequencyOff, time.Now().UTC())
+	if err != nil {
+		return err
+	}
+
+	if !found {
+		return &model.ValidationError{
+			Code:    constant.ERR_VALIDATION_CODE,
+			Message: "Unsubscribe token is invalid",
+			Param:   "token",
+		}
+	}
+
+	return nil
+}
+
+// notifyUser adds the notification to the inbox of its user unless the user caused it or muted its type.
+// Like server events it is best effort, the action it reports is already committed
+func notifyUser(ctx context.Context, notificationRepository *repository.NotificationRepository, log *zap.Logger, notification model.Notification) {
+	if notification.UserId == uuid.Nil || notification.UserId == notification.ActorId {
+		return
+	}
+
+	mutedTypes, err := notificationRepository.GetMutedNotificationTypes(ctx, notification.UserId)
+	if err != nil {
+		log.Warn("failed to read muted notification types", zap.String("userId", notification.UserId.String()), zap.Error(err))
+		return
+	}
+
+	if slices.Contains(mutedTypes, notification.Type) {
+		return
+	}
+
+	now := time.Now().UTC()
+	notification.Id = uuid.New()
+	notification.CreateDatetime = now
+	notification.UpdateDatetime = now
+	notification.CreateUserId = notification.ActorId
+	notification.UpdateUserId = notification.ActorId
+
+	err = notificationRepository.CreateNotification(ctx, notification)
+	if err != nil {
+		log.Warn("failed to create notification", zap.String("type", string(notification.Type)), zap.String("userId", notification.UserId.String()), zap.Error(err))
+	}
+}
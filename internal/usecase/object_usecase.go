@@ -0,0 +1,91 @@
+package usecase
+
+import (
+	"context"
+	"time"
+
+	"github.com/ferdian3456/virdanproject/internal/model"
+	"github.com/ferdian3456/virdanproject/internal/repository"
+	"github.com/knadh/koanf/v2"
+	"go.uber.org/zap"
+)
+
+// managedObjectPrefixes are the prefixes the app uploads to, anything else in the bucket is left alone
+var managedObjectPrefixes = []string{
+	"server/avatar/",
+	"server/banner/",
+	"server/post/",
+	"user/avatar/",
+	model.PrivateObjectPrefix,
+	"upload/",
+}
+
+// ObjectUsecase reconciles the bucket with the image tables
+type ObjectUsecase struct {
+	ObjectRepository *repository.ObjectRepository
+	Log              *zap.Logger
+	Config           *koanf.Koanf
+}
+
+func NewObjectUsecase(objectRepository *repository.ObjectRepository, zap *zap.Logger, koanf *koanf.Koanf) *ObjectUsecase {
+	return &ObjectUsecase{
+		ObjectRepository: objectRepository,
+		Log:              zap,
+		Config:           koanf,
+	}
+}
+
+// CollectOrphanedObjects removes objects under the managed prefixes that no row points to. Objects younger than
+// minAge are skipped because uploads happen before the row is committed, dry run only reports the orphans
+func (usecase *ObjectUsecase) CollectOrphanedObjects(ctx context.Context, dryRun bool, minAge time.Duration) (model.OrphanedObjectReport, error) {
+	report := model.OrphanedObjectReport{
+		DryRun:   dryRun,
+		Orphaned: []model.StoredObject{},
+	}
+
+	bucketName := usecase.Config.String("MINIO_BUCKET_NAME")
+	cutoff := time.Now().UTC().Add(-minAge)
+
+	// The bucket is listed before the rows are read, an object committed in between is then seen as referenced
+	objects := []model.StoredObject{}
+	for _, prefix := range managedObjectPrefixes {
+		prefixObjects, err := usecase.ObjectRepository.ListObjects(ctx, bucketName, prefix)
+		if err != nil {
+			return report, err
+		}
+
+		objects = append(objects, prefixObjects...)
+	}
+
+	referencedKeys, err := usecase.ObjectRepository.GetReferencedObjectKeys(ctx)
+	if err != nil {
+		return report, err
+	}
+
+	report.Scanned = len(objects)
+
+	for _, object := range objects {
+		if referencedKeys[object.Key] || object.LastModified.After(cutoff) {
+			continue
+		}
+
+		report.Orphaned = append(report.Orphaned, object)
+		report.OrphanedSize += object.Size
+
+		if dryRun {
+			usecase.Log.Info("orphaned object found", zap.String("objectKey", object.Key), zap.Int64("size", object.Size), zap.Time("lastModified", object.LastModified))
+			continue
+		}
+
+		err = usecase.ObjectRepository.RemoveObject(ctx, bucketName, object.Key)
+		if err != nil {
+			usecase.Log.Warn("failed to remove orphaned object", zap.String("objectKey", object.Key), zap.Error(err))
+			continue
+		}
+
+		usecase.Log.Info("orphaned object removed", zap.String("objectKey", object.Key), zap.Int64("size", object.Size))
+		report.Removed++
+	}
+
+	return report, nil
+}
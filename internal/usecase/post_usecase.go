@@ -1,9 +1,11 @@
 package usecase
 
 import (
-	"bytes"
+	"context"
 	"encoding/base64"
+	"errors"
 	"fmt"
+	"mime/multipart"
 	"time"
 
 	"github.com/bytedance/sonic"
@@ -15,71 +17,95 @@ import (
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/knadh/koanf/v2"
+	"github.com/valyala/fasthttp"
 	"go.uber.org/zap"
 )
 
 type PostUsecase struct {
-	PostRepository *repository.PostRepository
-	DB             *pgxpool.Pool
-	Log            *zap.Logger
-	Config         *koanf.Koanf
+	PostRepository         *repository.PostRepository
+	ServerRepository       *repository.ServerRepository
+	EventRepository        *repository.EventRepository
+	NotificationRepository *repository.NotificationRepository
+	DB                     *pgxpool.Pool
+	Log                    *zap.Logger
+	Config                 *koanf.Koanf
 }
 
-func NewPostUsecase(postRepository *repository.PostRepository, db *pgxpool.Pool, zap *zap.Logger, koanf *koanf.Koanf) *PostUsecase {
+func NewPostUsecase(postRepository *repository.PostRepository, serverRepository *repository.ServerRepository, eventRepository *repository.EventRepository, notificationRepository *repository.NotificationRepository, db *pgxpool.Pool, zap *zap.Logger, koanf *koanf.Koanf) *PostUsecase {
 	return &PostUsecase{
-		PostRepository: postRepository,
-		DB:             db,
-		Log:            zap,
-		Config:         koanf,
+		PostRepository:         postRepository,
+		ServerRepository:       serverRepository,
+		EventRepository:        eventRepository,
+		NotificationRepository: notificationRepository,
+		DB:                     db,
+		Log:                    zap,
+		Config:                 koanf,
 	}
 }
 
+// authorizePostMember resolves the server of the post and runs the server permission check on it
+func (usecase *PostUsecase) authorizePostMember(ctx context.Context, postId uuid.UUID, userId uuid.UUID, permission model.Permission) (model.ServerMemberPermission, error) {
+	serverId, err := usecase.PostRepository.GetPostServerId(ctx, postId)
+	if err != nil {
+		return model.ServerMemberPermission{}, err
+	}
+
+	if serverId == uuid.Nil {
+		return model.ServerMemberPermission{}, &model.ValidationError{
+			Code:    constant.ERR_VALIDATION_CODE,
+			Message: "Post not found",
+			Param:   "postId",
+		}
+	}
+
+	return authorizeServerMember(ctx, usecase.ServerRepository, serverId, userId, permission, "postId")
+}
+
 func (usecase *PostUsecase) CreatePost(ctx *fiber.Ctx, serverId uuid.UUID, userId uuid.UUID) (model.ServerPostResponse, error) {
 	response := model.ServerPostResponse{}
 	ctxContext := ctx.Context()
 
-	// Check if user is a member of the server
-	exists, err := usecase.PostRepository.CheckServerMember(ctxContext, serverId, userId)
+	// Check if user is allowed to post in the server
+	_, err := authorizeServerMember(ctxContext, usecase.ServerRepository, serverId, userId, model.PermissionCreatePost, "serverId")
 	if err != nil {
 		return response, err
 	}
 
-	if exists != 1 {
+	// Validate every image part, they make up the carousel in upload order. A text-only post may come
+	// without a multipart body, it simply has no image parts
+	fieldName := "image"
+	var fileHeaders []*multipart.FileHeader
+
+	form, err := ctx.MultipartForm()
+	if err == nil {
+		fileHeaders = form.File[fieldName]
+	} else if !errors.Is(err, fasthttp.ErrNoMultipartForm) {
+		return response, err
+	}
+	if len(fileHeaders) > constant.MAX_POST_MEDIA {
 		return response, &model.ValidationError{
 			Code:    constant.ERR_VALIDATION_CODE,
-			Message: "You are not a member of this server",
-			Param:   "serverId",
+			Message: fmt.Sprintf("A post can have at most %d images", constant.MAX_POST_MEDIA),
+			Param:   fieldName,
 		}
 	}
 
-	// Validate and get image file
-	fieldName := "image"
-	fileHeader, err := ctx.FormFile(fieldName)
+	prefix, err := usecase.postImagePrefix(ctxContext, serverId)
 	if err != nil {
 		return response, err
 	}
 
-	var imageFile *bytes.Reader
-	var imageSize int64
-	var postImageId *uuid.UUID
-
-	if fileHeader.Size != 0 {
-		imageFile, imageSize, err = util.ValidateImage(fileHeader, fieldName)
+	images := make([]storedImage, 0, len(fileHeaders))
+	for _, fileHeader := range fileHeaders {
+		processed, err := util.ValidateImage(fileHeader, fieldName, util.PostImageProfile)
 		if err != nil {
 			return response, err
 		}
 
-		id := uuid.New()
-		postImageId = &id
-	} else {
-		return response, &model.ValidationError{
-			Code:    constant.ERR_VALIDATION_CODE,
-			Message: "Image is required",
-			Param:   "image",
-		}
+		images = append(images, newStoredImage(prefix, processed))
 	}
 
-	// Validate caption
+	// Validate caption, it is required even when the post has images
 	caption := ctx.FormValue("caption")
 	if caption == "" {
 		return response, &model.ValidationError{
@@ -92,27 +118,13 @@ func (usecase *PostUsecase) CreatePost(ctx *fiber.Ctx, serverId uuid.UUID, userI
 	now := time.Now().UTC()
 	postId := uuid.New()
 
-	// Create post image struct
 	bucketName := usecase.Config.String("MINIO_BUCKET_NAME")
 
-	serverPostImage := model.ServerPostImages{
-		Id:             *postImageId,
-		Bucket:         bucketName,
-		ObjectKey:      fmt.Sprintf("server/post/%s.webp", *postImageId),
-		MimeType:       "webp",
-		Size:           imageSize,
-		CreateDatetime: now,
-		UpdateDatetime: now,
-		CreateUserId:   userId,
-		UpdateUserId:   userId,
-	}
-
 	// Create post struct
 	serverPost := model.ServerPosts{
 		Id:             postId,
 		ServerId:       serverId,
 		AuthorId:       userId,
-		PostImageId:    *postImageId,
 		Caption:        caption,
 		CreateDatetime: now,
 		UpdateDatetime: now,
@@ -122,6 +134,20 @@ func (usecase *PostUsecase) CreatePost(ctx *fiber.Ctx, serverId uuid.UUID, userI
 
 	commited := false
 
+	// Uploaded objects are removed again when the post is not created
+	defer func() {
+		if !commited {
+			for _, image := range images {
+				for _, objectKey := range image.ObjectKeys() {
+					removeErr := usecase.PostRepository.DeletePostObject(ctxContext, bucketName, objectKey)
+					if removeErr != nil {
+						usecase.Log.Warn("failed to remove uploaded post image object", zap.String("objectKey", objectKey), zap.Error(removeErr))
+					}
+				}
+			}
+		}
+	}()
+
 	// Start transaction
 	tx, err := usecase.DB.Begin(ctxContext)
 	if err != nil {
@@ -134,22 +160,53 @@ func (usecase *PostUsecase) CreatePost(ctx *fiber.Ctx, serverId uuid.UUID, userI
 		}
 	}()
 
-	// Upload image to MinIO
-	err = usecase.PostRepository.UploadPostObject(ctxContext, bucketName, serverPostImage.ObjectKey, imageFile, imageSize)
+	// Insert post to database
+	err = usecase.PostRepository.CreateServerPost(ctxContext, tx, serverPost)
 	if err != nil {
 		return response, err
 	}
 
-	// Insert post image to database
-	err = usecase.PostRepository.CreateServerPostImage(ctxContext, tx, serverPostImage)
-	if err != nil {
-		return response, err
-	}
+	for position, image := range images {
+		serverPostImage := model.ServerPostImages{
+			Id:             image.Id,
+			Bucket:         bucketName,
+			ObjectKey:      image.ObjectKey,
+			MimeType:       image.MimeType,
+			Size:           image.Size,
+			Variants:       image.Variants,
+			CreateDatetime: now,
+			UpdateDatetime: now,
+			CreateUserId:   userId,
+			UpdateUserId:   userId,
+		}
 
-	// Insert post to database
-	err = usecase.PostRepository.CreateServerPost(ctxContext, tx, serverPost)
-	if err != nil {
-		return response, err
+		// Upload the original and its variants to MinIO
+		err = image.upload(ctxContext, bucketName, usecase.PostRepository.UploadPostObject)
+		if err != nil {
+			return response, err
+		}
+
+		// Insert post image to database
+		err = usecase.PostRepository.CreateServerPostImage(ctxContext, tx, serverPostImage)
+		if err != nil {
+			return response, err
+		}
+
+		serverPostMedia := model.ServerPostMedia{
+			Id:             uuid.New(),
+			PostId:         postId,
+			PostImageId:    image.Id,
+			Position:       position,
+			CreateDatetime: now,
+			UpdateDatetime: now,
+			CreateUserId:   userId,
+			UpdateUserId:   userId,
+		}
+
+		err = usecase.PostRepository.CreateServerPostMedia(ctxContext, tx, serverPostMedia)
+		if err != nil {
+			return response, err
+		}
 	}
 
 	// Commit transaction
@@ -161,13 +218,20 @@ func (usecase *PostUsecase) CreatePost(ctx *fiber.Ctx, serverId uuid.UUID, userI
 	commited = true
 
 	// Fetch full post object after creation
-	MINIO_FULL_URL := fmt.Sprintf("%s%s/%s", usecase.Config.String("MINIO_HTTP"), usecase.Config.String("MINIO_URL"), usecase.Config.String("MINIO_BUCKET_NAME"))
-	response, err = usecase.PostRepository.GetPost(ctxContext, postId, MINIO_FULL_URL)
+	response, err = usecase.PostRepository.GetPost(ctxContext, postId, usecase.minioFullUrl())
 	if err != nil {
 		return response, err
 	}
 
-	return response, nil
+	posts := []model.ServerPostResponse{response}
+	err = usecase.attachPostDetails(ctxContext, posts, userId)
+	if err != nil {
+		return response, err
+	}
+
+	publishServerEvent(ctxContext, usecase.EventRepository, usecase.Log, model.ServerEventPostCreated, serverId, posts[0])
+
+	return posts[0], nil
 }
 
 func (usecase *PostUsecase) UpdatePostCaption(ctx *fiber.Ctx, serverIdParam string, postIdParam string, userId uuid.UUID, payload model.ServerPostUpdateCaptionRequest) (model.ServerPostResponse, error) {
@@ -203,19 +267,11 @@ func (usecase *PostUsecase) UpdatePostCaption(ctx *fiber.Ctx, serverIdParam stri
 	ctxContext := ctx.Context()
 
 	// Check if user is a member of the server
-	serverMemberExists, err := usecase.PostRepository.CheckServerMember(ctxContext, serverId, userId)
+	_, err = authorizeServerMember(ctxContext, usecase.ServerRepository, serverId, userId, "", "serverId")
 	if err != nil {
 		return response, err
 	}
 
-	if serverMemberExists != 1 {
-		return response, &model.ValidationError{
-			Code:    constant.ERR_VALIDATION_CODE,
-			Message: "You are not a member of this server",
-			Param:   "serverId",
-		}
-	}
-
 	// Check if user is the author of the post
 	postOwnerExists, err := usecase.PostRepository.CheckPostOwnership(ctxContext, postId, userId)
 	if err != nil {
@@ -238,13 +294,134 @@ func (usecase *PostUsecase) UpdatePostCaption(ctx *fiber.Ctx, serverIdParam stri
 	}
 
 	// Fetch full post object after update
-	MINIO_FULL_URL := fmt.Sprintf("%s%s/%s", usecase.Config.String("MINIO_HTTP"), usecase.Config.String("MINIO_URL"), usecase.Config.String("MINIO_BUCKET_NAME"))
-	response, err = usecase.PostRepository.GetPost(ctxContext, postId, MINIO_FULL_URL)
+	response, err = usecase.PostRepository.GetPost(ctxContext, postId, usecase.minioFullUrl())
 	if err != nil {
 		return response, err
 	}
 
-	return response, nil
+	posts := []model.ServerPostResponse{response}
+	err = usecase.attachPostDetails(ctxContext, posts, userId)
+	if err != nil {
+		return response, err
+	}
+
+	return posts[0], nil
+}
+
+// postImagePrefix keeps the images of private servers out of the publicly readable prefixes
+func (usecase *PostUsecase) postImagePrefix(ctx context.Context, serverId uuid.UUID) (string, error) {
+	private, err := usecase.ServerRepository.CheckServerPrivate(ctx, serverId)
+	if err != nil {
+		return "", err
+	}
+
+	return model.PlaceObjectKey("server/post", private == 1), nil
+}
+
+// appendPostImage stores the processed image at the end of the carousel of the post.
+// The caller checks the user may add images to the post
+func (usecase *PostUsecase) appendPostImage(ctxContext context.Context, userId uuid.UUID, postId uuid.UUID, processed *util.ProcessedImage) error {
+	serverId, err := usecase.PostRepository.GetPostServerId(ctxContext, postId)
+	if err != nil {
+		return err
+	}
+
+	prefix, err := usecase.postImagePrefix(ctxContext, serverId)
+	if err != nil {
+		return err
+	}
+
+	image := newStoredImage(prefix, processed)
+	now := time.Now().UTC()
+
+	bucketName := usecase.Config.String("MINIO_BUCKET_NAME")
+
+	commited := false
+
+	defer func() {
+		if !commited {
+			for _, objectKey := range image.ObjectKeys() {
+				removeErr := usecase.PostRepository.DeletePostObject(ctxContext, bucketName, objectKey)
+				if removeErr != nil {
+					usecase.Log.Warn("failed to remove uploaded post image object", zap.String("objectKey", objectKey), zap.Error(removeErr))
+				}
+			}
+		}
+	}()
+
+	// upload first so a failed upload never leaves a row pointing to a missing object
+	err = image.upload(ctxContext, bucketName, usecase.PostRepository.UploadPostObject)
+	if err != nil {
+		return err
+	}
+
+	// Start transaction
+	tx, err := usecase.DB.Begin(ctxContext)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if !commited {
+			_ = tx.Rollback(ctxContext)
+		}
+	}()
+
+	total, position, err := usecase.PostRepository.GetNextMediaPosition(ctxContext, tx, postId)
+	if err != nil {
+		return err
+	}
+
+	if total >= constant.MAX_POST_MEDIA {
+		return &model.ValidationError{
+			Code:    constant.ERR_VALIDATION_CODE,
+			Message: fmt.Sprintf("A post can have at most %d images", constant.MAX_POST_MEDIA),
+			Param:   "postId",
+		}
+	}
+
+	serverPostImage := model.ServerPostImages{
+		Id:             image.Id,
+		Bucket:         bucketName,
+		ObjectKey:      image.ObjectKey,
+		MimeType:       image.MimeType,
+		Size:           image.Size,
+		Variants:       image.Variants,
+		CreateDatetime: now,
+		UpdateDatetime: now,
+		CreateUserId:   userId,
+		UpdateUserId:   userId,
+	}
+
+	err = usecase.PostRepository.CreateServerPostImage(ctxContext, tx, serverPostImage)
+	if err != nil {
+		return err
+	}
+
+	serverPostMedia := model.ServerPostMedia{
+		Id:             uuid.New(),
+		PostId:         postId,
+		PostImageId:    image.Id,
+		Position:       position,
+		CreateDatetime: now,
+		UpdateDatetime: now,
+		CreateUserId:   userId,
+		UpdateUserId:   userId,
+	}
+
+	err = usecase.PostRepository.CreateServerPostMedia(ctxContext, tx, serverPostMedia)
+	if err != nil {
+		return err
+	}
+
+	err = tx.Commit(ctxContext)
+	if err != nil {
+		return err
+	}
+
+	commited = true
+
+	return nil
 }
 
 func (usecase *PostUsecase) DeletePost(ctx *fiber.Ctx, serverIdParam string, postIdParam string, userId uuid.UUID) error {
@@ -269,26 +446,31 @@ func (usecase *PostUsecase) DeletePost(ctx *fiber.Ctx, serverIdParam string, pos
 	ctxContext := ctx.Context()
 
 	// Check if user is a member of the server
-	serverMemberExists, err := usecase.PostRepository.CheckServerMember(ctxContext, serverId, userId)
+	member, err := authorizeServerMember(ctxContext, usecase.ServerRepository, serverId, userId, "", "serverId")
+	if err != nil {
+		return err
+	}
+
+	postServerId, err := usecase.PostRepository.GetPostServerId(ctxContext, postId)
 	if err != nil {
 		return err
 	}
 
-	if serverMemberExists != 1 {
+	if postServerId != serverId {
 		return &model.ValidationError{
 			Code:    constant.ERR_VALIDATION_CODE,
-			Message: "You are not a member of this server",
-			Param:   "serverId",
+			Message: "Post not found",
+			Param:   "postId",
 		}
 	}
 
-	// Check if user is the author of the post
+	// Authors can always delete their post, anyone else needs delete_any_post
 	postOwnerExists, err := usecase.PostRepository.CheckPostOwnership(ctxContext, postId, userId)
 	if err != nil {
 		return err
 	}
 
-	if postOwnerExists != 1 {
+	if postOwnerExists != 1 && !member.Has(model.PermissionDeleteAnyPost) {
 		return &model.ValidationError{
 			Code:    constant.ERR_VALIDATION_CODE,
 			Message: "You are not the author of this post",
@@ -310,28 +492,14 @@ func (usecase *PostUsecase) DeletePost(ctx *fiber.Ctx, serverIdParam string, pos
 		}
 	}()
 
-	// Get post image info before deleting
-	postImageId, objectKey, err := usecase.PostRepository.GetPostImage(ctxContext, tx, postId)
+	// Delete post images, their media rows cascade
+	objectKeys, err := usecase.PostRepository.DeletePostImages(ctxContext, tx, postId)
 	if err != nil {
 		return err
 	}
 
-	if postImageId == uuid.Nil {
-		return &model.ValidationError{
-			Code:    constant.ERR_VALIDATION_CODE,
-			Message: "Post not found",
-			Param:   "postId",
-		}
-	}
-
 	// Delete post (CASCADE will delete comments and likes)
-	err = usecase.PostRepository.DeletePost(ctxContext, postId)
-	if err != nil {
-		return err
-	}
-
-	// Delete post image
-	err = usecase.PostRepository.DeletePostImage(ctxContext, tx, postImageId)
+	err = usecase.PostRepository.DeletePost(ctxContext, tx, postId)
 	if err != nil {
 		return err
 	}
@@ -344,13 +512,17 @@ func (usecase *PostUsecase) DeletePost(ctx *fiber.Ctx, serverIdParam string, pos
 
 	commited = true
 
-	// Delete from MinIO after successful commit
+	// Delete from MinIO after successful commit, the post is already gone so a leftover object is only logged
 	bucketName := usecase.Config.String("MINIO_BUCKET_NAME")
-	err = usecase.PostRepository.DeletePostObject(ctxContext, bucketName, objectKey)
-	if err != nil {
-		return err
+	for _, objectKey := range objectKeys {
+		err = usecase.PostRepository.DeletePostObject(ctxContext, bucketName, objectKey)
+		if err != nil {
+			usecase.Log.Warn("failed to remove object of deleted post", zap.String("objectKey", objectKey), zap.Error(err))
+		}
 	}
 
+	publishServerEvent(ctxContext, usecase.EventRepository, usecase.Log, model.ServerEventPostDeleted, serverId, model.PostDeletedEventData{PostId: postId})
+
 	return nil
 }
 
@@ -386,19 +558,11 @@ func (usecase *PostUsecase) GetServerPosts(ctx *fiber.Ctx, serverIdParam string,
 	ctxContext := ctx.Context()
 
 	// Check if user is a member of the server
-	serverMemberExists, err := usecase.PostRepository.CheckServerMember(ctxContext, serverId, userId)
+	_, err = authorizeServerMember(ctxContext, usecase.ServerRepository, serverId, userId, "", "serverId")
 	if err != nil {
 		return response, err
 	}
 
-	if serverMemberExists != 1 {
-		return response, &model.ValidationError{
-			Code:    constant.ERR_VALIDATION_CODE,
-			Message: "You are not a member of this server",
-			Param:   "serverId",
-		}
-	}
-
 	var serverPostCursor model.ServerPostCursor
 	if cursor != "" {
 		b, err := base64.RawURLEncoding.DecodeString(cursor)
@@ -412,19 +576,12 @@ func (usecase *PostUsecase) GetServerPosts(ctx *fiber.Ctx, serverIdParam string,
 		}
 	}
 
-	MINIO_FULL_URL := fmt.Sprintf("%s%s/%s", usecase.Config.String("MINIO_HTTP"), usecase.Config.String("MINIO_URL"), usecase.Config.String("MINIO_BUCKET_NAME"))
-
 	// Fetch limit + 1 untuk cek apakah ada data lagi
-	serverPosts, err := usecase.PostRepository.GetServerPosts(ctxContext, limit+1, serverId, &serverPostCursor, MINIO_FULL_URL)
+	serverPosts, err := usecase.PostRepository.GetServerPosts(ctxContext, limit+1, serverId, &serverPostCursor, usecase.minioFullUrl())
 	if err != nil {
 		return response, err
 	}
 
-	// Add URL prefix to post images
-	for i := range serverPosts {
-		serverPosts[i].PostImageUrl = fmt.Sprintf("%s/%s.webp", MINIO_FULL_URL, serverPosts[i].PostImageUrl)
-	}
-
 	// Initialize with empty array
 	response.Data = []model.ServerPostResponse{}
 
@@ -454,49 +611,91 @@ func (usecase *PostUsecase) GetServerPosts(ctx *fiber.Ctx, serverIdParam string,
 		// Jika kosong, Data sudah []empty array dari inisialisasi
 	}
 
+	err = usecase.attachPostDetails(ctxContext, response.Data, userId)
+	if err != nil {
+		return response, err
+	}
+
 	return response, nil
 }
 
-func (usecase *PostUsecase) GetPost(ctx *fiber.Ctx, postIdParam string, userId uuid.UUID) (model.ServerPostResponse, error) {
-	var response model.ServerPostResponse
+// GetFeed merges the posts of every server the user is an active member of, newest first
+func (usecase *PostUsecase) GetFeed(ctx *fiber.Ctx, userId uuid.UUID) (model.FeedListResponse, error) {
+	response := model.FeedListResponse{}
 
-	postId, err := uuid.Parse(postIdParam)
-	if err != nil {
+	limit := ctx.QueryInt("limit", constant.DEFAULT_LIMIT)
+	cursor := ctx.Query("cursor", "")
+
+	if limit < 1 {
 		return response, &model.ValidationError{
 			Code:    constant.ERR_VALIDATION_CODE,
-			Message: "Invalid post id",
-			Param:   "postId",
+			Message: "Limit must be greater or equal than 1",
+			Param:   "limit",
+		}
+	} else if limit > constant.MAX_LIMIT {
+		return response, &model.ValidationError{
+			Code:    constant.ERR_VALIDATION_CODE,
+			Message: fmt.Sprintf("Limit is exceeded max limit: %d", constant.MAX_LIMIT),
+			Param:   "limit",
 		}
 	}
 
 	ctxContext := ctx.Context()
 
-	// Check if user is a member of the server where the post belongs (single query)
-	serverMemberExists, err := usecase.PostRepository.CheckPostServerMember(ctxContext, postId, userId)
+	var feedCursor model.ServerPostCursor
+	if cursor != "" {
+		b, err := base64.RawURLEncoding.DecodeString(cursor)
+		if err != nil {
+			return response, err
+		}
+
+		err = sonic.Unmarshal(b, &feedCursor)
+		if err != nil {
+			return response, err
+		}
+	}
+
+	feedPosts, err := usecase.PostRepository.GetFeedPosts(ctxContext, limit+1, userId, &feedCursor, usecase.minioFullUrl())
 	if err != nil {
 		return response, err
 	}
 
-	if serverMemberExists != 1 {
-		return response, &model.ValidationError{
-			Code:    constant.ERR_VALIDATION_CODE,
-			Message: "You are not a member of this server",
-			Param:   "postId",
+	response.Data = feedPosts
+	if len(feedPosts) > limit {
+		response.Data = feedPosts[:limit]
+
+		last := feedPosts[limit-1]
+		b, err := sonic.Marshal(model.ServerPostCursor{
+			Id:             last.PostId,
+			CreateDatetime: last.CreateDatetime,
+		})
+		if err != nil {
+			return response, err
 		}
+
+		response.Page.NextCursor = base64.RawURLEncoding.EncodeToString(b)
 	}
 
-	MINIO_FULL_URL := fmt.Sprintf("%s%s/%s", usecase.Config.String("MINIO_HTTP"), usecase.Config.String("MINIO_URL"), usecase.Config.String("MINIO_BUCKET_NAME"))
+	// The media and reactions are shared with the server posts, they are attached to the embedded posts
+	posts := make([]model.ServerPostResponse, len(response.Data))
+	for i := range response.Data {
+		posts[i] = response.Data[i].ServerPostResponse
+	}
 
-	response, err = usecase.PostRepository.GetPost(ctxContext, postId, MINIO_FULL_URL)
+	err = usecase.attachPostDetails(ctxContext, posts, userId)
 	if err != nil {
 		return response, err
 	}
 
+	for i := range response.Data {
+		response.Data[i].ServerPostResponse = posts[i]
+	}
+
 	return response, nil
 }
 
-func (usecase *PostUsecase) LikePost(ctx *fiber.Ctx, postIdParam string, userId uuid.UUID) (model.PostLikeResponse, error) {
-	response := model.PostLikeResponse{}
+func (usecase *PostUsecase) GetPost(ctx *fiber.Ctx, postIdParam string, userId uuid.UUID) (model.ServerPostResponse, error) {
+	var response model.ServerPostResponse
 
 	postId, err := uuid.Parse(postIdParam)
 	if err != nil {
@@ -509,27 +708,53 @@ func (usecase *PostUsecase) LikePost(ctx *fiber.Ctx, postIdParam string, userId
 
 	ctxContext := ctx.Context()
 
-	// Check if user is a member of the server where the post belongs (single query)
-	serverMemberExists, err := usecase.PostRepository.CheckPostServerMember(ctxContext, postId, userId)
+	// Check if user is a member of the server where the post belongs
+	_, err = usecase.authorizePostMember(ctxContext, postId, userId, "")
 	if err != nil {
 		return response, err
 	}
 
-	if serverMemberExists != 1 {
-		return response, &model.ValidationError{
-			Code:    constant.ERR_VALIDATION_CODE,
-			Message: "You are not a member of this server",
-			Param:   "postId",
-		}
+	response, err = usecase.PostRepository.GetPost(ctxContext, postId, usecase.minioFullUrl())
+	if err != nil {
+		return response, err
 	}
 
-	// Check if user already liked this post
-	likeExists, err := usecase.PostRepository.CheckPostLike(ctxContext, postId, userId)
+	posts := []model.ServerPostResponse{response}
+	err = usecase.attachPostDetails(ctxContext, posts, userId)
 	if err != nil {
 		return response, err
 	}
 
-	if likeExists == 1 {
+	return posts[0], nil
+}
+
+func (usecase *PostUsecase) LikePost(ctx *fiber.Ctx, postIdParam string, userId uuid.UUID) (model.PostLikeResponse, error) {
+	response := model.PostLikeResponse{}
+
+	postId, err := uuid.Parse(postIdParam)
+	if err != nil {
+		return response, &model.ValidationError{
+			Code:    constant.ERR_VALIDATION_CODE,
+			Message: "Invalid post id",
+			Param:   "postId",
+		}
+	}
+
+	ctxContext := ctx.Context()
+
+	// Check if user is a member of the server where the post belongs
+	member, err := usecase.authorizePostMember(ctxContext, postId, userId, "")
+	if err != nil {
+		return response, err
+	}
+
+	// Check if user already liked this post
+	likeExists, err := usecase.PostRepository.CheckPostLike(ctxContext, postId, userId)
+	if err != nil {
+		return response, err
+	}
+
+	if likeExists == 1 {
 		return response, &model.ValidationError{
 			Code:    constant.ERR_VALIDATION_CODE,
 			Message: "You already liked this post",
@@ -555,13 +780,26 @@ func (usecase *PostUsecase) LikePost(ctx *fiber.Ctx, postIdParam string, userId
 	}
 
 	// Fetch updated post to get new like count
-	MINIO_FULL_URL := fmt.Sprintf("%s%s/%s", usecase.Config.String("MINIO_HTTP"), usecase.Config.String("MINIO_URL"), usecase.Config.String("MINIO_BUCKET_NAME"))
-	post, err := usecase.PostRepository.GetPost(ctxContext, postId, MINIO_FULL_URL)
+	post, err := usecase.PostRepository.GetPost(ctxContext, postId, usecase.minioFullUrl())
 	if err != nil {
 		return response, err
 	}
 
 	response.LikeCount = post.LikeCount
+
+	publishServerEvent(ctxContext, usecase.EventRepository, usecase.Log, model.ServerEventLikeCountChanged, member.ServerId, model.LikeCountChangedEventData{
+		PostId:    postId,
+		LikeCount: post.LikeCount,
+	})
+
+	notifyUser(ctxContext, usecase.NotificationRepository, usecase.Log, model.Notification{
+		UserId:   post.OwnerId,
+		ActorId:  userId,
+		Type:     model.NotificationPostLiked,
+		ServerId: &member.ServerId,
+		PostId:   &postId,
+	})
+
 	return response, nil
 }
 
@@ -579,20 +817,12 @@ func (usecase *PostUsecase) UnlikePost(ctx *fiber.Ctx, postIdParam string, userI
 
 	ctxContext := ctx.Context()
 
-	// Check if user is a member of the server where the post belongs (single query)
-	serverMemberExists, err := usecase.PostRepository.CheckPostServerMember(ctxContext, postId, userId)
+	// Check if user is a member of the server where the post belongs
+	member, err := usecase.authorizePostMember(ctxContext, postId, userId, "")
 	if err != nil {
 		return response, err
 	}
 
-	if serverMemberExists != 1 {
-		return response, &model.ValidationError{
-			Code:    constant.ERR_VALIDATION_CODE,
-			Message: "You are not a member of this server",
-			Param:   "postId",
-		}
-	}
-
 	// Check if user already liked this post
 	likeExists, err := usecase.PostRepository.CheckPostLike(ctxContext, postId, userId)
 	if err != nil {
@@ -613,13 +843,18 @@ func (usecase *PostUsecase) UnlikePost(ctx *fiber.Ctx, postIdParam string, userI
 	}
 
 	// Fetch updated post to get new like count
-	MINIO_FULL_URL := fmt.Sprintf("%s%s/%s", usecase.Config.String("MINIO_HTTP"), usecase.Config.String("MINIO_URL"), usecase.Config.String("MINIO_BUCKET_NAME"))
-	post, err := usecase.PostRepository.GetPost(ctxContext, postId, MINIO_FULL_URL)
+	post, err := usecase.PostRepository.GetPost(ctxContext, postId, usecase.minioFullUrl())
 	if err != nil {
 		return response, err
 	}
 
 	response.LikeCount = post.LikeCount
+
+	publishServerEvent(ctxContext, usecase.EventRepository, usecase.Log, model.ServerEventLikeCountChanged, member.ServerId, model.LikeCountChangedEventData{
+		PostId:    postId,
+		LikeCount: post.LikeCount,
+	})
+
 	return response, nil
 }
 
@@ -646,34 +881,46 @@ func (usecase *PostUsecase) CreateComment(ctx *fiber.Ctx, postIdParam string, us
 
 	ctxContext := ctx.Context()
 
-	// Check if user is a member of the server where the post belongs (single query)
-	serverMemberExists, err := usecase.PostRepository.CheckPostServerMember(ctxContext, postId, userId)
+	// Check if user is a member of the server where the post belongs
+	member, err := usecase.authorizePostMember(ctxContext, postId, userId, "")
 	if err != nil {
 		return response, err
 	}
 
-	if serverMemberExists != 1 {
-		return response, &model.ValidationError{
-			Code:    constant.ERR_VALIDATION_CODE,
-			Message: "You are not a member of this server",
-			Param:   "postId",
-		}
-	}
-
-	// If parentId is provided, check if parent comment exists and belongs to the same post
+	// If parentId is provided, the parent must belong to the same post and leave room for one more level
+	depth := 0
 	if payload.ParentId != nil {
-		parentExists, err := usecase.PostRepository.CheckCommentExists(ctxContext, *payload.ParentId, postId)
+		parentPostId, parentDepth, err := usecase.PostRepository.GetCommentPostAndDepth(ctxContext, *payload.ParentId)
 		if err != nil {
 			return response, err
 		}
 
-		if parentExists != 1 {
+		if parentPostId == uuid.Nil {
 			return response, &model.ValidationError{
 				Code:    constant.ERR_VALIDATION_CODE,
 				Message: "Parent comment not found",
 				Param:   "parentId",
 			}
 		}
+
+		if parentPostId != postId {
+			return response, &model.ValidationError{
+				Code:    constant.ERR_VALIDATION_CODE,
+				Message: "Parent comment belongs to a different post",
+				Param:   "parentId",
+			}
+		}
+
+		depth = parentDepth + 1
+
+		maxDepth := usecase.commentMaxDepth()
+		if depth > maxDepth {
+			return response, &model.ValidationError{
+				Code:    constant.ERR_VALIDATION_CODE,
+				Message: fmt.Sprintf("Replies can not be nested deeper than %d levels", maxDepth),
+				Param:   "parentId",
+			}
+		}
 	}
 
 	now := time.Now().UTC()
@@ -684,6 +931,7 @@ func (usecase *PostUsecase) CreateComment(ctx *fiber.Ctx, postIdParam string, us
 		PostId:         postId,
 		AuthorId:       userId,
 		ParentId:       payload.ParentId,
+		Depth:          depth,
 		Content:        payload.Content,
 		CreateDatetime: now,
 		UpdateDatetime: now,
@@ -696,24 +944,75 @@ func (usecase *PostUsecase) CreateComment(ctx *fiber.Ctx, postIdParam string, us
 		return response, err
 	}
 
-	// Construct response from the created comment
-	response = model.ServerCommentResponse{
-		Id:             commentId,
-		AuthorId:       userId,
-		ParentId:       payload.ParentId,
-		Content:        payload.Content,
-		CreateDatetime: now,
-		UpdateDatetime: now,
+	// Fetch the created comment so it carries the author summary
+	response, err = usecase.PostRepository.GetComment(ctxContext, commentId, usecase.minioFullUrl())
+	if err != nil {
+		return response, err
 	}
 
+	response.Reactions = model.NewReactionSummary().Reactions
+
+	publishServerEvent(ctxContext, usecase.EventRepository, usecase.Log, model.ServerEventCommentCreated, member.ServerId, model.CommentCreatedEventData{
+		PostId:  postId,
+		Comment: response,
+	})
+
+	usecase.notifyCommentCreated(ctxContext, member.ServerId, postId, commentId, payload.ParentId, userId)
+
 	return response, nil
 }
 
+// notifyCommentCreated tells the post author about a new comment and the parent comment author about a reply,
+// an author who is both only gets the reply notification
+func (usecase *PostUsecase) notifyCommentCreated(ctx context.Context, serverId uuid.UUID, postId uuid.UUID, commentId uuid.UUID, parentId *uuid.UUID, userId uuid.UUID) {
+	var parentAuthorId uuid.UUID
+	if parentId != nil {
+		parent, err := usecase.PostRepository.GetComment(ctx, *parentId, usecase.minioFullUrl())
+		if err != nil {
+			usecase.Log.Warn("failed to read parent comment for notification", zap.String("commentId", parentId.String()), zap.Error(err))
+		} else if parent.AuthorId != nil {
+			parentAuthorId = *parent.AuthorId
+		}
+
+		notifyUser(ctx, usecase.NotificationRepository, usecase.Log, model.Notification{
+			UserId:    parentAuthorId,
+			ActorId:   userId,
+			Type:      model.NotificationCommentReplied,
+			ServerId:  &serverId,
+			PostId:    &postId,
+			CommentId: &commentId,
+		})
+	}
+
+	post, err := usecase.PostRepository.GetPost(ctx, postId, usecase.minioFullUrl())
+	if err != nil {
+		usecase.Log.Warn("failed to read post for notification", zap.String("postId", postId.String()), zap.Error(err))
+		return
+	}
+
+	if post.OwnerId == parentAuthorId {
+		return
+	}
+
+	notifyUser(ctx, usecase.NotificationRepository, usecase.Log, model.Notification{
+		UserId:    post.OwnerId,
+		ActorId:   userId,
+		Type:      model.NotificationPostCommented,
+		ServerId:  &serverId,
+		PostId:    &postId,
+		CommentId: &commentId,
+	})
+}
+
+// GetComments lists the comments of a post, in threaded mode only top level comments are paginated
+// and each of them carries its first replies
 func (usecase *PostUsecase) GetComments(ctx *fiber.Ctx, postIdParam string, userId uuid.UUID) (model.ServerCommentListResponse, error) {
 	response := model.ServerCommentListResponse{}
 
 	limit := ctx.QueryInt("limit", constant.DEFAULT_LIMIT)
 	cursor := ctx.Query("cursor", "")
+	threaded := ctx.QueryBool("threaded", false)
+	replyLimit := ctx.QueryInt("replyLimit", constant.DEFAULT_REPLY_PREVIEW_LIMIT)
 
 	postId, err := uuid.Parse(postIdParam)
 	if err != nil {
@@ -724,10 +1023,10 @@ func (usecase *PostUsecase) GetComments(ctx *fiber.Ctx, postIdParam string, user
 		}
 	}
 
-	if limit < 0 {
+	if limit < 1 {
 		return response, &model.ValidationError{
 			Code:    constant.ERR_VALIDATION_CODE,
-			Message: "Limit must be greater or equal than 0",
+			Message: "Limit must be greater or equal than 1",
 			Param:   "limit",
 		}
 	} else if limit > constant.MAX_LIMIT {
@@ -738,22 +1037,28 @@ func (usecase *PostUsecase) GetComments(ctx *fiber.Ctx, postIdParam string, user
 		}
 	}
 
+	if replyLimit < 0 {
+		return response, &model.ValidationError{
+			Code:    constant.ERR_VALIDATION_CODE,
+			Message: "Reply limit must be greater or equal than 0",
+			Param:   "replyLimit",
+		}
+	} else if replyLimit > constant.MAX_LIMIT {
+		return response, &model.ValidationError{
+			Code:    constant.ERR_VALIDATION_CODE,
+			Message: fmt.Sprintf("Reply limit is exceeded max limit: %d", constant.MAX_LIMIT),
+			Param:   "replyLimit",
+		}
+	}
+
 	ctxContext := ctx.Context()
 
-	// Check if user is a member of the server where the post belongs (single query)
-	serverMemberExists, err := usecase.PostRepository.CheckPostServerMember(ctxContext, postId, userId)
+	// Check if user is a member of the server where the post belongs
+	_, err = usecase.authorizePostMember(ctxContext, postId, userId, "")
 	if err != nil {
 		return response, err
 	}
 
-	if serverMemberExists != 1 {
-		return response, &model.ValidationError{
-			Code:    constant.ERR_VALIDATION_CODE,
-			Message: "You are not a member of this server",
-			Param:   "postId",
-		}
-	}
-
 	var serverCommentCursor model.ServerCommentCursor
 	if cursor != "" {
 		b, err := base64.RawURLEncoding.DecodeString(cursor)
@@ -768,7 +1073,7 @@ func (usecase *PostUsecase) GetComments(ctx *fiber.Ctx, postIdParam string, user
 	}
 
 	// Fetch limit + 1 to check if there's more data
-	comments, err := usecase.PostRepository.GetComments(ctxContext, limit+1, postId, &serverCommentCursor)
+	comments, err := usecase.PostRepository.GetComments(ctxContext, limit+1, postId, &serverCommentCursor, threaded, usecase.minioFullUrl())
 	if err != nil {
 		return response, err
 	}
@@ -802,13 +1107,169 @@ func (usecase *PostUsecase) GetComments(ctx *fiber.Ctx, postIdParam string, user
 		// If empty, Data is already []empty array from initialization
 	}
 
+	if threaded && replyLimit > 0 {
+		err = usecase.attachReplyPreviews(ctxContext, response.Data, replyLimit)
+		if err != nil {
+			return response, err
+		}
+	}
+
+	err = usecase.attachCommentReactions(ctxContext, response.Data, userId)
+	if err != nil {
+		return response, err
+	}
+
 	return response, nil
 }
 
-func (usecase *PostUsecase) DeleteComment(ctx *fiber.Ctx, postIdParam string, commentIdParam string, userId uuid.UUID) error {
+// attachReplyPreviews fills the first replies of every comment that has any
+func (usecase *PostUsecase) attachReplyPreviews(ctx context.Context, comments []model.ServerCommentResponse, replyLimit int) error {
+	parentIds := []uuid.UUID{}
+	for _, comment := range comments {
+		if comment.ReplyCount > 0 {
+			parentIds = append(parentIds, comment.Id)
+		}
+	}
+
+	if len(parentIds) == 0 {
+		return nil
+	}
+
+	replies, err := usecase.PostRepository.GetCommentReplyPreviews(ctx, parentIds, replyLimit, usecase.minioFullUrl())
+	if err != nil {
+		return err
+	}
+
+	repliesByParent := make(map[uuid.UUID][]model.ServerCommentResponse, len(parentIds))
+	for _, reply := range replies {
+		repliesByParent[*reply.ParentId] = append(repliesByParent[*reply.ParentId], reply)
+	}
+
+	for i := range comments {
+		comments[i].Replies = repliesByParent[comments[i].Id]
+	}
+
+	return nil
+}
+
+// attachPostDetails fills the media and the reactions of every post as seen by the user
+func (usecase *PostUsecase) attachPostDetails(ctx context.Context, posts []model.ServerPostResponse, userId uuid.UUID) error {
+	err := usecase.attachPostMedia(ctx, posts)
+	if err != nil {
+		return err
+	}
+
+	return usecase.attachPostReactions(ctx, posts, userId)
+}
+
+// minioFullUrl is the base of the public object urls, such as the avatars of authors
+func (usecase *PostUsecase) minioFullUrl() string {
+	return fmt.Sprintf("%s%s/%s", usecase.Config.String("MINIO_HTTP"), usecase.Config.String("MINIO_URL"), usecase.Config.String("MINIO_BUCKET_NAME"))
+}
+
+// attachPostMedia fills the ordered media of every post with the urls of their variants
+func (usecase *PostUsecase) attachPostMedia(ctx context.Context, posts []model.ServerPostResponse) error {
+	if len(posts) == 0 {
+		return nil
+	}
+
+	postIds := make([]uuid.UUID, 0, len(posts))
+	for _, post := range posts {
+		postIds = append(postIds, post.PostId)
+	}
+
+	mediaByPost, err := usecase.PostRepository.GetPostMedia(ctx, postIds, usecase.minioFullUrl())
+	if err != nil {
+		return err
+	}
+
+	for i := range posts {
+		posts[i].Media = mediaByPost[posts[i].PostId]
+		if posts[i].Media == nil {
+			posts[i].Media = []model.ServerPostMediaResponse{}
+		}
+	}
+
+	return nil
+}
+
+// attachPostReactions fills the reaction counts of every post and the reaction left by the user
+func (usecase *PostUsecase) attachPostReactions(ctx context.Context, posts []model.ServerPostResponse, userId uuid.UUID) error {
+	if len(posts) == 0 {
+		return nil
+	}
+
+	postIds := make([]uuid.UUID, 0, len(posts))
+	for _, post := range posts {
+		postIds = append(postIds, post.PostId)
+	}
+
+	summaries, err := usecase.PostRepository.GetPostReactionSummaries(ctx, postIds, userId)
+	if err != nil {
+		return err
+	}
+
+	for i := range posts {
+		summary, ok := summaries[posts[i].PostId]
+		if !ok {
+			summary = model.NewReactionSummary()
+		}
+
+		posts[i].Reactions = summary.Reactions
+		posts[i].MyReaction = summary.MyReaction
+	}
+
+	return nil
+}
+
+// attachCommentReactions fills the reaction counts of every comment, including the embedded replies,
+// and the reaction left by the user
+func (usecase *PostUsecase) attachCommentReactions(ctx context.Context, comments []model.ServerCommentResponse, userId uuid.UUID) error {
+	commentIds := []uuid.UUID{}
+	for _, comment := range comments {
+		commentIds = append(commentIds, comment.Id)
+		for _, reply := range comment.Replies {
+			commentIds = append(commentIds, reply.Id)
+		}
+	}
+
+	if len(commentIds) == 0 {
+		return nil
+	}
+
+	summaries, err := usecase.PostRepository.GetCommentReactionSummaries(ctx, commentIds, userId)
+	if err != nil {
+		return err
+	}
+
+	var fill func(comments []model.ServerCommentResponse)
+	fill = func(comments []model.ServerCommentResponse) {
+		for i := range comments {
+			summary, ok := summaries[comments[i].Id]
+			if !ok {
+				summary = model.NewReactionSummary()
+			}
+
+			comments[i].Reactions = summary.Reactions
+			comments[i].MyReaction = summary.MyReaction
+
+			fill(comments[i].Replies)
+		}
+	}
+	fill(comments)
+
+	return nil
+}
+
+func (usecase *PostUsecase) GetCommentReplies(ctx *fiber.Ctx, postIdParam string, commentIdParam string, userId uuid.UUID) (model.ServerCommentListResponse, error) {
+	response := model.ServerCommentListResponse{}
+
+	limit := ctx.QueryInt("limit", constant.DEFAULT_LIMIT)
+	cursor := ctx.Query("cursor", "")
+
 	postId, err := uuid.Parse(postIdParam)
 	if err != nil {
-		return &model.ValidationError{
+		return response, &model.ValidationError{
 			Code:    constant.ERR_VALIDATION_CODE,
 			Message: "Invalid post id",
 			Param:   "postId",
@@ -817,46 +1278,602 @@ func (usecase *PostUsecase) DeleteComment(ctx *fiber.Ctx, postIdParam string, co
 
 	commentId, err := uuid.Parse(commentIdParam)
 	if err != nil {
-		return &model.ValidationError{
+		return response, &model.ValidationError{
 			Code:    constant.ERR_VALIDATION_CODE,
 			Message: "Invalid comment id",
 			Param:   "commentId",
 		}
 	}
 
+	if limit < 1 {
+		return response, &model.ValidationError{
+			Code:    constant.ERR_VALIDATION_CODE,
+			Message: "Limit must be greater or equal than 1",
+			Param:   "limit",
+		}
+	} else if limit > constant.MAX_LIMIT {
+		return response, &model.ValidationError{
+			Code:    constant.ERR_VALIDATION_CODE,
+			Message: fmt.Sprintf("Limit is exceeded max limit: %d", constant.MAX_LIMIT),
+			Param:   "limit",
+		}
+	}
+
 	ctxContext := ctx.Context()
 
-	// Check if user is a member of the server where the post belongs (single query)
-	serverMemberExists, err := usecase.PostRepository.CheckPostServerMember(ctxContext, postId, userId)
+	_, err = usecase.authorizePostMember(ctxContext, postId, userId, "")
 	if err != nil {
-		return err
+		return response, err
 	}
 
-	if serverMemberExists != 1 {
-		return &model.ValidationError{
+	commentExists, err := usecase.PostRepository.CheckCommentExists(ctxContext, commentId, postId)
+	if err != nil {
+		return response, err
+	}
+
+	if commentExists != 1 {
+		return response, &model.ValidationError{
 			Code:    constant.ERR_VALIDATION_CODE,
-			Message: "You are not a member of this server",
-			Param:   "postId",
+			Message: "Comment not found",
+			Param:   "commentId",
 		}
 	}
 
-	// Check if user is the author of the comment
-	commentOwnerExists, err := usecase.PostRepository.CheckCommentOwnership(ctxContext, commentId, userId)
+	var serverCommentCursor model.ServerCommentCursor
+	if cursor != "" {
+		b, err := base64.RawURLEncoding.DecodeString(cursor)
+		if err != nil {
+			return response, err
+		}
+
+		err = sonic.Unmarshal(b, &serverCommentCursor)
+		if err != nil {
+			return response, err
+		}
+	}
+
+	// Fetch limit + 1 to check if there's more data
+	replies, err := usecase.PostRepository.GetCommentReplies(ctxContext, limit+1, commentId, &serverCommentCursor, usecase.minioFullUrl())
 	if err != nil {
-		return err
+		return response, err
 	}
 
-	if commentOwnerExists != 1 {
-		return &model.ValidationError{
-			Code:    constant.ERR_VALIDATION_CODE,
-			Message: "You are not the author of this comment",
-			Param:   "commentId",
+	response.Data = []model.ServerCommentResponse{}
+
+	if len(replies) > limit {
+		response.Data = replies[:limit]
+
+		last := replies[limit-1]
+
+		replyCursor := model.ServerCommentCursor{
+			Id:             last.Id,
+			CreateDatetime: last.CreateDatetime,
+		}
+
+		b, err := sonic.Marshal(replyCursor)
+		if err != nil {
+			return response, err
 		}
+
+		response.Page.NextCursor = base64.RawURLEncoding.EncodeToString(b)
+	} else if len(replies) > 0 {
+		response.Data = replies
 	}
 
-	err = usecase.PostRepository.DeleteComment(ctxContext, commentId)
+	err = usecase.attachCommentReactions(ctxContext, response.Data, userId)
 	if err != nil {
-		return err
+		return response, err
+	}
+
+	return response, nil
+}
+
+// commentMaxDepth reads COMMENT_MAX_DEPTH, the deepest level a reply may sit at
+func (usecase *PostUsecase) commentMaxDepth() int {
+	maxDepth := usecase.Config.Int("COMMENT_MAX_DEPTH")
+	if maxDepth <= 0 {
+		return constant.DEFAULT_COMMENT_MAX_DEPTH
+	}
+
+	return maxDepth
+}
+
+func (usecase *PostUsecase) UpdateComment(ctx *fiber.Ctx, postIdParam string, commentIdParam string, userId uuid.UUID, payload model.ServerCommentUpdateRequest) (model.ServerCommentResponse, error) {
+	response := model.ServerCommentResponse{}
+
+	postId, err := uuid.Parse(postIdParam)
+	if err != nil {
+		return response, &model.ValidationError{
+			Code:    constant.ERR_VALIDATION_CODE,
+			Message: "Invalid post id",
+			Param:   "postId",
+		}
+	}
+
+	commentId, err := uuid.Parse(commentIdParam)
+	if err != nil {
+		return response, &model.ValidationError{
+			Code:    constant.ERR_VALIDATION_CODE,
+			Message: "Invalid comment id",
+			Param:   "commentId",
+		}
+	}
+
+	// Validate content
+	if payload.Content == "" {
+		return response, &model.ValidationError{
+			Code:    constant.ERR_VALIDATION_CODE,
+			Message: "Content is required",
+			Param:   "content",
+		}
+	}
+
+	ctxContext := ctx.Context()
+
+	// Check if user is a member of the server where the post belongs
+	_, err = usecase.authorizePostMember(ctxContext, postId, userId, "")
+	if err != nil {
+		return response, err
+	}
+
+	commentExists, err := usecase.PostRepository.CheckCommentExists(ctxContext, commentId, postId)
+	if err != nil {
+		return response, err
+	}
+
+	if commentExists != 1 {
+		return response, &model.ValidationError{
+			Code:    constant.ERR_VALIDATION_CODE,
+			Message: "Comment not found",
+			Param:   "commentId",
+		}
+	}
+
+	// Only the author can edit a comment, moderators can only delete it
+	commentOwnerExists, err := usecase.PostRepository.CheckCommentOwnership(ctxContext, commentId, userId)
+	if err != nil {
+		return response, err
+	}
+
+	if commentOwnerExists != 1 {
+		return response, &model.ValidationError{
+			Code:    constant.ERR_VALIDATION_CODE,
+			Message: "You are not the author of this comment",
+			Param:   "commentId",
+		}
+	}
+
+	now := time.Now().UTC()
+
+	commited := false
+
+	// Start transaction
+	tx, err := usecase.DB.Begin(ctxContext)
+	if err != nil {
+		return response, err
+	}
+
+	defer func() {
+		if !commited {
+			_ = tx.Rollback(ctxContext)
+		}
+	}()
+
+	// Lock the comment so concurrent edits each save the version they replace
+	previousContent, writtenDatetime, err := usecase.PostRepository.LockCommentContent(ctxContext, tx, commentId)
+	if err != nil {
+		return response, err
+	}
+
+	// Saving the same content again is not an edit
+	if previousContent != payload.Content {
+		revision := model.ServerPostCommentRevision{
+			Id:              uuid.New(),
+			CommentId:       commentId,
+			Content:         previousContent,
+			WrittenDatetime: writtenDatetime,
+			CreateDatetime:  now,
+			UpdateDatetime:  now,
+			CreateUserId:    userId,
+			UpdateUserId:    userId,
+		}
+
+		err = usecase.PostRepository.CreateCommentRevision(ctxContext, tx, revision)
+		if err != nil {
+			return response, err
+		}
+
+		err = usecase.PostRepository.UpdateCommentContent(ctxContext, tx, commentId, payload.Content, userId, now)
+		if err != nil {
+			return response, err
+		}
+	}
+
+	// Commit transaction
+	err = tx.Commit(ctxContext)
+	if err != nil {
+		return response, err
+	}
+
+	commited = true
+
+	// Fetch full comment object after update
+	response, err = usecase.PostRepository.GetComment(ctxContext, commentId, usecase.minioFullUrl())
+	if err != nil {
+		return response, err
+	}
+
+	comments := []model.ServerCommentResponse{response}
+	err = usecase.attachCommentReactions(ctxContext, comments, userId)
+	if err != nil {
+		return response, err
+	}
+
+	return comments[0], nil
+}
+
+// GetCommentRevisions returns the previous versions of a comment to its author and to members with delete_any_comment
+func (usecase *PostUsecase) GetCommentRevisions(ctx *fiber.Ctx, postIdParam string, commentIdParam string, userId uuid.UUID) (model.ServerCommentRevisionListResponse, error) {
+	response := model.ServerCommentRevisionListResponse{}
+
+	postId, err := uuid.Parse(postIdParam)
+	if err != nil {
+		return response, &model.ValidationError{
+			Code:    constant.ERR_VALIDATION_CODE,
+			Message: "Invalid post id",
+			Param:   "postId",
+		}
+	}
+
+	commentId, err := uuid.Parse(commentIdParam)
+	if err != nil {
+		return response, &model.ValidationError{
+			Code:    constant.ERR_VALIDATION_CODE,
+			Message: "Invalid comment id",
+			Param:   "commentId",
+		}
+	}
+
+	ctxContext := ctx.Context()
+
+	// Check if user is a member of the server where the post belongs
+	member, err := usecase.authorizePostMember(ctxContext, postId, userId, "")
+	if err != nil {
+		return response, err
+	}
+
+	commentExists, err := usecase.PostRepository.CheckCommentExists(ctxContext, commentId, postId)
+	if err != nil {
+		return response, err
+	}
+
+	if commentExists != 1 {
+		return response, &model.ValidationError{
+			Code:    constant.ERR_VALIDATION_CODE,
+			Message: "Comment not found",
+			Param:   "commentId",
+		}
+	}
+
+	if !member.Has(model.PermissionDeleteAnyComment) {
+		commentOwnerExists, err := usecase.PostRepository.CheckCommentOwnership(ctxContext, commentId, userId)
+		if err != nil {
+			return response, err
+		}
+
+		if commentOwnerExists != 1 {
+			return response, &model.ValidationError{
+				Code:    constant.ERR_VALIDATION_CODE,
+				Message: fmt.Sprintf("You do not have the %s permission in this server", model.PermissionDeleteAnyComment),
+				Param:   "commentId",
+			}
+		}
+	}
+
+	response.Data, err = usecase.PostRepository.GetCommentRevisions(ctxContext, commentId)
+	if err != nil {
+		return response, err
+	}
+
+	return response, nil
+}
+
+func (usecase *PostUsecase) DeleteComment(ctx *fiber.Ctx, postIdParam string, commentIdParam string, userId uuid.UUID) error {
+	postId, err := uuid.Parse(postIdParam)
+	if err != nil {
+		return &model.ValidationError{
+			Code:    constant.ERR_VALIDATION_CODE,
+			Message: "Invalid post id",
+			Param:   "postId",
+		}
+	}
+
+	commentId, err := uuid.Parse(commentIdParam)
+	if err != nil {
+		return &model.ValidationError{
+			Code:    constant.ERR_VALIDATION_CODE,
+			Message: "Invalid comment id",
+			Param:   "commentId",
+		}
+	}
+
+	ctxContext := ctx.Context()
+
+	// Check if user is a member of the server where the post belongs
+	member, err := usecase.authorizePostMember(ctxContext, postId, userId, "")
+	if err != nil {
+		return err
+	}
+
+	commentExists, err := usecase.PostRepository.CheckCommentExists(ctxContext, commentId, postId)
+	if err != nil {
+		return err
+	}
+
+	if commentExists != 1 {
+		return &model.ValidationError{
+			Code:    constant.ERR_VALIDATION_CODE,
+			Message: "Comment not found",
+			Param:   "commentId",
+		}
+	}
+
+	// Authors can always delete their comment, anyone else needs delete_any_comment
+	commentOwnerExists, err := usecase.PostRepository.CheckCommentOwnership(ctxContext, commentId, userId)
+	if err != nil {
+		return err
+	}
+
+	if commentOwnerExists != 1 && !member.Has(model.PermissionDeleteAnyComment) {
+		return &model.ValidationError{
+			Code:    constant.ERR_VALIDATION_CODE,
+			Message: "You are not the author of this comment",
+			Param:   "commentId",
+		}
+	}
+
+	err = usecase.PostRepository.DeleteComment(ctxContext, commentId)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// ReactToPost sets the reaction of the user on a post, reacting again replaces the previous reaction
+func (usecase *PostUsecase) ReactToPost(ctx *fiber.Ctx, postIdParam string, userId uuid.UUID, payload model.ReactionRequest) (model.ReactionSummaryResponse, error) {
+	response := model.NewReactionSummary()
+
+	postId, err := uuid.Parse(postIdParam)
+	if err != nil {
+		return response, &model.ValidationError{
+			Code:    constant.ERR_VALIDATION_CODE,
+			Message: "Invalid post id",
+			Param:   "postId",
+		}
+	}
+
+	err = validateReaction(payload.Reaction)
+	if err != nil {
+		return response, err
+	}
+
+	ctxContext := ctx.Context()
+
+	// Check if user is a member of the server where the post belongs
+	_, err = usecase.authorizePostMember(ctxContext, postId, userId, "")
+	if err != nil {
+		return response, err
+	}
+
+	now := time.Now().UTC()
+
+	reaction := model.ServerReaction{
+		Id:             uuid.New(),
+		TargetId:       postId,
+		UserId:         userId,
+		Reaction:       payload.Reaction,
+		CreateDatetime: now,
+		UpdateDatetime: now,
+		CreateUserId:   userId,
+		UpdateUserId:   userId,
+	}
+
+	err = usecase.PostRepository.UpsertPostReaction(ctxContext, reaction)
+	if err != nil {
+		return response, err
+	}
+
+	return usecase.postReactionSummary(ctxContext, postId, userId)
+}
+
+// RemovePostReaction clears the reaction of the user on a post, removing a missing reaction is not an error
+func (usecase *PostUsecase) RemovePostReaction(ctx *fiber.Ctx, postIdParam string, userId uuid.UUID) (model.ReactionSummaryResponse, error) {
+	response := model.NewReactionSummary()
+
+	postId, err := uuid.Parse(postIdParam)
+	if err != nil {
+		return response, &model.ValidationError{
+			Code:    constant.ERR_VALIDATION_CODE,
+			Message: "Invalid post id",
+			Param:   "postId",
+		}
+	}
+
+	ctxContext := ctx.Context()
+
+	// Check if user is a member of the server where the post belongs
+	_, err = usecase.authorizePostMember(ctxContext, postId, userId, "")
+	if err != nil {
+		return response, err
+	}
+
+	err = usecase.PostRepository.DeletePostReaction(ctxContext, postId, userId)
+	if err != nil {
+		return response, err
+	}
+
+	return usecase.postReactionSummary(ctxContext, postId, userId)
+}
+
+// ReactToComment sets the reaction of the user on a comment, reacting again replaces the previous reaction
+func (usecase *PostUsecase) ReactToComment(ctx *fiber.Ctx, postIdParam string, commentIdParam string, userId uuid.UUID, payload model.ReactionRequest) (model.ReactionSummaryResponse, error) {
+	response := model.NewReactionSummary()
+
+	postId, err := uuid.Parse(postIdParam)
+	if err != nil {
+		return response, &model.ValidationError{
+			Code:    constant.ERR_VALIDATION_CODE,
+			Message: "Invalid post id",
+			Param:   "postId",
+		}
+	}
+
+	commentId, err := uuid.Parse(commentIdParam)
+	if err != nil {
+		return response, &model.ValidationError{
+			Code:    constant.ERR_VALIDATION_CODE,
+			Message: "Invalid comment id",
+			Param:   "commentId",
+		}
+	}
+
+	err = validateReaction(payload.Reaction)
+	if err != nil {
+		return response, err
+	}
+
+	ctxContext := ctx.Context()
+
+	err = usecase.authorizeCommentMember(ctxContext, postId, commentId, userId)
+	if err != nil {
+		return response, err
+	}
+
+	now := time.Now().UTC()
+
+	reaction := model.ServerReaction{
+		Id:             uuid.New(),
+		TargetId:       commentId,
+		UserId:         userId,
+		Reaction:       payload.Reaction,
+		CreateDatetime: now,
+		UpdateDatetime: now,
+		CreateUserId:   userId,
+		UpdateUserId:   userId,
+	}
+
+	err = usecase.PostRepository.UpsertCommentReaction(ctxContext, reaction)
+	if err != nil {
+		return response, err
+	}
+
+	return usecase.commentReactionSummary(ctxContext, commentId, userId)
+}
+
+// RemoveCommentReaction clears the reaction of the user on a comment, removing a missing reaction is not an error
+func (usecase *PostUsecase) RemoveCommentReaction(ctx *fiber.Ctx, postIdParam string, commentIdParam string, userId uuid.UUID) (model.ReactionSummaryResponse, error) {
+	response := model.NewReactionSummary()
+
+	postId, err := uuid.Parse(postIdParam)
+	if err != nil {
+		return response, &model.ValidationError{
+			Code:    constant.ERR_VALIDATION_CODE,
+			Message: "Invalid post id",
+			Param:   "postId",
+		}
+	}
+
+	commentId, err := uuid.Parse(commentIdParam)
+	if err != nil {
+		return response, &model.ValidationError{
+			Code:    constant.ERR_VALIDATION_CODE,
+			Message: "Invalid comment id",
+			Param:   "commentId",
+		}
+	}
+
+	ctxContext := ctx.Context()
+
+	err = usecase.authorizeCommentMember(ctxContext, postId, commentId, userId)
+	if err != nil {
+		return response, err
+	}
+
+	err = usecase.PostRepository.DeleteCommentReaction(ctxContext, commentId, userId)
+	if err != nil {
+		return response, err
+	}
+
+	return usecase.commentReactionSummary(ctxContext, commentId, userId)
+}
+
+// authorizeCommentMember checks the user is a member of the server of the post and the comment belongs to the post
+func (usecase *PostUsecase) authorizeCommentMember(ctx context.Context, postId uuid.UUID, commentId uuid.UUID, userId uuid.UUID) error {
+	_, err := usecase.authorizePostMember(ctx, postId, userId, "")
+	if err != nil {
+		return err
+	}
+
+	commentExists, err := usecase.PostRepository.CheckCommentExists(ctx, commentId, postId)
+	if err != nil {
+		return err
+	}
+
+	if commentExists != 1 {
+		return &model.ValidationError{
+			Code:    constant.ERR_VALIDATION_CODE,
+			Message: "Comment not found",
+			Param:   "commentId",
+		}
+	}
+
+	return nil
+}
+
+func (usecase *PostUsecase) postReactionSummary(ctx context.Context, postId uuid.UUID, userId uuid.UUID) (model.ReactionSummaryResponse, error) {
+	summaries, err := usecase.PostRepository.GetPostReactionSummaries(ctx, []uuid.UUID{postId}, userId)
+	if err != nil {
+		return model.NewReactionSummary(), err
+	}
+
+	summary, ok := summaries[postId]
+	if !ok {
+		return model.NewReactionSummary(), nil
+	}
+
+	return summary, nil
+}
+
+func (usecase *PostUsecase) commentReactionSummary(ctx context.Context, commentId uuid.UUID, userId uuid.UUID) (model.ReactionSummaryResponse, error) {
+	summaries, err := usecase.PostRepository.GetCommentReactionSummaries(ctx, []uuid.UUID{commentId}, userId)
+	if err != nil {
+		return model.NewReactionSummary(), err
+	}
+
+	summary, ok := summaries[commentId]
+	if !ok {
+		return model.NewReactionSummary(), nil
+	}
+
+	return summary, nil
+}
+
+func validateReaction(reaction string) error {
+	if reaction == "" {
+		return &model.ValidationError{
+			Code:    constant.ERR_VALIDATION_CODE,
+			Message: "Reaction is required",
+			Param:   "reaction",
+		}
+	}
+
+	if !model.IsValidReaction(reaction) {
+		return &model.ValidationError{
+			Code:    constant.ERR_VALIDATION_CODE,
+			Message: fmt.Sprintf("Unknown reaction: %s", reaction),
+			Param:   "reaction",
+		}
 	}
 
 	return nil
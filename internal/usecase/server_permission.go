@@ -0,0 +1,68 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/ferdian3456/virdanproject/internal/constant"
+	"github.com/ferdian3456/virdanproject/internal/model"
+	"github.com/ferdian3456/virdanproject/internal/repository"
+	"github.com/google/uuid"
+)
+
+// authorizeServerMember is the single permission check for anything scoped to a server.
+// It loads the role of the user and fails unless they are an active member holding the permission,
+// an empty permission only requires the membership. param names the field reported on failure.
+func authorizeServerMember(ctx context.Context, serverRepository *repository.ServerRepository, serverId uuid.UUID, userId uuid.UUID, permission model.Permission, param string) (model.ServerMemberPermission, error) {
+	member, err := serverRepository.GetMemberPermission(ctx, serverId, userId)
+	if err != nil {
+		return member, err
+	}
+
+	if member.RoleId == uuid.Nil {
+		return member, &model.ValidationError{
+			Code:    constant.ERR_VALIDATION_CODE,
+			Message: "You are not a member of this server",
+			Param:   param,
+		}
+	}
+
+	if permission != "" && !member.Has(permission) {
+		return member, &model.ValidationError{
+			Code:    constant.ERR_VALIDATION_CODE,
+			Message: fmt.Sprintf("You do not have the %s permission in this server", permission),
+			Param:   param,
+		}
+	}
+
+	return member, nil
+}
+
+// parsePermissions validates the requested permission keys and builds the JSONB document of a role
+func parsePermissions(permissions []string) (map[string]bool, []byte, error) {
+	allowed := make(map[string]bool, len(model.AssignablePermissions))
+	for _, permission := range model.AssignablePermissions {
+		allowed[string(permission)] = true
+	}
+
+	granted := make(map[string]bool, len(permissions))
+	for _, permission := range permissions {
+		if !allowed[permission] {
+			return nil, nil, &model.ValidationError{
+				Code:    constant.ERR_VALIDATION_CODE,
+				Message: fmt.Sprintf("Unknown permission: %s", permission),
+				Param:   "permissions",
+			}
+		}
+
+		granted[permission] = true
+	}
+
+	permissionsBytes, err := json.Marshal(granted)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return granted, permissionsBytes, nil
+}
@@ -2,9 +2,12 @@ package usecase
 
 import (
 	"bytes"
+	"context"
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	"html/template"
+	"strings"
 	"time"
 
 	"github.com/bytedance/sonic"
@@ -14,24 +17,32 @@ import (
 	"github.com/ferdian3456/virdanproject/internal/util"
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/knadh/koanf/v2"
 	"go.uber.org/zap"
+	"golang.org/x/crypto/bcrypt"
 )
 
 type ServerUsecase struct {
-	ServerRepository *repository.ServerRepository
-	DB               *pgxpool.Pool
-	Log              *zap.Logger
-	Config           *koanf.Koanf
+	ServerRepository       *repository.ServerRepository
+	UserRepository         *repository.UserRepository
+	EventRepository        *repository.EventRepository
+	NotificationRepository *repository.NotificationRepository
+	DB                     *pgxpool.Pool
+	Log                    *zap.Logger
+	Config                 *koanf.Koanf
 }
 
-func NewServerUsecase(serverRepository *repository.ServerRepository, db *pgxpool.Pool, zap *zap.Logger, koanf *koanf.Koanf) *ServerUsecase {
+func NewServerUsecase(serverRepository *repository.ServerRepository, userRepository *repository.UserRepository, eventRepository *repository.EventRepository, notificationRepository *repository.NotificationRepository, db *pgxpool.Pool, zap *zap.Logger, koanf *koanf.Koanf) *ServerUsecase {
 	return &ServerUsecase{
-		ServerRepository: serverRepository,
-		DB:               db,
-		Log:              zap,
-		Config:           koanf,
+		ServerRepository:       serverRepository,
+		UserRepository:         userRepository,
+		EventRepository:        eventRepository,
+		NotificationRepository: notificationRepository,
+		DB:                     db,
+		Log:                    zap,
+		Config:                 koanf,
 	}
 }
 
@@ -77,6 +88,12 @@ func (usecase *ServerUsecase) CreateInviteLink(ctx *fiber.Ctx, userId uuid.UUID,
 	}
 
 	ctxContext := ctx.Context()
+
+	_, err = authorizeServerMember(ctxContext, usecase.ServerRepository, serverId, userId, model.PermissionCreateInvite, "serverId")
+	if err != nil {
+		return response, err
+	}
+
 	var inviteCode string
 
 	for i := 0; i < 10; i++ {
@@ -90,8 +107,8 @@ func (usecase *ServerUsecase) CreateInviteLink(ctx *fiber.Ctx, userId uuid.UUID,
 			return response, err
 		}
 
-		if exists == 1 {
-			continue
+		if exists != 1 {
+			break
 		}
 	}
 
@@ -140,11 +157,9 @@ func (usecase *ServerUsecase) JoinServerFromInvite(ctx *fiber.Ctx, userId uuid.U
 	}
 
 	ctxContext := ctx.Context()
-	usecase.Log.Debug("got here?")
-	serverId, err := usecase.ServerRepository.CheckInviteCodesAndRetrieveServerId(ctxContext, payload.InviteCode)
-	if err != nil {
-		usecase.Log.Debug("got here??")
 
+	serverId, err := usecase.ServerRepository.CheckInviteCodesAndRetrieveServerId(ctxContext, payload.InviteCode, time.Now().UTC())
+	if err != nil {
 		return err
 	}
 
@@ -156,94 +171,23 @@ func (usecase *ServerUsecase) JoinServerFromInvite(ctx *fiber.Ctx, userId uuid.U
 		}
 	}
 
-	exists, err := usecase.ServerRepository.CheckServerMember(ctxContext, serverId, userId)
-	if err != nil {
-		usecase.Log.Debug("got here 1")
-		return err
-	}
-
-	if exists == 1 {
-		return &model.ValidationError{
-			Code:    constant.ERR_VALIDATION_CODE,
-			Message: "Unable to join server because user is already a member",
-			Param:   "serverId",
-		}
-	}
-
-	now := time.Now().UTC()
-
-	serverRoleId := uuid.New()
-
-	serverRole := model.ServerRole{
-		Id:             serverRoleId,
-		ServerId:       serverId,
-		Name:           model.MemberRole,
-		Permissions:    sonic.NoCopyRawMessage("{}"),
-		CreateDatetime: now,
-		UpdateDatetime: now,
-		CreateUserId:   userId,
-		UpdateUserId:   userId,
-	}
-
-	serverMemberId := uuid.New()
-
-	serverMember := model.ServerMember{
-		Id:             serverMemberId,
-		ServerId:       serverId,
-		UserId:         userId,
-		ServerRoleId:   uuid.Nil,
-		Status:         model.MemberStatusActive,
-		JoinedDatetime: now,
-		LeftDatetime:   nil,
-		CreateDatetime: now,
-		UpdateDatetime: now,
-		CreateUserId:   userId,
-		UpdateUserId:   userId,
-	}
-
-	serverMember.ServerRoleId = serverRoleId
-
-	commited := false
-
-	// start transaction
-	tx, err := usecase.DB.Begin(ctxContext)
-	if err != nil {
-		return err
-	}
-
-	defer func() {
-		if !commited {
-			_ = tx.Rollback(ctxContext)
-		}
-	}()
-
-	err = usecase.ServerRepository.CreateServerRole(ctxContext, tx, serverRole)
-	if err != nil {
-		return err
-	}
-
-	err = usecase.ServerRepository.CreateServerMember(ctxContext, tx, serverMember)
-	if err != nil {
-		return err
-	}
-
-	err = tx.Commit(ctxContext)
+	err = usecase.joinServer(ctxContext, serverId, userId, payload.InviteCode)
 	if err != nil {
 		return err
 	}
 
-	commited = true
-
 	return nil
 }
 
 func (usecase *ServerUsecase) GetServerInfoForInvite(ctx *fiber.Ctx, inviteCode string) (model.ServerInfoForInviteResponse, error) {
-	server, err := usecase.ServerRepository.GetServerInfoForInvite(ctx.Context(), inviteCode)
+	MINIO_FULL_URL := fmt.Sprintf("%s%s/%s", usecase.Config.String("MINIO_HTTP"), usecase.Config.String("MINIO_URL"), usecase.Config.String("MINIO_BUCKET_NAME"))
+
+	server, err := usecase.ServerRepository.GetServerInfoForInvite(ctx.Context(), inviteCode, MINIO_FULL_URL)
 	if err != nil {
 		return server, err
 	}
 
-	if server.ServerName == "" {
+	if server.ServerId == uuid.Nil {
 		return server, &model.ValidationError{
 			Code:    constant.ERR_VALIDATION_CODE,
 			Message: "Invite code is not exists",
@@ -251,17 +195,15 @@ func (usecase *ServerUsecase) GetServerInfoForInvite(ctx *fiber.Ctx, inviteCode
 		}
 	}
 
-	MINIO_URL := usecase.Config.String("MINIO_URL")
-	MINIO_BUCKET_NAME := usecase.Config.String("MINIO_BUCKET_NAME")
-	MINIO_HTTP := usecase.Config.String("MINIO_HTTP")
-
-	if server.AvatarImageId != nil {
-		*server.AvatarImageId = fmt.Sprintf("%s%s/%s/%s.webp", MINIO_HTTP, MINIO_URL, MINIO_BUCKET_NAME, *server.AvatarImageId)
-	}
-
-	if server.BannerImageId != nil {
-		*server.BannerImageId = fmt.Sprintf("%s%s/%s/%s.webp", MINIO_HTTP, MINIO_URL, MINIO_BUCKET_NAME, *server.BannerImageId)
-
+	// Same rules as the join, so the preview never promises a join that would be refused
+	if !server.IsActive {
+		server.Status = model.InviteStatusRevoked
+	} else if server.ExpiresDatetime != nil && !server.ExpiresDatetime.After(time.Now().UTC()) {
+		server.Status = model.InviteStatusExpired
+	} else if server.UsedCount >= server.MaxUses {
+		server.Status = model.InviteStatusUsedUp
+	} else {
+		server.Status = model.InviteStatusActive
 	}
 
 	return server, nil
@@ -614,7 +556,19 @@ func (usecase *ServerUsecase) CreateServer(ctx *fiber.Ctx, userId uuid.UUID, pay
 		Id:             serverRoleId,
 		ServerId:       serverId,
 		Name:           model.OwnerRole,
-		Permissions:    sonic.NoCopyRawMessage(`{"*": true}`), // TODO
+		Permissions:    sonic.NoCopyRawMessage(model.OwnerPermissions),
+		CreateDatetime: now,
+		UpdateDatetime: now,
+		CreateUserId:   userId,
+		UpdateUserId:   userId,
+	}
+
+	// every joiner shares this role instead of getting a copy of their own
+	memberRole := model.ServerRole{
+		Id:             uuid.New(),
+		ServerId:       serverId,
+		Name:           model.MemberRole,
+		Permissions:    sonic.NoCopyRawMessage(model.DefaultMemberPermissions),
 		CreateDatetime: now,
 		UpdateDatetime: now,
 		CreateUserId:   userId,
@@ -673,6 +627,11 @@ func (usecase *ServerUsecase) CreateServer(ctx *fiber.Ctx, userId uuid.UUID, pay
 		return response, err
 	}
 
+	err = usecase.ServerRepository.CreateServerRole(ctxContext, tx, memberRole)
+	if err != nil {
+		return response, err
+	}
+
 	err = usecase.ServerRepository.CreateServerMember(ctxContext, tx, serverMember)
 	if err != nil {
 		return response, err
@@ -685,6 +644,9 @@ func (usecase *ServerUsecase) CreateServer(ctx *fiber.Ctx, userId uuid.UUID, pay
 
 	commited = true
 
+	// Open connections of the owner start following the new server
+	publishServerEvent(ctxContext, usecase.EventRepository, usecase.Log, model.ServerEventMemberJoined, server.Id, model.ServerMemberEventData{UserId: userId})
+
 	return response, nil
 }
 
@@ -858,82 +820,11 @@ func (usecase *ServerUsecase) JoinServer(ctx *fiber.Ctx, userId uuid.UUID) error
 		}
 	}
 
-	exists, err = usecase.ServerRepository.CheckServerMember(ctx.Context(), serverId, userId)
-	if err != nil {
-		return err
-	}
-
-	if exists == 1 {
-		return &model.ValidationError{
-			Code:    constant.ERR_VALIDATION_CODE,
-			Message: "Unable to join server because user is already a member",
-			Param:   "serverId",
-		}
-	}
-
-	now := time.Now().UTC()
-	serverRoleId := uuid.New()
-
-	serverRole := model.ServerRole{
-		Id:             serverRoleId,
-		ServerId:       serverId,
-		Name:           model.MemberRole,
-		Permissions:    sonic.NoCopyRawMessage("{}"),
-		CreateDatetime: now,
-		UpdateDatetime: now,
-		CreateUserId:   userId,
-		UpdateUserId:   userId,
-	}
-
-	serverMemberId := uuid.New()
-
-	serverMember := model.ServerMember{
-		Id:             serverMemberId,
-		ServerId:       serverId,
-		UserId:         userId,
-		ServerRoleId:   serverRoleId,
-		Status:         model.MemberStatusActive,
-		JoinedDatetime: now,
-		LeftDatetime:   nil,
-		CreateDatetime: now,
-		UpdateDatetime: now,
-		CreateUserId:   userId,
-		UpdateUserId:   userId,
-	}
-
-	ctxContext := ctx.Context()
-
-	commited := false
-
-	// start transaction
-	tx, err := usecase.DB.Begin(ctxContext)
-	if err != nil {
-		return err
-	}
-
-	defer func() {
-		if !commited {
-			_ = tx.Rollback(ctxContext)
-		}
-	}()
-
-	err = usecase.ServerRepository.CreateServerRole(ctxContext, tx, serverRole)
-	if err != nil {
-		return err
-	}
-
-	err = usecase.ServerRepository.CreateServerMember(ctxContext, tx, serverMember)
-	if err != nil {
-		return err
-	}
-
-	err = tx.Commit(ctxContext)
+	err = usecase.joinServer(ctx.Context(), serverId, userId, "")
 	if err != nil {
 		return err
 	}
 
-	commited = true
-
 	return nil
 }
 
@@ -1000,19 +891,11 @@ func (usecase *ServerUsecase) UpdateServerName(ctx *fiber.Ctx, userId uuid.UUID,
 
 	ctxContext := ctx.Context()
 
-	exists, err := usecase.ServerRepository.CheckServerOwnership(ctxContext, serverId, userId)
+	_, err = authorizeServerMember(ctxContext, usecase.ServerRepository, serverId, userId, model.PermissionManageServer, "serverId")
 	if err != nil {
 		return response, err
 	}
 
-	if exists != 1 {
-		return response, &model.ValidationError{
-			Code:    constant.ERR_VALIDATION_CODE,
-			Message: "You are not the owner of this server",
-			Param:   "serverId",
-		}
-	}
-
 	now := time.Now().UTC()
 
 	err = usecase.ServerRepository.UpdateServerName(ctxContext, serverId, payload.Name, userId, now)
@@ -1062,19 +945,11 @@ func (usecase *ServerUsecase) UpdateServerShortName(ctx *fiber.Ctx, userId uuid.
 
 	ctxContext := ctx.Context()
 
-	exists, err := usecase.ServerRepository.CheckServerOwnership(ctxContext, serverId, userId)
+	_, err = authorizeServerMember(ctxContext, usecase.ServerRepository, serverId, userId, model.PermissionManageServer, "serverId")
 	if err != nil {
 		return response, err
 	}
 
-	if exists != 1 {
-		return response, &model.ValidationError{
-			Code:    constant.ERR_VALIDATION_CODE,
-			Message: "You are not the owner of this server",
-			Param:   "serverId",
-		}
-	}
-
 	now := time.Now().UTC()
 
 	err = usecase.ServerRepository.UpdateServerShortName(ctxContext, serverId, payload.ShortName, userId, now)
@@ -1119,19 +994,11 @@ func (usecase *ServerUsecase) UpdateServerCategory(ctx *fiber.Ctx, userId uuid.U
 		}
 	}
 
-	exists, err := usecase.ServerRepository.CheckServerOwnership(ctxContext, serverId, userId)
+	_, err = authorizeServerMember(ctxContext, usecase.ServerRepository, serverId, userId, model.PermissionManageServer, "serverId")
 	if err != nil {
 		return response, err
 	}
 
-	if exists != 1 {
-		return response, &model.ValidationError{
-			Code:    constant.ERR_VALIDATION_CODE,
-			Message: "You are not the owner of this server",
-			Param:   "serverId",
-		}
-	}
-
 	now := time.Now().UTC()
 
 	err = usecase.ServerRepository.UpdateServerCategory(ctxContext, serverId, payload.CategoryId, userId, now)
@@ -1161,19 +1028,11 @@ func (usecase *ServerUsecase) UpdateServerDescription(ctx *fiber.Ctx, userId uui
 
 	ctxContext := ctx.Context()
 
-	exists, err := usecase.ServerRepository.CheckServerOwnership(ctxContext, serverId, userId)
+	_, err = authorizeServerMember(ctxContext, usecase.ServerRepository, serverId, userId, model.PermissionManageServer, "serverId")
 	if err != nil {
 		return response, err
 	}
 
-	if exists != 1 {
-		return response, &model.ValidationError{
-			Code:    constant.ERR_VALIDATION_CODE,
-			Message: "You are not the owner of this server",
-			Param:   "serverId",
-		}
-	}
-
 	now := time.Now().UTC()
 
 	err = usecase.ServerRepository.UpdateServerDescription(ctxContext, serverId, payload.Description, userId, now)
@@ -1201,12 +1060,12 @@ func (usecase *ServerUsecase) DeleteServer(ctx *fiber.Ctx, userId uuid.UUID, ser
 
 	ctxContext := ctx.Context()
 
-	exists, err := usecase.ServerRepository.CheckServerOwnership(ctxContext, serverId, userId)
+	member, err := authorizeServerMember(ctxContext, usecase.ServerRepository, serverId, userId, "", "serverId")
 	if err != nil {
 		return err
 	}
 
-	if exists != 1 {
+	if !member.IsOwner {
 		return &model.ValidationError{
 			Code:    constant.ERR_VALIDATION_CODE,
 			Message: "You are not the owner of this server",
@@ -1214,11 +1073,39 @@ func (usecase *ServerUsecase) DeleteServer(ctx *fiber.Ctx, userId uuid.UUID, ser
 		}
 	}
 
-	err = usecase.ServerRepository.DeleteServer(ctxContext, serverId)
+	commited := false
+
+	tx, err := usecase.DB.Begin(ctxContext)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if !commited {
+			_ = tx.Rollback(ctxContext)
+		}
+	}()
+
+	objectKeys, err := usecase.ServerRepository.DeleteServerWithContent(ctxContext, tx, serverId)
+	if err != nil {
+		return err
+	}
+
+	err = tx.Commit(ctxContext)
 	if err != nil {
 		return err
 	}
 
+	commited = true
+
+	bucketName := usecase.Config.String("MINIO_BUCKET_NAME")
+	for _, objectKey := range objectKeys {
+		err = usecase.ServerRepository.RemoveObject(ctxContext, bucketName, objectKey)
+		if err != nil {
+			usecase.Log.Warn("failed to remove object of deleted server", zap.String("objectKey", objectKey), zap.Error(err))
+		}
+	}
+
 	return nil
 }
 
@@ -1234,60 +1121,63 @@ func (usecase *ServerUsecase) UpdateServerAvatar(ctx *fiber.Ctx, userId uuid.UUI
 
 	ctxContext := ctx.Context()
 
-	exists, err := usecase.ServerRepository.CheckServerOwnership(ctxContext, serverId, userId)
+	_, err = authorizeServerMember(ctxContext, usecase.ServerRepository, serverId, userId, model.PermissionManageServer, "serverId")
 	if err != nil {
 		return err
 	}
 
-	if exists != 1 {
-		return &model.ValidationError{
-			Code:    constant.ERR_VALIDATION_CODE,
-			Message: "You are not the owner of this server",
-			Param:   "serverId",
-		}
-	}
-
 	fieldName := "avatar"
 	fileHeader, err := ctx.FormFile(fieldName)
 	if err != nil {
 		return err
 	}
 
-	var imageFile *bytes.Reader
-	var imageSize int64
-	var avatarImageId *uuid.UUID
-
+	// An empty file removes the avatar
+	var processed *util.ProcessedImage
 	if fileHeader.Size != 0 {
-		imageFile, imageSize, err = util.ValidateImage(fileHeader, fieldName)
+		processed, err = util.ValidateImage(fileHeader, fieldName, util.AvatarImageProfile)
 		if err != nil {
 			return err
 		}
+	}
 
-		id := uuid.New()
-		avatarImageId = &id
-	} else {
-		avatarImageId = nil
+	return usecase.replaceServerAvatar(ctxContext, userId, serverId, processed)
+}
+
+// replaceServerAvatar stores the processed image as the new avatar of the server and removes the previous one,
+// a nil image only removes it. The caller checks the manage_server permission
+func (usecase *ServerUsecase) replaceServerAvatar(ctxContext context.Context, userId uuid.UUID, serverId uuid.UUID, processed *util.ProcessedImage) error {
+	var avatarImage *storedImage
+	if processed != nil {
+		image := newStoredImage("server/avatar", processed)
+		avatarImage = &image
 	}
 
-	serverAvatarImage := model.ServerAvatarImage{}
 	now := time.Now().UTC()
 
 	bucketName := usecase.Config.String("MINIO_BUCKET_NAME")
 
-	if avatarImageId != nil {
-		serverAvatarImage.Id = *avatarImageId
-		serverAvatarImage.ServerId = serverId
-		serverAvatarImage.Bucket = bucketName
-		serverAvatarImage.ObjectKey = fmt.Sprintf("server/avatar/%s.webp", *avatarImageId)
-		serverAvatarImage.MimeType = "webp"
-		serverAvatarImage.CreateDatetime = now
-		serverAvatarImage.UpdateDatetime = now
-		serverAvatarImage.CreateUserId = userId
-		serverAvatarImage.UpdateUserId = userId
-	}
-
 	commited := false
 
+	if avatarImage != nil {
+		defer func() {
+			if !commited {
+				for _, objectKey := range avatarImage.ObjectKeys() {
+					removeErr := usecase.ServerRepository.RemoveServerAvatarObject(ctxContext, bucketName, objectKey)
+					if removeErr != nil {
+						usecase.Log.Warn("failed to remove uploaded server avatar object", zap.String("objectKey", objectKey), zap.Error(removeErr))
+					}
+				}
+			}
+		}()
+
+		// upload first so a failed upload never leaves a row pointing to a missing object
+		err := avatarImage.upload(ctxContext, bucketName, usecase.ServerRepository.UploadObject)
+		if err != nil {
+			return err
+		}
+	}
+
 	// start transaction
 	tx, err := usecase.DB.Begin(ctxContext)
 	if err != nil {
@@ -1300,32 +1190,42 @@ func (usecase *ServerUsecase) UpdateServerAvatar(ctx *fiber.Ctx, userId uuid.UUI
 		}
 	}()
 
-	fileName, err := usecase.ServerRepository.GetServerAvatar(ctxContext, tx, serverId)
+	oldAvatarImageId, oldObjectKeys, err := usecase.ServerRepository.GetServerAvatar(ctxContext, tx, serverId)
 	if err != nil {
 		return err
 	}
 
-	err = usecase.ServerRepository.UpdateServerAvatarImage(ctxContext, tx, serverId, avatarImageId, userId, now)
-	if err != nil {
-		return err
-	}
+	var avatarImageId *uuid.UUID
+	if avatarImage != nil {
+		serverAvatarImage := model.ServerAvatarImage{
+			Id:             avatarImage.Id,
+			Bucket:         bucketName,
+			ObjectKey:      avatarImage.ObjectKey,
+			MimeType:       avatarImage.MimeType,
+			Size:           avatarImage.Size,
+			Variants:       avatarImage.Variants,
+			CreateDatetime: now,
+			UpdateDatetime: now,
+			CreateUserId:   userId,
+			UpdateUserId:   userId,
+		}
 
-	if avatarImageId != nil {
 		err = usecase.ServerRepository.CreateServerAvatarImage(ctxContext, tx, serverAvatarImage)
 		if err != nil {
 			return err
 		}
-		err = usecase.ServerRepository.UploadObject(ctxContext, bucketName, serverAvatarImage.ObjectKey, imageFile, imageSize)
-		if err != nil {
-			return err
-		}
-	} else {
-		err = usecase.ServerRepository.DeleteServerAvatarImage(ctxContext, tx, serverId)
-		if err != nil {
-			return err
-		}
 
-		err = usecase.ServerRepository.RemoveServerAvatarObject(ctxContext, bucketName, fileName)
+		avatarImageId = &avatarImage.Id
+	}
+
+	err = usecase.ServerRepository.UpdateServerAvatarImage(ctxContext, tx, serverId, avatarImageId, userId, now)
+	if err != nil {
+		return err
+	}
+
+	// The old row goes once the server no longer points to it, servers cascade from their images
+	if oldAvatarImageId != nil {
+		err = usecase.ServerRepository.DeleteServerAvatarImage(ctxContext, tx, *oldAvatarImageId)
 		if err != nil {
 			return err
 		}
@@ -1338,6 +1238,13 @@ func (usecase *ServerUsecase) UpdateServerAvatar(ctx *fiber.Ctx, userId uuid.UUI
 
 	commited = true
 
+	for _, objectKey := range oldObjectKeys {
+		err = usecase.ServerRepository.RemoveServerAvatarObject(ctxContext, bucketName, objectKey)
+		if err != nil {
+			usecase.Log.Warn("failed to remove old server avatar object", zap.String("objectKey", objectKey), zap.Error(err))
+		}
+	}
+
 	return nil
 }
 
@@ -1353,60 +1260,63 @@ func (usecase *ServerUsecase) UpdateServerBanner(ctx *fiber.Ctx, userId uuid.UUI
 
 	ctxContext := ctx.Context()
 
-	exists, err := usecase.ServerRepository.CheckServerOwnership(ctxContext, serverId, userId)
+	_, err = authorizeServerMember(ctxContext, usecase.ServerRepository, serverId, userId, model.PermissionManageServer, "serverId")
 	if err != nil {
 		return err
 	}
 
-	if exists != 1 {
-		return &model.ValidationError{
-			Code:    constant.ERR_VALIDATION_CODE,
-			Message: "You are not the owner of this server",
-			Param:   "serverId",
-		}
-	}
-
 	fieldName := "banner"
 	fileHeader, err := ctx.FormFile(fieldName)
 	if err != nil {
 		return err
 	}
 
-	var imageFile *bytes.Reader
-	var imageSize int64
-	var bannerImageId *uuid.UUID
-
+	// An empty file removes the banner
+	var processed *util.ProcessedImage
 	if fileHeader.Size != 0 {
-		imageFile, imageSize, err = util.ValidateImage(fileHeader, fieldName)
+		processed, err = util.ValidateImage(fileHeader, fieldName, util.BannerImageProfile)
 		if err != nil {
 			return err
 		}
+	}
 
-		id := uuid.New()
-		bannerImageId = &id
-	} else {
-		bannerImageId = nil
+	return usecase.replaceServerBanner(ctxContext, userId, serverId, processed)
+}
+
+// replaceServerBanner stores the processed image as the new banner of the server and removes the previous one,
+// a nil image only removes it. The caller checks the manage_server permission
+func (usecase *ServerUsecase) replaceServerBanner(ctxContext context.Context, userId uuid.UUID, serverId uuid.UUID, processed *util.ProcessedImage) error {
+	var bannerImage *storedImage
+	if processed != nil {
+		image := newStoredImage("server/banner", processed)
+		bannerImage = &image
 	}
 
-	serverBannerImage := model.ServerBannerImage{}
 	now := time.Now().UTC()
 
 	bucketName := usecase.Config.String("MINIO_BUCKET_NAME")
 
-	if bannerImageId != nil {
-		serverBannerImage.Id = *bannerImageId
-		serverBannerImage.ServerId = serverId
-		serverBannerImage.Bucket = bucketName
-		serverBannerImage.ObjectKey = fmt.Sprintf("server/banner/%s.webp", *bannerImageId)
-		serverBannerImage.MimeType = "webp"
-		serverBannerImage.CreateDatetime = now
-		serverBannerImage.UpdateDatetime = now
-		serverBannerImage.CreateUserId = userId
-		serverBannerImage.UpdateUserId = userId
-	}
-
 	commited := false
 
+	if bannerImage != nil {
+		defer func() {
+			if !commited {
+				for _, objectKey := range bannerImage.ObjectKeys() {
+					removeErr := usecase.ServerRepository.RemoveServerBannerObject(ctxContext, bucketName, objectKey)
+					if removeErr != nil {
+						usecase.Log.Warn("failed to remove uploaded server banner object", zap.String("objectKey", objectKey), zap.Error(removeErr))
+					}
+				}
+			}
+		}()
+
+		// upload first so a failed upload never leaves a row pointing to a missing object
+		err := bannerImage.upload(ctxContext, bucketName, usecase.ServerRepository.UploadObject)
+		if err != nil {
+			return err
+		}
+	}
+
 	// start transaction
 	tx, err := usecase.DB.Begin(ctxContext)
 	if err != nil {
@@ -1419,32 +1329,42 @@ func (usecase *ServerUsecase) UpdateServerBanner(ctx *fiber.Ctx, userId uuid.UUI
 		}
 	}()
 
-	fileName, err := usecase.ServerRepository.GetServerBanner(ctxContext, tx, serverId)
+	oldBannerImageId, oldObjectKeys, err := usecase.ServerRepository.GetServerBanner(ctxContext, tx, serverId)
 	if err != nil {
 		return err
 	}
 
-	err = usecase.ServerRepository.UpdateServerBannerImage(ctxContext, tx, serverId, bannerImageId, userId, now)
-	if err != nil {
-		return err
-	}
+	var bannerImageId *uuid.UUID
+	if bannerImage != nil {
+		serverBannerImage := model.ServerBannerImage{
+			Id:             bannerImage.Id,
+			Bucket:         bucketName,
+			ObjectKey:      bannerImage.ObjectKey,
+			MimeType:       bannerImage.MimeType,
+			Size:           bannerImage.Size,
+			Variants:       bannerImage.Variants,
+			CreateDatetime: now,
+			UpdateDatetime: now,
+			CreateUserId:   userId,
+			UpdateUserId:   userId,
+		}
 
-	if bannerImageId != nil {
 		err = usecase.ServerRepository.CreateServerBannerImage(ctxContext, tx, serverBannerImage)
 		if err != nil {
 			return err
 		}
-		err = usecase.ServerRepository.UploadObject(ctxContext, bucketName, serverBannerImage.ObjectKey, imageFile, imageSize)
-		if err != nil {
-			return err
-		}
-	} else {
-		err = usecase.ServerRepository.DeleteServerBannerImage(ctxContext, tx, serverId)
-		if err != nil {
-			return err
-		}
 
-		err = usecase.ServerRepository.RemoveServerBannerObject(ctxContext, bucketName, fileName)
+		bannerImageId = &bannerImage.Id
+	}
+
+	err = usecase.ServerRepository.UpdateServerBannerImage(ctxContext, tx, serverId, bannerImageId, userId, now)
+	if err != nil {
+		return err
+	}
+
+	// The old row goes once the server no longer points to it, servers cascade from their images
+	if oldBannerImageId != nil {
+		err = usecase.ServerRepository.DeleteServerBannerImage(ctxContext, tx, *oldBannerImageId)
 		if err != nil {
 			return err
 		}
@@ -1457,6 +1377,13 @@ func (usecase *ServerUsecase) UpdateServerBanner(ctx *fiber.Ctx, userId uuid.UUI
 
 	commited = true
 
+	for _, objectKey := range oldObjectKeys {
+		err = usecase.ServerRepository.RemoveServerBannerObject(ctxContext, bucketName, objectKey)
+		if err != nil {
+			usecase.Log.Warn("failed to remove old server banner object", zap.String("objectKey", objectKey), zap.Error(err))
+		}
+	}
+
 	return nil
 }
 
@@ -1472,30 +1399,1314 @@ func (usecase *ServerUsecase) UpdateServerSettings(ctx *fiber.Ctx, userId uuid.U
 
 	ctxContext := ctx.Context()
 
-	exists, err := usecase.ServerRepository.CheckServerOwnership(ctxContext, serverId, userId)
+	_, err = authorizeServerMember(ctxContext, usecase.ServerRepository, serverId, userId, model.PermissionManageServer, "serverId")
 	if err != nil {
 		return err
 	}
 
-	if exists != 1 {
+	settingsBytes, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+
+	err = usecase.ServerRepository.UpdateServerSettings(ctxContext, serverId, settingsBytes, userId, now)
+	if err != nil {
+		return err
+	}
+
+	// The settings are saved either way, images that fail to move are picked up again by the worker
+	err = usecase.RelocatePostImages(ctxContext, &serverId)
+	if err != nil {
+		usecase.Log.Error("failed to relocate post images", zap.String("serverId", serverId.String()), zap.Error(err))
+	}
+
+	return nil
+}
+
+// RelocatePostImages moves post images under or out of the private prefix until they match the privacy of their
+// server, so a server turning private stops serving its images to anyone holding an old link. A nil server id
+// relocates the images of every server
+func (usecase *ServerUsecase) RelocatePostImages(ctx context.Context, serverId *uuid.UUID) error {
+	const batchSize = 100
+
+	for {
+		images, err := usecase.ServerRepository.GetMisplacedPostImages(ctx, serverId, batchSize)
+		if err != nil {
+			return err
+		}
+
+		moved := 0
+		for _, image := range images {
+			err = usecase.relocatePostImage(ctx, image)
+			if err != nil {
+				usecase.Log.Error("failed to relocate post image", zap.String("imageId", image.Id.String()), zap.Error(err))
+				continue
+			}
+
+			moved++
+		}
+
+		// Images that failed are listed again, stop once a batch makes no progress
+		if len(images) < batchSize || moved == 0 {
+			return nil
+		}
+	}
+}
+
+// relocatePostImage copies every object of the image to its new key before pointing the row at them,
+// the old objects are only removed once nothing references them
+func (usecase *ServerUsecase) relocatePostImage(ctx context.Context, image model.MisplacedPostImage) error {
+	oldKeys := image.Variants.ObjectKeys(image.ObjectKey)
+	newKeys := make([]string, 0, len(oldKeys))
+
+	removeObjects := func(objectKeys []string) {
+		for _, objectKey := range objectKeys {
+			removeErr := usecase.ServerRepository.RemoveObject(ctx, image.Bucket, objectKey)
+			if removeErr != nil {
+				usecase.Log.Warn("failed to remove post image object", zap.String("objectKey", objectKey), zap.Error(removeErr))
+			}
+		}
+	}
+
+	for _, objectKey := range oldKeys {
+		newObjectKey := model.PlaceObjectKey(objectKey, image.Private)
+
+		err := usecase.ServerRepository.CopyPostImageObject(ctx, image.Bucket, objectKey, newObjectKey)
+		if err != nil {
+			removeObjects(newKeys)
+			return err
+		}
+
+		newKeys = append(newKeys, newObjectKey)
+	}
+
+	err := usecase.ServerRepository.UpdatePostImageObjects(ctx, image.Id, model.PlaceObjectKey(image.ObjectKey, image.Private), image.Variants.Place(image.Private), time.Now().UTC())
+	if err != nil {
+		removeObjects(newKeys)
+		return err
+	}
+
+	removeObjects(oldKeys)
+
+	return nil
+}
+
+// joinServer adds the user to the server with the default member role, users who left get their row back
+// and banned users are refused. A non empty invite code is consumed in the same transaction as the join
+func (usecase *ServerUsecase) joinServer(ctx context.Context, serverId uuid.UUID, userId uuid.UUID, inviteCode string) error {
+	status, err := usecase.ServerRepository.GetServerMemberStatus(ctx, serverId, userId)
+	if err != nil {
+		return err
+	}
+
+	if status == model.MemberStatusActive {
 		return &model.ValidationError{
 			Code:    constant.ERR_VALIDATION_CODE,
-			Message: "You are not the owner of this server",
+			Message: "Unable to join server because user is already a member",
+			Param:   "serverId",
+		}
+	} else if status == model.MemberStatusBanned {
+		return &model.ValidationError{
+			Code:    constant.ERR_VALIDATION_CODE,
+			Message: "Unable to join server because user is banned",
 			Param:   "serverId",
 		}
 	}
 
-	settingsBytes, err := json.Marshal(payload)
+	now := time.Now().UTC()
+
+	serverMemberId := uuid.New()
+
+	serverMember := model.ServerMember{
+		Id:             serverMemberId,
+		ServerId:       serverId,
+		UserId:         userId,
+		ServerRoleId:   uuid.Nil,
+		Status:         model.MemberStatusActive,
+		JoinedDatetime: now,
+		LeftDatetime:   nil,
+		CreateDatetime: now,
+		UpdateDatetime: now,
+		CreateUserId:   userId,
+		UpdateUserId:   userId,
+	}
+
+	commited := false
+
+	// start transaction
+	tx, err := usecase.DB.Begin(ctx)
 	if err != nil {
 		return err
 	}
 
-	now := time.Now().UTC()
+	defer func() {
+		if !commited {
+			_ = tx.Rollback(ctx)
+		}
+	}()
 
-	err = usecase.ServerRepository.UpdateServerSettings(ctxContext, serverId, settingsBytes, userId, now)
+	serverMember.ServerRoleId, err = defaultMemberRoleId(ctx, tx, usecase.ServerRepository, serverId, userId, now)
+	if err != nil {
+		return err
+	}
+
+	if status == model.MemberStatusLeft {
+		// the status was read before the transaction, a ban or join in between leaves no row to update
+		rejoined, err := usecase.ServerRepository.RejoinServerMember(ctx, tx, serverId, userId, serverMember.ServerRoleId, now)
+		if err != nil {
+			return err
+		}
+
+		if !rejoined {
+			return &model.ValidationError{
+				Code:    constant.ERR_VALIDATION_CODE,
+				Message: "Unable to join server because membership status has changed",
+				Param:   "serverId",
+			}
+		}
+	} else {
+		err = usecase.ServerRepository.CreateServerMember(ctx, tx, serverMember)
+		if err != nil {
+			return err
+		}
+	}
+
+	if inviteCode != "" {
+		inviteId, err := usecase.ServerRepository.ConsumeServerInvite(ctx, tx, inviteCode, serverId, userId, now)
+		if err != nil {
+			return err
+		}
+
+		if inviteId == uuid.Nil {
+			return &model.ValidationError{
+				Code:    constant.ERR_VALIDATION_CODE,
+				Message: "Invite code is not exists, expired or used up",
+				Param:   "inviteCode",
+			}
+		}
+
+		inviteUse := model.ServerInviteUse{
+			Id:             uuid.New(),
+			InviteId:       inviteId,
+			ServerId:       serverId,
+			UserId:         userId,
+			UsedDatetime:   now,
+			CreateDatetime: now,
+			UpdateDatetime: now,
+			CreateUserId:   userId,
+			UpdateUserId:   userId,
+		}
+
+		err = usecase.ServerRepository.CreateServerInviteUse(ctx, tx, inviteUse)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = tx.Commit(ctx)
 	if err != nil {
 		return err
 	}
 
+	commited = true
+
+	publishServerEvent(ctx, usecase.EventRepository, usecase.Log, model.ServerEventMemberJoined, serverId, model.ServerMemberEventData{UserId: userId})
+
+	server, err := usecase.ServerRepository.GetServerDetail(ctx, serverId)
+	if err != nil {
+		usecase.Log.Warn("failed to read server for notification", zap.String("serverId", serverId.String()), zap.Error(err))
+		return nil
+	}
+
+	notifyUser(ctx, usecase.NotificationRepository, usecase.Log, model.Notification{
+		UserId:   server.OwnerId,
+		ActorId:  userId,
+		Type:     model.NotificationMemberJoined,
+		ServerId: &serverId,
+	})
+
+	return nil
+}
+
+// defaultMemberRoleId returns the shared Member role of the server, creating it for servers that predate it
+func defaultMemberRoleId(ctx context.Context, tx pgx.Tx, serverRepository *repository.ServerRepository, serverId uuid.UUID, userId uuid.UUID, now time.Time) (uuid.UUID, error) {
+	roleId, err := serverRepository.GetServerRoleIdByName(ctx, tx, serverId, model.MemberRole)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	if roleId != uuid.Nil {
+		return roleId, nil
+	}
+
+	serverRole := model.ServerRole{
+		Id:             uuid.New(),
+		ServerId:       serverId,
+		Name:           model.MemberRole,
+		Permissions:    sonic.NoCopyRawMessage(model.DefaultMemberPermissions),
+		CreateDatetime: now,
+		UpdateDatetime: now,
+		CreateUserId:   userId,
+		UpdateUserId:   userId,
+	}
+
+	err = serverRepository.CreateServerRole(ctx, tx, serverRole)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	return serverRole.Id, nil
+}
+
+// transferServerOwnership gives the server and its Owner role to newOwnerId and moves the previous owner to the
+// Member role. Both the transfer by the owner and the account purge go through it, the server must be locked.
+// false means newOwnerId is not an active member
+func transferServerOwnership(ctx context.Context, tx pgx.Tx, serverRepository *repository.ServerRepository, serverId uuid.UUID, ownerId uuid.UUID, newOwnerId uuid.UUID, updateUserId uuid.UUID, now time.Time) (bool, error) {
+	ownerRoleId, err := serverRepository.GetServerRoleIdByName(ctx, tx, serverId, model.OwnerRole)
+	if err != nil {
+		return false, err
+	}
+
+	if ownerRoleId == uuid.Nil {
+		return false, fmt.Errorf("owner role of server %s not found", serverId)
+	}
+
+	memberRoleId, err := defaultMemberRoleId(ctx, tx, serverRepository, serverId, updateUserId, now)
+	if err != nil {
+		return false, err
+	}
+
+	assigned, err := serverRepository.AssignActiveMemberRole(ctx, tx, serverId, newOwnerId, ownerRoleId, updateUserId, now)
+	if err != nil || !assigned {
+		return false, err
+	}
+
+	_, err = serverRepository.AssignActiveMemberRole(ctx, tx, serverId, ownerId, memberRoleId, updateUserId, now)
+	if err != nil {
+		return false, err
+	}
+
+	err = serverRepository.UpdateServerOwner(ctx, tx, serverId, newOwnerId, updateUserId, now)
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+func validateServerRoleName(name string) error {
+	if name == "" {
+		return &model.ValidationError{
+			Code:    constant.ERR_VALIDATION_CODE,
+			Message: "Name is required to not be empty",
+			Param:   "name",
+		}
+	} else if len(name) > 30 {
+		return &model.ValidationError{
+			Code:    constant.ERR_VALIDATION_CODE,
+			Message: "Name must be at most 30 characters",
+			Param:   "name",
+		}
+	} else if strings.EqualFold(name, model.OwnerRole) {
+		return &model.ValidationError{
+			Code:    constant.ERR_VALIDATION_CODE,
+			Message: "Name is reserved",
+			Param:   "name",
+		}
+	}
+
+	return nil
+}
+
+// canGrantPermissions reports whether the member holds every permission, so nobody can hand out more than they have
+func canGrantPermissions(member model.ServerMemberPermission, permissions []string) bool {
+	for _, permission := range permissions {
+		if !member.Has(model.Permission(permission)) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (usecase *ServerUsecase) GetServerRoles(ctx *fiber.Ctx, userId uuid.UUID, serverIdParam string) (model.ServerRoleListResponse, error) {
+	response := model.ServerRoleListResponse{}
+
+	serverId, err := uuid.Parse(serverIdParam)
+	if err != nil {
+		return response, &model.ValidationError{
+			Code:    constant.ERR_VALIDATION_CODE,
+			Message: "Invalid server id",
+			Param:   "serverId",
+		}
+	}
+
+	ctxContext := ctx.Context()
+
+	_, err = authorizeServerMember(ctxContext, usecase.ServerRepository, serverId, userId, "", "serverId")
+	if err != nil {
+		return response, err
+	}
+
+	response.Data, err = usecase.ServerRepository.GetServerRoles(ctxContext, serverId)
+	if err != nil {
+		return response, err
+	}
+
+	return response, nil
+}
+
+func (usecase *ServerUsecase) CreateServerRole(ctx *fiber.Ctx, userId uuid.UUID, serverIdParam string, payload model.ServerRoleCreateRequest) (model.ServerRoleResponse, error) {
+	response := model.ServerRoleResponse{}
+
+	serverId, err := uuid.Parse(serverIdParam)
+	if err != nil {
+		return response, &model.ValidationError{
+			Code:    constant.ERR_VALIDATION_CODE,
+			Message: "Invalid server id",
+			Param:   "serverId",
+		}
+	}
+
+	err = validateServerRoleName(payload.Name)
+	if err != nil {
+		return response, err
+	}
+
+	_, permissionsBytes, err := parsePermissions(payload.Permissions)
+	if err != nil {
+		return response, err
+	}
+
+	ctxContext := ctx.Context()
+
+	member, err := authorizeServerMember(ctxContext, usecase.ServerRepository, serverId, userId, model.PermissionManageRoles, "serverId")
+	if err != nil {
+		return response, err
+	}
+
+	if !canGrantPermissions(member, payload.Permissions) {
+		return response, &model.ValidationError{
+			Code:    constant.ERR_VALIDATION_CODE,
+			Message: "You can not grant permissions you do not have",
+			Param:   "permissions",
+		}
+	}
+
+	exists, err := usecase.ServerRepository.CheckServerRoleName(ctxContext, serverId, payload.Name, uuid.Nil)
+	if err != nil {
+		return response, err
+	}
+
+	if exists == 1 {
+		return response, &model.ValidationError{
+			Code:    constant.ERR_VALIDATION_CODE,
+			Message: "Role name already exists",
+			Param:   "name",
+		}
+	}
+
+	now := time.Now().UTC()
+
+	serverRole := model.ServerRole{
+		Id:             uuid.New(),
+		ServerId:       serverId,
+		Name:           payload.Name,
+		Permissions:    sonic.NoCopyRawMessage(permissionsBytes),
+		CreateDatetime: now,
+		UpdateDatetime: now,
+		CreateUserId:   userId,
+		UpdateUserId:   userId,
+	}
+
+	commited := false
+
+	// start transaction
+	tx, err := usecase.DB.Begin(ctxContext)
+	if err != nil {
+		return response, err
+	}
+
+	defer func() {
+		if !commited {
+			_ = tx.Rollback(ctxContext)
+		}
+	}()
+
+	err = usecase.ServerRepository.CreateServerRole(ctxContext, tx, serverRole)
+	if err != nil {
+		return response, err
+	}
+
+	err = tx.Commit(ctxContext)
+	if err != nil {
+		return response, err
+	}
+
+	commited = true
+
+	response, err = usecase.ServerRepository.GetServerRole(ctxContext, serverId, serverRole.Id)
+	if err != nil {
+		return response, err
+	}
+
+	return response, nil
+}
+
+func (usecase *ServerUsecase) UpdateServerRole(ctx *fiber.Ctx, userId uuid.UUID, serverIdParam string, roleIdParam string, payload model.ServerRoleUpdateRequest) (model.ServerRoleResponse, error) {
+	response := model.ServerRoleResponse{}
+
+	serverId, err := uuid.Parse(serverIdParam)
+	if err != nil {
+		return response, &model.ValidationError{
+			Code:    constant.ERR_VALIDATION_CODE,
+			Message: "Invalid server id",
+			Param:   "serverId",
+		}
+	}
+
+	roleId, err := uuid.Parse(roleIdParam)
+	if err != nil {
+		return response, &model.ValidationError{
+			Code:    constant.ERR_VALIDATION_CODE,
+			Message: "Invalid role id",
+			Param:   "roleId",
+		}
+	}
+
+	err = validateServerRoleName(payload.Name)
+	if err != nil {
+		return response, err
+	}
+
+	_, permissionsBytes, err := parsePermissions(payload.Permissions)
+	if err != nil {
+		return response, err
+	}
+
+	ctxContext := ctx.Context()
+
+	member, err := authorizeServerMember(ctxContext, usecase.ServerRepository, serverId, userId, model.PermissionManageRoles, "serverId")
+	if err != nil {
+		return response, err
+	}
+
+	role, err := usecase.ServerRepository.GetServerRole(ctxContext, serverId, roleId)
+	if err != nil {
+		return response, err
+	}
+
+	if role.Id == uuid.Nil {
+		return response, &model.ValidationError{
+			Code:    constant.ERR_VALIDATION_CODE,
+			Message: "Role not found",
+			Param:   "roleId",
+		}
+	}
+
+	if role.Name == model.OwnerRole {
+		return response, &model.ValidationError{
+			Code:    constant.ERR_VALIDATION_CODE,
+			Message: "The owner role can not be modified",
+			Param:   "roleId",
+		}
+	}
+
+	if role.Name == model.MemberRole && payload.Name != model.MemberRole {
+		return response, &model.ValidationError{
+			Code:    constant.ERR_VALIDATION_CODE,
+			Message: "The default member role can not be renamed",
+			Param:   "name",
+		}
+	}
+
+	if !canGrantPermissions(member, role.Permissions) || !canGrantPermissions(member, payload.Permissions) {
+		return response, &model.ValidationError{
+			Code:    constant.ERR_VALIDATION_CODE,
+			Message: "You can not grant permissions you do not have",
+			Param:   "permissions",
+		}
+	}
+
+	exists, err := usecase.ServerRepository.CheckServerRoleName(ctxContext, serverId, payload.Name, roleId)
+	if err != nil {
+		return response, err
+	}
+
+	if exists == 1 {
+		return response, &model.ValidationError{
+			Code:    constant.ERR_VALIDATION_CODE,
+			Message: "Role name already exists",
+			Param:   "name",
+		}
+	}
+
+	now := time.Now().UTC()
+
+	err = usecase.ServerRepository.UpdateServerRole(ctxContext, roleId, payload.Name, permissionsBytes, userId, now)
+	if err != nil {
+		return response, err
+	}
+
+	response, err = usecase.ServerRepository.GetServerRole(ctxContext, serverId, roleId)
+	if err != nil {
+		return response, err
+	}
+
+	return response, nil
+}
+
+func (usecase *ServerUsecase) DeleteServerRole(ctx *fiber.Ctx, userId uuid.UUID, serverIdParam string, roleIdParam string) error {
+	serverId, err := uuid.Parse(serverIdParam)
+	if err != nil {
+		return &model.ValidationError{
+			Code:    constant.ERR_VALIDATION_CODE,
+			Message: "Invalid server id",
+			Param:   "serverId",
+		}
+	}
+
+	roleId, err := uuid.Parse(roleIdParam)
+	if err != nil {
+		return &model.ValidationError{
+			Code:    constant.ERR_VALIDATION_CODE,
+			Message: "Invalid role id",
+			Param:   "roleId",
+		}
+	}
+
+	ctxContext := ctx.Context()
+
+	member, err := authorizeServerMember(ctxContext, usecase.ServerRepository, serverId, userId, model.PermissionManageRoles, "serverId")
+	if err != nil {
+		return err
+	}
+
+	role, err := usecase.ServerRepository.GetServerRole(ctxContext, serverId, roleId)
+	if err != nil {
+		return err
+	}
+
+	if role.Id == uuid.Nil {
+		return &model.ValidationError{
+			Code:    constant.ERR_VALIDATION_CODE,
+			Message: "Role not found",
+			Param:   "roleId",
+		}
+	}
+
+	if role.Name == model.OwnerRole || role.Name == model.MemberRole {
+		return &model.ValidationError{
+			Code:    constant.ERR_VALIDATION_CODE,
+			Message: "The owner and default member roles can not be deleted",
+			Param:   "roleId",
+		}
+	}
+
+	if !canGrantPermissions(member, role.Permissions) {
+		return &model.ValidationError{
+			Code:    constant.ERR_VALIDATION_CODE,
+			Message: "You can not delete a role with permissions you do not have",
+			Param:   "roleId",
+		}
+	}
+
+	now := time.Now().UTC()
+
+	commited := false
+
+	// start transaction
+	tx, err := usecase.DB.Begin(ctxContext)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if !commited {
+			_ = tx.Rollback(ctxContext)
+		}
+	}()
+
+	// members of the deleted role fall back to the default member role
+	memberRoleId, err := defaultMemberRoleId(ctxContext, tx, usecase.ServerRepository, serverId, userId, now)
+	if err != nil {
+		return err
+	}
+
+	err = usecase.ServerRepository.ReassignServerRoleMembers(ctxContext, tx, roleId, memberRoleId, userId, now)
+	if err != nil {
+		return err
+	}
+
+	err = usecase.ServerRepository.DeleteServerRole(ctxContext, tx, roleId)
+	if err != nil {
+		return err
+	}
+
+	err = tx.Commit(ctxContext)
+	if err != nil {
+		return err
+	}
+
+	commited = true
+
+	return nil
+}
+
+func (usecase *ServerUsecase) UpdateServerMemberRole(ctx *fiber.Ctx, userId uuid.UUID, serverIdParam string, memberIdParam string, payload model.ServerMemberRoleUpdateRequest) error {
+	serverId, err := uuid.Parse(serverIdParam)
+	if err != nil {
+		return &model.ValidationError{
+			Code:    constant.ERR_VALIDATION_CODE,
+			Message: "Invalid server id",
+			Param:   "serverId",
+		}
+	}
+
+	memberId, err := uuid.Parse(memberIdParam)
+	if err != nil {
+		return &model.ValidationError{
+			Code:    constant.ERR_VALIDATION_CODE,
+			Message: "Invalid user id",
+			Param:   "userId",
+		}
+	}
+
+	roleId, err := uuid.Parse(payload.RoleId)
+	if err != nil {
+		return &model.ValidationError{
+			Code:    constant.ERR_VALIDATION_CODE,
+			Message: "Invalid role id",
+			Param:   "roleId",
+		}
+	}
+
+	ctxContext := ctx.Context()
+
+	member, err := authorizeServerMember(ctxContext, usecase.ServerRepository, serverId, userId, model.PermissionManageRoles, "serverId")
+	if err != nil {
+		return err
+	}
+
+	target, err := usecase.ServerRepository.GetMemberPermission(ctxContext, serverId, memberId)
+	if err != nil {
+		return err
+	}
+
+	if target.RoleId == uuid.Nil {
+		return &model.ValidationError{
+			Code:    constant.ERR_VALIDATION_CODE,
+			Message: "Member not found",
+			Param:   "userId",
+		}
+	}
+
+	if target.IsOwner {
+		return &model.ValidationError{
+			Code:    constant.ERR_VALIDATION_CODE,
+			Message: "The role of the owner can not be changed",
+			Param:   "userId",
+		}
+	}
+
+	role, err := usecase.ServerRepository.GetServerRole(ctxContext, serverId, roleId)
+	if err != nil {
+		return err
+	}
+
+	if role.Id == uuid.Nil {
+		return &model.ValidationError{
+			Code:    constant.ERR_VALIDATION_CODE,
+			Message: "Role not found",
+			Param:   "roleId",
+		}
+	}
+
+	if role.Name == model.OwnerRole {
+		return &model.ValidationError{
+			Code:    constant.ERR_VALIDATION_CODE,
+			Message: "The owner role can not be assigned",
+			Param:   "roleId",
+		}
+	}
+
+	if !canGrantPermissions(member, role.Permissions) || !canGrantPermissions(member, model.PermissionList(target.Permissions)) {
+		return &model.ValidationError{
+			Code:    constant.ERR_VALIDATION_CODE,
+			Message: "You can not grant permissions you do not have",
+			Param:   "roleId",
+		}
+	}
+
+	now := time.Now().UTC()
+
+	err = usecase.ServerRepository.UpdateServerMemberRole(ctxContext, serverId, memberId, roleId, userId, now)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (usecase *ServerUsecase) GetServerMembers(ctx *fiber.Ctx, userId uuid.UUID, serverIdParam string) (model.ServerMemberListResponse, error) {
+	response := model.ServerMemberListResponse{}
+
+	serverId, err := uuid.Parse(serverIdParam)
+	if err != nil {
+		return response, &model.ValidationError{
+			Code:    constant.ERR_VALIDATION_CODE,
+			Message: "Invalid server id",
+			Param:   "serverId",
+		}
+	}
+
+	limit := ctx.QueryInt("limit", constant.DEFAULT_LIMIT)
+	cursor := ctx.Query("cursor", "")
+
+	if limit < 1 {
+		return response, &model.ValidationError{
+			Code:    constant.ERR_VALIDATION_CODE,
+			Message: "Limit must be greater or equal than 1",
+			Param:   "limit",
+		}
+	} else if limit > constant.MAX_LIMIT {
+		return response, &model.ValidationError{
+			Code:    constant.ERR_VALIDATION_CODE,
+			Message: fmt.Sprintf("Limit is exceeded max limit: %d", constant.MAX_LIMIT),
+			Param:   "limit",
+		}
+	}
+
+	var serverMemberCursor model.ServerMemberCursor
+	if cursor != "" {
+		b, err := base64.RawURLEncoding.DecodeString(cursor)
+		if err != nil {
+			return response, err
+		}
+
+		err = sonic.Unmarshal(b, &serverMemberCursor)
+		if err != nil {
+			return response, err
+		}
+	}
+
+	ctxContext := ctx.Context()
+
+	_, err = authorizeServerMember(ctxContext, usecase.ServerRepository, serverId, userId, "", "serverId")
+	if err != nil {
+		return response, err
+	}
+
+	MINIO_FULL_URL := fmt.Sprintf("%s%s/%s", usecase.Config.String("MINIO_HTTP"), usecase.Config.String("MINIO_URL"), usecase.Config.String("MINIO_BUCKET_NAME"))
+
+	// Fetch limit + 1 to check whether there is a next page
+	serverMembers, err := usecase.ServerRepository.GetServerMembers(ctxContext, limit+1, &serverMemberCursor, serverId, MINIO_FULL_URL)
+	if err != nil {
+		return response, err
+	}
+
+	response.Data = []model.ServerMemberResponse{}
+
+	if len(serverMembers) > limit {
+		response.Data = serverMembers[:limit]
+
+		last := serverMembers[limit-1]
+
+		lastCursor := model.ServerMemberCursor{
+			UserId:         last.UserId.String(),
+			JoinedDatetime: last.JoinedDatetime,
+		}
+
+		b, err := sonic.Marshal(lastCursor)
+		if err != nil {
+			return response, err
+		}
+
+		response.Page.NextCursor = base64.RawURLEncoding.EncodeToString(b)
+	} else if len(serverMembers) > 0 {
+		response.Data = serverMembers
+	}
+
+	return response, nil
+}
+
+func (usecase *ServerUsecase) LeaveServer(ctx *fiber.Ctx, userId uuid.UUID, serverIdParam string) error {
+	serverId, err := uuid.Parse(serverIdParam)
+	if err != nil {
+		return &model.ValidationError{
+			Code:    constant.ERR_VALIDATION_CODE,
+			Message: "Invalid server id",
+			Param:   "serverId",
+		}
+	}
+
+	ctxContext := ctx.Context()
+
+	member, err := authorizeServerMember(ctxContext, usecase.ServerRepository, serverId, userId, "", "serverId")
+	if err != nil {
+		return err
+	}
+
+	if member.IsOwner {
+		return &model.ValidationError{
+			Code:    constant.ERR_VALIDATION_CODE,
+			Message: "The owner can not leave the server, transfer the ownership first",
+			Param:   "serverId",
+		}
+	}
+
+	now := time.Now().UTC()
+
+	err = usecase.ServerRepository.UpdateServerMemberStatus(ctxContext, serverId, userId, model.MemberStatusLeft, &now, userId, now)
+	if err != nil {
+		return err
+	}
+
+	publishServerEvent(ctxContext, usecase.EventRepository, usecase.Log, model.ServerEventMemberLeft, serverId, model.ServerMemberEventData{UserId: userId})
+
+	return nil
+}
+
+// moderatableMember checks that the actor may remove the target, the owner and members holding
+// permissions the actor lacks are out of reach
+func moderatableMember(actor model.ServerMemberPermission, target model.ServerMemberPermission) error {
+	if target.UserId == actor.UserId {
+		return &model.ValidationError{
+			Code:    constant.ERR_VALIDATION_CODE,
+			Message: "You can not remove yourself, leave the server instead",
+			Param:   "userId",
+		}
+	}
+
+	if target.IsOwner {
+		return &model.ValidationError{
+			Code:    constant.ERR_VALIDATION_CODE,
+			Message: "The owner can not be removed from the server",
+			Param:   "userId",
+		}
+	}
+
+	if !canGrantPermissions(actor, model.PermissionList(target.Permissions)) {
+		return &model.ValidationError{
+			Code:    constant.ERR_VALIDATION_CODE,
+			Message: "You can not remove a member with permissions you do not have",
+			Param:   "userId",
+		}
+	}
+
+	return nil
+}
+
+func (usecase *ServerUsecase) KickServerMember(ctx *fiber.Ctx, userId uuid.UUID, serverIdParam string, memberIdParam string) error {
+	serverId, err := uuid.Parse(serverIdParam)
+	if err != nil {
+		return &model.ValidationError{
+			Code:    constant.ERR_VALIDATION_CODE,
+			Message: "Invalid server id",
+			Param:   "serverId",
+		}
+	}
+
+	memberId, err := uuid.Parse(memberIdParam)
+	if err != nil {
+		return &model.ValidationError{
+			Code:    constant.ERR_VALIDATION_CODE,
+			Message: "Invalid user id",
+			Param:   "userId",
+		}
+	}
+
+	ctxContext := ctx.Context()
+
+	member, err := authorizeServerMember(ctxContext, usecase.ServerRepository, serverId, userId, model.PermissionKickMembers, "serverId")
+	if err != nil {
+		return err
+	}
+
+	target, err := usecase.ServerRepository.GetMemberPermission(ctxContext, serverId, memberId)
+	if err != nil {
+		return err
+	}
+
+	if target.RoleId == uuid.Nil {
+		return &model.ValidationError{
+			Code:    constant.ERR_VALIDATION_CODE,
+			Message: "Member not found",
+			Param:   "userId",
+		}
+	}
+
+	err = moderatableMember(member, target)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+
+	err = usecase.ServerRepository.UpdateServerMemberStatus(ctxContext, serverId, memberId, model.MemberStatusLeft, &now, userId, now)
+	if err != nil {
+		return err
+	}
+
+	publishServerEvent(ctxContext, usecase.EventRepository, usecase.Log, model.ServerEventMemberLeft, serverId, model.ServerMemberEventData{UserId: memberId})
+
+	return nil
+}
+
+func (usecase *ServerUsecase) BanServerMember(ctx *fiber.Ctx, userId uuid.UUID, serverIdParam string, memberIdParam string) error {
+	serverId, err := uuid.Parse(serverIdParam)
+	if err != nil {
+		return &model.ValidationError{
+			Code:    constant.ERR_VALIDATION_CODE,
+			Message: "Invalid server id",
+			Param:   "serverId",
+		}
+	}
+
+	memberId, err := uuid.Parse(memberIdParam)
+	if err != nil {
+		return &model.ValidationError{
+			Code:    constant.ERR_VALIDATION_CODE,
+			Message: "Invalid user id",
+			Param:   "userId",
+		}
+	}
+
+	ctxContext := ctx.Context()
+
+	member, err := authorizeServerMember(ctxContext, usecase.ServerRepository, serverId, userId, model.PermissionBanMembers, "serverId")
+	if err != nil {
+		return err
+	}
+
+	status, err := usecase.ServerRepository.GetServerMemberStatus(ctxContext, serverId, memberId)
+	if err != nil {
+		return err
+	}
+
+	if status == 0 {
+		return &model.ValidationError{
+			Code:    constant.ERR_VALIDATION_CODE,
+			Message: "Member not found",
+			Param:   "userId",
+		}
+	} else if status == model.MemberStatusBanned {
+		return &model.ValidationError{
+			Code:    constant.ERR_VALIDATION_CODE,
+			Message: "Member is already banned",
+			Param:   "userId",
+		}
+	}
+
+	// Members who already left can be banned to keep them from joining again
+	if status == model.MemberStatusActive {
+		target, err := usecase.ServerRepository.GetMemberPermission(ctxContext, serverId, memberId)
+		if err != nil {
+			return err
+		}
+
+		err = moderatableMember(member, target)
+		if err != nil {
+			return err
+		}
+	}
+
+	now := time.Now().UTC()
+
+	err = usecase.ServerRepository.UpdateServerMemberStatus(ctxContext, serverId, memberId, model.MemberStatusBanned, &now, userId, now)
+	if err != nil {
+		return err
+	}
+
+	if status == model.MemberStatusActive {
+		publishServerEvent(ctxContext, usecase.EventRepository, usecase.Log, model.ServerEventMemberLeft, serverId, model.ServerMemberEventData{UserId: memberId})
+	}
+
+	return nil
+}
+
+func (usecase *ServerUsecase) UnbanServerMember(ctx *fiber.Ctx, userId uuid.UUID, serverIdParam string, memberIdParam string) error {
+	serverId, err := uuid.Parse(serverIdParam)
+	if err != nil {
+		return &model.ValidationError{
+			Code:    constant.ERR_VALIDATION_CODE,
+			Message: "Invalid server id",
+			Param:   "serverId",
+		}
+	}
+
+	memberId, err := uuid.Parse(memberIdParam)
+	if err != nil {
+		return &model.ValidationError{
+			Code:    constant.ERR_VALIDATION_CODE,
+			Message: "Invalid user id",
+			Param:   "userId",
+		}
+	}
+
+	ctxContext := ctx.Context()
+
+	_, err = authorizeServerMember(ctxContext, usecase.ServerRepository, serverId, userId, model.PermissionBanMembers, "serverId")
+	if err != nil {
+		return err
+	}
+
+	status, err := usecase.ServerRepository.GetServerMemberStatus(ctxContext, serverId, memberId)
+	if err != nil {
+		return err
+	}
+
+	if status != model.MemberStatusBanned {
+		return &model.ValidationError{
+			Code:    constant.ERR_VALIDATION_CODE,
+			Message: "Member is not banned",
+			Param:   "userId",
+		}
+	}
+
+	now := time.Now().UTC()
+
+	// The unbanned user is treated as having left, so they can join again
+	err = usecase.ServerRepository.UpdateServerMemberStatus(ctxContext, serverId, memberId, model.MemberStatusLeft, &now, userId, now)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (usecase *ServerUsecase) TransferOwnership(ctx *fiber.Ctx, userId uuid.UUID, serverIdParam string, payload model.ServerTransferOwnershipRequest) error {
+	serverId, err := uuid.Parse(serverIdParam)
+	if err != nil {
+		return &model.ValidationError{
+			Code:    constant.ERR_VALIDATION_CODE,
+			Message: "Invalid server id",
+			Param:   "serverId",
+		}
+	}
+
+	newOwnerId, err := uuid.Parse(payload.UserId)
+	if err != nil {
+		return &model.ValidationError{
+			Code:    constant.ERR_VALIDATION_CODE,
+			Message: "Invalid user id",
+			Param:   "userId",
+		}
+	}
+
+	if payload.Password == "" {
+		return &model.ValidationError{
+			Code:    constant.ERR_VALIDATION_CODE,
+			Message: "Password is required to not be empty",
+			Param:   "password",
+		}
+	}
+
+	if newOwnerId == userId {
+		return &model.ValidationError{
+			Code:    constant.ERR_VALIDATION_CODE,
+			Message: "You already own this server",
+			Param:   "userId",
+		}
+	}
+
+	ctxContext := ctx.Context()
+
+	member, err := authorizeServerMember(ctxContext, usecase.ServerRepository, serverId, userId, "", "serverId")
+	if err != nil {
+		return err
+	}
+
+	if !member.IsOwner {
+		return &model.ValidationError{
+			Code:    constant.ERR_VALIDATION_CODE,
+			Message: "You are not the owner of this server",
+			Param:   "serverId",
+		}
+	}
+
+	password, err := usecase.UserRepository.GetUserPassword(ctxContext, userId)
+	if err != nil {
+		return err
+	}
+
+	err = bcrypt.CompareHashAndPassword([]byte(password), []byte(payload.Password))
+	if err != nil {
+		return &model.ValidationError{
+			Code:    constant.ERR_VALIDATION_CODE,
+			Message: "Password is incorrect",
+			Param:   "password",
+		}
+	}
+
+	now := time.Now().UTC()
+
+	commited := false
+
+	// start transaction
+	tx, err := usecase.DB.Begin(ctxContext)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if !commited {
+			_ = tx.Rollback(ctxContext)
+		}
+	}()
+
+	// Locking the server serializes concurrent transfers, the owner is checked again under the lock
+	ownerId, serverName, err := usecase.ServerRepository.LockServer(ctxContext, tx, serverId)
+	if err != nil {
+		return err
+	}
+
+	if ownerId != userId {
+		return &model.ValidationError{
+			Code:    constant.ERR_VALIDATION_CODE,
+			Message: "You are not the owner of this server",
+			Param:   "serverId",
+		}
+	}
+
+	assigned, err := transferServerOwnership(ctxContext, tx, usecase.ServerRepository, serverId, userId, newOwnerId, userId, now)
+	if err != nil {
+		return err
+	}
+
+	if !assigned {
+		return &model.ValidationError{
+			Code:    constant.ERR_VALIDATION_CODE,
+			Message: "The new owner must be a member of this server",
+			Param:   "userId",
+		}
+	}
+
+	err = tx.Commit(ctxContext)
+	if err != nil {
+		return err
+	}
+
+	commited = true
+
+	MINIO_FULL_URL := fmt.Sprintf("%s%s/%s", usecase.Config.String("MINIO_HTTP"), usecase.Config.String("MINIO_URL"), usecase.Config.String("MINIO_BUCKET_NAME"))
+
+	// The transfer is done at this point, a failing notification must not report it as failed
+	previousOwner, err := usecase.UserRepository.GetUserInfo(ctxContext, userId, MINIO_FULL_URL)
+	if err != nil {
+		usecase.Log.Warn("failed to load previous owner for ownership transfer email", zap.String("serverId", serverId.String()), zap.Error(err))
+		return nil
+	}
+
+	newOwner, err := usecase.UserRepository.GetUserInfo(ctxContext, newOwnerId, MINIO_FULL_URL)
+	if err != nil {
+		usecase.Log.Warn("failed to load new owner for ownership transfer email", zap.String("serverId", serverId.String()), zap.Error(err))
+		return nil
+	}
+
+	err = usecase.sendOwnershipTransferEmail(previousOwner.Email, model.OwnershipTransferTemplateData{
+		Username:      previousOwner.Username,
+		OtherUsername: newOwner.Username,
+		ServerName:    serverName,
+		IsNewOwner:    false,
+	})
+	if err != nil {
+		usecase.Log.Warn("failed to send ownership transfer email to previous owner", zap.String("serverId", serverId.String()), zap.Error(err))
+	}
+
+	err = usecase.sendOwnershipTransferEmail(newOwner.Email, model.OwnershipTransferTemplateData{
+		Username:      newOwner.Username,
+		OtherUsername: previousOwner.Username,
+		ServerName:    serverName,
+		IsNewOwner:    true,
+	})
+	if err != nil {
+		usecase.Log.Warn("failed to send ownership transfer email to new owner", zap.String("serverId", serverId.String()), zap.Error(err))
+	}
+
+	return nil
+}
+
+func (usecase *ServerUsecase) sendOwnershipTransferEmail(email string, data model.OwnershipTransferTemplateData) error {
+	template, err := template.ParseFS(util.TemplateFS, "template/ownership_transfer.html")
+	if err != nil {
+		return err
+	}
+
+	var tmpl bytes.Buffer
+	err = template.Execute(&tmpl, data)
+	if err != nil {
+		return err
+	}
+
+	smtpHost := usecase.Config.String("SMTP_HOST")
+	smtpPort := usecase.Config.Int("SMTP_PORT")
+	senderName := usecase.Config.String("SENDER_NAME")
+	senderEmail := usecase.Config.String("SENDER_EMAIL")
+	senderPassword := usecase.Config.String("SENDER_PASSWORD")
+
+	subject := "Server Ownership Transferred"
+	return util.SendEmail(smtpHost, smtpPort, senderName, senderEmail, senderPassword, email, subject, tmpl.String())
+}
+
+func (usecase *ServerUsecase) GetServerInvites(ctx *fiber.Ctx, userId uuid.UUID, serverIdParam string) (model.ServerInviteListResponse, error) {
+	response := model.ServerInviteListResponse{}
+
+	serverId, err := uuid.Parse(serverIdParam)
+	if err != nil {
+		return response, &model.ValidationError{
+			Code:    constant.ERR_VALIDATION_CODE,
+			Message: "Invalid server id",
+			Param:   "serverId",
+		}
+	}
+
+	ctxContext := ctx.Context()
+
+	_, err = authorizeServerMember(ctxContext, usecase.ServerRepository, serverId, userId, model.PermissionManageInvites, "serverId")
+	if err != nil {
+		return response, err
+	}
+
+	response.Data, err = usecase.ServerRepository.GetServerInvites(ctxContext, serverId, time.Now().UTC())
+	if err != nil {
+		return response, err
+	}
+
+	return response, nil
+}
+
+func (usecase *ServerUsecase) RevokeServerInvite(ctx *fiber.Ctx, userId uuid.UUID, serverIdParam string, inviteCode string) error {
+	serverId, err := uuid.Parse(serverIdParam)
+	if err != nil {
+		return &model.ValidationError{
+			Code:    constant.ERR_VALIDATION_CODE,
+			Message: "Invalid server id",
+			Param:   "serverId",
+		}
+	}
+
+	ctxContext := ctx.Context()
+
+	_, err = authorizeServerMember(ctxContext, usecase.ServerRepository, serverId, userId, model.PermissionManageInvites, "serverId")
+	if err != nil {
+		return err
+	}
+
+	revoked, err := usecase.ServerRepository.RevokeServerInvite(ctxContext, serverId, inviteCode, userId, time.Now().UTC())
+	if err != nil {
+		return err
+	}
+
+	if !revoked {
+		return &model.ValidationError{
+			Code:    constant.ERR_VALIDATION_CODE,
+			Message: "Invite not found",
+			Param:   "code",
+		}
+	}
+
 	return nil
 }
@@ -0,0 +1,305 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/ferdian3456/virdanproject/internal/constant"
+	"github.com/ferdian3456/virdanproject/internal/model"
+	"github.com/ferdian3456/virdanproject/internal/repository"
+	"github.com/ferdian3456/virdanproject/internal/util"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/knadh/koanf/v2"
+	"go.uber.org/zap"
+)
+
+// UploadUsecase lets clients send images straight to object storage. The image is attached
+// through the usecase owning its target once the upload is finalized
+type UploadUsecase struct {
+	UploadRepository *repository.UploadRepository
+	UserUsecase      *UserUsecase
+	ServerUsecase    *ServerUsecase
+	PostUsecase      *PostUsecase
+	Log              *zap.Logger
+	Config           *koanf.Koanf
+}
+
+func NewUploadUsecase(uploadRepository *repository.UploadRepository, userUsecase *UserUsecase, serverUsecase *ServerUsecase, postUsecase *PostUsecase, zap *zap.Logger, koanf *koanf.Koanf) *UploadUsecase {
+	return &UploadUsecase{
+		UploadRepository: uploadRepository,
+		UserUsecase:      userUsecase,
+		ServerUsecase:    serverUsecase,
+		PostUsecase:      postUsecase,
+		Log:              zap,
+		Config:           koanf,
+	}
+}
+
+// authorizeUploadTarget checks the user may attach an image of the purpose to the target,
+// it runs when the intent is created and again when it is finalized
+func (usecase *UploadUsecase) authorizeUploadTarget(ctx context.Context, userId uuid.UUID, purpose model.UploadPurpose, targetId *uuid.UUID) error {
+	if purpose == model.UploadPurposeUserAvatar {
+		return nil
+	}
+
+	if targetId == nil {
+		return &model.ValidationError{
+			Code:    constant.ERR_VALIDATION_CODE,
+			Message: fmt.Sprintf("Target id is required for %s uploads", purpose),
+			Param:   "targetId",
+		}
+	}
+
+	if purpose == model.UploadPurposeServerAvatar || purpose == model.UploadPurposeServerBanner {
+		_, err := authorizeServerMember(ctx, usecase.ServerUsecase.ServerRepository, *targetId, userId, model.PermissionManageServer, "targetId")
+		return err
+	}
+
+	_, err := usecase.PostUsecase.authorizePostMember(ctx, *targetId, userId, model.PermissionCreatePost)
+	if err != nil {
+		return err
+	}
+
+	exists, err := usecase.PostUsecase.PostRepository.CheckPostOwnership(ctx, *targetId, userId)
+	if err != nil {
+		return err
+	}
+
+	if exists != 1 {
+		return &model.ValidationError{
+			Code:    constant.ERR_VALIDATION_CODE,
+			Message: "Only the author can add images to the post",
+			Param:   "targetId",
+		}
+	}
+
+	return nil
+}
+
+func (usecase *UploadUsecase) CreateUploadIntent(ctx *fiber.Ctx, userId uuid.UUID, payload model.UploadIntentCreateRequest) (model.UploadIntentResponse, error) {
+	response := model.UploadIntentResponse{}
+	ctxContext := ctx.Context()
+
+	if !model.IsValidUploadPurpose(payload.Purpose) {
+		return response, &model.ValidationError{
+			Code:    constant.ERR_VALIDATION_CODE,
+			Message: "Invalid upload purpose. allowed purposes: user_avatar, server_avatar, server_banner, post",
+			Param:   "purpose",
+		}
+	}
+
+	if !util.AllowedImageTypes[payload.ContentType] {
+		return response, &model.ValidationError{
+			Code:    constant.ERR_VALIDATION_CODE,
+			Message: fmt.Sprintf("Invalid file type: %s. allowed types: jpeg, jpg, png, gif, webp", payload.ContentType),
+			Param:   "contentType",
+		}
+	}
+
+	targetId := payload.TargetId
+	if payload.Purpose == model.UploadPurposeUserAvatar {
+		targetId = nil
+	}
+
+	err := usecase.authorizeUploadTarget(ctxContext, userId, payload.Purpose, targetId)
+	if err != nil {
+		return response, err
+	}
+
+	now := time.Now().UTC()
+
+	total, err := usecase.UploadRepository.CountPendingUploadIntents(ctxContext, userId, now)
+	if err != nil {
+		return response, err
+	}
+
+	if total >= constant.MAX_PENDING_UPLOADS {
+		return response, &model.ValidationError{
+			Code:    constant.ERR_VALIDATION_CODE,
+			Message: fmt.Sprintf("You can have at most %d pending uploads", constant.MAX_PENDING_UPLOADS),
+			Param:   "purpose",
+		}
+	}
+
+	uploadId := uuid.New()
+	bucketName := usecase.Config.String("MINIO_BUCKET_NAME")
+
+	intent := model.UploadIntent{
+		Id:              uploadId,
+		UserId:          userId,
+		Purpose:         payload.Purpose,
+		TargetId:        targetId,
+		Bucket:          bucketName,
+		ObjectKey:       fmt.Sprintf("upload/%s/%s", userId, uploadId),
+		ContentType:     payload.ContentType,
+		ExpiresDatetime: now.Add(constant.UPLOAD_INTENT_EXPIRY),
+		CreateDatetime:  now,
+		UpdateDatetime:  now,
+		CreateUserId:    userId,
+		UpdateUserId:    userId,
+	}
+
+	uploadUrl, uploadFields, err := usecase.UploadRepository.PresignUpload(ctxContext, bucketName, intent.ObjectKey, intent.ContentType, constant.MAX_FILE_SIZE, constant.UPLOAD_INTENT_EXPIRY)
+	if err != nil {
+		return response, err
+	}
+
+	err = usecase.UploadRepository.CreateUploadIntent(ctxContext, intent)
+	if err != nil {
+		return response, err
+	}
+
+	response = model.UploadIntentResponse{
+		Id:              uploadId,
+		UploadUrl:       uploadUrl,
+		UploadFields:    uploadFields,
+		Method:          http.MethodPost,
+		ContentType:     intent.ContentType,
+		MaxSize:         constant.MAX_FILE_SIZE,
+		ExpiresDatetime: intent.ExpiresDatetime,
+	}
+
+	return response, nil
+}
+
+// FinalizeUpload checks the uploaded object, processes it with the profile of its purpose and attaches it
+// to its target. The intent is used up even when the image turns out to be invalid
+func (usecase *UploadUsecase) FinalizeUpload(ctx *fiber.Ctx, userId uuid.UUID, uploadIdParam string) error {
+	uploadId, err := uuid.Parse(uploadIdParam)
+	if err != nil {
+		return &model.ValidationError{
+			Code:    constant.ERR_VALIDATION_CODE,
+			Message: "Invalid upload id",
+			Param:   "uploadId",
+		}
+	}
+
+	ctxContext := ctx.Context()
+
+	intent, err := usecase.UploadRepository.GetUploadIntent(ctxContext, uploadId)
+	if err != nil {
+		return err
+	}
+
+	if intent.Id == uuid.Nil || intent.UserId != userId {
+		return &model.ValidationError{
+			Code:    constant.ERR_VALIDATION_CODE,
+			Message: "Upload not found",
+			Param:   "uploadId",
+		}
+	}
+
+	if !intent.ExpiresDatetime.After(time.Now().UTC()) {
+		return &model.ValidationError{
+			Code:    constant.ERR_VALIDATION_CODE,
+			Message: "Upload has expired",
+			Param:   "uploadId",
+		}
+	}
+
+	err = usecase.authorizeUploadTarget(ctxContext, userId, intent.Purpose, intent.TargetId)
+	if err != nil {
+		return err
+	}
+
+	data, size, err := usecase.UploadRepository.GetUploadObject(ctxContext, intent.Bucket, intent.ObjectKey, constant.MAX_FILE_SIZE)
+	if err != nil {
+		return err
+	}
+
+	// Nothing uploaded yet, the intent stays so the client can still upload and retry
+	if size < 0 {
+		return &model.ValidationError{
+			Code:    constant.ERR_VALIDATION_CODE,
+			Message: "Image has not been uploaded yet",
+			Param:   "uploadId",
+		}
+	}
+
+	claimed, err := usecase.UploadRepository.DeleteUploadIntent(ctxContext, uploadId)
+	if err != nil {
+		return err
+	}
+
+	if !claimed {
+		return &model.ValidationError{
+			Code:    constant.ERR_VALIDATION_CODE,
+			Message: "Upload not found",
+			Param:   "uploadId",
+		}
+	}
+
+	// The uploaded object is only a staging copy, the attached image is stored under its own keys
+	defer func() {
+		removeErr := usecase.UploadRepository.RemoveObject(ctxContext, intent.Bucket, intent.ObjectKey)
+		if removeErr != nil {
+			usecase.Log.Warn("failed to remove finalized upload object", zap.String("objectKey", intent.ObjectKey), zap.Error(removeErr))
+		}
+	}()
+
+	if data == nil {
+		return &model.ValidationError{
+			Code:    constant.ERR_VALIDATION_CODE,
+			Message: fmt.Sprintf("Image size exceeded %dMB limit", constant.MAX_FILE_SIZE/(1024*1024)),
+			Param:   "uploadId",
+		}
+	}
+
+	switch intent.Purpose {
+	case model.UploadPurposeUserAvatar:
+		processed, err := util.ProcessImage(data, "uploadId", util.AvatarImageProfile)
+		if err != nil {
+			return err
+		}
+
+		return usecase.UserUsecase.replaceAvatar(ctxContext, userId, processed)
+	case model.UploadPurposeServerAvatar:
+		processed, err := util.ProcessImage(data, "uploadId", util.AvatarImageProfile)
+		if err != nil {
+			return err
+		}
+
+		return usecase.ServerUsecase.replaceServerAvatar(ctxContext, userId, *intent.TargetId, processed)
+	case model.UploadPurposeServerBanner:
+		processed, err := util.ProcessImage(data, "uploadId", util.BannerImageProfile)
+		if err != nil {
+			return err
+		}
+
+		return usecase.ServerUsecase.replaceServerBanner(ctxContext, userId, *intent.TargetId, processed)
+	default:
+		processed, err := util.ProcessImage(data, "uploadId", util.PostImageProfile)
+		if err != nil {
+			return err
+		}
+
+		return usecase.PostUsecase.appendPostImage(ctxContext, userId, *intent.TargetId, processed)
+	}
+}
+
+// PurgeExpiredUploadIntents removes the intents nobody finalized in time together with anything uploaded under them
+func (usecase *UploadUsecase) PurgeExpiredUploadIntents(ctx context.Context) error {
+	intents, err := usecase.UploadRepository.GetExpiredUploadIntents(ctx, time.Now().UTC(), 100)
+	if err != nil {
+		return err
+	}
+
+	for _, intent := range intents {
+		// The row goes last so a failed removal is retried on the next run
+		err = usecase.UploadRepository.RemoveObject(ctx, intent.Bucket, intent.ObjectKey)
+		if err != nil {
+			usecase.Log.Error("failed to remove expired upload object", zap.String("objectKey", intent.ObjectKey), zap.Error(err))
+			continue
+		}
+
+		_, err = usecase.UploadRepository.DeleteUploadIntent(ctx, intent.Id)
+		if err != nil {
+			usecase.Log.Error("failed to delete expired upload intent", zap.String("uploadId", intent.Id.String()), zap.Error(err))
+		}
+	}
+
+	return nil
+}
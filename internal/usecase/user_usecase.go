@@ -2,9 +2,11 @@ package usecase
 
 import (
 	"bytes"
+	"context"
 	"crypto/subtle"
 	"fmt"
 	"html/template"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -292,6 +294,14 @@ func (usecase *UserUsecase) Login(ctx *fiber.Ctx, payload model.UserLoginRequest
 		}
 	}
 
+	if len(payload.DeviceLabel) > 50 {
+		return token, &model.ValidationError{
+			Code:    constant.ERR_VALIDATION_CODE,
+			Message: "Device label must be at most 50 characters",
+			Param:   "deviceLabel",
+		}
+	}
+
 	payload.Username = strings.ToLower(payload.Username)
 
 	userId, password, err := usecase.UserRepository.GetUserAuth(ctxContext, payload.Username)
@@ -308,12 +318,17 @@ func (usecase *UserUsecase) Login(ctx *fiber.Ctx, payload model.UserLoginRequest
 		}
 	}
 
-	token, err = util.GenerateTokenPair(userId, usecase.Config.String("JWT_SECRET_KEY"))
+	// Logging in during the grace period cancels a pending account deletion
+	restored, err := usecase.UserRepository.RestoreAccount(ctxContext, userId, time.Now().UTC())
 	if err != nil {
 		return token, err
 	}
 
-	err = usecase.UserRepository.SetAuthTokenInCache(ctxContext, token.AccessToken, token.RefreshToken, userId)
+	if restored {
+		usecase.Log.Info("account deletion cancelled by login", zap.String("userId", userId.String()))
+	}
+
+	token, err = usecase.createAuthSession(ctx, userId, payload.DeviceLabel)
 	if err != nil {
 		return token, err
 	}
@@ -322,24 +337,66 @@ func (usecase *UserUsecase) Login(ctx *fiber.Ctx, payload model.UserLoginRequest
 }
 
 func (usecase *UserUsecase) GetUserInfo(ctx *fiber.Ctx, userId uuid.UUID) (model.UserResponse, error) {
-	user, err := usecase.UserRepository.GetUserInfo(ctx.Context(), userId)
+	MINIO_FULL_URL := fmt.Sprintf("%s%s/%s", usecase.Config.String("MINIO_HTTP"), usecase.Config.String("MINIO_URL"), usecase.Config.String("MINIO_BUCKET_NAME"))
+
+	user, err := usecase.UserRepository.GetUserInfo(ctx.Context(), userId, MINIO_FULL_URL)
 	if err != nil {
 		return user, err
 	}
 
-	MINIO_URL := usecase.Config.String("MINIO_URL")
-	MINIO_BUCKET_NAME := usecase.Config.String("MINIO_BUCKET_NAME")
-	MINIO_HTTP := usecase.Config.String("MINIO_HTTP")
+	return user, nil
+}
+
+// createAuthSession starts a new device session for the user and issues its first token pair
+func (usecase *UserUsecase) createAuthSession(ctx *fiber.Ctx, userId uuid.UUID, deviceLabel string) (model.TokenResponse, error) {
+	ctxContext := ctx.Context()
 
-	if user.AvatarImage != nil {
-		*user.AvatarImage = fmt.Sprintf("%s%s/%s/%s", MINIO_HTTP, MINIO_URL, MINIO_BUCKET_NAME, *user.AvatarImage)
+	if deviceLabel == "" {
+		deviceLabel = "Unknown device"
 	}
 
-	return user, nil
+	userAgent := ctx.Get(fiber.HeaderUserAgent)
+	if len(userAgent) > 255 {
+		userAgent = userAgent[:255]
+	}
+
+	now := time.Now().Unix()
+	session := model.AuthSession{
+		Id:          uuid.New(),
+		UserId:      userId,
+		DeviceLabel: deviceLabel,
+		IpAddress:   ctx.IP(),
+		UserAgent:   userAgent,
+		CreateAt:    now,
+		LastSeenAt:  now,
+	}
+
+	token, err := util.GenerateTokenPair(userId, session.Id, usecase.Config.String("JWT_SECRET_KEY"))
+	if err != nil {
+		return token, err
+	}
+
+	err = usecase.UserRepository.CreateAuthSession(ctxContext, session)
+	if err != nil {
+		return token, err
+	}
+
+	err = usecase.UserRepository.SetAuthTokenInCache(ctxContext, token.AccessToken, token.RefreshToken, userId, session.Id)
+	if err != nil {
+		return token, err
+	}
+
+	return token, nil
+}
+
+func (usecase *UserUsecase) GetAccessToken(ctx *fiber.Ctx, userId uuid.UUID, sessionId uuid.UUID, accessToken string) error {
+	return usecase.CheckAccessToken(ctx.Context(), userId, sessionId, accessToken)
 }
 
-func (usecase *UserUsecase) GetAccessToken(ctx *fiber.Ctx, userId uuid.UUID, accessToken string) error {
-	hashedTokenFromCache, err := usecase.UserRepository.GetAccessTokenInCache(ctx.Context(), userId)
+// CheckAccessToken is GetAccessToken for callers outside a request, such as a WebSocket connection that
+// re-checks its session while it stays open
+func (usecase *UserUsecase) CheckAccessToken(ctxContext context.Context, userId uuid.UUID, sessionId uuid.UUID, accessToken string) error {
+	hashedTokenFromCache, err := usecase.UserRepository.GetAccessTokenInCache(ctxContext, userId, sessionId)
 	if err != nil {
 		return err
 	}
@@ -355,97 +412,641 @@ func (usecase *UserUsecase) GetAccessToken(ctx *fiber.Ctx, userId uuid.UUID, acc
 		}
 	}
 
+	err = usecase.UserRepository.TouchAuthSession(ctxContext, sessionId, time.Now().Unix())
+	if err != nil {
+		return err
+	}
+
 	return nil
 }
 
-func (usecase *UserUsecase) Logout(ctx *fiber.Ctx, userId uuid.UUID) error {
-	err := usecase.UserRepository.RemoveAuthToken(ctx.Context(), userId)
+func (usecase *UserUsecase) Logout(ctx *fiber.Ctx, userId uuid.UUID, sessionId uuid.UUID) error {
+	err := usecase.UserRepository.RemoveAuthSession(ctx.Context(), userId, sessionId)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (usecase *UserUsecase) GetSessions(ctx *fiber.Ctx, userId uuid.UUID, currentSessionId uuid.UUID) (model.SessionListResponse, error) {
+	ctxContext := ctx.Context()
+	response := model.SessionListResponse{
+		Data: []model.SessionResponse{},
+	}
+
+	sessionIds, err := usecase.UserRepository.GetUserSessionIds(ctxContext, userId)
+	if err != nil {
+		return response, err
+	}
+
+	for _, id := range sessionIds {
+		sessionId, err := uuid.Parse(id)
+		if err != nil {
+			continue
+		}
+
+		data, err := usecase.UserRepository.GetAuthSession(ctxContext, sessionId)
+		if err != nil {
+			return response, err
+		}
+
+		// Session already expired, drop the dangling id from the user's set
+		if len(data) == 0 {
+			err = usecase.UserRepository.RemoveAuthSession(ctxContext, userId, sessionId)
+			if err != nil {
+				return response, err
+			}
+			continue
+		}
+
+		createAt, _ := strconv.ParseInt(data["create_at"], 10, 64)
+		lastSeenAt, _ := strconv.ParseInt(data["last_seen_at"], 10, 64)
+
+		response.Data = append(response.Data, model.SessionResponse{
+			Id:               sessionId,
+			DeviceLabel:      data["device_label"],
+			IpAddress:        data["ip_address"],
+			UserAgent:        data["user_agent"],
+			Current:          sessionId == currentSessionId,
+			CreateDatetime:   time.Unix(createAt, 0).UTC(),
+			LastSeenDatetime: time.Unix(lastSeenAt, 0).UTC(),
+		})
+	}
+
+	sort.Slice(response.Data, func(i, j int) bool {
+		return response.Data[i].LastSeenDatetime.After(response.Data[j].LastSeenDatetime)
+	})
+
+	return response, nil
+}
+
+func (usecase *UserUsecase) DeleteSession(ctx *fiber.Ctx, userId uuid.UUID, sessionIdParam string) error {
+	ctxContext := ctx.Context()
+
+	sessionId, err := uuid.Parse(sessionIdParam)
+	if err != nil {
+		return &model.ValidationError{
+			Code:    constant.ERR_VALIDATION_CODE,
+			Message: "Invalid session id",
+			Param:   "sessionId",
+		}
+	}
+
+	data, err := usecase.UserRepository.GetAuthSession(ctxContext, sessionId)
+	if err != nil {
+		return err
+	}
+
+	if len(data) == 0 || data["user_id"] != userId.String() {
+		return &model.ValidationError{
+			Code:    constant.ERR_NOT_FOUND_ERROR,
+			Message: "Session not found",
+			Param:   "sessionId",
+		}
+	}
+
+	err = usecase.UserRepository.RemoveAuthSession(ctxContext, userId, sessionId)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (usecase *UserUsecase) LogoutOtherSessions(ctx *fiber.Ctx, userId uuid.UUID, currentSessionId uuid.UUID) error {
+	err := usecase.UserRepository.RemoveAllAuthSessions(ctx.Context(), userId, currentSessionId)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (usecase *UserUsecase) Refresh(ctx *fiber.Ctx, payload model.TokenRefreshRequest) (model.TokenResponse, error) {
+	ctxContext := ctx.Context()
+	token := model.TokenResponse{}
+
+	if payload.RefreshToken == "" {
+		return token, &model.ValidationError{
+			Code:    constant.ERR_VALIDATION_CODE,
+			Message: "Refresh token is required to not be empty",
+			Param:   "refreshToken",
+		}
+	}
+
+	hashedRefreshToken := util.HashToken(payload.RefreshToken)
+
+	data, err := usecase.UserRepository.GetRefreshTokenData(ctxContext, hashedRefreshToken)
+	if err != nil {
+		return token, err
+	}
+
+	if len(data) == 0 {
+		return token, &model.ValidationError{
+			Code:    constant.ERR_VALIDATION_CODE,
+			Message: "Refresh token is invalid or expired",
+			Param:   "refreshToken",
+		}
+	}
+
+	userId, err := uuid.Parse(data["user_id"])
+	if err != nil {
+		return token, err
+	}
+
+	// Tokens issued before per-device sessions carry no session id and can't be rotated
+	sessionId, err := uuid.Parse(data["session_id"])
+	if err != nil {
+		return token, &model.ValidationError{
+			Code:    constant.ERR_VALIDATION_CODE,
+			Message: "Refresh token is invalid or expired",
+			Param:   "refreshToken",
+		}
+	}
+
+	used, err := usecase.UserRepository.MarkRefreshTokenUsed(ctxContext, hashedRefreshToken)
+	if err != nil {
+		return token, err
+	}
+
+	// The token data expired after it was read
+	if used == 0 {
+		return token, &model.ValidationError{
+			Code:    constant.ERR_VALIDATION_CODE,
+			Message: "Refresh token is invalid or expired",
+			Param:   "refreshToken",
+		}
+	}
+
+	session, err := usecase.UserRepository.GetAuthSession(ctxContext, sessionId)
+	if err != nil {
+		return token, err
+	}
+
+	if used > 1 {
+		// A rotated refresh token came back, assume it was stolen and revoke the whole session
+		if len(session) != 0 {
+			err = usecase.UserRepository.RemoveAuthSession(ctxContext, userId, sessionId)
+			if err != nil {
+				return token, err
+			}
+		}
+
+		usecase.Log.Warn("refresh token reuse detected", zap.String("userId", userId.String()), zap.String("sessionId", sessionId.String()))
+
+		return token, &model.ValidationError{
+			Code:    constant.ERR_VALIDATION_CODE,
+			Message: "Refresh token has already been used",
+			Param:   "refreshToken",
+		}
+	}
+
+	if len(session) == 0 || session["refresh_token"] != hashedRefreshToken {
+		return token, &model.ValidationError{
+			Code:    constant.ERR_VALIDATION_CODE,
+			Message: "Refresh token is invalid or expired",
+			Param:   "refreshToken",
+		}
+	}
+
+	token, err = util.GenerateTokenPair(userId, sessionId, usecase.Config.String("JWT_SECRET_KEY"))
+	if err != nil {
+		return token, err
+	}
+
+	err = usecase.UserRepository.SetAuthTokenInCache(ctxContext, token.AccessToken, token.RefreshToken, userId, sessionId)
+	if err != nil {
+		return token, err
+	}
+
+	return token, nil
+}
+
+func (usecase *UserUsecase) ForgotPassword(ctx *fiber.Ctx, payload model.UserForgotPasswordRequest) (model.UserForgotPasswordResponse, error) {
+	ctxContext := ctx.Context()
+
+	response := model.UserForgotPasswordResponse{}
+
+	if payload.Email == "" {
+		return response, &model.ValidationError{
+			Code:    constant.ERR_VALIDATION_CODE,
+			Message: "Email is required to not be empty",
+			Param:   "email",
+		}
+	} else if len(payload.Email) < 16 {
+		return response, &model.ValidationError{
+			Code:    constant.ERR_VALIDATION_CODE,
+			Message: "email must be at least 16 characters",
+			Param:   "email",
+		}
+	} else if len(payload.Email) > 80 {
+		return response, &model.ValidationError{
+			Code:    constant.ERR_VALIDATION_CODE,
+			Message: "Email must be at most 80 characters",
+			Param:   "email",
+		}
+	}
+
+	payload.Email = strings.ToLower(payload.Email)
+
+	sessionId := uuid.New()
+	otpExpiresAt := time.Now().UTC().Add(5 * time.Minute).Unix()
+
+	response.SessionId = sessionId
+	response.OtpExpiresAt = otpExpiresAt
+
+	userId, err := usecase.UserRepository.GetUserIdByEmail(ctxContext, payload.Email)
+	if err != nil {
+		return response, err
+	}
+
+	// Respond the same way for unknown emails so this endpoint can't be used to find registered accounts
+	if userId == uuid.Nil {
+		usecase.Log.Debug("password reset requested for unknown email", zap.String("email", payload.Email))
+		return response, nil
+	}
+
+	exists, emailSessionId, err := usecase.UserRepository.CheckPasswordResetEmailSession(ctxContext, payload.Email)
+	if err != nil {
+		return response, err
+	}
+
+	if exists {
+		usecase.Log.Debug("password reset session is exists, preparing to delete email and password reset session", zap.String("email", payload.Email))
+		err = usecase.UserRepository.DeletePasswordResetEmailSession(ctxContext, payload.Email)
+		if err != nil {
+			return response, err
+		}
+		err = usecase.UserRepository.DeletePasswordResetSession(ctxContext, emailSessionId)
+		if err != nil {
+			return response, err
+		}
+	}
+
+	otp, err := util.GenerateOTP()
+	if err != nil {
+		return response, err
+	}
+
+	otpHash := util.HashSHA256(otp)
+
+	OtpTemplateData := model.OTPTemplateData{
+		OTP:       otp,
+		ExpiresIn: 5,
+	}
+
+	template, err := template.ParseFS(util.TemplateFS, "template/otp.html")
+	if err != nil {
+		return response, err
+	}
+
+	var tmpl bytes.Buffer
+	err = template.Execute(&tmpl, OtpTemplateData)
+	if err != nil {
+		return response, err
+	}
+
+	smtpHost := usecase.Config.String("SMTP_HOST")
+	smtpPort := usecase.Config.Int("SMTP_PORT")
+	senderName := usecase.Config.String("SENDER_NAME")
+	senderEmail := usecase.Config.String("SENDER_EMAIL")
+	senderPassword := usecase.Config.String("SENDER_PASSWORD")
+
+	subject := "Reset Password OTP Verification Code"
+	err = util.SendEmail(smtpHost, smtpPort, senderName, senderEmail, senderPassword, payload.Email, subject, tmpl.String())
+	if err != nil {
+		return response, err
+	}
+
+	err = usecase.UserRepository.SetPasswordResetSession(ctxContext, sessionId, userId, payload.Email, otpHash, otpExpiresAt)
+	if err != nil {
+		return response, err
+	}
+
+	err = usecase.UserRepository.SetPasswordResetEmailSession(ctxContext, sessionId.String(), payload.Email)
+	if err != nil {
+		return response, err
+	}
+
+	return response, nil
+}
+
+func (usecase *UserUsecase) ResetPassword(ctx *fiber.Ctx, payload model.UserResetPasswordRequest) error {
+	ctxContext := ctx.Context()
+
+	sessionId, err := uuid.Parse(payload.SessionId)
+	if err != nil {
+		return &model.ValidationError{
+			Code:    constant.ERR_VALIDATION_CODE,
+			Message: "Invalid session id",
+			Param:   "sessionId",
+		}
+	}
+
+	if payload.OTP == "" {
+		return &model.ValidationError{
+			Code:    constant.ERR_VALIDATION_CODE,
+			Message: "OTP is required to not be empty",
+			Param:   "otp",
+		}
+	} else if len(payload.OTP) < 6 {
+		return &model.ValidationError{
+			Code:    constant.ERR_VALIDATION_CODE,
+			Message: "OTP must be at least 6 characters",
+			Param:   "otp",
+		}
+	}
+
+	if payload.Password == "" {
+		return &model.ValidationError{
+			Code:    constant.ERR_VALIDATION_CODE,
+			Message: "Password is required to not be empty",
+			Param:   "password",
+		}
+	} else if len(payload.Password) < 5 {
+		return &model.ValidationError{
+			Code:    constant.ERR_VALIDATION_CODE,
+			Message: "Password must be at least 5 characters",
+			Param:   "password",
+		}
+	} else if len(payload.Password) > 20 {
+		return &model.ValidationError{
+			Code:    constant.ERR_VALIDATION_CODE,
+			Message: "Password must be at most 20 characters",
+			Param:   "password",
+		}
+	}
+
+	data, err := usecase.UserRepository.GetPasswordResetSession(ctxContext, sessionId)
+	if err != nil {
+		return err
+	}
+
+	if len(data) == 0 {
+		return &model.ValidationError{
+			Code:    constant.ERR_VALIDATION_CODE,
+			Message: "OTP does not exists or expired",
+			Param:   "otp",
+		}
+	}
+
+	otpExpiresAt, err := strconv.ParseInt(data["otp_expires_at"], 10, 64)
+	if err != nil {
+		return err
+	}
+
+	if time.Now().Unix() > otpExpiresAt {
+		return &model.ValidationError{
+			Code:    constant.ERR_VALIDATION_CODE,
+			Message: "Otp is expired",
+			Param:   "otp",
+		}
+	}
+
+	attempts, err := usecase.UserRepository.IncrementPasswordResetAttempts(ctxContext, sessionId)
+	if err != nil {
+		return err
+	}
+
+	if attempts == 0 {
+		return &model.ValidationError{
+			Code:    constant.ERR_VALIDATION_CODE,
+			Message: "OTP does not exists or expired",
+			Param:   "otp",
+		}
+	} else if attempts > constant.PASSWORD_RESET_MAX_ATTEMPTS {
+		err = usecase.UserRepository.DeletePasswordResetSession(ctxContext, sessionId.String())
+		if err != nil {
+			return err
+		}
+
+		err = usecase.UserRepository.DeletePasswordResetEmailSession(ctxContext, data["email"])
+		if err != nil {
+			return err
+		}
+
+		return &model.ValidationError{
+			Code:    constant.ERR_VALIDATION_CODE,
+			Message: "Too many attempts, please request a new OTP",
+			Param:   "otp",
+		}
+	}
+
+	if subtle.ConstantTimeCompare([]byte(data["otp"]), []byte(util.HashSHA256(payload.OTP))) != 1 {
+		return &model.ValidationError{
+			Code:    constant.ERR_VALIDATION_CODE,
+			Message: "Otp does not match",
+			Param:   "otp",
+		}
+	}
+
+	userId, err := uuid.Parse(data["user_id"])
+	if err != nil {
+		return err
+	}
+
+	// The session is single use, remove it before touching the password
+	err = usecase.UserRepository.DeletePasswordResetSession(ctxContext, sessionId.String())
+	if err != nil {
+		return err
+	}
+
+	err = usecase.UserRepository.DeletePasswordResetEmailSession(ctxContext, data["email"])
+	if err != nil {
+		return err
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(payload.Password), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+
+	err = usecase.UserRepository.UpdatePassword(ctxContext, userId, string(hashedPassword), userId, time.Now().UTC())
+	if err != nil {
+		return err
+	}
+
+	err = usecase.UserRepository.RemoveAllAuthSessions(ctxContext, userId, uuid.Nil)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (usecase *UserUsecase) UpdateAvatar(ctx *fiber.Ctx, userId uuid.UUID) error {
+	ctxContext := ctx.Context()
+
+	fieldName := "avatar"
+	fileHeader, err := ctx.FormFile(fieldName)
+	if err != nil {
+		return &model.ValidationError{
+			Code:    constant.ERR_VALIDATION_CODE,
+			Message: "Avatar is required to not be empty",
+			Param:   fieldName,
+		}
+	}
+
+	processed, err := util.ValidateImage(fileHeader, fieldName, util.AvatarImageProfile)
+	if err != nil {
+		return err
+	}
+
+	return usecase.replaceAvatar(ctxContext, userId, processed)
+}
+
+// replaceAvatar stores the processed image as the new avatar of the user and removes the previous one
+func (usecase *UserUsecase) replaceAvatar(ctxContext context.Context, userId uuid.UUID, processed *util.ProcessedImage) error {
+	image := newStoredImage("user/avatar", processed)
+	avatarImageId := image.Id
+
+	now := time.Now().UTC()
+
+	bucketName := usecase.Config.String("MINIO_BUCKET_NAME")
+
+	avatarImage := model.UserAvatarImage{
+		Id:             avatarImageId,
+		UserId:         userId,
+		Bucket:         bucketName,
+		ObjectKey:      image.ObjectKey,
+		MimeType:       image.MimeType,
+		Size:           image.Size,
+		Variants:       image.Variants,
+		CreateDatetime: now,
+		UpdateDatetime: now,
+		CreateUserId:   userId,
+		UpdateUserId:   userId,
+	}
+
+	commited := false
+
+	defer func() {
+		if !commited {
+			for _, objectKey := range image.ObjectKeys() {
+				removeErr := usecase.UserRepository.DeleteUserAvatar(ctxContext, bucketName, objectKey)
+				if removeErr != nil {
+					usecase.Log.Warn("failed to remove uploaded avatar object", zap.String("objectKey", objectKey), zap.Error(removeErr))
+				}
+			}
+		}
+	}()
+
+	// upload first so a failed upload never leaves a row pointing to a missing object
+	err := image.upload(ctxContext, bucketName, usecase.UserRepository.UploadUserAvatar)
+	if err != nil {
+		return err
+	}
+
+	// start transaction
+	tx, err := usecase.DB.Begin(ctxContext)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if !commited {
+			_ = tx.Rollback(ctxContext)
+		}
+	}()
+
+	err = usecase.UserRepository.LockUser(ctxContext, tx, userId)
+	if err != nil {
+		return err
+	}
+
+	oldObjectKeys, err := usecase.UserRepository.GetUserAvatar(ctxContext, tx, userId)
+	if err != nil {
+		return err
+	}
+
+	err = usecase.UserRepository.DeleteAvatarImage(ctxContext, tx, userId)
+	if err != nil {
+		return err
+	}
+
+	err = usecase.UserRepository.AddUserAvatar(ctxContext, tx, avatarImage)
+	if err != nil {
+		return err
+	}
+
+	err = usecase.UserRepository.UpdateUserAvatarImage(ctxContext, tx, userId, &avatarImageId, userId, now)
 	if err != nil {
 		return err
 	}
 
-	return nil
-}
+	err = tx.Commit(ctxContext)
+	if err != nil {
+		return err
+	}
 
-func (usecase *UserUsecase) UpdateAvatar(ctx *fiber.Ctx, userId uuid.UUID) error {
-	ctxContext := ctx.Context()
+	commited = true
 
-	fieldName := "avatar"
-	fileHeader, err := ctx.FormFile(fieldName)
-	if err != nil {
-		return &model.ValidationError{
-			Code:    constant.ERR_VALIDATION_CODE,
-			Message: "Avatar is required to not be empty",
-			Param:   fieldName,
+	for _, objectKey := range oldObjectKeys {
+		err = usecase.UserRepository.DeleteUserAvatar(ctxContext, bucketName, objectKey)
+		if err != nil {
+			usecase.Log.Warn("failed to remove old avatar object", zap.String("objectKey", objectKey), zap.Error(err))
 		}
 	}
 
-	imageFile, imageSize, err := util.ValidateImage(fileHeader, fieldName)
-	if err != nil {
-		return err
-	}
+	return nil
+}
 
-	avatarImageId := uuid.New()
+func (usecase *UserUsecase) DeleteAvatar(ctx *fiber.Ctx, userId uuid.UUID) error {
+	ctxContext := ctx.Context()
 
 	now := time.Now().UTC()
 
 	bucketName := usecase.Config.String("MINIO_BUCKET_NAME")
 
-	avatarImage := model.UserAvatarImage{
-		Id:             avatarImageId,
-		UserId:         userId,
-		Bucket:         bucketName,
-		ObjectKey:      fmt.Sprintf("user/avatar/%s.webp", avatarImageId),
-		MimeType:       "webp",
-		Size:           0,
-		CreateDatetime: now,
-		UpdateDatetime: now,
-		CreateUserId:   userId,
-		UpdateUserId:   userId,
-	}
+	commited := false
 
 	// start transaction
-	tx, err := usecase.DB.Begin(ctx.Context())
+	tx, err := usecase.DB.Begin(ctxContext)
 	if err != nil {
 		return err
 	}
 
 	defer func() {
-		_ = tx.Rollback(ctxContext)
+		if !commited {
+			_ = tx.Rollback(ctxContext)
+		}
 	}()
 
-	fileName, err := usecase.UserRepository.GetUserAvatar(ctxContext, tx, userId)
+	err = usecase.UserRepository.LockUser(ctxContext, tx, userId)
 	if err != nil {
 		return err
 	}
 
-	if fileName != "" {
-		err = usecase.UserRepository.DeleteAvatarImage(ctxContext, tx, userId)
-		if err != nil {
-			return err
-		}
-
-		err = usecase.UserRepository.DeleteUserAvatar(ctxContext, bucketName, fileName)
-		if err != nil {
-			return err
-		}
+	oldObjectKeys, err := usecase.UserRepository.GetUserAvatar(ctxContext, tx, userId)
+	if err != nil {
+		return err
 	}
 
-	err = usecase.UserRepository.AddUserAvatar(ctxContext, tx, avatarImage)
+	err = usecase.UserRepository.DeleteAvatarImage(ctxContext, tx, userId)
 	if err != nil {
 		return err
 	}
 
-	err = tx.Commit(ctxContext)
+	err = usecase.UserRepository.UpdateUserAvatarImage(ctxContext, tx, userId, nil, userId, now)
 	if err != nil {
 		return err
 	}
 
-	err = usecase.UserRepository.UploadUserAvatar(ctxContext, bucketName, avatarImage.ObjectKey, imageFile, imageSize)
+	err = tx.Commit(ctxContext)
 	if err != nil {
 		return err
 	}
 
+	commited = true
+
+	for _, objectKey := range oldObjectKeys {
+		err = usecase.UserRepository.DeleteUserAvatar(ctxContext, bucketName, objectKey)
+		if err != nil {
+			usecase.Log.Warn("failed to remove old avatar object", zap.String("objectKey", objectKey), zap.Error(err))
+		}
+	}
+
 	return nil
 }
 
@@ -858,12 +1459,7 @@ func (usecase *UserUsecase) VerifyPassword(ctx *fiber.Ctx, payload model.UserVer
 		return token, err
 	}
 
-	token, err = util.GenerateTokenPair(userId, usecase.Config.String("JWT_SECRET_KEY"))
-	if err != nil {
-		return token, err
-	}
-
-	err = usecase.UserRepository.SetAuthTokenInCache(ctxContext, token.AccessToken, token.RefreshToken, userId)
+	token, err = usecase.createAuthSession(ctx, userId, "")
 	if err != nil {
 		return token, err
 	}
@@ -1007,3 +1603,298 @@ func (usecase *UserUsecase) UpdateBio(ctx *fiber.Ctx, userId uuid.UUID, payload
 
 	return nil
 }
+
+func (usecase *UserUsecase) ChangePassword(ctx *fiber.Ctx, userId uuid.UUID, sessionId uuid.UUID, payload model.PasswordChangeRequest) (model.TokenResponse, error) {
+	ctxContext := ctx.Context()
+	token := model.TokenResponse{}
+
+	if payload.CurrentPassword == "" {
+		return token, &model.ValidationError{
+			Code:    constant.ERR_VALIDATION_CODE,
+			Message: "Current password is required to not be empty",
+			Param:   "currentPassword",
+		}
+	}
+
+	if payload.NewPassword == "" {
+		return token, &model.ValidationError{
+			Code:    constant.ERR_VALIDATION_CODE,
+			Message: "Password is required to not be empty",
+			Param:   "newPassword",
+		}
+	} else if len(payload.NewPassword) < 5 {
+		return token, &model.ValidationError{
+			Code:    constant.ERR_VALIDATION_CODE,
+			Message: "Password must be at least 5 characters",
+			Param:   "newPassword",
+		}
+	} else if len(payload.NewPassword) > 20 {
+		return token, &model.ValidationError{
+			Code:    constant.ERR_VALIDATION_CODE,
+			Message: "Password must be at most 20 characters",
+			Param:   "newPassword",
+		}
+	}
+
+	if payload.NewPassword == payload.CurrentPassword {
+		return token, &model.ValidationError{
+			Code:    constant.ERR_VALIDATION_CODE,
+			Message: "New password must be different from current password",
+			Param:   "newPassword",
+		}
+	}
+
+	password, err := usecase.UserRepository.GetUserPassword(ctxContext, userId)
+	if err != nil {
+		return token, err
+	}
+
+	err = bcrypt.CompareHashAndPassword([]byte(password), []byte(payload.CurrentPassword))
+	if err != nil {
+		return token, &model.ValidationError{
+			Code:    constant.ERR_VALIDATION_CODE,
+			Message: "Current password is incorrect",
+			Param:   "currentPassword",
+		}
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(payload.NewPassword), bcrypt.DefaultCost)
+	if err != nil {
+		return token, err
+	}
+
+	err = usecase.UserRepository.UpdatePassword(ctxContext, userId, string(hashedPassword), userId, time.Now().UTC())
+	if err != nil {
+		return token, err
+	}
+
+	err = usecase.UserRepository.RemoveAllAuthSessions(ctxContext, userId, sessionId)
+	if err != nil {
+		return token, err
+	}
+
+	// Rotate the caller's own tokens too, the old refresh token stops matching the session
+	token, err = util.GenerateTokenPair(userId, sessionId, usecase.Config.String("JWT_SECRET_KEY"))
+	if err != nil {
+		return token, err
+	}
+
+	err = usecase.UserRepository.SetAuthTokenInCache(ctxContext, token.AccessToken, token.RefreshToken, userId, sessionId)
+	if err != nil {
+		return token, err
+	}
+
+	return token, nil
+}
+
+func (usecase *UserUsecase) DeleteAccount(ctx *fiber.Ctx, userId uuid.UUID, payload model.AccountDeleteRequest) (model.AccountDeleteResponse, error) {
+	ctxContext := ctx.Context()
+	response := model.AccountDeleteResponse{}
+
+	if payload.Password == "" {
+		return response, &model.ValidationError{
+			Code:    constant.ERR_VALIDATION_CODE,
+			Message: "Password is required to not be empty",
+			Param:   "password",
+		}
+	}
+
+	password, err := usecase.UserRepository.GetUserPassword(ctxContext, userId)
+	if err != nil {
+		return response, err
+	}
+
+	err = bcrypt.CompareHashAndPassword([]byte(password), []byte(payload.Password))
+	if err != nil {
+		return response, &model.ValidationError{
+			Code:    constant.ERR_VALIDATION_CODE,
+			Message: "Password is incorrect",
+			Param:   "password",
+		}
+	}
+
+	total, err := usecase.ServerRepository.CountOwnedServersWithOtherMembers(ctxContext, userId)
+	if err != nil {
+		return response, err
+	}
+
+	if total > 0 {
+		return response, &model.ValidationError{
+			Code:    constant.ERR_VALIDATION_CODE,
+			Message: fmt.Sprintf("You still own %d server(s) with other members, transfer the ownership or delete them first", total),
+			Param:   "servers",
+		}
+	}
+
+	now := time.Now().UTC()
+	response.DeleteScheduledDatetime = now.Add(constant.ACCOUNT_DELETION_GRACE_PERIOD)
+
+	err = usecase.UserRepository.ScheduleAccountDeletion(ctxContext, userId, now, response.DeleteScheduledDatetime)
+	if err != nil {
+		return response, err
+	}
+
+	err = usecase.UserRepository.RemoveAllAuthSessions(ctxContext, userId, uuid.Nil)
+	if err != nil {
+		return response, err
+	}
+
+	return response, nil
+}
+
+func (usecase *UserUsecase) ExportAccountData(ctx *fiber.Ctx, userId uuid.UUID) (model.UserDataExportResponse, error) {
+	ctxContext := ctx.Context()
+	response := model.UserDataExportResponse{}
+
+	profile, err := usecase.GetUserInfo(ctx, userId)
+	if err != nil {
+		return response, err
+	}
+
+	memberships, err := usecase.UserRepository.GetUserMembershipsForExport(ctxContext, userId)
+	if err != nil {
+		return response, err
+	}
+
+	MINIO_FULL_URL := fmt.Sprintf("%s%s/%s", usecase.Config.String("MINIO_HTTP"), usecase.Config.String("MINIO_URL"), usecase.Config.String("MINIO_BUCKET_NAME"))
+	posts, err := usecase.UserRepository.GetUserPostsForExport(ctxContext, userId, usecase.Config.String("MINIO_BUCKET_NAME"), MINIO_FULL_URL)
+	if err != nil {
+		return response, err
+	}
+
+	comments, err := usecase.UserRepository.GetUserCommentsForExport(ctxContext, userId)
+	if err != nil {
+		return response, err
+	}
+
+	response.Profile = profile
+	response.Memberships = memberships
+	response.Posts = posts
+	response.Comments = comments
+	response.ExportDatetime = time.Now().UTC()
+
+	return response, nil
+}
+
+// PurgeDeletedAccounts hard deletes every account whose grace period is over
+func (usecase *UserUsecase) PurgeDeletedAccounts(ctx context.Context) error {
+	userIds, err := usecase.UserRepository.GetAccountsDueForDeletion(ctx, time.Now().UTC(), 100)
+	if err != nil {
+		return err
+	}
+
+	for _, userId := range userIds {
+		err = usecase.purgeAccount(ctx, userId)
+		if err != nil {
+			usecase.Log.Error("failed to purge deleted account", zap.String("userId", userId.String()), zap.Error(err))
+			continue
+		}
+
+		usecase.Log.Info("deleted account purged", zap.String("userId", userId.String()))
+	}
+
+	return nil
+}
+
+func (usecase *UserUsecase) purgeAccount(ctx context.Context, userId uuid.UUID) error {
+	now := time.Now().UTC()
+	objectKeys := []string{}
+
+	commited := false
+
+	tx, err := usecase.DB.Begin(ctx)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if !commited {
+			_ = tx.Rollback(ctx)
+		}
+	}()
+
+	exists, err := usecase.UserRepository.LockAccountDueForDeletion(ctx, tx, userId, now)
+	if err != nil {
+		return err
+	}
+
+	if exists != 1 {
+		return nil
+	}
+
+	// Members who joined during the grace period still deserve to keep their community,
+	// so owned servers go to the longest standing member and only empty ones are deleted
+	serverIds, err := usecase.ServerRepository.GetOwnedServerIds(ctx, tx, userId)
+	if err != nil {
+		return err
+	}
+
+	for _, serverId := range serverIds {
+		successorId, err := usecase.ServerRepository.GetServerSuccessor(ctx, tx, serverId, userId)
+		if err != nil {
+			return err
+		}
+
+		if successorId != uuid.Nil {
+			transferred, err := transferServerOwnership(ctx, tx, usecase.ServerRepository, serverId, userId, successorId, successorId, now)
+			if err != nil {
+				return err
+			}
+
+			// the successor left in the meantime, the next purge run picks another one
+			if !transferred {
+				return fmt.Errorf("successor %s of server %s is no longer a member", successorId, serverId)
+			}
+			continue
+		}
+
+		serverObjectKeys, err := usecase.ServerRepository.DeleteServerWithContent(ctx, tx, serverId)
+		if err != nil {
+			return err
+		}
+
+		objectKeys = append(objectKeys, serverObjectKeys...)
+	}
+
+	postObjectKeys, err := usecase.UserRepository.DeleteUserPostImages(ctx, tx, userId)
+	if err != nil {
+		return err
+	}
+
+	objectKeys = append(objectKeys, postObjectKeys...)
+
+	avatarObjectKeys, err := usecase.UserRepository.GetUserAvatar(ctx, tx, userId)
+	if err != nil {
+		return err
+	}
+
+	objectKeys = append(objectKeys, avatarObjectKeys...)
+
+	// Comments are kept with a NULL author by the foreign key
+	err = usecase.UserRepository.DeleteUser(ctx, tx, userId)
+	if err != nil {
+		return err
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return err
+	}
+
+	commited = true
+
+	bucketName := usecase.Config.String("MINIO_BUCKET_NAME")
+	for _, objectKey := range objectKeys {
+		err = usecase.UserRepository.RemoveObject(ctx, bucketName, objectKey)
+		if err != nil {
+			usecase.Log.Warn("failed to remove object of deleted account", zap.String("objectKey", objectKey), zap.Error(err))
+		}
+	}
+
+	err = usecase.UserRepository.RemoveAllAuthSessions(ctx, userId, uuid.Nil)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
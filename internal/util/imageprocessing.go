@@ -6,7 +6,9 @@ import (
 	_ "image/gif"
 	_ "image/jpeg"
 	_ "image/png"
+	"math"
 	"mime/multipart"
+	"net/http"
 	"path/filepath"
 	"strings"
 
@@ -23,9 +25,69 @@ var AllowedImageTypes = map[string]bool{
 	"image/webp": true,
 }
 
-func ValidateImage(fileHeader *multipart.FileHeader, fieldName string) (*bytes.Reader, int64, error) {
+// imageExtensions maps the detected type of an upload to the extension its original is stored with
+var imageExtensions = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/gif":  ".gif",
+	"image/webp": ".webp",
+}
+
+// ImageVariant is one size stored for an upload. A cropped variant fills the box exactly,
+// otherwise the image is scaled down to fit inside it and never enlarged
+type ImageVariant struct {
+	Name   string
+	Width  int
+	Height int
+	Crop   bool
+}
+
+// ImageProfile describes how the uploads of one use case are processed
+type ImageProfile struct {
+	Quality  int
+	Variants []ImageVariant
+}
+
+var AvatarImageProfile = ImageProfile{
+	Quality: 80,
+	Variants: []ImageVariant{
+		{Name: "thumb", Width: 64, Height: 64, Crop: true},
+		{Name: "medium", Width: 256, Height: 256, Crop: true},
+		{Name: "large", Width: 512, Height: 512, Crop: true},
+	},
+}
+
+// BannerImageProfile keeps the 3:1 aspect ratio of the server banner
+var BannerImageProfile = ImageProfile{
+	Quality: 80,
+	Variants: []ImageVariant{
+		{Name: "thumb", Width: 480, Height: 160, Crop: true},
+		{Name: "medium", Width: 960, Height: 320, Crop: true},
+		{Name: "large", Width: 1920, Height: 640, Crop: true},
+	},
+}
+
+// PostImageProfile keeps the whole photo, only its longest side is bounded
+var PostImageProfile = ImageProfile{
+	Quality: 82,
+	Variants: []ImageVariant{
+		{Name: "thumb", Width: 320, Height: 320},
+		{Name: "medium", Width: 1080, Height: 1080},
+		{Name: "large", Width: 2048, Height: 2048},
+	},
+}
+
+// ProcessedImage holds the upload as it was sent and its WebP variants keyed by variant name
+type ProcessedImage struct {
+	Original    []byte
+	ContentType string
+	Extension   string
+	Variants    map[string][]byte
+}
+
+func ValidateImage(fileHeader *multipart.FileHeader, fieldName string, profile ImageProfile) (*ProcessedImage, error) {
 	if fileHeader.Size > constant.MAX_FILE_SIZE {
-		return nil, 0, &model.ValidationError{
+		return nil, &model.ValidationError{
 			Code:    constant.ERR_VALIDATION_CODE,
 			Message: fmt.Sprintf("Image size exceeded %dMB limit", constant.MAX_FILE_SIZE/(1024*1024)),
 			Param:   fieldName,
@@ -34,7 +96,7 @@ func ValidateImage(fileHeader *multipart.FileHeader, fieldName string) (*bytes.R
 
 	contentType := fileHeader.Header.Get("Content-Type")
 	if !AllowedImageTypes[contentType] {
-		return nil, 0, &model.ValidationError{
+		return nil, &model.ValidationError{
 			Code:    constant.ERR_VALIDATION_CODE,
 			Message: fmt.Sprintf("Invalid file type: %s. allowed types: jpeg, jpg, png, gif, webp", contentType),
 			Param:   fieldName,
@@ -44,54 +106,103 @@ func ValidateImage(fileHeader *multipart.FileHeader, fieldName string) (*bytes.R
 	ext := strings.ToLower(filepath.Ext(fileHeader.Filename))
 	validExts := map[string]bool{".jpg": true, ".jpeg": true, ".png": true, ".gif": true, ".webp": true}
 	if !validExts[ext] {
-		return nil, 0, &model.ValidationError{
+		return nil, &model.ValidationError{
 			Code:    constant.ERR_VALIDATION_CODE,
 			Message: fmt.Sprintf("Invalid file extension: %s", ext),
 			Param:   fieldName,
 		}
 	}
 
-	webBuf, err := ConvertToWebP(fileHeader, 75, 512, 512)
+	original, err := readFile(fileHeader)
 	if err != nil {
-		return nil, 0, &model.ValidationError{
+		return nil, &model.ValidationError{
 			Code:    constant.ERR_VALIDATION_CODE,
 			Message: "Failed to process image. File may be corrupted or not a valid image",
 			Param:   fieldName,
 		}
 	}
 
-	webpSize := int64(webBuf.Len())
+	return ProcessImage(original, fieldName, profile)
+}
+
+// ProcessImage detects the type of an upload and renders the variants of the profile, it is shared by
+// multipart uploads and the uploads sent straight to object storage
+func ProcessImage(original []byte, fieldName string, profile ImageProfile) (*ProcessedImage, error) {
+	processingErr := &model.ValidationError{
+		Code:    constant.ERR_VALIDATION_CODE,
+		Message: "Failed to process image. File may be corrupted or not a valid image",
+		Param:   fieldName,
+	}
+
+	// The stored content type comes from the bytes, the header is only a hint from the client
+	detectedType := http.DetectContentType(original)
+	extension, ok := imageExtensions[detectedType]
+	if !ok {
+		return nil, processingErr
+	}
 
-	return bytes.NewReader(webBuf.Bytes()), webpSize, nil
+	processed := &ProcessedImage{
+		Original:    original,
+		ContentType: detectedType,
+		Extension:   extension,
+		Variants:    make(map[string][]byte, len(profile.Variants)),
+	}
+
+	for _, variant := range profile.Variants {
+		output, err := ConvertToWebP(original, profile.Quality, variant)
+		if err != nil {
+			return nil, processingErr
+		}
+
+		processed.Variants[variant.Name] = output
+	}
+
+	return processed, nil
 }
 
-func ConvertToWebP(file *multipart.FileHeader, quality int, maxW int, maxH int) (*bytes.Buffer, error) {
-	src, err := file.Open()
+func ConvertToWebP(source []byte, quality int, variant ImageVariant) ([]byte, error) {
+	image := bimg.NewImage(source)
+
+	options := bimg.Options{
+		Width:         variant.Width,
+		Height:        variant.Height,
+		Quality:       quality,
+		Type:          bimg.WEBP,
+		StripMetadata: true,
+	}
+
+	if variant.Crop {
+		options.Crop = true
+		options.Enlarge = true
+		options.Gravity = bimg.GravityCentre
+	} else {
+		size, err := image.Size()
+		if err != nil {
+			return nil, err
+		}
+
+		// Scale down to fit inside the box, the aspect ratio is kept and small photos stay as they are
+		scale := math.Min(1, math.Min(float64(variant.Width)/float64(size.Width), float64(variant.Height)/float64(size.Height)))
+		options.Width = max(1, int(math.Round(float64(size.Width)*scale)))
+		options.Height = max(1, int(math.Round(float64(size.Height)*scale)))
+		options.Force = true
+	}
+
+	return image.Process(options)
+}
+
+func readFile(fileHeader *multipart.FileHeader) ([]byte, error) {
+	src, err := fileHeader.Open()
 	if err != nil {
 		return nil, err
 	}
 	defer func() { _ = src.Close() }()
 
-	// Read file into buffer
 	buffer := new(bytes.Buffer)
 	_, err = buffer.ReadFrom(src)
 	if err != nil {
 		return nil, err
 	}
 
-	output, err := bimg.NewImage(buffer.Bytes()).Process(bimg.Options{
-		Width:   maxW,
-		Height:  maxH,
-		Quality: quality,
-		Type:    bimg.WEBP,
-		Crop:    true,
-		Embed:   false, // Maintains aspect ratio
-		Force:   true,  // Dont force exact dimension
-	})
-
-	if err != nil {
-		return nil, err
-	}
-
-	return bytes.NewBuffer(output), nil
+	return buffer.Bytes(), nil
 }
@@ -32,14 +32,15 @@ func HashToken(token string) string {
 	return hex.EncodeToString(hash[:])
 }
 
-func GenerateAccessToken(userId uuid.UUID, jwtSecretKey string) (string, error) {
+func GenerateAccessToken(userId uuid.UUID, sessionId uuid.UUID, jwtSecretKey string) (string, error) {
 	if jwtSecretKey == "" {
 		return "", errors.New("jwt secret key is not configured")
 	}
 
 	now := time.Now().UTC()
 	claims := &model.Claims{
-		UserId: userId,
+		UserId:    userId,
+		SessionId: sessionId,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(now.Add(AccessTokenDuration)),
 			IssuedAt:  jwt.NewNumericDate(now),
@@ -64,9 +65,9 @@ func GenerateRefreshToken() string {
 	return uuid.New().String()
 }
 
-// GenerateTokenPair creates both access and refresh tokens for a user
-func GenerateTokenPair(userId uuid.UUID, jwtSecretKey string) (model.TokenResponse, error) {
-	accessToken, err := GenerateAccessToken(userId, jwtSecretKey)
+// GenerateTokenPair creates both access and refresh tokens for a user session
+func GenerateTokenPair(userId uuid.UUID, sessionId uuid.UUID, jwtSecretKey string) (model.TokenResponse, error) {
+	accessToken, err := GenerateAccessToken(userId, sessionId, jwtSecretKey)
 	if err != nil {
 		return model.TokenResponse{}, err
 	}
@@ -82,16 +83,16 @@ func GenerateTokenPair(userId uuid.UUID, jwtSecretKey string) (model.TokenRespon
 	}, nil
 }
 
-// ValidateAccessToken validates a JWT access token and returns the user ID
-func ValidateAccessToken(accessToken string, log *zap.Logger, jwtSecretKey string) (string, uuid.UUID, error) {
+// ValidateAccessToken validates a JWT access token and returns the user ID and session ID
+func ValidateAccessToken(accessToken string, log *zap.Logger, jwtSecretKey string) (string, uuid.UUID, uuid.UUID, error) {
 	if jwtSecretKey == "" {
-		return "", uuid.Nil, errors.New("jwt secret key is not configured")
+		return "", uuid.Nil, uuid.Nil, errors.New("jwt secret key is not configured")
 	}
 
 	// Extract token from Authorization header
 	tokenString, err := extractBearerToken(accessToken)
 	if err != nil {
-		return "", uuid.Nil, err
+		return "", uuid.Nil, uuid.Nil, err
 	}
 
 	// Don't log the full token - security risk
@@ -107,20 +108,20 @@ func ValidateAccessToken(accessToken string, log *zap.Logger, jwtSecretKey strin
 	})
 
 	if err != nil {
-		return "", uuid.Nil, handleParseError(err)
+		return "", uuid.Nil, uuid.Nil, handleParseError(err)
 	}
 
 	// Extract and validate claims
 	claims, ok := token.Claims.(*model.Claims)
-	if !ok || !token.Valid {
-		return "", uuid.Nil, &model.ValidationError{
+	if !ok || !token.Valid || claims.SessionId == uuid.Nil {
+		return "", uuid.Nil, uuid.Nil, &model.ValidationError{
 			Code:    constant.ERR_UNATHORIZED_ERROR,
 			Message: "Authentication token is invalid",
 			Param:   "accessToken",
 		}
 	}
 
-	return tokenString, claims.UserId, nil
+	return tokenString, claims.UserId, claims.SessionId, nil
 }
 
 // extractBearerToken extracts the token from "Bearer <token>" format
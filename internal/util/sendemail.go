@@ -5,10 +5,18 @@ import (
 )
 
 func SendEmail(smtpHost string, smtpPort int, senderName string, senderEmail string, senderPassowrd string, receiverEmail string, subject string, body string) error {
+	return SendEmailWithHeaders(smtpHost, smtpPort, senderName, senderEmail, senderPassowrd, receiverEmail, subject, body, nil)
+}
+
+// SendEmailWithHeaders is SendEmail with extra headers, such as List-Unsubscribe for bulk mail
+func SendEmailWithHeaders(smtpHost string, smtpPort int, senderName string, senderEmail string, senderPassowrd string, receiverEmail string, subject string, body string, headers map[string]string) error {
 	mailer := gomail.NewMessage()
 	mailer.SetHeader("From", senderName)
 	mailer.SetHeader("To", receiverEmail)
 	mailer.SetHeader("Subject", subject)
+	for name, value := range headers {
+		mailer.SetHeader(name, value)
+	}
 	mailer.SetBody("text/html", body)
 
 	dialer := gomail.NewDialer(
@@ -0,0 +1,62 @@
+package util
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"strings"
+
+	"github.com/ferdian3456/virdanproject/internal/constant"
+	"github.com/ferdian3456/virdanproject/internal/model"
+	"github.com/google/uuid"
+)
+
+// unsubscribeTokenPurpose keeps an unsubscribe signature from being valid for anything else signed with the same key
+const unsubscribeTokenPurpose = "digest-unsubscribe:"
+
+// GenerateUnsubscribeToken signs the user id for the one-click unsubscribe link of digest emails. The link is
+// meant to work for as long as the email sits in the inbox, so the token does not expire
+func GenerateUnsubscribeToken(userId uuid.UUID, secretKey string) (string, error) {
+	if secretKey == "" {
+		return "", errors.New("unsubscribe secret key is not configured")
+	}
+
+	return userId.String() + "." + unsubscribeSignature(userId, secretKey), nil
+}
+
+// ParseUnsubscribeToken returns the user of a token made by GenerateUnsubscribeToken
+func ParseUnsubscribeToken(token string, secretKey string) (uuid.UUID, error) {
+	invalidErr := &model.ValidationError{
+		Code:    constant.ERR_VALIDATION_CODE,
+		Message: "Unsubscribe token is invalid",
+		Param:   "token",
+	}
+
+	if secretKey == "" {
+		return uuid.Nil, errors.New("unsubscribe secret key is not configured")
+	}
+
+	userIdPart, signature, found := strings.Cut(token, ".")
+	if !found {
+		return uuid.Nil, invalidErr
+	}
+
+	userId, err := uuid.Parse(userIdPart)
+	if err != nil {
+		return uuid.Nil, invalidErr
+	}
+
+	if !hmac.Equal([]byte(signature), []byte(unsubscribeSignature(userId, secretKey))) {
+		return uuid.Nil, invalidErr
+	}
+
+	return userId, nil
+}
+
+func unsubscribeSignature(userId uuid.UUID, secretKey string) string {
+	mac := hmac.New(sha256.New, []byte(secretKey))
+	mac.Write([]byte(unsubscribeTokenPurpose + userId.String()))
+
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
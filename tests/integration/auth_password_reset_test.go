@@ -0,0 +1,195 @@
+package integration
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/ferdian3456/virdanproject/tests/integration/setup"
+	"github.com/stretchr/testify/require"
+)
+
+// TestForgotAndResetPassword tests the POST /auth/forgot-password and POST /auth/reset-password endpoints
+func TestForgotAndResetPassword(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test in short mode")
+	}
+
+	ctx := context.Background()
+
+	t.Log("=== Starting Test Infrastructure ===")
+	infra, err := setup.StartInfra(ctx, t)
+	require.NoError(t, err, "infrastructure should start successfully")
+	defer func() { _ = infra.Terminate(ctx, t) }()
+
+	t.Log("=== Running Database Migrations ===")
+	_ = setup.RunMigration(infra.PgURL, t)
+
+	t.Log("=== Setting Up Test Application ===")
+	app, db, _, _ := setup.SetupTestApp(t, infra.PgURL, infra.RedisURL, infra.MinioURL, infra.MailhogSMTP)
+	defer db.Close()
+
+	// Create test user
+	t.Log("=== Setup: Creating Test User ===")
+	testEmail := "resetpassword@example.com"
+	accessToken := createTestUser(t, app, infra.MailhogURL, testEmail, "resetuser", "pass123")
+	setup.ClearMailhogMessages(t, infra.MailhogURL)
+
+	// Test 1: Forgot password with empty email
+	t.Log("=== Test 1: Forgot Password With Empty Email ===")
+	req := setup.CreateJSONRequest(http.MethodPost, "/api/auth/forgot-password", []byte(`{"email":""}`))
+	resp, err := app.Test(req)
+	require.NoError(t, err, "request should complete")
+	require.Equal(t, 400, resp.StatusCode, "empty email should return 400")
+
+	result := setup.ParseJSONResponse(t, resp)
+	code, message, param := setup.ParseErrorDetail(t, result)
+	require.Equal(t, "VALIDATION_ERROR", code, "error code should be VALIDATION_ERROR")
+	require.Equal(t, "email", param, "error param should be 'email'")
+
+	t.Logf("✓ Validation Error: Code=%s, Message=%s, Param=%s", code, message, param)
+
+	// Test 2: Forgot password for unknown email looks the same as a known one
+	t.Log("=== Test 2: Forgot Password With Unknown Email ===")
+	req = setup.CreateJSONRequest(http.MethodPost, "/api/auth/forgot-password", []byte(`{"email":"unknownuser@example.com"}`))
+	resp, err = app.Test(req)
+	require.NoError(t, err, "request should complete")
+	require.Equal(t, 200, resp.StatusCode, "unknown email should still return 200")
+
+	result = setup.ParseJSONResponse(t, resp)
+	require.NotEmpty(t, result["sessionId"], "sessionId should be present")
+
+	t.Log("✓ Unknown email does not leak account existence")
+
+	// Test 3: Forgot password for registered email sends OTP
+	t.Log("=== Test 3: Forgot Password With Registered Email ===")
+	reqBody := []byte(fmt.Sprintf(`{"email":"%s"}`, testEmail))
+	req = setup.CreateJSONRequest(http.MethodPost, "/api/auth/forgot-password", reqBody)
+	resp, err = app.Test(req)
+	require.NoError(t, err, "request should complete")
+	require.Equal(t, 200, resp.StatusCode, "forgot password should return 200")
+
+	result = setup.ParseJSONResponse(t, resp)
+	sessionId := result["sessionId"].(string)
+	require.NotEmpty(t, sessionId, "sessionId should not be empty")
+
+	otp := setup.GetOTPFromMailhog(t, infra.MailhogURL, testEmail)
+
+	t.Logf("✓ Reset OTP sent: sessionId=%s", sessionId)
+
+	// Test 4: Reset password with wrong OTP
+	t.Log("=== Test 4: Reset Password With Wrong OTP ===")
+	wrongOtp := "000000"
+	if otp == wrongOtp {
+		wrongOtp = "111111"
+	}
+	reqBody = []byte(fmt.Sprintf(`{"sessionId":"%s","otp":"%s","password":"newpass123"}`, sessionId, wrongOtp))
+	req = setup.CreateJSONRequest(http.MethodPost, "/api/auth/reset-password", reqBody)
+	resp, err = app.Test(req)
+	require.NoError(t, err, "request should complete")
+	require.Equal(t, 400, resp.StatusCode, "wrong OTP should return 400")
+
+	result = setup.ParseJSONResponse(t, resp)
+	code, message, param = setup.ParseErrorDetail(t, result)
+	require.Equal(t, "otp", param, "error param should be 'otp'")
+	require.Contains(t, message, "does not match", "error message should mention does not match")
+
+	t.Logf("✓ Validation Error: Code=%s, Message=%s, Param=%s", code, message, param)
+
+	// Test 5: Reset password with invalid new password
+	t.Log("=== Test 5: Reset Password With Too Short Password ===")
+	reqBody = []byte(fmt.Sprintf(`{"sessionId":"%s","otp":"%s","password":"abc"}`, sessionId, otp))
+	req = setup.CreateJSONRequest(http.MethodPost, "/api/auth/reset-password", reqBody)
+	resp, err = app.Test(req)
+	require.NoError(t, err, "request should complete")
+	require.Equal(t, 400, resp.StatusCode, "short password should return 400")
+
+	result = setup.ParseJSONResponse(t, resp)
+	_, _, param = setup.ParseErrorDetail(t, result)
+	require.Equal(t, "password", param, "error param should be 'password'")
+
+	t.Log("✓ Short password rejected")
+
+	// Test 6: Reset password successfully
+	t.Log("=== Test 6: Reset Password Successfully ===")
+	reqBody = []byte(fmt.Sprintf(`{"sessionId":"%s","otp":"%s","password":"newpass123"}`, sessionId, otp))
+	req = setup.CreateJSONRequest(http.MethodPost, "/api/auth/reset-password", reqBody)
+	resp, err = app.Test(req)
+	require.NoError(t, err, "request should complete")
+	require.Equal(t, 200, resp.StatusCode, "reset password should return 200")
+
+	t.Log("✓ Password reset successfully")
+
+	// Test 7: Existing tokens are revoked
+	t.Log("=== Test 7: Existing Tokens Are Revoked ===")
+	req = setup.CreateAuthRequest(http.MethodGet, "/api/users/me", nil, accessToken)
+	resp, err = app.Test(req)
+	require.NoError(t, err, "request should complete")
+	require.NotEqual(t, 200, resp.StatusCode, "old access token should be revoked")
+
+	t.Log("✓ Old access token revoked")
+
+	// Test 8: Login with old and new password
+	t.Log("=== Test 8: Login With Old And New Password ===")
+	req = setup.CreateJSONRequest(http.MethodPost, "/api/auth/login", []byte(`{"username":"resetuser","password":"pass123"}`))
+	resp, err = app.Test(req)
+	require.NoError(t, err, "request should complete")
+	require.Equal(t, 400, resp.StatusCode, "old password should be rejected")
+
+	req = setup.CreateJSONRequest(http.MethodPost, "/api/auth/login", []byte(`{"username":"resetuser","password":"newpass123"}`))
+	resp, err = app.Test(req)
+	require.NoError(t, err, "request should complete")
+	require.Equal(t, 200, resp.StatusCode, "new password should be accepted")
+
+	t.Log("✓ Login works with new password only")
+
+	// Test 9: Session can not be reused
+	t.Log("=== Test 9: Reuse Reset Session ===")
+	reqBody = []byte(fmt.Sprintf(`{"sessionId":"%s","otp":"%s","password":"another123"}`, sessionId, otp))
+	req = setup.CreateJSONRequest(http.MethodPost, "/api/auth/reset-password", reqBody)
+	resp, err = app.Test(req)
+	require.NoError(t, err, "request should complete")
+	require.Equal(t, 400, resp.StatusCode, "used reset session should return 400")
+
+	t.Log("✓ Reset session is single use")
+
+	// Test 10: Too many wrong attempts invalidate the session
+	t.Log("=== Test 10: Too Many Wrong Attempts ===")
+	setup.ClearMailhogMessages(t, infra.MailhogURL)
+	reqBody = []byte(fmt.Sprintf(`{"email":"%s"}`, testEmail))
+	req = setup.CreateJSONRequest(http.MethodPost, "/api/auth/forgot-password", reqBody)
+	resp, err = app.Test(req)
+	require.NoError(t, err, "request should complete")
+	require.Equal(t, 200, resp.StatusCode, "forgot password should return 200")
+
+	result = setup.ParseJSONResponse(t, resp)
+	sessionId = result["sessionId"].(string)
+	otp = setup.GetOTPFromMailhog(t, infra.MailhogURL, testEmail)
+
+	wrongOtp = "000000"
+	if otp == wrongOtp {
+		wrongOtp = "111111"
+	}
+	for i := 0; i < 5; i++ {
+		reqBody = []byte(fmt.Sprintf(`{"sessionId":"%s","otp":"%s","password":"another123"}`, sessionId, wrongOtp))
+		req = setup.CreateJSONRequest(http.MethodPost, "/api/auth/reset-password", reqBody)
+		resp, err = app.Test(req)
+		require.NoError(t, err, "request should complete")
+		require.Equal(t, 400, resp.StatusCode, "wrong OTP should return 400")
+	}
+
+	reqBody = []byte(fmt.Sprintf(`{"sessionId":"%s","otp":"%s","password":"another123"}`, sessionId, otp))
+	req = setup.CreateJSONRequest(http.MethodPost, "/api/auth/reset-password", reqBody)
+	resp, err = app.Test(req)
+	require.NoError(t, err, "request should complete")
+	require.Equal(t, 400, resp.StatusCode, "correct OTP after too many attempts should return 400")
+
+	result = setup.ParseJSONResponse(t, resp)
+	_, message, _ = setup.ParseErrorDetail(t, result)
+	require.Contains(t, message, "Too many attempts", "error message should mention too many attempts")
+
+	t.Log("✓ Reset session locked after too many attempts")
+
+	t.Log("=== All Forgot And Reset Password Tests Passed ===")
+}
@@ -0,0 +1,118 @@
+package integration
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/ferdian3456/virdanproject/tests/integration/setup"
+	"github.com/stretchr/testify/require"
+)
+
+// TestRefreshToken tests the POST /auth/refresh endpoint
+func TestRefreshToken(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test in short mode")
+	}
+
+	ctx := context.Background()
+
+	t.Log("=== Starting Test Infrastructure ===")
+	infra, err := setup.StartInfra(ctx, t)
+	require.NoError(t, err, "infrastructure should start successfully")
+	defer func() { _ = infra.Terminate(ctx, t) }()
+
+	t.Log("=== Running Database Migrations ===")
+	_ = setup.RunMigration(infra.PgURL, t)
+
+	t.Log("=== Setting Up Test Application ===")
+	app, db, _, _ := setup.SetupTestApp(t, infra.PgURL, infra.RedisURL, infra.MinioURL, infra.MailhogSMTP)
+	defer db.Close()
+
+	// Create test user and login to get a refresh token
+	t.Log("=== Setup: Creating Test User ===")
+	_ = createTestUser(t, app, infra.MailhogURL, "refreshuser@example.com", "refreshuser", "pass123")
+
+	reqBody := []byte(`{"username":"refreshuser","password":"pass123"}`)
+	req := setup.CreateJSONRequest(http.MethodPost, "/api/auth/login", reqBody)
+	resp, err := app.Test(req)
+	require.NoError(t, err, "login should succeed")
+	require.Equal(t, 200, resp.StatusCode, "login should return 200")
+
+	result := setup.ParseJSONResponse(t, resp)
+	firstRefreshToken := result["refreshToken"].(string)
+
+	// Test 1: Refresh with a valid refresh token
+	t.Log("=== Test 1: Refresh With Valid Refresh Token ===")
+	reqBody = []byte(fmt.Sprintf(`{"refreshToken":"%s"}`, firstRefreshToken))
+	req = setup.CreateJSONRequest(http.MethodPost, "/api/auth/refresh", reqBody)
+	resp, err = app.Test(req)
+	require.NoError(t, err, "refresh request should complete")
+	require.Equal(t, 200, resp.StatusCode, "refresh should return 200")
+
+	result = setup.ParseJSONResponse(t, resp)
+	accessToken := result["accessToken"].(string)
+	secondRefreshToken := result["refreshToken"].(string)
+	require.NotEmpty(t, accessToken, "accessToken should not be empty")
+	require.NotEqual(t, firstRefreshToken, secondRefreshToken, "refresh token should be rotated")
+	require.Equal(t, float64(7*24*60*60), result["refreshTokenExpiresIn"], "refresh token should live for 7 days")
+
+	req = setup.CreateAuthRequest(http.MethodGet, "/api/users/me", nil, accessToken)
+	resp, err = app.Test(req)
+	require.NoError(t, err, "request should complete")
+	require.Equal(t, 200, resp.StatusCode, "new access token should be accepted")
+
+	t.Log("✓ Refresh token rotated successfully")
+
+	// Test 2: Refresh with empty refresh token
+	t.Log("=== Test 2: Refresh With Empty Refresh Token ===")
+	req = setup.CreateJSONRequest(http.MethodPost, "/api/auth/refresh", []byte(`{"refreshToken":""}`))
+	resp, err = app.Test(req)
+	require.NoError(t, err, "request should complete")
+	require.Equal(t, 400, resp.StatusCode, "empty refresh token should return 400")
+
+	result = setup.ParseJSONResponse(t, resp)
+	code, message, param := setup.ParseErrorDetail(t, result)
+	require.Equal(t, "VALIDATION_ERROR", code, "error code should be VALIDATION_ERROR")
+	require.Equal(t, "refreshToken", param, "error param should be 'refreshToken'")
+
+	t.Logf("✓ Validation Error: Code=%s, Message=%s, Param=%s", code, message, param)
+
+	// Test 3: Refresh with unknown refresh token
+	t.Log("=== Test 3: Refresh With Unknown Refresh Token ===")
+	req = setup.CreateJSONRequest(http.MethodPost, "/api/auth/refresh", []byte(`{"refreshToken":"unknown-refresh-token"}`))
+	resp, err = app.Test(req)
+	require.NoError(t, err, "request should complete")
+	require.Equal(t, 400, resp.StatusCode, "unknown refresh token should return 400")
+
+	t.Log("✓ Unknown refresh token rejected")
+
+	// Test 4: Reuse an already rotated refresh token revokes the whole family
+	t.Log("=== Test 4: Reuse Rotated Refresh Token ===")
+	reqBody = []byte(fmt.Sprintf(`{"refreshToken":"%s"}`, firstRefreshToken))
+	req = setup.CreateJSONRequest(http.MethodPost, "/api/auth/refresh", reqBody)
+	resp, err = app.Test(req)
+	require.NoError(t, err, "request should complete")
+	require.Equal(t, 400, resp.StatusCode, "reused refresh token should return 400")
+
+	result = setup.ParseJSONResponse(t, resp)
+	code, message, param = setup.ParseErrorDetail(t, result)
+	require.Equal(t, "refreshToken", param, "error param should be 'refreshToken'")
+	require.Contains(t, message, "already been used", "error message should mention reuse")
+
+	req = setup.CreateAuthRequest(http.MethodGet, "/api/users/me", nil, accessToken)
+	resp, err = app.Test(req)
+	require.NoError(t, err, "request should complete")
+	require.NotEqual(t, 200, resp.StatusCode, "access token of the revoked family should be rejected")
+
+	reqBody = []byte(fmt.Sprintf(`{"refreshToken":"%s"}`, secondRefreshToken))
+	req = setup.CreateJSONRequest(http.MethodPost, "/api/auth/refresh", reqBody)
+	resp, err = app.Test(req)
+	require.NoError(t, err, "request should complete")
+	require.Equal(t, 400, resp.StatusCode, "latest refresh token of the revoked family should be rejected")
+
+	t.Logf("✓ Token family revoked: Code=%s, Message=%s", code, message)
+
+	t.Log("=== All Refresh Token Tests Passed ===")
+}
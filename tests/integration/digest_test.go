@@ -0,0 +1,264 @@
+package integration
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/quotedprintable"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/knadh/koanf/v2"
+	"go.uber.org/zap"
+
+	"github.com/ferdian3456/virdanproject/internal/repository"
+	"github.com/ferdian3456/virdanproject/internal/usecase"
+	"github.com/ferdian3456/virdanproject/tests/integration/setup"
+	"github.com/stretchr/testify/require"
+)
+
+// getTestUnsubscribePath is a helper function to read the unsubscribe link of the digest sent to the email
+func getTestUnsubscribePath(t *testing.T, mailhogURL, email string) (string, string) {
+	// #nosec G107 -- mailhogURL is a trusted localhost test server (MailHog)
+	resp, err := http.Get(fmt.Sprintf("%s/api/v1/messages", mailhogURL))
+	require.NoError(t, err, "failed to fetch messages from MailHog")
+	defer func() { _ = resp.Body.Close() }()
+
+	var messages []struct {
+		Content struct {
+			Headers map[string][]string `json:"Headers"`
+		} `json:"Content"`
+	}
+	err = json.NewDecoder(resp.Body).Decode(&messages)
+	require.NoError(t, err, "failed to parse MailHog JSON response")
+
+	for _, message := range messages {
+		headers := message.Content.Headers
+		if len(headers["To"]) == 0 || headers["To"][0] != email || len(headers["List-Unsubscribe"]) == 0 {
+			continue
+		}
+
+		link, err := url.Parse(strings.Trim(headers["List-Unsubscribe"][0], "<>"))
+		require.NoError(t, err, "unsubscribe link should be a url")
+		require.Equal(t, []string{"List-Unsubscribe=One-Click"}, headers["List-Unsubscribe-Post"], "digest should offer one-click unsubscribe")
+
+		return link.Path, link.Query().Get("token")
+	}
+
+	t.Fatalf("no digest found in MailHog for %s", email)
+	return "", ""
+}
+
+// getTestDigestBody is a helper function to read the decoded body of the digest sent to the email
+func getTestDigestBody(t *testing.T, mailhogURL, email string) string {
+	// #nosec G107 -- mailhogURL is a trusted localhost test server (MailHog)
+	resp, err := http.Get(fmt.Sprintf("%s/api/v1/messages", mailhogURL))
+	require.NoError(t, err, "failed to fetch messages from MailHog")
+	defer func() { _ = resp.Body.Close() }()
+
+	var messages []struct {
+		Content struct {
+			Headers map[string][]string `json:"Headers"`
+			Body    string              `json:"Body"`
+		} `json:"Content"`
+	}
+	err = json.NewDecoder(resp.Body).Decode(&messages)
+	require.NoError(t, err, "failed to parse MailHog JSON response")
+
+	for _, message := range messages {
+		headers := message.Content.Headers
+		if len(headers["To"]) == 0 || headers["To"][0] != email || len(headers["List-Unsubscribe"]) == 0 {
+			continue
+		}
+
+		body, err := io.ReadAll(quotedprintable.NewReader(strings.NewReader(message.Content.Body)))
+		require.NoError(t, err, "digest body should be quoted-printable")
+
+		return string(body)
+	}
+
+	t.Fatalf("no digest found in MailHog for %s", email)
+	return ""
+}
+
+// updateTestDigestFrequency is a helper function to choose how often the user gets the digest
+func updateTestDigestFrequency(t *testing.T, app *fiber.App, accessToken, frequency string) {
+	body := []byte(fmt.Sprintf(`{"mutedTypes":[],"digestFrequency":"%s"}`, frequency))
+	req := setup.CreateAuthRequest(http.MethodPut, "/api/users/me/notifications/settings", body, accessToken)
+	resp, err := app.Test(req)
+	require.NoError(t, err, "update settings request should complete")
+	require.Equal(t, 200, resp.StatusCode, "update settings should return 200")
+}
+
+// getTestDigestFrequency is a helper function to read the digest frequency of the user
+func getTestDigestFrequency(t *testing.T, app *fiber.App, accessToken string) string {
+	req := setup.CreateAuthRequest(http.MethodGet, "/api/users/me/notifications/settings", nil, accessToken)
+	resp, err := app.Test(req)
+	require.NoError(t, err, "get settings request should complete")
+	require.Equal(t, 200, resp.StatusCode, "get settings should return 200")
+
+	result := setup.ParseJSONResponse(t, resp)
+	return result["digestFrequency"].(string)
+}
+
+// TestDigests tests the daily and weekly digest emails and the unsubscribe link
+func TestDigests(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test in short mode")
+	}
+
+	ctx := context.Background()
+
+	t.Log("=== Starting Test Infrastructure ===")
+	infra, err := setup.StartInfra(ctx, t)
+	require.NoError(t, err, "infrastructure should start successfully")
+	defer func() { _ = infra.Terminate(ctx, t) }()
+
+	t.Log("=== Running Database Migrations ===")
+	_ = setup.RunMigration(infra.PgURL, t)
+
+	t.Log("=== Setting Up Test Application ===")
+	app, db, _, _ := setup.SetupTestApp(t, infra.PgURL, infra.RedisURL, infra.MinioURL, infra.MailhogSMTP)
+	defer db.Close()
+
+	smtpParts := strings.Split(infra.MailhogSMTP, ":")
+	smtpPort, _ := strconv.Atoi(smtpParts[1])
+
+	digestConfig := koanf.New(".")
+	_ = digestConfig.Set("SMTP_HOST", smtpParts[0])
+	_ = digestConfig.Set("SMTP_PORT", smtpPort)
+	_ = digestConfig.Set("SENDER_NAME", "Virdan Test <noreply@virdan.test>")
+	_ = digestConfig.Set("SENDER_EMAIL", "noreply@virdan.test")
+	_ = digestConfig.Set("SENDER_PASSWORD", "")
+	_ = digestConfig.Set("JWT_SECRET_KEY", "test-secret-key-for-jwt-token-generation")
+	_ = digestConfig.Set("APP_URL", "http://localhost:8080")
+	digestRepository := repository.NewDigestRepository(zap.NewNop(), db, nil, nil)
+	digestUsecase := usecase.NewDigestUsecase(digestRepository, zap.NewNop(), digestConfig)
+
+	t.Log("=== Setup: Creating Users, Server And Activity ===")
+	ownerToken := createTestUser(t, app, infra.MailhogURL, "digestowner@example.com", "digestowner", "pass123")
+	memberToken := createTestUser(t, app, infra.MailhogURL, "digestmember@example.com", "digestmember", "pass123")
+	quietToken := createTestUser(t, app, infra.MailhogURL, "digestquiet@example.com", "digestquiet", "pass123")
+	server := createTestServer(t, app, ownerToken)
+	serverId := server["id"].(string)
+
+	inviteCode := createTestInvite(t, app, ownerToken, serverId, 5)
+	require.Equal(t, 200, joinFromInvite(t, app, memberToken, inviteCode), "member join should return 200")
+	require.Equal(t, 200, joinFromInvite(t, app, quietToken, inviteCode), "quiet member join should return 200")
+
+	require.Equal(t, "weekly", getTestDigestFrequency(t, app, ownerToken), "digest should default to weekly")
+	updateTestDigestFrequency(t, app, memberToken, "daily")
+	updateTestDigestFrequency(t, app, quietToken, "off")
+	require.Equal(t, "daily", getTestDigestFrequency(t, app, memberToken), "digest frequency should be stored")
+
+	req := setup.CreateAuthRequest(http.MethodPut, "/api/users/me/notifications/settings", []byte(`{"digestFrequency":"hourly"}`), ownerToken)
+	resp, err := app.Test(req)
+	require.NoError(t, err, "update settings request should complete")
+	require.Equal(t, 404, resp.StatusCode, "unknown frequency should be rejected")
+
+	postId := createTestPost(t, app, ownerToken, serverId, "Digest post")
+	likeTestPost(t, app, memberToken, postId, true)
+	createTestComment(t, app, memberToken, postId, "Digest comment", "")
+
+	now := time.Now().UTC()
+
+	// Test 1: Nothing is due before the first window has passed
+	t.Log("=== Test 1: Nothing Due ===")
+	report, err := digestUsecase.SendDigests(ctx, now)
+	require.NoError(t, err, "digest run should succeed")
+	require.Zero(t, report.Due, "fresh accounts should not be due")
+
+	t.Log("✓ Nothing due")
+
+	// Test 2: A day later only the daily recipient gets the new post of the owner
+	t.Log("=== Test 2: Daily Digest ===")
+	report, err = digestUsecase.SendDigests(ctx, now.Add(25*time.Hour))
+	require.NoError(t, err, "digest run should succeed")
+	require.Equal(t, 1, report.Sent, "only the daily digest should be sent")
+	require.Equal(t, 1, setup.CountMailhogMessages(t, infra.MailhogURL, "digestmember@example.com", "Your daily Virdan digest"), "member should get the daily digest")
+
+	report, err = digestUsecase.SendDigests(ctx, now.Add(25*time.Hour))
+	require.NoError(t, err, "digest run should succeed")
+	require.Zero(t, report.Due, "a sent digest should not be due again")
+
+	t.Log("✓ Daily digest sent once")
+
+	// Test 3: A week later the owner hears about the like and the comment, the member has nothing new
+	t.Log("=== Test 3: Weekly Digest ===")
+	report, err = digestUsecase.SendDigests(ctx, now.Add(8*24*time.Hour))
+	require.NoError(t, err, "digest run should succeed")
+	require.Equal(t, 1, report.Sent, "only the weekly digest should be sent")
+	require.Equal(t, 1, report.Skipped, "empty daily digest should be skipped")
+	require.Equal(t, 1, setup.CountMailhogMessages(t, infra.MailhogURL, "digestowner@example.com", "Your weekly Virdan digest"), "owner should get the weekly digest")
+	require.Equal(t, 1, setup.CountMailhogMessages(t, infra.MailhogURL, "digestmember@example.com", "Your daily Virdan digest"), "member should get no empty digest")
+	require.Zero(t, setup.CountMailhogMessages(t, infra.MailhogURL, "digestquiet@example.com", "Your weekly Virdan digest"), "turned off digest should not be sent")
+
+	t.Log("✓ Weekly digest sent")
+
+	// Test 4: The signed link turns the digest off without a login
+	t.Log("=== Test 4: Unsubscribe ===")
+	unsubscribePath, token := getTestUnsubscribePath(t, infra.MailhogURL, "digestowner@example.com")
+
+	resp, err = app.Test(setup.CreateJSONRequest(http.MethodGet, unsubscribePath+"?token="+url.QueryEscape(token), nil))
+	require.NoError(t, err, "unsubscribe page request should complete")
+	require.Equal(t, 200, resp.StatusCode, "unsubscribe page should return 200")
+	result := setup.ParseJSONResponse(t, resp)
+	require.Equal(t, "weekly", result["digestFrequency"], "unsubscribe page should show the current frequency")
+	require.Equal(t, "weekly", getTestDigestFrequency(t, app, ownerToken), "opening the link should not unsubscribe")
+
+	resp, err = app.Test(setup.CreateJSONRequest(http.MethodPost, unsubscribePath+"?token="+url.QueryEscape(token), nil))
+	require.NoError(t, err, "unsubscribe request should complete")
+	require.Equal(t, 200, resp.StatusCode, "unsubscribe should return 200")
+	require.Equal(t, "off", getTestDigestFrequency(t, app, ownerToken), "digest should be turned off")
+
+	flipped := "A"
+	if token[len(token)-5] == 'A' {
+		flipped = "B"
+	}
+	tampered := token[:len(token)-5] + flipped + token[len(token)-4:]
+
+	resp, err = app.Test(setup.CreateJSONRequest(http.MethodPost, unsubscribePath+"?token="+url.QueryEscape(tampered), nil))
+	require.NoError(t, err, "unsubscribe request should complete")
+	require.Equal(t, 404, resp.StatusCode, "tampered token should be rejected")
+
+	createTestComment(t, app, memberToken, postId, "After unsubscribe", "")
+	report, err = digestUsecase.SendDigests(ctx, now.Add(16*24*time.Hour))
+	require.NoError(t, err, "digest run should succeed")
+	require.Equal(t, 1, setup.CountMailhogMessages(t, infra.MailhogURL, "digestowner@example.com", "Your weekly Virdan digest"), "unsubscribed owner should get no more digests")
+
+	t.Log("✓ Unsubscribed")
+
+	// Test 5: An account older than digests only hears about the last period, not everything since signup
+	t.Log("=== Test 5: Legacy Account ===")
+	legacyToken := createTestUser(t, app, infra.MailhogURL, "digestlegacy@example.com", "digestlegacy", "pass123")
+	require.Equal(t, 200, joinFromInvite(t, app, legacyToken, inviteCode), "legacy member join should return 200")
+	updateTestDigestFrequency(t, app, legacyToken, "daily")
+
+	_, err = db.Exec(ctx, "UPDATE users SET create_datetime = $1, digest_sent_datetime = NULL WHERE id = $2", now.Add(-60*24*time.Hour), getUserId(t, app, legacyToken))
+	require.NoError(t, err, "should backdate the legacy account")
+
+	oldPostId := createTestPost(t, app, ownerToken, serverId, "Old news")
+	freshPostId := createTestPost(t, app, ownerToken, serverId, "Fresh news")
+	_, err = db.Exec(ctx, "UPDATE server_posts SET create_datetime = $1 WHERE id = $2", now.Add(-30*24*time.Hour), oldPostId)
+	require.NoError(t, err, "should backdate the old post")
+	_, err = db.Exec(ctx, "UPDATE server_posts SET create_datetime = $1 WHERE id = $2", now.Add(16*24*time.Hour+12*time.Hour), freshPostId)
+	require.NoError(t, err, "should move the fresh post into the window")
+
+	_, err = digestUsecase.SendDigests(ctx, now.Add(17*24*time.Hour))
+	require.NoError(t, err, "digest run should succeed")
+	require.Equal(t, 1, setup.CountMailhogMessages(t, infra.MailhogURL, "digestlegacy@example.com", "Your daily Virdan digest"), "legacy account should get one digest")
+
+	body := getTestDigestBody(t, infra.MailhogURL, "digestlegacy@example.com")
+	require.Contains(t, body, "Fresh news", "digest should include the post of the last day")
+	require.NotContains(t, body, "Old news", "digest should not reach back to the signup")
+
+	t.Log("✓ Legacy account capped at one period")
+
+	t.Log("=== All Digest Tests Passed ===")
+}
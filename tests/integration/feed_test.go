@@ -0,0 +1,147 @@
+package integration
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/ferdian3456/virdanproject/tests/integration/setup"
+	"github.com/stretchr/testify/require"
+)
+
+// getTestFeed is a helper function to read a page of the home feed
+func getTestFeed(t *testing.T, app *fiber.App, accessToken, query string) ([]interface{}, string) {
+	req := setup.CreateAuthRequest(http.MethodGet, "/api/feed"+query, nil, accessToken)
+	resp, err := app.Test(req)
+	require.NoError(t, err, "get feed request should complete")
+	require.Equal(t, 200, resp.StatusCode, "get feed should return 200")
+
+	result := setup.ParseJSONResponse(t, resp)
+	page := result["page"].(map[string]interface{})
+
+	return result["data"].([]interface{}), page["nextCursor"].(string)
+}
+
+// feedPostIds is a helper function to list the post ids of feed items
+func feedPostIds(posts []interface{}) []string {
+	postIds := []string{}
+	for _, post := range posts {
+		postIds = append(postIds, post.(map[string]interface{})["postId"].(string))
+	}
+
+	return postIds
+}
+
+// TestFeed tests the home feed across the servers of a member
+func TestFeed(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test in short mode")
+	}
+
+	ctx := context.Background()
+
+	t.Log("=== Starting Test Infrastructure ===")
+	infra, err := setup.StartInfra(ctx, t)
+	require.NoError(t, err, "infrastructure should start successfully")
+	defer func() { _ = infra.Terminate(ctx, t) }()
+
+	t.Log("=== Running Database Migrations ===")
+	_ = setup.RunMigration(infra.PgURL, t)
+
+	t.Log("=== Setting Up Test Application ===")
+	app, db, _, _ := setup.SetupTestApp(t, infra.PgURL, infra.RedisURL, infra.MinioURL, infra.MailhogSMTP)
+	defer db.Close()
+
+	t.Log("=== Setup: Creating Users, Servers And Posts ===")
+	firstOwnerToken := createTestUser(t, app, infra.MailhogURL, "feedowner1@example.com", "feedowner1", "pass123")
+	secondOwnerToken := createTestUser(t, app, infra.MailhogURL, "feedowner2@example.com", "feedowner2", "pass123")
+	strangerToken := createTestUser(t, app, infra.MailhogURL, "feedstranger@example.com", "feedstranger", "pass123")
+	readerToken := createTestUser(t, app, infra.MailhogURL, "feedreader@example.com", "feedreader", "pass123")
+
+	firstServerId := createTestServer(t, app, firstOwnerToken)["id"].(string)
+	secondServerId := createTestServer(t, app, secondOwnerToken)["id"].(string)
+	strangerServerId := createTestServer(t, app, strangerToken)["id"].(string)
+
+	require.Equal(t, 200, joinFromInvite(t, app, readerToken, createTestInvite(t, app, firstOwnerToken, firstServerId, 5)), "join should return 200")
+	require.Equal(t, 200, joinFromInvite(t, app, readerToken, createTestInvite(t, app, secondOwnerToken, secondServerId, 5)), "join should return 200")
+
+	firstPostId := createTestPost(t, app, firstOwnerToken, firstServerId, "First server post")
+	secondPostId := createTestPost(t, app, secondOwnerToken, secondServerId, "Second server post")
+	createTestPost(t, app, strangerToken, strangerServerId, "Stranger post")
+	readerPostId := createTestPost(t, app, readerToken, firstServerId, "Reader post")
+
+	// Test 1: The feed merges the servers of the member, newest first
+	t.Log("=== Test 1: Merged Feed ===")
+	posts, nextCursor := getTestFeed(t, app, readerToken, "")
+	require.Equal(t, []string{readerPostId, secondPostId, firstPostId}, feedPostIds(posts), "feed should hold the posts of both servers only")
+	require.Empty(t, nextCursor, "single page should have no next cursor")
+
+	second := posts[1].(map[string]interface{})
+	server := second["server"].(map[string]interface{})
+	require.Equal(t, secondServerId, server["id"], "item should carry its server")
+	require.Equal(t, "Test Server", server["name"], "item should carry the server name")
+	require.Contains(t, server, "avatarImageUrls", "item should carry the server avatar")
+
+	author := second["author"].(map[string]interface{})
+	require.Equal(t, "feedowner2", author["username"], "item should carry the author")
+	require.Contains(t, author, "avatarImageUrls", "item should carry the author avatar")
+	require.Equal(t, "Second server post", second["caption"], "item should carry the post")
+	require.NotEmpty(t, second["media"], "item should carry the post media")
+	require.False(t, second["isLiked"].(bool), "post should not be liked yet")
+
+	t.Log("✓ Feed merged")
+
+	// Test 2: The like state is the one of the caller
+	t.Log("=== Test 2: Like State ===")
+	likeTestPost(t, app, readerToken, secondPostId, true)
+	likeTestPost(t, app, firstOwnerToken, firstPostId, true)
+
+	posts, _ = getTestFeed(t, app, readerToken, "")
+	require.True(t, posts[1].(map[string]interface{})["isLiked"].(bool), "liked post should be marked")
+	require.Equal(t, float64(1), posts[1].(map[string]interface{})["likeCount"], "like count should be carried")
+	require.False(t, posts[2].(map[string]interface{})["isLiked"].(bool), "likes of others should not be marked")
+
+	t.Log("✓ Like state tracked")
+
+	// Test 3: The cursor pages through the merged feed
+	t.Log("=== Test 3: Cursor Pagination ===")
+	firstPage, nextCursor := getTestFeed(t, app, readerToken, "?limit=2")
+	require.Equal(t, []string{readerPostId, secondPostId}, feedPostIds(firstPage), "first page should be full")
+	require.NotEmpty(t, nextCursor, "first page should have a next cursor")
+
+	secondPage, nextCursor := getTestFeed(t, app, readerToken, "?limit=2&cursor="+nextCursor)
+	require.Equal(t, []string{firstPostId}, feedPostIds(secondPage), "second page should hold the rest")
+	require.Empty(t, nextCursor, "last page should have no next cursor")
+
+	req := setup.CreateAuthRequest(http.MethodGet, "/api/feed?limit=1000", nil, readerToken)
+	resp, err := app.Test(req)
+	require.NoError(t, err, "get feed request should complete")
+	require.Equal(t, 404, resp.StatusCode, "limit above max should be rejected")
+
+	req = setup.CreateAuthRequest(http.MethodGet, "/api/feed?limit=0", nil, readerToken)
+	resp, err = app.Test(req)
+	require.NoError(t, err, "get feed request should complete")
+	require.Equal(t, 404, resp.StatusCode, "zero limit should be rejected")
+
+	t.Log("✓ Feed paginated")
+
+	// Test 4: Leaving a server removes its posts from the feed
+	t.Log("=== Test 4: Leave Server ===")
+	req = setup.CreateAuthRequest(http.MethodPost, fmt.Sprintf("/api/servers/%s/leave", secondServerId), nil, readerToken)
+	resp, err = app.Test(req)
+	require.NoError(t, err, "leave request should complete")
+	require.Equal(t, 200, resp.StatusCode, "leave should return 200")
+
+	posts, _ = getTestFeed(t, app, readerToken, "")
+	require.Equal(t, []string{readerPostId, firstPostId}, feedPostIds(posts), "posts of the left server should be gone")
+
+	posts, _ = getTestFeed(t, app, strangerToken, "")
+	require.Len(t, posts, 1, "stranger should only see their own server")
+
+	t.Log("✓ Feed follows membership")
+
+	t.Log("=== All Feed Tests Passed ===")
+}
@@ -0,0 +1,217 @@
+package integration
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/h2non/bimg"
+	"github.com/minio/minio-go/v7"
+
+	"github.com/ferdian3456/virdanproject/tests/integration/setup"
+	"github.com/stretchr/testify/require"
+)
+
+// uploadTestServerImage is a helper function to upload a server avatar or banner, empty data removes it
+func uploadTestServerImage(t *testing.T, app *fiber.App, accessToken, serverId, fieldName string, imageData []byte) *http.Response {
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+
+	h := make(textproto.MIMEHeader)
+	h.Set("Content-Disposition", fmt.Sprintf(`form-data; name="%s"; filename="%s.jpg"`, fieldName, fieldName))
+	h.Set("Content-Type", "image/jpeg")
+	part, err := writer.CreatePart(h)
+	require.NoError(t, err, "should create form part")
+	_, err = part.Write(imageData)
+	require.NoError(t, err, "should write image data")
+
+	err = writer.Close()
+	require.NoError(t, err, "should close writer")
+
+	req := setup.CreateAuthRequest(http.MethodPut, fmt.Sprintf("/api/servers/%s/%s", serverId, fieldName), body.Bytes(), accessToken)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	resp, err := app.Test(req, -1)
+	require.NoError(t, err, "upload request should complete")
+
+	return resp
+}
+
+// getObjectImageSize is a helper function to read the dimensions of an image stored in MinIO
+func getObjectImageSize(t *testing.T, minioClient *minio.Client, objectKey string) bimg.ImageSize {
+	object, err := minioClient.GetObject(context.Background(), "virdan-test", objectKey, minio.GetObjectOptions{})
+	require.NoError(t, err, "should open object")
+	defer func() { _ = object.Close() }()
+
+	data, err := io.ReadAll(object)
+	require.NoError(t, err, "should read object")
+
+	size, err := bimg.NewImage(data).Size()
+	require.NoError(t, err, "object should be a valid image")
+
+	return size
+}
+
+// TestImageVariants tests the processing profiles and the stored variants of every upload
+func TestImageVariants(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test in short mode")
+	}
+
+	ctx := context.Background()
+
+	t.Log("=== Starting Test Infrastructure ===")
+	infra, err := setup.StartInfra(ctx, t)
+	require.NoError(t, err, "infrastructure should start successfully")
+	defer func() { _ = infra.Terminate(ctx, t) }()
+
+	t.Log("=== Running Database Migrations ===")
+	_ = setup.RunMigration(infra.PgURL, t)
+
+	t.Log("=== Setting Up Test Application ===")
+	app, db, _, minioClient := setup.SetupTestApp(t, infra.PgURL, infra.RedisURL, infra.MinioURL, infra.MailhogSMTP)
+	defer db.Close()
+
+	t.Log("=== Setup: Creating User And Server ===")
+	accessToken := createTestUser(t, app, infra.MailhogURL, "variantuser@example.com", "variantuser", "pass123")
+	server := createTestServer(t, app, accessToken)
+	serverId := server["id"].(string)
+
+	testImageData, err := getTestImage()
+	require.NoError(t, err, "should read test image")
+
+	sourceSize, err := bimg.NewImage(testImageData).Size()
+	require.NoError(t, err, "test image should be readable")
+
+	// Test 1: Banner variants are cropped to 3:1 and the original is kept
+	t.Log("=== Test 1: Upload Server Banner ===")
+	resp := uploadTestServerImage(t, app, accessToken, serverId, "banner", testImageData)
+	require.Equal(t, 200, resp.StatusCode, "upload banner should return 200")
+
+	var firstObjectKey, mimeType string
+	var firstVariants map[string]string
+	err = db.QueryRow(ctx, `SELECT B.object_key, B.mime_type, B.variants FROM servers A
+		INNER JOIN server_banner_images B ON B.id = A.banner_image_id
+		WHERE A.id = $1`, serverId).Scan(&firstObjectKey, &mimeType, &firstVariants)
+	require.NoError(t, err, "server should reference the banner row")
+	require.Equal(t, "image/jpeg", mimeType, "original should keep its type")
+
+	object, err := minioClient.GetObject(ctx, "virdan-test", firstObjectKey, minio.GetObjectOptions{})
+	require.NoError(t, err, "should open original")
+	original, err := io.ReadAll(object)
+	_ = object.Close()
+	require.NoError(t, err, "should read original")
+	require.Equal(t, testImageData, original, "original should be stored untouched")
+
+	expectedSizes := map[string][2]int{"thumb": {480, 160}, "medium": {960, 320}, "large": {1920, 640}}
+	require.Len(t, firstVariants, len(expectedSizes), "banner should have every variant")
+	for name, expected := range expectedSizes {
+		size := getObjectImageSize(t, minioClient, firstVariants[name])
+		require.Equal(t, expected[0], size.Width, "%s banner width should match the profile", name)
+		require.Equal(t, expected[1], size.Height, "%s banner height should match the profile", name)
+	}
+
+	t.Log("✓ Banner variants stored")
+
+	// Test 2: Responses expose the variant urls, never the original
+	t.Log("=== Test 2: Banner Urls ===")
+	inviteCode := createTestInvite(t, app, accessToken, serverId, 1)
+	req := setup.CreateJSONRequest(http.MethodGet, "/api/servers/invites/"+inviteCode, nil)
+	resp, err = app.Test(req)
+	require.NoError(t, err, "preview request should complete")
+	require.Equal(t, 200, resp.StatusCode, "preview should return 200")
+
+	result := setup.ParseJSONResponse(t, resp)
+	bannerImageUrls, ok := result["bannerImageUrls"].(map[string]interface{})
+	require.True(t, ok, "banner urls should be a map")
+	require.Len(t, bannerImageUrls, len(expectedSizes), "every variant should have an url")
+	for name, url := range bannerImageUrls {
+		require.Contains(t, url, firstVariants[name], "%s url should point at its variant", name)
+		require.NotContains(t, url, firstObjectKey, "original should not be exposed")
+	}
+
+	t.Log("✓ Banner urls exposed")
+
+	// Test 3: Replacing the banner keeps the server and removes the old objects
+	t.Log("=== Test 3: Replace Server Banner ===")
+	resp = uploadTestServerImage(t, app, accessToken, serverId, "banner", testImageData)
+	require.Equal(t, 200, resp.StatusCode, "replace banner should return 200")
+
+	var total int
+	err = db.QueryRow(ctx, "SELECT COUNT(*) FROM server_banner_images").Scan(&total)
+	require.NoError(t, err, "should count banner rows")
+	require.Equal(t, 1, total, "only one banner row should remain")
+
+	err = db.QueryRow(ctx, "SELECT COUNT(*) FROM servers WHERE id = $1", serverId).Scan(&total)
+	require.NoError(t, err, "should count servers")
+	require.Equal(t, 1, total, "server should survive the replaced banner")
+
+	for _, objectKey := range append([]string{firstObjectKey}, firstVariants["thumb"], firstVariants["medium"], firstVariants["large"]) {
+		_, err = minioClient.StatObject(ctx, "virdan-test", objectKey, minio.StatObjectOptions{})
+		require.Error(t, err, "old banner object should be removed from MinIO")
+	}
+
+	t.Log("✓ Banner replaced")
+
+	// Test 4: An empty file removes the banner
+	t.Log("=== Test 4: Remove Server Banner ===")
+	resp = uploadTestServerImage(t, app, accessToken, serverId, "banner", []byte{})
+	require.Equal(t, 200, resp.StatusCode, "remove banner should return 200")
+
+	err = db.QueryRow(ctx, "SELECT COUNT(*) FROM server_banner_images").Scan(&total)
+	require.NoError(t, err, "should count banner rows")
+	require.Equal(t, 0, total, "banner row should be deleted")
+
+	err = db.QueryRow(ctx, "SELECT COUNT(*) FROM servers WHERE id = $1", serverId).Scan(&total)
+	require.NoError(t, err, "should count servers")
+	require.Equal(t, 1, total, "server should survive the removed banner")
+
+	t.Log("✓ Banner removed")
+
+	// Test 5: Server avatar variants are square
+	t.Log("=== Test 5: Upload Server Avatar ===")
+	resp = uploadTestServerImage(t, app, accessToken, serverId, "avatar", testImageData)
+	require.Equal(t, 200, resp.StatusCode, "upload avatar should return 200")
+
+	var avatarVariants map[string]string
+	err = db.QueryRow(ctx, `SELECT B.variants FROM servers A
+		INNER JOIN server_avatar_images B ON B.id = A.avatar_image_id
+		WHERE A.id = $1`, serverId).Scan(&avatarVariants)
+	require.NoError(t, err, "server should reference the avatar row")
+
+	for name, side := range map[string]int{"thumb": 64, "medium": 256, "large": 512} {
+		size := getObjectImageSize(t, minioClient, avatarVariants[name])
+		require.Equal(t, side, size.Width, "%s avatar width should match the profile", name)
+		require.Equal(t, side, size.Height, "%s avatar height should match the profile", name)
+	}
+
+	t.Log("✓ Avatar variants stored")
+
+	// Test 6: Post photos are fitted, not cropped
+	t.Log("=== Test 6: Post Photo Variants ===")
+	postId := createTestPost(t, app, accessToken, serverId, "Photo post")
+
+	var postVariants map[string]string
+	err = db.QueryRow(ctx, `SELECT B.variants FROM server_post_media A
+		INNER JOIN server_post_images B ON B.id = A.post_image_id
+		WHERE A.post_id = $1`, postId).Scan(&postVariants)
+	require.NoError(t, err, "post image row should exist")
+
+	sourceRatio := float64(sourceSize.Width) / float64(sourceSize.Height)
+	for name, bound := range map[string]int{"thumb": 320, "medium": 1080, "large": 2048} {
+		size := getObjectImageSize(t, minioClient, postVariants[name])
+		require.LessOrEqual(t, max(size.Width, size.Height), bound, "%s photo should fit inside its box", name)
+		require.LessOrEqual(t, size.Width, sourceSize.Width, "%s photo should never be enlarged", name)
+		require.InDelta(t, sourceRatio, float64(size.Width)/float64(size.Height), 0.02*math.Max(1, sourceRatio), "%s photo should keep the aspect ratio", name)
+	}
+
+	t.Log("✓ Post photos fitted")
+
+	t.Log("=== All Image Variant Tests Passed ===")
+}
@@ -0,0 +1,221 @@
+package integration
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+
+	"github.com/ferdian3456/virdanproject/tests/integration/setup"
+	"github.com/stretchr/testify/require"
+)
+
+// getTestNotifications is a helper function to read a page of the inbox
+func getTestNotifications(t *testing.T, app *fiber.App, accessToken, query string) ([]interface{}, string) {
+	req := setup.CreateAuthRequest(http.MethodGet, "/api/users/me/notifications"+query, nil, accessToken)
+	resp, err := app.Test(req)
+	require.NoError(t, err, "get notifications request should complete")
+	require.Equal(t, 200, resp.StatusCode, "get notifications should return 200")
+
+	result := setup.ParseJSONResponse(t, resp)
+	page := result["page"].(map[string]interface{})
+
+	return result["data"].([]interface{}), page["nextCursor"].(string)
+}
+
+// getTestUnreadCount is a helper function to read the unread notification count
+func getTestUnreadCount(t *testing.T, app *fiber.App, accessToken string) int {
+	req := setup.CreateAuthRequest(http.MethodGet, "/api/users/me/notifications/unread-count", nil, accessToken)
+	resp, err := app.Test(req)
+	require.NoError(t, err, "unread count request should complete")
+	require.Equal(t, 200, resp.StatusCode, "unread count should return 200")
+
+	result := setup.ParseJSONResponse(t, resp)
+	return int(result["unreadCount"].(float64))
+}
+
+// likeTestPost is a helper function to like or unlike a post
+func likeTestPost(t *testing.T, app *fiber.App, accessToken, postId string, like bool) {
+	method := http.MethodPost
+	if !like {
+		method = http.MethodDelete
+	}
+
+	req := setup.CreateAuthRequest(method, fmt.Sprintf("/api/posts/%s/likes", postId), nil, accessToken)
+	resp, err := app.Test(req)
+	require.NoError(t, err, "like request should complete")
+	require.Equal(t, 200, resp.StatusCode, "like should return 200")
+}
+
+// notificationTypes is a helper function to list the types of inbox entries
+func notificationTypes(notifications []interface{}) []string {
+	types := []string{}
+	for _, notification := range notifications {
+		types = append(types, notification.(map[string]interface{})["type"].(string))
+	}
+
+	return types
+}
+
+// TestNotifications tests the notification producers, the inbox and the mute settings
+func TestNotifications(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test in short mode")
+	}
+
+	ctx := context.Background()
+
+	t.Log("=== Starting Test Infrastructure ===")
+	infra, err := setup.StartInfra(ctx, t)
+	require.NoError(t, err, "infrastructure should start successfully")
+	defer func() { _ = infra.Terminate(ctx, t) }()
+
+	t.Log("=== Running Database Migrations ===")
+	_ = setup.RunMigration(infra.PgURL, t)
+
+	t.Log("=== Setting Up Test Application ===")
+	app, db, _, _ := setup.SetupTestApp(t, infra.PgURL, infra.RedisURL, infra.MinioURL, infra.MailhogSMTP)
+	defer db.Close()
+
+	t.Log("=== Setup: Creating Users And Server ===")
+	ownerToken := createTestUser(t, app, infra.MailhogURL, "notifyowner@example.com", "notifyowner", "pass123")
+	memberToken := createTestUser(t, app, infra.MailhogURL, "notifymember@example.com", "notifymember", "pass123")
+	server := createTestServer(t, app, ownerToken)
+	serverId := server["id"].(string)
+
+	// Test 1: The owner hears about new members
+	t.Log("=== Test 1: Member Joined ===")
+	inviteCode := createTestInvite(t, app, ownerToken, serverId, 5)
+	require.Equal(t, 200, joinFromInvite(t, app, memberToken, inviteCode), "join should return 200")
+
+	notifications, _ := getTestNotifications(t, app, ownerToken, "")
+	require.Equal(t, []string{"member_joined"}, notificationTypes(notifications), "owner should be told about the member")
+	joined := notifications[0].(map[string]interface{})
+	require.Equal(t, "notifymember", joined["actorUsername"], "notification should name the actor")
+	require.Equal(t, serverId, joined["serverId"], "notification should carry the server")
+	require.False(t, joined["isRead"].(bool), "notification should start unread")
+
+	t.Log("✓ Member joined notification created")
+
+	// Test 2: Likes notify the author, but not when the author likes their own post
+	t.Log("=== Test 2: Post Liked ===")
+	postId := createTestPost(t, app, ownerToken, serverId, "Notified post")
+	likeTestPost(t, app, ownerToken, postId, true)
+	likeTestPost(t, app, memberToken, postId, true)
+
+	notifications, _ = getTestNotifications(t, app, ownerToken, "")
+	require.Equal(t, []string{"post_liked", "member_joined"}, notificationTypes(notifications), "only the like of the member should notify")
+	require.Equal(t, postId, notifications[0].(map[string]interface{})["postId"], "notification should carry the post")
+
+	t.Log("✓ Post liked notification created")
+
+	// Test 3: Comments notify the post author, replies the parent author, and nobody is told twice
+	t.Log("=== Test 3: Comments And Replies ===")
+	memberCommentId := createTestComment(t, app, memberToken, postId, "Member comment", "")
+	ownerReplyId := createTestComment(t, app, ownerToken, postId, "Owner reply", memberCommentId)
+	createTestComment(t, app, memberToken, postId, "Member reply", ownerReplyId)
+
+	notifications, _ = getTestNotifications(t, app, memberToken, "")
+	require.Equal(t, []string{"comment_replied"}, notificationTypes(notifications), "member should be told about the reply")
+	require.Equal(t, ownerReplyId, notifications[0].(map[string]interface{})["commentId"], "notification should carry the reply")
+
+	notifications, _ = getTestNotifications(t, app, ownerToken, "")
+	require.Equal(t, []string{"comment_replied", "post_commented", "post_liked", "member_joined"}, notificationTypes(notifications), "owner should get one notification per comment")
+
+	t.Log("✓ Comment notifications created")
+
+	// Test 4: The inbox is paginated newest first
+	t.Log("=== Test 4: Cursor Pagination ===")
+	firstPage, nextCursor := getTestNotifications(t, app, ownerToken, "?limit=3")
+	require.Len(t, firstPage, 3, "first page should be full")
+	require.NotEmpty(t, nextCursor, "first page should have a next cursor")
+
+	secondPage, nextCursor := getTestNotifications(t, app, ownerToken, "?limit=3&cursor="+nextCursor)
+	require.Equal(t, []string{"member_joined"}, notificationTypes(secondPage), "second page should hold the rest")
+	require.Empty(t, nextCursor, "last page should have no next cursor")
+
+	req := setup.CreateAuthRequest(http.MethodGet, "/api/users/me/notifications?limit=100", nil, ownerToken)
+	resp, err := app.Test(req)
+	require.NoError(t, err, "request should complete")
+	require.Equal(t, 404, resp.StatusCode, "limit above the maximum should be rejected")
+
+	req = setup.CreateAuthRequest(http.MethodGet, "/api/users/me/notifications?limit=0", nil, ownerToken)
+	resp, err = app.Test(req)
+	require.NoError(t, err, "request should complete")
+	require.Equal(t, 404, resp.StatusCode, "zero limit should be rejected")
+
+	t.Log("✓ Pagination works")
+
+	// Test 5: Read state and unread count
+	t.Log("=== Test 5: Mark Read ===")
+	require.Equal(t, 4, getTestUnreadCount(t, app, ownerToken), "every notification should be unread")
+
+	notificationId := firstPage[0].(map[string]interface{})["id"].(string)
+	for i := 0; i < 2; i++ {
+		req = setup.CreateAuthRequest(http.MethodPost, fmt.Sprintf("/api/users/me/notifications/%s/read", notificationId), nil, ownerToken)
+		resp, err = app.Test(req)
+		require.NoError(t, err, "mark read request should complete")
+		require.Equal(t, 200, resp.StatusCode, "mark read should return 200 every time")
+	}
+	require.Equal(t, 3, getTestUnreadCount(t, app, ownerToken), "marked notification should be read")
+
+	notifications, _ = getTestNotifications(t, app, ownerToken, "?limit=1")
+	require.True(t, notifications[0].(map[string]interface{})["isRead"].(bool), "notification should be read")
+
+	req = setup.CreateAuthRequest(http.MethodPost, fmt.Sprintf("/api/users/me/notifications/%s/read", notificationId), nil, memberToken)
+	resp, err = app.Test(req)
+	require.NoError(t, err, "mark read request should complete")
+	require.Equal(t, 404, resp.StatusCode, "users can not mark notifications of others")
+
+	req = setup.CreateAuthRequest(http.MethodPost, fmt.Sprintf("/api/users/me/notifications/%s/read", uuid.New()), nil, ownerToken)
+	resp, err = app.Test(req)
+	require.NoError(t, err, "mark read request should complete")
+	require.Equal(t, 404, resp.StatusCode, "unknown notification should return 404")
+
+	req = setup.CreateAuthRequest(http.MethodPost, "/api/users/me/notifications/read-all", nil, ownerToken)
+	resp, err = app.Test(req)
+	require.NoError(t, err, "mark all read request should complete")
+	require.Equal(t, 200, resp.StatusCode, "mark all read should return 200")
+	require.Equal(t, 0, getTestUnreadCount(t, app, ownerToken), "every notification should be read")
+	require.Equal(t, 1, getTestUnreadCount(t, app, memberToken), "other inboxes should be untouched")
+
+	t.Log("✓ Read state tracked")
+
+	// Test 6: Muted types are not produced
+	t.Log("=== Test 6: Mute Settings ===")
+	req = setup.CreateAuthRequest(http.MethodPut, "/api/users/me/notifications/settings", []byte(`{"mutedTypes":["unknown"]}`), ownerToken)
+	resp, err = app.Test(req)
+	require.NoError(t, err, "update settings request should complete")
+	require.Equal(t, 404, resp.StatusCode, "unknown type should be rejected")
+
+	req = setup.CreateAuthRequest(http.MethodPut, "/api/users/me/notifications/settings", []byte(`{"mutedTypes":["post_liked","post_liked"]}`), ownerToken)
+	resp, err = app.Test(req)
+	require.NoError(t, err, "update settings request should complete")
+	require.Equal(t, 200, resp.StatusCode, "update settings should return 200")
+
+	req = setup.CreateAuthRequest(http.MethodGet, "/api/users/me/notifications/settings", nil, ownerToken)
+	resp, err = app.Test(req)
+	require.NoError(t, err, "get settings request should complete")
+	result := setup.ParseJSONResponse(t, resp)
+	require.Equal(t, []interface{}{"post_liked"}, result["mutedTypes"], "muted types should be stored once")
+
+	likeTestPost(t, app, memberToken, postId, false)
+	likeTestPost(t, app, memberToken, postId, true)
+	createTestComment(t, app, memberToken, postId, "Still notified", "")
+
+	notifications, _ = getTestNotifications(t, app, ownerToken, "?limit=1")
+	require.Equal(t, []string{"post_commented"}, notificationTypes(notifications), "only the unmuted comment should notify")
+	require.Equal(t, 1, getTestUnreadCount(t, app, ownerToken), "muted like should not be counted")
+
+	var settings string
+	err = db.QueryRow(ctx, "SELECT settings::text FROM users WHERE username = 'notifyowner'").Scan(&settings)
+	require.NoError(t, err, "settings should be readable")
+	require.Contains(t, settings, "mutedNotificationTypes", "mute settings should live in users.settings")
+
+	t.Log("✓ Muted types skipped")
+
+	t.Log("=== All Notification Tests Passed ===")
+}
@@ -0,0 +1,136 @@
+package integration
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/knadh/koanf/v2"
+	"github.com/minio/minio-go/v7"
+	"go.uber.org/zap"
+
+	"github.com/ferdian3456/virdanproject/internal/model"
+	"github.com/ferdian3456/virdanproject/internal/repository"
+	"github.com/ferdian3456/virdanproject/internal/usecase"
+	"github.com/ferdian3456/virdanproject/tests/integration/setup"
+	"github.com/stretchr/testify/require"
+)
+
+// orphanedObjectKeys is a helper function to list the keys of a garbage collector report
+func orphanedObjectKeys(report model.OrphanedObjectReport) []string {
+	objectKeys := []string{}
+	for _, object := range report.Orphaned {
+		objectKeys = append(objectKeys, object.Key)
+	}
+
+	return objectKeys
+}
+
+// TestOrphanedObjectCollector tests the reconciliation of the bucket with the image tables
+func TestOrphanedObjectCollector(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test in short mode")
+	}
+
+	ctx := context.Background()
+
+	t.Log("=== Starting Test Infrastructure ===")
+	infra, err := setup.StartInfra(ctx, t)
+	require.NoError(t, err, "infrastructure should start successfully")
+	defer func() { _ = infra.Terminate(ctx, t) }()
+
+	t.Log("=== Running Database Migrations ===")
+	_ = setup.RunMigration(infra.PgURL, t)
+
+	t.Log("=== Setting Up Test Application ===")
+	app, db, _, minioClient := setup.SetupTestApp(t, infra.PgURL, infra.RedisURL, infra.MinioURL, infra.MailhogSMTP)
+	defer db.Close()
+
+	gcConfig := koanf.New(".")
+	_ = gcConfig.Set("MINIO_BUCKET_NAME", "virdan-test")
+	objectRepository := repository.NewObjectRepository(zap.NewNop(), db, nil, minioClient)
+	objectUsecase := usecase.NewObjectUsecase(objectRepository, zap.NewNop(), gcConfig)
+
+	t.Log("=== Setup: Creating User, Server, Post And Stray Objects ===")
+	accessToken := createTestUser(t, app, infra.MailhogURL, "gcuser@example.com", "gcuser", "pass123")
+	server := createTestServer(t, app, accessToken)
+	serverId := server["id"].(string)
+	postId := createTestPost(t, app, accessToken, serverId, "Kept post")
+
+	var postObjectKey string
+	err = db.QueryRow(ctx, `SELECT B.object_key FROM server_post_media A
+		INNER JOIN server_post_images B ON B.id = A.post_image_id
+		WHERE A.post_id = $1`, postId).Scan(&postObjectKey)
+	require.NoError(t, err, "post image row should exist")
+
+	strayKeys := []string{"server/post/stray/medium.webp", "user/avatar/stray/thumb.webp", "private/server/post/stray/large.webp"}
+	unmanagedKey := "backup/keep.txt"
+	for _, objectKey := range append(strayKeys, unmanagedKey) {
+		_, err = minioClient.PutObject(ctx, "virdan-test", objectKey, bytes.NewReader([]byte("stray")), 5, minio.PutObjectOptions{})
+		require.NoError(t, err, "should upload stray object")
+	}
+
+	// Test 1: Recent objects are skipped, they may belong to an upload that has not committed yet
+	t.Log("=== Test 1: Recent Objects Skipped ===")
+	report, err := objectUsecase.CollectOrphanedObjects(ctx, true, time.Hour)
+	require.NoError(t, err, "collection should succeed")
+	require.Empty(t, report.Orphaned, "objects younger than the minimum age should not be orphans")
+	require.Greater(t, report.Scanned, len(strayKeys), "managed objects should be scanned")
+
+	t.Log("✓ Recent objects skipped")
+
+	// Test 2: Dry run reports the orphans without removing them
+	t.Log("=== Test 2: Dry Run ===")
+	report, err = objectUsecase.CollectOrphanedObjects(ctx, true, 0)
+	require.NoError(t, err, "collection should succeed")
+	require.True(t, report.DryRun, "report should be marked as dry run")
+	require.ElementsMatch(t, strayKeys, orphanedObjectKeys(report), "only the stray objects should be orphans")
+	require.Equal(t, int64(5*len(strayKeys)), report.OrphanedSize, "orphaned size should add up")
+	require.Zero(t, report.Removed, "dry run should remove nothing")
+
+	for _, objectKey := range strayKeys {
+		_, err = minioClient.StatObject(ctx, "virdan-test", objectKey, minio.StatObjectOptions{})
+		require.NoError(t, err, "dry run should keep the stray object")
+	}
+
+	t.Log("✓ Orphans reported")
+
+	// Test 3: A real run removes only the orphans
+	t.Log("=== Test 3: Remove Orphans ===")
+	report, err = objectUsecase.CollectOrphanedObjects(ctx, false, 0)
+	require.NoError(t, err, "collection should succeed")
+	require.Equal(t, len(strayKeys), report.Removed, "every orphan should be removed")
+
+	for _, objectKey := range strayKeys {
+		_, err = minioClient.StatObject(ctx, "virdan-test", objectKey, minio.StatObjectOptions{})
+		require.Error(t, err, "stray object should be removed")
+	}
+
+	for _, objectKey := range []string{postObjectKey, unmanagedKey} {
+		_, err = minioClient.StatObject(ctx, "virdan-test", objectKey, minio.StatObjectOptions{})
+		require.NoError(t, err, "referenced and unmanaged objects should be kept")
+	}
+
+	t.Log("✓ Orphans removed")
+
+	// Test 4: Deleting a server removes its objects so nothing is left to collect
+	t.Log("=== Test 4: Delete Server ===")
+	req := setup.CreateAuthRequest(http.MethodDelete, fmt.Sprintf("/api/servers/%s", serverId), nil, accessToken)
+	resp, err := app.Test(req)
+	require.NoError(t, err, "delete server request should complete")
+	require.Equal(t, 200, resp.StatusCode, "delete server should return 200")
+
+	_, err = minioClient.StatObject(ctx, "virdan-test", postObjectKey, minio.StatObjectOptions{})
+	require.Error(t, err, "post image of the deleted server should be removed")
+
+	report, err = objectUsecase.CollectOrphanedObjects(ctx, true, 0)
+	require.NoError(t, err, "collection should succeed")
+	require.Empty(t, report.Orphaned, "deleted server should leave no orphans")
+
+	t.Log("✓ Server objects removed on delete")
+
+	t.Log("=== All Orphaned Object Collector Tests Passed ===")
+}
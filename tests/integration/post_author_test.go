@@ -0,0 +1,129 @@
+package integration
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/ferdian3456/virdanproject/tests/integration/setup"
+	"github.com/stretchr/testify/require"
+)
+
+// getTestAuthor is a helper function to read the author summary of a post or comment
+func getTestAuthor(t *testing.T, item interface{}) map[string]interface{} {
+	author, ok := item.(map[string]interface{})["author"].(map[string]interface{})
+	require.True(t, ok, "item should carry an author summary")
+
+	return author
+}
+
+// getTestList is a helper function to read the items of a paginated list
+func getTestList(t *testing.T, app *fiber.App, accessToken, url string) []interface{} {
+	req := setup.CreateAuthRequest(http.MethodGet, url, nil, accessToken)
+	resp, err := app.Test(req)
+	require.NoError(t, err, "list request should complete")
+	require.Equal(t, 200, resp.StatusCode, "list should return 200")
+
+	result := setup.ParseJSONResponse(t, resp)
+	return result["data"].([]interface{})
+}
+
+// TestPostAuthors tests the author summaries embedded in posts and comments
+func TestPostAuthors(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test in short mode")
+	}
+
+	ctx := context.Background()
+
+	t.Log("=== Starting Test Infrastructure ===")
+	infra, err := setup.StartInfra(ctx, t)
+	require.NoError(t, err, "infrastructure should start successfully")
+	defer func() { _ = infra.Terminate(ctx, t) }()
+
+	t.Log("=== Running Database Migrations ===")
+	_ = setup.RunMigration(infra.PgURL, t)
+
+	t.Log("=== Setting Up Test Application ===")
+	app, db, _, _ := setup.SetupTestApp(t, infra.PgURL, infra.RedisURL, infra.MinioURL, infra.MailhogSMTP)
+	defer db.Close()
+
+	t.Log("=== Setup: Creating Users, Server, Posts And Comments ===")
+	ownerToken := createTestUser(t, app, infra.MailhogURL, "authorowner@example.com", "authorowner", "pass123")
+	memberToken := createTestUser(t, app, infra.MailhogURL, "authormember@example.com", "authormember", "pass123")
+	ownerId := getUserId(t, app, ownerToken)
+	memberId := getUserId(t, app, memberToken)
+
+	serverId := createTestServer(t, app, ownerToken)["id"].(string)
+	require.Equal(t, 200, joinFromInvite(t, app, memberToken, createTestInvite(t, app, ownerToken, serverId, 5)), "join should return 200")
+
+	ownerPostId := createTestPost(t, app, ownerToken, serverId, "Owner post")
+	memberPostId := createTestPost(t, app, memberToken, serverId, "Member post")
+	createTestComment(t, app, memberToken, ownerPostId, "Member comment", "")
+
+	// Test 1: Posts carry the profile and role of their author
+	t.Log("=== Test 1: Post Authors ===")
+	posts := getTestList(t, app, ownerToken, fmt.Sprintf("/api/servers/%s/posts", serverId))
+	require.Len(t, posts, 2, "both posts should be listed")
+
+	memberAuthor := getTestAuthor(t, posts[0])
+	require.Equal(t, memberId.String(), memberAuthor["userId"], "author should be the member")
+	require.Equal(t, "authormember", memberAuthor["username"], "author should carry the username")
+	require.Contains(t, memberAuthor, "fullname", "author should carry the fullname")
+	require.Contains(t, memberAuthor, "avatarImageUrls", "author should carry the avatar")
+	require.Equal(t, "member", memberAuthor["status"], "author should be a member")
+	require.Equal(t, "Member", memberAuthor["role"].(map[string]interface{})["name"], "author should carry the role")
+
+	ownerAuthor := getTestAuthor(t, posts[1])
+	require.Equal(t, "authorowner", ownerAuthor["username"], "author should be the owner")
+	require.Equal(t, "Owner", ownerAuthor["role"].(map[string]interface{})["name"], "owner should carry the owner role")
+
+	req := setup.CreateAuthRequest(http.MethodGet, fmt.Sprintf("/api/posts/%s", ownerPostId), nil, memberToken)
+	resp, err := app.Test(req)
+	require.NoError(t, err, "get post request should complete")
+	require.Equal(t, 200, resp.StatusCode, "get post should return 200")
+	result := setup.ParseJSONResponse(t, resp)
+	require.Equal(t, ownerId.String(), getTestAuthor(t, result)["userId"], "single post should carry its author")
+
+	t.Log("✓ Post authors embedded")
+
+	// Test 2: Comments carry their author too, including a freshly created one
+	t.Log("=== Test 2: Comment Authors ===")
+	comments := getTestList(t, app, ownerToken, fmt.Sprintf("/api/posts/%s/comments", ownerPostId))
+	require.Len(t, comments, 1, "comment should be listed")
+	require.Equal(t, "authormember", getTestAuthor(t, comments[0])["username"], "comment should carry its author")
+
+	reqBody := []byte(`{"content":"Owner comment"}`)
+	req = setup.CreateAuthRequest(http.MethodPost, fmt.Sprintf("/api/posts/%s/comments", memberPostId), reqBody, ownerToken)
+	resp, err = app.Test(req)
+	require.NoError(t, err, "create comment request should complete")
+	require.Equal(t, 200, resp.StatusCode, "create comment should return 200")
+	result = setup.ParseJSONResponse(t, resp)
+	require.Equal(t, "Owner", getTestAuthor(t, result)["role"].(map[string]interface{})["name"], "created comment should carry its author")
+
+	t.Log("✓ Comment authors embedded")
+
+	// Test 3: Authors who left the server become placeholders
+	t.Log("=== Test 3: Left Author Placeholder ===")
+	req = setup.CreateAuthRequest(http.MethodPost, fmt.Sprintf("/api/servers/%s/leave", serverId), nil, memberToken)
+	resp, err = app.Test(req)
+	require.NoError(t, err, "leave request should complete")
+	require.Equal(t, 200, resp.StatusCode, "leave should return 200")
+
+	posts = getTestList(t, app, ownerToken, fmt.Sprintf("/api/servers/%s/posts", serverId))
+	leftAuthor := getTestAuthor(t, posts[0])
+	require.Equal(t, "left", leftAuthor["status"], "left author should be marked")
+	require.Equal(t, "Former member", leftAuthor["username"], "left author should not show the profile")
+	require.Nil(t, leftAuthor["role"], "left author should have no role")
+	require.Nil(t, leftAuthor["avatarImageUrls"], "left author should have no avatar")
+
+	comments = getTestList(t, app, ownerToken, fmt.Sprintf("/api/posts/%s/comments", ownerPostId))
+	require.Equal(t, "left", getTestAuthor(t, comments[0])["status"], "comment of the left author should be a placeholder")
+
+	t.Log("✓ Left authors replaced")
+
+	t.Log("=== All Post Author Tests Passed ===")
+}
@@ -0,0 +1,157 @@
+package integration
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/ferdian3456/virdanproject/tests/integration/setup"
+	"github.com/stretchr/testify/require"
+)
+
+// TestCommentEdit tests comment editing and the revision history
+func TestCommentEdit(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test in short mode")
+	}
+
+	ctx := context.Background()
+
+	t.Log("=== Starting Test Infrastructure ===")
+	infra, err := setup.StartInfra(ctx, t)
+	require.NoError(t, err, "infrastructure should start successfully")
+	defer func() { _ = infra.Terminate(ctx, t) }()
+
+	t.Log("=== Running Database Migrations ===")
+	_ = setup.RunMigration(infra.PgURL, t)
+
+	t.Log("=== Setting Up Test Application ===")
+	app, db, _, _ := setup.SetupTestApp(t, infra.PgURL, infra.RedisURL, infra.MinioURL, infra.MailhogSMTP)
+	defer db.Close()
+
+	// Setup: owner with a post, an author who comments on it and a moderator
+	t.Log("=== Setup: Creating Users, Server, Post And Comment ===")
+	ownerToken := createTestUser(t, app, infra.MailhogURL, "editowner@example.com", "editowner", "pass123")
+	authorToken := createTestUser(t, app, infra.MailhogURL, "editauthor@example.com", "editauthor", "pass123")
+	moderatorToken := createTestUser(t, app, infra.MailhogURL, "editmoderator@example.com", "editmoderator", "pass123")
+	authorId := getUserId(t, app, authorToken)
+	moderatorId := getUserId(t, app, moderatorToken)
+
+	server := createTestServer(t, app, ownerToken)
+	serverId := server["id"].(string)
+	postId := createTestPost(t, app, ownerToken, serverId, "Post to comment on")
+
+	joinTestServer(t, app, authorToken, serverId)
+	joinTestServer(t, app, moderatorToken, serverId)
+
+	commentId := createTestComment(t, app, authorToken, postId, "Frist comment", "")
+	commentURL := fmt.Sprintf("/api/posts/%s/comments/%s", postId, commentId)
+	revisionsURL := commentURL + "/revisions"
+
+	// Test 1: Only the author can edit the comment
+	t.Log("=== Test 1: Edit By Someone Else ===")
+	req := setup.CreateAuthRequest(http.MethodPut, commentURL, []byte(`{"content":"Hijacked"}`), ownerToken)
+	resp, err := app.Test(req)
+	require.NoError(t, err, "request should complete")
+	require.NotEqual(t, 200, resp.StatusCode, "only the author should edit the comment")
+
+	result := setup.ParseJSONResponse(t, resp)
+	code, message, param := setup.ParseErrorDetail(t, result)
+	require.Equal(t, "commentId", param, "error param should be 'commentId'")
+
+	t.Logf("✓ Validation Error: Code=%s, Message=%s, Param=%s", code, message, param)
+
+	// Test 2: Empty content is rejected
+	t.Log("=== Test 2: Edit With Empty Content ===")
+	req = setup.CreateAuthRequest(http.MethodPut, commentURL, []byte(`{"content":""}`), authorToken)
+	resp, err = app.Test(req)
+	require.NoError(t, err, "request should complete")
+	require.NotEqual(t, 200, resp.StatusCode, "empty content should be rejected")
+
+	result = setup.ParseJSONResponse(t, resp)
+	_, _, param = setup.ParseErrorDetail(t, result)
+	require.Equal(t, "content", param, "error param should be 'content'")
+
+	t.Log("✓ Empty content rejected")
+
+	// Test 3: Author edits the comment twice
+	t.Log("=== Test 3: Author Edits Comment ===")
+	for _, content := range []string{"First comment", "First comment, edited"} {
+		req = setup.CreateAuthRequest(http.MethodPut, commentURL, []byte(fmt.Sprintf(`{"content":"%s"}`, content)), authorToken)
+		resp, err = app.Test(req)
+		require.NoError(t, err, "edit comment request should complete")
+		require.Equal(t, 200, resp.StatusCode, "edit comment should return 200")
+
+		result = setup.ParseJSONResponse(t, resp)
+		require.Equal(t, content, result["content"], "content should be updated")
+		require.Equal(t, true, result["edited"], "comment should be flagged as edited")
+	}
+
+	req = setup.CreateAuthRequest(http.MethodGet, fmt.Sprintf("/api/posts/%s/comments", postId), nil, ownerToken)
+	resp, err = app.Test(req)
+	require.NoError(t, err, "list comments request should complete")
+	require.Equal(t, 200, resp.StatusCode, "list comments should return 200")
+
+	apiResp := setup.ParseAPIResponse(t, resp)
+	comments := setup.GetDataAsArray(t, apiResp)
+	require.Len(t, comments, 1, "post should have one comment")
+	require.Equal(t, true, comments[0].(map[string]interface{})["edited"], "listed comment should be flagged as edited")
+
+	t.Log("✓ Comment edited")
+
+	// Test 4: Member without delete_any_comment can not read the history
+	t.Log("=== Test 4: Revisions Without Permission ===")
+	req = setup.CreateAuthRequest(http.MethodGet, revisionsURL, nil, moderatorToken)
+	resp, err = app.Test(req)
+	require.NoError(t, err, "request should complete")
+	require.NotEqual(t, 200, resp.StatusCode, "member without permission should be rejected")
+
+	result = setup.ParseJSONResponse(t, resp)
+	_, message, _ = setup.ParseErrorDetail(t, result)
+	require.Contains(t, message, "delete_any_comment", "error message should mention the missing permission")
+
+	t.Log("✓ History hidden from members")
+
+	// Test 5: Moderator reads the history, latest replaced version first
+	t.Log("=== Test 5: Moderator Reads Revisions ===")
+	req = setup.CreateAuthRequest(http.MethodPost, fmt.Sprintf("/api/servers/%s/roles", serverId), []byte(`{"name":"Moderator","permissions":["delete_any_comment"]}`), ownerToken)
+	resp, err = app.Test(req)
+	require.NoError(t, err, "create role request should complete")
+	require.Equal(t, 200, resp.StatusCode, "create role should return 200")
+
+	result = setup.ParseJSONResponse(t, resp)
+	moderatorRoleId := result["id"].(string)
+
+	req = setup.CreateAuthRequest(http.MethodPut, fmt.Sprintf("/api/servers/%s/members/%s/role", serverId, moderatorId), []byte(fmt.Sprintf(`{"roleId":"%s"}`, moderatorRoleId)), ownerToken)
+	resp, err = app.Test(req)
+	require.NoError(t, err, "assign role request should complete")
+	require.Equal(t, 200, resp.StatusCode, "assign role should return 200")
+
+	req = setup.CreateAuthRequest(http.MethodGet, revisionsURL, nil, moderatorToken)
+	resp, err = app.Test(req)
+	require.NoError(t, err, "list revisions request should complete")
+	require.Equal(t, 200, resp.StatusCode, "list revisions should return 200")
+
+	apiResp = setup.ParseAPIResponse(t, resp)
+	revisions := setup.GetDataAsArray(t, apiResp)
+	require.Len(t, revisions, 2, "each edit should save one revision")
+
+	latest := revisions[0].(map[string]interface{})
+	require.Equal(t, "First comment", latest["content"], "latest replaced version comes first")
+	require.Equal(t, authorId.String(), latest["editedBy"], "revision should record the editor")
+	require.Equal(t, "Frist comment", revisions[1].(map[string]interface{})["content"], "original content should be kept")
+
+	t.Log("✓ Revisions listed")
+
+	// Test 6: Author reads the history of their own comment
+	t.Log("=== Test 6: Author Reads Revisions ===")
+	req = setup.CreateAuthRequest(http.MethodGet, revisionsURL, nil, authorToken)
+	resp, err = app.Test(req)
+	require.NoError(t, err, "list revisions request should complete")
+	require.Equal(t, 200, resp.StatusCode, "author should read the history of their comment")
+
+	t.Log("✓ Author reads own history")
+
+	t.Log("=== All Comment Edit Tests Passed ===")
+}
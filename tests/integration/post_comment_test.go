@@ -118,7 +118,7 @@ func TestCreatePost(t *testing.T) {
 	// Verify response contains post data
 	require.Contains(t, result, "postId", "response should contain postId")
 	require.Contains(t, result, "caption", "response should contain caption")
-	require.Contains(t, result, "postImageUrl", "response should contain postImageUrl")
+	require.Contains(t, result, "media", "response should contain media")
 	require.Contains(t, result, "likeCount", "response should contain likeCount")
 	require.Contains(t, result, "commentCount", "response should contain commentCount")
 
@@ -166,7 +166,7 @@ func TestCreatePost(t *testing.T) {
 
 	t.Logf("✓ Validation Error: Code=%s, Param=%s, Message=%s", code, param, message)
 
-	// Test 3: Create post without image (should fail)
+	// Test 3: Create post without image (text only post)
 	t.Log("=== Test 3: Create Post Without Image ===")
 
 	body3 := &bytes.Buffer{}
@@ -185,20 +185,35 @@ func TestCreatePost(t *testing.T) {
 
 	resp3, err := app.Test(req3)
 	require.NoError(t, err, "request should complete")
+	require.Equal(t, 200, resp3.StatusCode, "text only post should return 200")
 
 	result3 := setup.ParseJSONResponse(t, resp3)
-	code, message, param = setup.ParseErrorDetail(t, result3)
-
-	// Backend may return either VALIDATION_ERROR or INTERNAL_SERVER_ERROR when no file is uploaded
-	require.Contains(t, []string{"VALIDATION_ERROR", "INTERNAL_SERVER_ERROR"}, code,
-		"error code should be VALIDATION_ERROR or INTERNAL_SERVER_ERROR")
-	if param == "" {
-		require.Equal(t, "INTERNAL_SERVER_ERROR", code, "error code should be INTERNAL_SERVER_ERROR when no param")
-	} else {
-		require.Equal(t, "image", param, "error param should be 'image'")
-	}
+	require.Equal(t, "Caption without image", result3["caption"], "caption should match")
+	require.Empty(t, result3["media"], "text only post should have no media")
+
+	// A text only post does not need a multipart body
+	req3 = setup.CreateAuthRequest(http.MethodPost, url3, []byte("caption=Caption+from+a+plain+form"), accessToken)
+	req3.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp3, err = app.Test(req3)
+	require.NoError(t, err, "request should complete")
+	require.Equal(t, 200, resp3.StatusCode, "text only post from a plain form should return 200")
+
+	result3 = setup.ParseJSONResponse(t, resp3)
+	require.Equal(t, "Caption from a plain form", result3["caption"], "caption should match")
+	require.Empty(t, result3["media"], "text only post should have no media")
+
+	// A JSON body carries no form caption, it is rejected as a validation error instead of failing
+	req3 = setup.CreateAuthRequest(http.MethodPost, url3, []byte(`{"caption":"Caption as JSON"}`), accessToken)
+	resp3, err = app.Test(req3)
+	require.NoError(t, err, "request should complete")
+	require.Equal(t, 404, resp3.StatusCode, "JSON post should be rejected as a validation error")
+
+	result3 = setup.ParseJSONResponse(t, resp3)
+	_, _, param3 := setup.ParseErrorDetail(t, result3)
+	require.Equal(t, "caption", param3, "error param should be 'caption'")
 
-	t.Logf("✓ Error: Code=%s, Param=%s, Message=%s", code, param, message)
+	t.Log("✓ Text only post created")
 
 	// Test 4: Create post when not a server member (should fail)
 	t.Log("=== Test 4: Create Post When Not Server Member ===")
@@ -325,7 +340,7 @@ func TestGetServerPosts(t *testing.T) {
 	firstPost := data[0].(map[string]interface{})
 	require.Contains(t, firstPost, "postId", "post should have postId")
 	require.Contains(t, firstPost, "caption", "post should have caption")
-	require.Contains(t, firstPost, "postImageUrl", "post should have postImageUrl")
+	require.Contains(t, firstPost, "media", "post should have media")
 	require.Contains(t, firstPost, "likeCount", "post should have likeCount")
 	require.Contains(t, firstPost, "commentCount", "post should have commentCount")
 
@@ -486,7 +501,7 @@ func TestGetPostDetail(t *testing.T) {
 	// Verify response structure
 	require.Contains(t, result, "postId", "response should contain postId")
 	require.Contains(t, result, "caption", "response should contain caption")
-	require.Contains(t, result, "postImageUrl", "response should contain postImageUrl")
+	require.Contains(t, result, "media", "response should contain media")
 	require.Contains(t, result, "likeCount", "response should contain likeCount")
 	require.Contains(t, result, "commentCount", "response should contain commentCount")
 	require.Contains(t, result, "ownerId", "response should contain ownerId")
@@ -620,7 +635,7 @@ func TestUpdatePostCaption(t *testing.T) {
 	// Verify response contains updated post data
 	require.Contains(t, result, "postId", "response should contain postId")
 	require.Contains(t, result, "caption", "response should contain caption")
-	require.Contains(t, result, "postImageUrl", "response should contain postImageUrl")
+	require.Contains(t, result, "media", "response should contain media")
 
 	updatedCaption := result["caption"].(string)
 	require.Equal(t, "Updated caption text", updatedCaption, "caption should be updated")
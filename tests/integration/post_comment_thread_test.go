@@ -0,0 +1,207 @@
+package integration
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/ferdian3456/virdanproject/tests/integration/setup"
+	"github.com/stretchr/testify/require"
+)
+
+// createTestComment is a helper function to comment on a post, an empty parentId creates a top level comment
+func createTestComment(t *testing.T, app *fiber.App, accessToken, postId, content, parentId string) string {
+	reqBody := []byte(fmt.Sprintf(`{"content":"%s"}`, content))
+	if parentId != "" {
+		reqBody = []byte(fmt.Sprintf(`{"content":"%s","parentId":"%s"}`, content, parentId))
+	}
+
+	req := setup.CreateAuthRequest(http.MethodPost, fmt.Sprintf("/api/posts/%s/comments", postId), reqBody, accessToken)
+	resp, err := app.Test(req)
+	require.NoError(t, err, "create comment request should complete")
+	require.Equal(t, 200, resp.StatusCode, "create comment should return 200")
+
+	result := setup.ParseJSONResponse(t, resp)
+	commentId, ok := result["id"].(string)
+	require.True(t, ok, "comment id should be a string")
+
+	return commentId
+}
+
+// TestThreadedComments tests threaded comment listing, reply pagination and reply depth
+func TestThreadedComments(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test in short mode")
+	}
+
+	ctx := context.Background()
+
+	t.Log("=== Starting Test Infrastructure ===")
+	infra, err := setup.StartInfra(ctx, t)
+	require.NoError(t, err, "infrastructure should start successfully")
+	defer func() { _ = infra.Terminate(ctx, t) }()
+
+	t.Log("=== Running Database Migrations ===")
+	_ = setup.RunMigration(infra.PgURL, t)
+
+	t.Log("=== Setting Up Test Application ===")
+	app, db, _, _ := setup.SetupTestApp(t, infra.PgURL, infra.RedisURL, infra.MinioURL, infra.MailhogSMTP)
+	defer db.Close()
+
+	// Setup: one server with two posts, a thread on the first post
+	t.Log("=== Setup: Creating User, Server, Posts And Thread ===")
+	accessToken := createTestUser(t, app, infra.MailhogURL, "threaduser@example.com", "threaduser", "pass123")
+	server := createTestServer(t, app, accessToken)
+	serverId := server["id"].(string)
+	postId := createTestPost(t, app, accessToken, serverId, "Threaded post")
+	otherPostId := createTestPost(t, app, accessToken, serverId, "Other post")
+
+	firstCommentId := createTestComment(t, app, accessToken, postId, "First comment", "")
+	replyIds := make([]string, 4)
+	for i := range replyIds {
+		replyIds[i] = createTestComment(t, app, accessToken, postId, fmt.Sprintf("Reply %d", i+1), firstCommentId)
+	}
+	secondCommentId := createTestComment(t, app, accessToken, postId, "Second comment", "")
+	otherCommentId := createTestComment(t, app, accessToken, otherPostId, "Comment on other post", "")
+
+	commentsURL := fmt.Sprintf("/api/posts/%s/comments", postId)
+
+	// Test 1: Reply to a comment of another post
+	t.Log("=== Test 1: Reply Across Posts ===")
+	reqBody := []byte(fmt.Sprintf(`{"content":"Wrong thread","parentId":"%s"}`, otherCommentId))
+	req := setup.CreateAuthRequest(http.MethodPost, commentsURL, reqBody, accessToken)
+	resp, err := app.Test(req)
+	require.NoError(t, err, "request should complete")
+	require.NotEqual(t, 200, resp.StatusCode, "reply across posts should be rejected")
+
+	result := setup.ParseJSONResponse(t, resp)
+	code, message, param := setup.ParseErrorDetail(t, result)
+	require.Equal(t, "parentId", param, "error param should be 'parentId'")
+	require.Contains(t, message, "different post", "error message should mention the other post")
+
+	t.Logf("✓ Validation Error: Code=%s, Message=%s, Param=%s", code, message, param)
+
+	// Test 2: Replies are limited in depth
+	t.Log("=== Test 2: Reply Depth Limit ===")
+	parentId := replyIds[0]
+	for depth := 2; depth <= 3; depth++ {
+		parentId = createTestComment(t, app, accessToken, postId, fmt.Sprintf("Depth %d", depth), parentId)
+	}
+
+	reqBody = []byte(fmt.Sprintf(`{"content":"Too deep","parentId":"%s"}`, parentId))
+	req = setup.CreateAuthRequest(http.MethodPost, commentsURL, reqBody, accessToken)
+	resp, err = app.Test(req)
+	require.NoError(t, err, "request should complete")
+	require.NotEqual(t, 200, resp.StatusCode, "reply beyond the max depth should be rejected")
+
+	result = setup.ParseJSONResponse(t, resp)
+	_, message, _ = setup.ParseErrorDetail(t, result)
+	require.Contains(t, message, "deeper", "error message should mention the depth")
+
+	t.Log("✓ Depth limit enforced")
+
+	// Test 3: Threaded listing paginates top level comments only
+	t.Log("=== Test 3: Threaded Listing ===")
+	req = setup.CreateAuthRequest(http.MethodGet, commentsURL+"?threaded=true&limit=1", nil, accessToken)
+	resp, err = app.Test(req)
+	require.NoError(t, err, "list comments request should complete")
+	require.Equal(t, 200, resp.StatusCode, "list comments should return 200")
+
+	apiResp := setup.ParseAPIResponse(t, resp)
+	comments := setup.GetDataAsArray(t, apiResp)
+	require.Len(t, comments, 1, "first page should hold one top level comment")
+	newest := comments[0].(map[string]interface{})
+	require.Equal(t, secondCommentId, newest["id"], "newest top level comment comes first")
+	require.Equal(t, float64(0), newest["replyCount"], "second comment has no replies")
+
+	nextCursor := setup.GetNextCursor(t, apiResp)
+	require.NotEmpty(t, nextCursor, "next cursor should be present")
+
+	req = setup.CreateAuthRequest(http.MethodGet, commentsURL+"?threaded=true&limit=1&cursor="+nextCursor, nil, accessToken)
+	resp, err = app.Test(req)
+	require.NoError(t, err, "list comments request should complete")
+	require.Equal(t, 200, resp.StatusCode, "list comments should return 200")
+
+	apiResp = setup.ParseAPIResponse(t, resp)
+	comments = setup.GetDataAsArray(t, apiResp)
+	require.Len(t, comments, 1, "second page should hold one top level comment")
+	oldest := comments[0].(map[string]interface{})
+	require.Equal(t, firstCommentId, oldest["id"], "first comment comes last")
+	require.Equal(t, float64(4), oldest["replyCount"], "first comment has four direct replies")
+
+	replies := oldest["replies"].([]interface{})
+	require.Len(t, replies, 3, "only the first replies should be embedded")
+	require.Equal(t, replyIds[0], replies[0].(map[string]interface{})["id"], "replies read oldest first")
+	require.Equal(t, float64(1), replies[0].(map[string]interface{})["replyCount"], "nested reply should be counted")
+	require.Empty(t, setup.GetNextCursor(t, apiResp), "no more top level comments")
+
+	t.Log("✓ Threaded listing returned top level comments with replies")
+
+	// Test 4: Reply pagination
+	t.Log("=== Test 4: Reply Pagination ===")
+	repliesURL := fmt.Sprintf("%s/%s/replies", commentsURL, firstCommentId)
+	req = setup.CreateAuthRequest(http.MethodGet, repliesURL+"?limit=2", nil, accessToken)
+	resp, err = app.Test(req)
+	require.NoError(t, err, "list replies request should complete")
+	require.Equal(t, 200, resp.StatusCode, "list replies should return 200")
+
+	apiResp = setup.ParseAPIResponse(t, resp)
+	replies = setup.GetDataAsArray(t, apiResp)
+	require.Len(t, replies, 2, "first page should hold two replies")
+	require.Equal(t, replyIds[0], replies[0].(map[string]interface{})["id"], "first reply comes first")
+	nextCursor = setup.GetNextCursor(t, apiResp)
+	require.NotEmpty(t, nextCursor, "next cursor should be present")
+
+	req = setup.CreateAuthRequest(http.MethodGet, repliesURL+"?limit=2&cursor="+nextCursor, nil, accessToken)
+	resp, err = app.Test(req)
+	require.NoError(t, err, "list replies request should complete")
+	require.Equal(t, 200, resp.StatusCode, "list replies should return 200")
+
+	apiResp = setup.ParseAPIResponse(t, resp)
+	replies = setup.GetDataAsArray(t, apiResp)
+	require.Len(t, replies, 2, "second page should hold the last two replies")
+	require.Equal(t, replyIds[3], replies[1].(map[string]interface{})["id"], "last reply comes last")
+	require.Empty(t, setup.GetNextCursor(t, apiResp), "no more replies")
+
+	req = setup.CreateAuthRequest(http.MethodGet, repliesURL+"?limit=0", nil, accessToken)
+	resp, err = app.Test(req)
+	require.NoError(t, err, "list replies request should complete")
+	require.Equal(t, 404, resp.StatusCode, "zero limit should be rejected")
+
+	req = setup.CreateAuthRequest(http.MethodGet, commentsURL+"?threaded=true&limit=0", nil, accessToken)
+	resp, err = app.Test(req)
+	require.NoError(t, err, "list comments request should complete")
+	require.Equal(t, 404, resp.StatusCode, "zero limit should be rejected for comments too")
+
+	t.Log("✓ Replies paginated")
+
+	// Test 5: Replies of a comment from another post
+	t.Log("=== Test 5: Replies Of Comment From Another Post ===")
+	req = setup.CreateAuthRequest(http.MethodGet, fmt.Sprintf("%s/%s/replies", commentsURL, otherCommentId), nil, accessToken)
+	resp, err = app.Test(req)
+	require.NoError(t, err, "request should complete")
+	require.NotEqual(t, 200, resp.StatusCode, "comment of another post should not be found")
+
+	result = setup.ParseJSONResponse(t, resp)
+	_, _, param = setup.ParseErrorDetail(t, result)
+	require.Equal(t, "commentId", param, "error param should be 'commentId'")
+
+	t.Log("✓ Comment of another post rejected")
+
+	// Test 6: Flat listing still returns every comment
+	t.Log("=== Test 6: Flat Listing ===")
+	req = setup.CreateAuthRequest(http.MethodGet, commentsURL+"?limit=20", nil, accessToken)
+	resp, err = app.Test(req)
+	require.NoError(t, err, "list comments request should complete")
+	require.Equal(t, 200, resp.StatusCode, "list comments should return 200")
+
+	apiResp = setup.ParseAPIResponse(t, resp)
+	require.Len(t, setup.GetDataAsArray(t, apiResp), 8, "flat listing should include every comment and reply")
+
+	t.Log("✓ Flat listing unchanged")
+
+	t.Log("=== All Threaded Comment Tests Passed ===")
+}
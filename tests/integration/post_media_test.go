@@ -0,0 +1,164 @@
+package integration
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/minio/minio-go/v7"
+
+	"github.com/ferdian3456/virdanproject/tests/integration/setup"
+	"github.com/stretchr/testify/require"
+)
+
+// createMultiImagePostRequest is a helper function to build a post request carrying imageCount copies of the test image
+func createMultiImagePostRequest(t *testing.T, app *fiber.App, accessToken, serverId, caption string, imageCount int) *http.Response {
+	testImageData, err := getTestImage()
+	require.NoError(t, err, "should read test image")
+
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+
+	for i := 0; i < imageCount; i++ {
+		h := make(textproto.MIMEHeader)
+		h.Set("Content-Disposition", fmt.Sprintf(`form-data; name="image"; filename="test_image_%d.jpg"`, i))
+		h.Set("Content-Type", "image/jpeg")
+		part, err := writer.CreatePart(h)
+		require.NoError(t, err, "should create form part")
+		_, err = part.Write(testImageData)
+		require.NoError(t, err, "should write image data")
+	}
+
+	err = writer.WriteField("caption", caption)
+	require.NoError(t, err, "should write caption field")
+
+	err = writer.Close()
+	require.NoError(t, err, "should close writer")
+
+	req := setup.CreateAuthRequest(http.MethodPost, fmt.Sprintf("/api/servers/%s/posts", serverId), body.Bytes(), accessToken)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	resp, err := app.Test(req, -1)
+	require.NoError(t, err, "create post request should complete")
+
+	return resp
+}
+
+// TestPostMedia tests carousel posts and their cleanup
+func TestPostMedia(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test in short mode")
+	}
+
+	ctx := context.Background()
+
+	t.Log("=== Starting Test Infrastructure ===")
+	infra, err := setup.StartInfra(ctx, t)
+	require.NoError(t, err, "infrastructure should start successfully")
+	defer func() { _ = infra.Terminate(ctx, t) }()
+
+	t.Log("=== Running Database Migrations ===")
+	_ = setup.RunMigration(infra.PgURL, t)
+
+	t.Log("=== Setting Up Test Application ===")
+	app, db, _, minioClient := setup.SetupTestApp(t, infra.PgURL, infra.RedisURL, infra.MinioURL, infra.MailhogSMTP)
+	defer db.Close()
+
+	t.Log("=== Setup: Creating User And Server ===")
+	accessToken := createTestUser(t, app, infra.MailhogURL, "mediauser@example.com", "mediauser", "pass123")
+	server := createTestServer(t, app, accessToken)
+	serverId := server["id"].(string)
+
+	// Test 1: Too many images are rejected
+	t.Log("=== Test 1: Too Many Images ===")
+	resp := createMultiImagePostRequest(t, app, accessToken, serverId, "Too many", 11)
+	require.NotEqual(t, 200, resp.StatusCode, "eleven images should be rejected")
+
+	result := setup.ParseJSONResponse(t, resp)
+	code, message, param := setup.ParseErrorDetail(t, result)
+	require.Equal(t, "image", param, "error param should be 'image'")
+
+	t.Logf("✓ Validation Error: Code=%s, Message=%s, Param=%s", code, message, param)
+
+	// Test 2: Post without image and caption is rejected
+	t.Log("=== Test 2: Empty Post ===")
+	resp = createMultiImagePostRequest(t, app, accessToken, serverId, "", 0)
+	require.NotEqual(t, 200, resp.StatusCode, "empty post should be rejected")
+
+	result = setup.ParseJSONResponse(t, resp)
+	_, _, param = setup.ParseErrorDetail(t, result)
+	require.Equal(t, "caption", param, "error param should be 'caption'")
+
+	t.Log("✓ Empty post rejected")
+
+	// Test 3: Carousel keeps the upload order
+	t.Log("=== Test 3: Create Carousel ===")
+	resp = createMultiImagePostRequest(t, app, accessToken, serverId, "Carousel", 3)
+	require.Equal(t, 200, resp.StatusCode, "carousel post should return 200")
+
+	result = setup.ParseJSONResponse(t, resp)
+	postId := result["postId"].(string)
+	media := result["media"].([]interface{})
+	require.Len(t, media, 3, "carousel should hold three images")
+	for i, item := range media {
+		require.Equal(t, float64(i), item.(map[string]interface{})["position"], "media should be ordered by position")
+	}
+	for _, name := range []string{"thumb", "medium", "large"} {
+		require.Contains(t, media[0].(map[string]interface{})["urls"], name, "media should expose every variant")
+	}
+
+	req := setup.CreateAuthRequest(http.MethodGet, fmt.Sprintf("/api/servers/%s/posts", serverId), nil, accessToken)
+	resp, err = app.Test(req)
+	require.NoError(t, err, "list posts request should complete")
+	require.Equal(t, 200, resp.StatusCode, "list posts should return 200")
+
+	apiResp := setup.ParseAPIResponse(t, resp)
+	posts := setup.GetDataAsArray(t, apiResp)
+	require.Len(t, posts, 1, "server should have one post")
+	require.Len(t, posts[0].(map[string]interface{})["media"], 3, "listed post should carry its media")
+
+	t.Log("✓ Carousel created")
+
+	// Test 4: Deleting the post removes every image
+	t.Log("=== Test 4: Delete Carousel ===")
+	rows, err := db.Query(ctx, `SELECT B.object_key, B.variants FROM server_post_media A
+		INNER JOIN server_post_images B ON A.post_image_id = B.id
+		WHERE A.post_id = $1`, postId)
+	require.NoError(t, err, "should read object keys")
+
+	objectKeys := []string{}
+	for rows.Next() {
+		var objectKey string
+		var variants map[string]string
+		require.NoError(t, rows.Scan(&objectKey, &variants), "should scan object keys")
+		objectKeys = append(objectKeys, objectKey)
+		for _, variantKey := range variants {
+			objectKeys = append(objectKeys, variantKey)
+		}
+	}
+	rows.Close()
+	require.Len(t, objectKeys, 12, "every original and its three variants should be stored")
+
+	req = setup.CreateAuthRequest(http.MethodDelete, fmt.Sprintf("/api/servers/%s/posts/%s", serverId, postId), nil, accessToken)
+	resp, err = app.Test(req)
+	require.NoError(t, err, "delete post request should complete")
+	require.Equal(t, 200, resp.StatusCode, "delete post should return 200")
+
+	var total int
+	err = db.QueryRow(ctx, "SELECT COUNT(*) FROM server_post_images").Scan(&total)
+	require.NoError(t, err, "should count post images")
+	require.Equal(t, 0, total, "post image rows should be deleted")
+
+	for _, objectKey := range objectKeys {
+		_, err = minioClient.StatObject(ctx, "virdan-test", objectKey, minio.StatObjectOptions{})
+		require.Error(t, err, "post image object should be removed from MinIO")
+	}
+
+	t.Log("✓ Carousel deleted with its images")
+
+	t.Log("=== All Post Media Tests Passed ===")
+}
@@ -0,0 +1,197 @@
+package integration
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+
+	"github.com/ferdian3456/virdanproject/tests/integration/setup"
+	"github.com/stretchr/testify/require"
+)
+
+// TestReactions tests reacting to posts and comments
+func TestReactions(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test in short mode")
+	}
+
+	ctx := context.Background()
+
+	t.Log("=== Starting Test Infrastructure ===")
+	infra, err := setup.StartInfra(ctx, t)
+	require.NoError(t, err, "infrastructure should start successfully")
+	defer func() { _ = infra.Terminate(ctx, t) }()
+
+	t.Log("=== Running Database Migrations ===")
+	_ = setup.RunMigration(infra.PgURL, t)
+
+	t.Log("=== Setting Up Test Application ===")
+	app, db, _, _ := setup.SetupTestApp(t, infra.PgURL, infra.RedisURL, infra.MinioURL, infra.MailhogSMTP)
+	defer db.Close()
+
+	// Setup: owner with a post and a comment, a member who reacts
+	t.Log("=== Setup: Creating Users, Server, Post And Comment ===")
+	ownerToken := createTestUser(t, app, infra.MailhogURL, "reactowner@example.com", "reactowner", "pass123")
+	memberToken := createTestUser(t, app, infra.MailhogURL, "reactmember@example.com", "reactmember", "pass123")
+	outsiderToken := createTestUser(t, app, infra.MailhogURL, "reactoutsider@example.com", "reactoutsider", "pass123")
+
+	server := createTestServer(t, app, ownerToken)
+	serverId := server["id"].(string)
+	postId := createTestPost(t, app, ownerToken, serverId, "Post to react to")
+	commentId := createTestComment(t, app, ownerToken, postId, "Comment to react to", "")
+
+	joinTestServer(t, app, memberToken, serverId)
+
+	postReactionsURL := fmt.Sprintf("/api/posts/%s/reactions", postId)
+	commentReactionsURL := fmt.Sprintf("/api/posts/%s/comments/%s/reactions", postId, commentId)
+
+	// Test 1: Unknown reaction is rejected
+	t.Log("=== Test 1: Unknown Reaction ===")
+	req := setup.CreateAuthRequest(http.MethodPut, postReactionsURL, []byte(`{"reaction":"poop"}`), memberToken)
+	resp, err := app.Test(req)
+	require.NoError(t, err, "request should complete")
+	require.NotEqual(t, 200, resp.StatusCode, "unknown reaction should be rejected")
+
+	result := setup.ParseJSONResponse(t, resp)
+	code, message, param := setup.ParseErrorDetail(t, result)
+	require.Equal(t, "reaction", param, "error param should be 'reaction'")
+
+	t.Logf("✓ Validation Error: Code=%s, Message=%s, Param=%s", code, message, param)
+
+	// Test 2: Non member can not react
+	t.Log("=== Test 2: Non Member Reacts ===")
+	req = setup.CreateAuthRequest(http.MethodPut, postReactionsURL, []byte(`{"reaction":"heart"}`), outsiderToken)
+	resp, err = app.Test(req)
+	require.NoError(t, err, "request should complete")
+	require.NotEqual(t, 200, resp.StatusCode, "non member should not react")
+
+	t.Log("✓ Non member rejected")
+
+	// Test 3: Reacting twice with the same reaction is idempotent, another reaction replaces it
+	t.Log("=== Test 3: React To Post ===")
+	for i := 0; i < 2; i++ {
+		req = setup.CreateAuthRequest(http.MethodPut, postReactionsURL, []byte(`{"reaction":"heart"}`), memberToken)
+		resp, err = app.Test(req)
+		require.NoError(t, err, "react request should complete")
+		require.Equal(t, 200, resp.StatusCode, "react should return 200")
+
+		result = setup.ParseJSONResponse(t, resp)
+		require.Equal(t, map[string]interface{}{"heart": float64(1)}, result["reactions"], "heart should be counted once")
+		require.Equal(t, "heart", result["myReaction"], "caller reaction should be returned")
+	}
+
+	req = setup.CreateAuthRequest(http.MethodPut, postReactionsURL, []byte(`{"reaction":"laugh"}`), memberToken)
+	resp, err = app.Test(req)
+	require.NoError(t, err, "react request should complete")
+	require.Equal(t, 200, resp.StatusCode, "react should return 200")
+
+	result = setup.ParseJSONResponse(t, resp)
+	require.Equal(t, map[string]interface{}{"laugh": float64(1)}, result["reactions"], "new reaction should replace the previous one")
+
+	req = setup.CreateAuthRequest(http.MethodPut, postReactionsURL, []byte(`{"reaction":"laugh"}`), ownerToken)
+	resp, err = app.Test(req)
+	require.NoError(t, err, "react request should complete")
+	require.Equal(t, 200, resp.StatusCode, "react should return 200")
+
+	t.Log("✓ Post reactions set")
+
+	// Test 4: Post responses carry the counts and the caller reaction
+	t.Log("=== Test 4: Post Detail Reactions ===")
+	req = setup.CreateAuthRequest(http.MethodGet, fmt.Sprintf("/api/posts/%s", postId), nil, memberToken)
+	resp, err = app.Test(req)
+	require.NoError(t, err, "get post request should complete")
+	require.Equal(t, 200, resp.StatusCode, "get post should return 200")
+
+	result = setup.ParseJSONResponse(t, resp)
+	require.Equal(t, map[string]interface{}{"laugh": float64(2)}, result["reactions"], "post should count both reactions")
+	require.Equal(t, "laugh", result["myReaction"], "caller reaction should be returned")
+
+	t.Log("✓ Post reactions reported")
+
+	// Test 5: Removing a reaction is idempotent
+	t.Log("=== Test 5: Remove Post Reaction ===")
+	for i := 0; i < 2; i++ {
+		req = setup.CreateAuthRequest(http.MethodDelete, postReactionsURL, nil, memberToken)
+		resp, err = app.Test(req)
+		require.NoError(t, err, "remove reaction request should complete")
+		require.Equal(t, 200, resp.StatusCode, "remove reaction should return 200")
+
+		result = setup.ParseJSONResponse(t, resp)
+		require.Equal(t, map[string]interface{}{"laugh": float64(1)}, result["reactions"], "only the owner reaction should remain")
+		require.Nil(t, result["myReaction"], "caller should have no reaction")
+	}
+
+	t.Log("✓ Post reaction removed")
+
+	// Test 6: Concurrent reactions of the same user leave a single reaction
+	t.Log("=== Test 6: Concurrent Comment Reactions ===")
+	reactions := []string{"heart", "wow", "sad", "angry", "thumbs_up"}
+	statusCodes := make([]int, len(reactions))
+	var wg sync.WaitGroup
+	for i := range reactions {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			reqBody := []byte(fmt.Sprintf(`{"reaction":"%s"}`, reactions[i]))
+			req := setup.CreateAuthRequest(http.MethodPut, commentReactionsURL, reqBody, memberToken)
+			resp, err := app.Test(req, -1)
+			if err == nil {
+				statusCodes[i] = resp.StatusCode
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	for _, statusCode := range statusCodes {
+		require.Equal(t, 200, statusCode, "every concurrent reaction should succeed")
+	}
+
+	var reactionCount int
+	err = db.QueryRow(ctx, "SELECT COUNT(*) FROM server_post_comment_reactions WHERE comment_id = $1", commentId).Scan(&reactionCount)
+	require.NoError(t, err, "should count comment reactions")
+	require.Equal(t, 1, reactionCount, "user should hold a single reaction on the comment")
+
+	t.Log("✓ Concurrent reactions kept one reaction")
+
+	// Test 7: Comment listing carries the counts and the caller reaction
+	t.Log("=== Test 7: Comment Listing Reactions ===")
+	req = setup.CreateAuthRequest(http.MethodGet, fmt.Sprintf("/api/posts/%s/comments", postId), nil, memberToken)
+	resp, err = app.Test(req)
+	require.NoError(t, err, "list comments request should complete")
+	require.Equal(t, 200, resp.StatusCode, "list comments should return 200")
+
+	apiResp := setup.ParseAPIResponse(t, resp)
+	comments := setup.GetDataAsArray(t, apiResp)
+	require.Len(t, comments, 1, "post should have one comment")
+	comment := comments[0].(map[string]interface{})
+	myReaction, ok := comment["myReaction"].(string)
+	require.True(t, ok, "caller reaction should be returned")
+	require.Equal(t, map[string]interface{}{myReaction: float64(1)}, comment["reactions"], "comment should count the single reaction")
+
+	req = setup.CreateAuthRequest(http.MethodGet, fmt.Sprintf("/api/posts/%s/comments", postId), nil, ownerToken)
+	resp, err = app.Test(req)
+	require.NoError(t, err, "list comments request should complete")
+	require.Equal(t, 200, resp.StatusCode, "list comments should return 200")
+
+	apiResp = setup.ParseAPIResponse(t, resp)
+	comments = setup.GetDataAsArray(t, apiResp)
+	require.Nil(t, comments[0].(map[string]interface{})["myReaction"], "owner has not reacted to the comment")
+
+	t.Log("✓ Comment reactions reported")
+
+	// Test 8: Remove comment reaction
+	t.Log("=== Test 8: Remove Comment Reaction ===")
+	req = setup.CreateAuthRequest(http.MethodDelete, commentReactionsURL, nil, memberToken)
+	resp, err = app.Test(req)
+	require.NoError(t, err, "remove reaction request should complete")
+	require.Equal(t, 200, resp.StatusCode, "remove reaction should return 200")
+
+	result = setup.ParseJSONResponse(t, resp)
+	require.Empty(t, result["reactions"], "comment should have no reaction left")
+
+	t.Log("✓ Comment reaction removed")
+
+	t.Log("=== All Reaction Tests Passed ===")
+}
@@ -0,0 +1,131 @@
+package integration
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/ferdian3456/virdanproject/tests/integration/setup"
+	"github.com/stretchr/testify/require"
+)
+
+// getTestPostMediaUrl is a helper function to read the medium url of the first image of a post
+func getTestPostMediaUrl(t *testing.T, app *fiber.App, accessToken, postId string) string {
+	req := setup.CreateAuthRequest(http.MethodGet, "/api/posts/"+postId, nil, accessToken)
+	resp, err := app.Test(req)
+	require.NoError(t, err, "get post request should complete")
+	require.Equal(t, 200, resp.StatusCode, "get post should return 200")
+
+	result := setup.ParseJSONResponse(t, resp)
+	media := result["media"].([]interface{})
+	require.NotEmpty(t, media, "post should carry its image")
+
+	urls := media[0].(map[string]interface{})["urls"].(map[string]interface{})
+	return urls["medium"].(string)
+}
+
+// fetchAnonymous is a helper function to read an object url without any credentials
+func fetchAnonymous(t *testing.T, url string) int {
+	resp, err := http.Get(url)
+	require.NoError(t, err, "anonymous request should complete")
+	_ = resp.Body.Close()
+
+	return resp.StatusCode
+}
+
+// setTestServerPrivacy is a helper function to change whether the server is private
+func setTestServerPrivacy(t *testing.T, app *fiber.App, accessToken, serverId string, private bool) {
+	reqBody := []byte(fmt.Sprintf(`{"isPrivate":%t}`, private))
+	req := setup.CreateAuthRequest(http.MethodPut, fmt.Sprintf("/api/servers/%s/settings", serverId), reqBody, accessToken)
+	resp, err := app.Test(req, -1)
+	require.NoError(t, err, "update settings request should complete")
+	require.Equal(t, 200, resp.StatusCode, "update settings should return 200")
+}
+
+// TestPrivateMedia tests that images of private servers are only served through presigned urls
+func TestPrivateMedia(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test in short mode")
+	}
+
+	ctx := context.Background()
+
+	t.Log("=== Starting Test Infrastructure ===")
+	infra, err := setup.StartInfra(ctx, t)
+	require.NoError(t, err, "infrastructure should start successfully")
+	defer func() { _ = infra.Terminate(ctx, t) }()
+
+	t.Log("=== Running Database Migrations ===")
+	_ = setup.RunMigration(infra.PgURL, t)
+
+	t.Log("=== Setting Up Test Application ===")
+	app, db, _, _ := setup.SetupTestApp(t, infra.PgURL, infra.RedisURL, infra.MinioURL, infra.MailhogSMTP)
+	defer db.Close()
+
+	t.Log("=== Setup: Creating User, Server And Post ===")
+	accessToken := createTestUser(t, app, infra.MailhogURL, "privatemedia@example.com", "privatemedia", "pass123")
+	server := createTestServer(t, app, accessToken)
+	serverId := server["id"].(string)
+	postId := createTestPost(t, app, accessToken, serverId, "Media post")
+
+	// Test 1: Public servers keep plain cacheable urls
+	t.Log("=== Test 1: Public Server Media ===")
+	publicUrl := getTestPostMediaUrl(t, app, accessToken, postId)
+	require.NotContains(t, publicUrl, "X-Amz-Signature", "public media should not be presigned")
+	require.Equal(t, 200, fetchAnonymous(t, publicUrl), "public media should be readable without credentials")
+
+	t.Log("✓ Public media served plainly")
+
+	// Test 2: Turning the server private moves its images out of reach of old links
+	t.Log("=== Test 2: Server Turns Private ===")
+	setTestServerPrivacy(t, app, accessToken, serverId, true)
+
+	var objectKey string
+	err = db.QueryRow(ctx, `SELECT B.object_key FROM server_post_media A
+		INNER JOIN server_post_images B ON B.id = A.post_image_id
+		WHERE A.post_id = $1`, postId).Scan(&objectKey)
+	require.NoError(t, err, "post image row should exist")
+	require.True(t, strings.HasPrefix(objectKey, "private/"), "image should be moved under the private prefix")
+
+	require.NotEqual(t, 200, fetchAnonymous(t, publicUrl), "old public link should stop working")
+
+	privateUrl := getTestPostMediaUrl(t, app, accessToken, postId)
+	require.Contains(t, privateUrl, "X-Amz-Signature", "private media should be presigned")
+	require.Equal(t, 200, fetchAnonymous(t, privateUrl), "presigned url should be readable")
+	require.NotEqual(t, 200, fetchAnonymous(t, strings.SplitN(privateUrl, "?", 2)[0]), "private media should need the signature")
+
+	t.Log("✓ Private media presigned")
+
+	// Test 3: New posts of a private server are stored privately right away
+	t.Log("=== Test 3: Post In Private Server ===")
+	privatePostId := createTestPost(t, app, accessToken, serverId, "Private post")
+
+	err = db.QueryRow(ctx, `SELECT B.object_key FROM server_post_media A
+		INNER JOIN server_post_images B ON B.id = A.post_image_id
+		WHERE A.post_id = $1`, privatePostId).Scan(&objectKey)
+	require.NoError(t, err, "post image row should exist")
+	require.True(t, strings.HasPrefix(objectKey, "private/"), "new image should be stored under the private prefix")
+
+	t.Log("✓ New private post stored privately")
+
+	// Test 4: Turning the server public again restores plain urls
+	t.Log("=== Test 4: Server Turns Public ===")
+	setTestServerPrivacy(t, app, accessToken, serverId, false)
+
+	var total int
+	err = db.QueryRow(ctx, "SELECT COUNT(*) FROM server_post_images WHERE object_key LIKE 'private/%'").Scan(&total)
+	require.NoError(t, err, "should count private images")
+	require.Equal(t, 0, total, "every image should be moved back")
+
+	publicUrl = getTestPostMediaUrl(t, app, accessToken, privatePostId)
+	require.NotContains(t, publicUrl, "X-Amz-Signature", "public media should not be presigned")
+	require.Equal(t, 200, fetchAnonymous(t, publicUrl), "media should be readable without credentials again")
+
+	t.Log("✓ Public media restored")
+
+	t.Log("=== All Private Media Tests Passed ===")
+}
@@ -0,0 +1,223 @@
+package integration
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/fasthttp/websocket"
+
+	"github.com/ferdian3456/virdanproject/tests/integration/setup"
+	"github.com/stretchr/testify/require"
+)
+
+// dialTestEvents is a helper function to open the event stream and wait for the connected event
+func dialTestEvents(t *testing.T, addr, accessToken string) *websocket.Conn {
+	header := http.Header{}
+	header.Set("Authorization", "Bearer "+accessToken)
+
+	conn, resp, err := websocket.DefaultDialer.Dial("ws://"+addr+"/api/ws", header)
+	require.NoError(t, err, "event stream handshake should succeed")
+	require.Equal(t, http.StatusSwitchingProtocols, resp.StatusCode, "handshake should switch protocols")
+
+	event := readTestEvent(t, conn)
+	require.Equal(t, "connected", event["type"], "first event should be connected")
+
+	return conn
+}
+
+// readTestEvent is a helper function to read the next event of the stream
+func readTestEvent(t *testing.T, conn *websocket.Conn) map[string]interface{} {
+	err := conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	require.NoError(t, err, "should set read deadline")
+
+	_, message, err := conn.ReadMessage()
+	require.NoError(t, err, "event should arrive")
+
+	var event map[string]interface{}
+	err = json.Unmarshal(message, &event)
+	require.NoError(t, err, "event should be json")
+
+	return event
+}
+
+// requireNoTestEvent is a helper function to check that nothing arrives on the stream for a while
+func requireNoTestEvent(t *testing.T, conn *websocket.Conn) {
+	err := conn.SetReadDeadline(time.Now().Add(500 * time.Millisecond))
+	require.NoError(t, err, "should set read deadline")
+
+	_, message, err := conn.ReadMessage()
+	require.Error(t, err, "no event should arrive, got %s", message)
+}
+
+// TestServerEvents tests that server activity is pushed over the WebSocket gateway to the members only
+func TestServerEvents(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test in short mode")
+	}
+
+	ctx := context.Background()
+
+	t.Log("=== Starting Test Infrastructure ===")
+	infra, err := setup.StartInfra(ctx, t)
+	require.NoError(t, err, "infrastructure should start successfully")
+	defer func() { _ = infra.Terminate(ctx, t) }()
+
+	t.Log("=== Running Database Migrations ===")
+	_ = setup.RunMigration(infra.PgURL, t)
+
+	t.Log("=== Setting Up Test Application ===")
+	app, db, _, _ := setup.SetupTestApp(t, infra.PgURL, infra.RedisURL, infra.MinioURL, infra.MailhogSMTP)
+	defer db.Close()
+
+	addr := setup.ListenTestApp(t, app)
+
+	t.Log("=== Setup: Creating Users And Server ===")
+	ownerToken := createTestUser(t, app, infra.MailhogURL, "eventowner@example.com", "eventowner", "pass123")
+	memberToken := createTestUser(t, app, infra.MailhogURL, "eventmember@example.com", "eventmember", "pass123")
+	outsiderToken := createTestUser(t, app, infra.MailhogURL, "eventoutsider@example.com", "eventoutsider", "pass123")
+	server := createTestServer(t, app, ownerToken)
+	serverId := server["id"].(string)
+
+	// Test 1: The handshake needs a valid access token and an upgrade request
+	t.Log("=== Test 1: Handshake Authentication ===")
+	_, resp, err := websocket.DefaultDialer.Dial("ws://"+addr+"/api/ws", nil)
+	require.Error(t, err, "handshake without token should fail")
+	require.NotNil(t, resp, "handshake should get a response")
+	require.NotEqual(t, http.StatusSwitchingProtocols, resp.StatusCode, "handshake without token should not switch protocols")
+
+	req := setup.CreateAuthRequest(http.MethodGet, "/api/ws", nil, ownerToken)
+	resp, err = app.Test(req)
+	require.NoError(t, err, "plain request should complete")
+	require.Equal(t, 400, resp.StatusCode, "plain request should return 400")
+
+	t.Log("✓ Handshake requires authentication")
+
+	ownerConn := dialTestEvents(t, addr, ownerToken)
+	defer func() { _ = ownerConn.Close() }()
+	outsiderConn := dialTestEvents(t, addr, outsiderToken)
+	defer func() { _ = outsiderConn.Close() }()
+
+	// Test 2: Members see who joins
+	t.Log("=== Test 2: Member Joined ===")
+	inviteCode := createTestInvite(t, app, ownerToken, serverId, 5)
+	require.Equal(t, 200, joinFromInvite(t, app, memberToken, inviteCode), "join should return 200")
+
+	event := readTestEvent(t, ownerConn)
+	require.Equal(t, "member.joined", event["type"], "owner should see the new member")
+	require.Equal(t, serverId, event["serverId"], "event should carry the server")
+
+	t.Log("✓ Member joined pushed")
+
+	// The access token may also come from the query string, browsers can not set handshake headers
+	memberConn, resp, err := websocket.DefaultDialer.Dial(fmt.Sprintf("ws://%s/api/ws?accessToken=%s", addr, memberToken), nil)
+	require.NoError(t, err, "handshake with query token should succeed")
+	require.Equal(t, http.StatusSwitchingProtocols, resp.StatusCode, "handshake should switch protocols")
+	defer func() { _ = memberConn.Close() }()
+	require.Equal(t, "connected", readTestEvent(t, memberConn)["type"], "first event should be connected")
+
+	// Test 3: New posts reach every member
+	t.Log("=== Test 3: Post Created ===")
+	postId := createTestPost(t, app, ownerToken, serverId, "Live post")
+
+	for _, conn := range []*websocket.Conn{ownerConn, memberConn} {
+		event = readTestEvent(t, conn)
+		require.Equal(t, "post.created", event["type"], "members should see the new post")
+		require.Equal(t, serverId, event["serverId"], "event should carry the server")
+		require.Equal(t, postId, event["data"].(map[string]interface{})["postId"], "event should carry the post")
+	}
+
+	t.Log("✓ Post created pushed")
+
+	// Test 4: Likes push the new count
+	t.Log("=== Test 4: Like Count Changed ===")
+	req = setup.CreateAuthRequest(http.MethodPost, fmt.Sprintf("/api/posts/%s/likes", postId), nil, memberToken)
+	resp, err = app.Test(req)
+	require.NoError(t, err, "like request should complete")
+	require.Equal(t, 200, resp.StatusCode, "like should return 200")
+
+	event = readTestEvent(t, ownerConn)
+	require.Equal(t, "post.like_count_changed", event["type"], "owner should see the like")
+	data := event["data"].(map[string]interface{})
+	require.Equal(t, postId, data["postId"], "event should carry the post")
+	require.Equal(t, float64(1), data["likeCount"], "event should carry the new count")
+	require.Equal(t, "post.like_count_changed", readTestEvent(t, memberConn)["type"], "liker should see the like too")
+
+	t.Log("✓ Like count pushed")
+
+	// Test 5: Comments are pushed with their content
+	t.Log("=== Test 5: Comment Created ===")
+	commentId := createTestComment(t, app, memberToken, postId, "Live comment", "")
+
+	event = readTestEvent(t, ownerConn)
+	require.Equal(t, "comment.created", event["type"], "owner should see the comment")
+	data = event["data"].(map[string]interface{})
+	require.Equal(t, postId, data["postId"], "event should carry the post")
+	comment := data["comment"].(map[string]interface{})
+	require.Equal(t, commentId, comment["id"], "event should carry the comment")
+	require.Equal(t, "Live comment", comment["content"], "event should carry the content")
+	require.Equal(t, "comment.created", readTestEvent(t, memberConn)["type"], "commenter should see the comment too")
+
+	t.Log("✓ Comment created pushed")
+
+	// Test 6: Deleted posts are announced
+	t.Log("=== Test 6: Post Deleted ===")
+	req = setup.CreateAuthRequest(http.MethodDelete, fmt.Sprintf("/api/servers/%s/posts/%s", serverId, postId), nil, ownerToken)
+	resp, err = app.Test(req)
+	require.NoError(t, err, "delete post request should complete")
+	require.Equal(t, 200, resp.StatusCode, "delete post should return 200")
+
+	for _, conn := range []*websocket.Conn{ownerConn, memberConn} {
+		event = readTestEvent(t, conn)
+		require.Equal(t, "post.deleted", event["type"], "members should see the deletion")
+		require.Equal(t, postId, event["data"].(map[string]interface{})["postId"], "event should carry the post")
+	}
+
+	t.Log("✓ Post deleted pushed")
+
+	// Test 7: Users outside the server receive nothing
+	t.Log("=== Test 7: Outsider Isolation ===")
+	requireNoTestEvent(t, outsiderConn)
+
+	t.Log("✓ Outsider received no events")
+
+	// Test 8: A member who leaves stops receiving the events of the server
+	t.Log("=== Test 8: Member Left ===")
+	req = setup.CreateAuthRequest(http.MethodPost, fmt.Sprintf("/api/servers/%s/leave", serverId), nil, memberToken)
+	resp, err = app.Test(req)
+	require.NoError(t, err, "leave request should complete")
+	require.Equal(t, 200, resp.StatusCode, "leave should return 200")
+
+	require.Equal(t, "member.left", readTestEvent(t, memberConn)["type"], "leaving member should see it left")
+	require.Equal(t, "member.left", readTestEvent(t, ownerConn)["type"], "owner should see the member leave")
+
+	createTestPost(t, app, ownerToken, serverId, "After leave")
+	require.Equal(t, "post.created", readTestEvent(t, ownerConn)["type"], "owner should still see new posts")
+	requireNoTestEvent(t, memberConn)
+
+	t.Log("✓ Former member no longer receives events")
+
+	// Test 9: Ending the session closes the open connection
+	t.Log("=== Test 9: Session Ended ===")
+	req = setup.CreateAuthRequest(http.MethodPost, "/api/users/logout", nil, memberToken)
+	resp, err = app.Test(req)
+	require.NoError(t, err, "logout request should complete")
+	require.Equal(t, 200, resp.StatusCode, "logout should return 200")
+
+	err = memberConn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	require.NoError(t, err, "should set read deadline")
+	for err == nil {
+		_, _, err = memberConn.ReadMessage()
+	}
+	require.True(t, websocket.IsCloseError(err, websocket.ClosePolicyViolation), "connection should be closed for the ended session, got %v", err)
+
+	createTestPost(t, app, ownerToken, serverId, "After logout")
+	require.Equal(t, "post.created", readTestEvent(t, ownerConn)["type"], "other sessions should stay open")
+
+	t.Log("✓ Ended session closed the connection")
+
+	t.Log("=== All Server Event Tests Passed ===")
+}
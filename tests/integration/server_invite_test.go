@@ -0,0 +1,312 @@
+package integration
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+
+	"github.com/ferdian3456/virdanproject/tests/integration/setup"
+	"github.com/stretchr/testify/require"
+)
+
+// createTestInvite is a helper function to create an invite code for a server
+func createTestInvite(t *testing.T, app *fiber.App, accessToken, serverId string, maxUses int) string {
+	reqBody := []byte(fmt.Sprintf(`{"expiresInMinutes":60,"maxUses":%d}`, maxUses))
+	req := setup.CreateAuthRequest(http.MethodPost, fmt.Sprintf("/api/servers/%s/invites", serverId), reqBody, accessToken)
+	resp, err := app.Test(req)
+	require.NoError(t, err, "create invite request should complete")
+	require.Equal(t, 200, resp.StatusCode, "create invite should return 200")
+
+	result := setup.ParseJSONResponse(t, resp)
+	inviteCode, ok := result["inviteCode"].(string)
+	require.True(t, ok, "inviteCode should be a string")
+
+	return inviteCode
+}
+
+// joinFromInvite is a helper function to join a server with an invite code and return the status code
+func joinFromInvite(t *testing.T, app *fiber.App, accessToken, inviteCode string) int {
+	reqBody := []byte(fmt.Sprintf(`{"inviteCode":"%s"}`, inviteCode))
+	req := setup.CreateAuthRequest(http.MethodPost, "/api/servers/join", reqBody, accessToken)
+	resp, err := app.Test(req)
+	require.NoError(t, err, "join from invite request should complete")
+
+	return resp.StatusCode
+}
+
+// TestServerInvites tests invite usage counting, expiry, listing and revocation
+func TestServerInvites(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test in short mode")
+	}
+
+	ctx := context.Background()
+
+	t.Log("=== Starting Test Infrastructure ===")
+	infra, err := setup.StartInfra(ctx, t)
+	require.NoError(t, err, "infrastructure should start successfully")
+	defer func() { _ = infra.Terminate(ctx, t) }()
+
+	t.Log("=== Running Database Migrations ===")
+	_ = setup.RunMigration(infra.PgURL, t)
+
+	t.Log("=== Setting Up Test Application ===")
+	app, db, _, _ := setup.SetupTestApp(t, infra.PgURL, infra.RedisURL, infra.MinioURL, infra.MailhogSMTP)
+	defer db.Close()
+
+	// Setup: owner with a server and a pool of users to join
+	t.Log("=== Setup: Creating Owner, Users And Server ===")
+	ownerToken := createTestUser(t, app, infra.MailhogURL, "inviteowner@example.com", "inviteowner", "pass123")
+	userTokens := make([]string, 8)
+	for i := range userTokens {
+		userTokens[i] = createTestUser(t, app, infra.MailhogURL, fmt.Sprintf("invitee%d@example.com", i), fmt.Sprintf("invitee%d", i), "pass123")
+	}
+
+	server := createTestServer(t, app, ownerToken)
+	serverId := server["id"].(string)
+	invitesURL := fmt.Sprintf("/api/servers/%s/invites", serverId)
+
+	// Test 1: Outsider can not create invites
+	t.Log("=== Test 1: Create Invite As Outsider ===")
+	req := setup.CreateAuthRequest(http.MethodPost, invitesURL, []byte(`{"expiresInMinutes":60,"maxUses":5}`), userTokens[0])
+	resp, err := app.Test(req)
+	require.NoError(t, err, "request should complete")
+	require.NotEqual(t, 200, resp.StatusCode, "outsider should not create invites")
+
+	t.Log("✓ Outsider rejected")
+
+	// Test 2: Used count is enforced
+	t.Log("=== Test 2: Invite Used Up ===")
+	limitedCode := createTestInvite(t, app, ownerToken, serverId, 2)
+	require.Equal(t, 200, joinFromInvite(t, app, userTokens[0], limitedCode), "first use should succeed")
+	require.Equal(t, 200, joinFromInvite(t, app, userTokens[1], limitedCode), "second use should succeed")
+	require.NotEqual(t, 200, joinFromInvite(t, app, userTokens[2], limitedCode), "third use should be rejected")
+
+	var usedCount int
+	err = db.QueryRow(ctx, "SELECT used_count FROM server_invites WHERE code = $1", limitedCode).Scan(&usedCount)
+	require.NoError(t, err, "should read used count")
+	require.Equal(t, 2, usedCount, "used count should match the joins")
+
+	var useCount int
+	err = db.QueryRow(ctx, "SELECT COUNT(*) FROM server_invite_uses A JOIN server_invites B ON B.id = A.invite_id WHERE B.code = $1", limitedCode).Scan(&useCount)
+	require.NoError(t, err, "should count invite uses")
+	require.Equal(t, 2, useCount, "every join should be recorded")
+
+	t.Log("✓ Invite usage counted and recorded")
+
+	// Test 3: Concurrent joins can not exceed max uses
+	t.Log("=== Test 3: Concurrent Joins ===")
+	singleCode := createTestInvite(t, app, ownerToken, serverId, 1)
+
+	statusCodes := make([]int, 5)
+	var wg sync.WaitGroup
+	for i := range statusCodes {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			reqBody := []byte(fmt.Sprintf(`{"inviteCode":"%s"}`, singleCode))
+			req := setup.CreateAuthRequest(http.MethodPost, "/api/servers/join", reqBody, userTokens[i+2])
+			resp, err := app.Test(req, -1)
+			if err == nil {
+				statusCodes[i] = resp.StatusCode
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	succeeded := 0
+	for _, statusCode := range statusCodes {
+		if statusCode == 200 {
+			succeeded++
+		}
+	}
+	require.Equal(t, 1, succeeded, "only one concurrent join should succeed")
+
+	err = db.QueryRow(ctx, "SELECT used_count FROM server_invites WHERE code = $1", singleCode).Scan(&usedCount)
+	require.NoError(t, err, "should read used count")
+	require.Equal(t, 1, usedCount, "used count should not exceed max uses")
+
+	t.Log("✓ Concurrent joins respect max uses")
+
+	// Test 4: Expired invite is rejected
+	t.Log("=== Test 4: Expired Invite ===")
+	expiredCode := createTestInvite(t, app, ownerToken, serverId, 5)
+	_, err = db.Exec(ctx, "UPDATE server_invites SET expires_datetime = NOW() - INTERVAL '1 minute' WHERE code = $1", expiredCode)
+	require.NoError(t, err, "should expire the invite")
+
+	require.NotEqual(t, 200, joinFromInvite(t, app, userTokens[7], expiredCode), "expired invite should be rejected")
+
+	t.Log("✓ Expired invite rejected")
+
+	// Test 5: List invites
+	t.Log("=== Test 5: List Invites ===")
+	activeCode := createTestInvite(t, app, ownerToken, serverId, 5)
+
+	req = setup.CreateAuthRequest(http.MethodGet, invitesURL, nil, userTokens[0])
+	resp, err = app.Test(req)
+	require.NoError(t, err, "request should complete")
+	require.NotEqual(t, 200, resp.StatusCode, "member without manage_invites should not list invites")
+
+	req = setup.CreateAuthRequest(http.MethodGet, invitesURL, nil, ownerToken)
+	resp, err = app.Test(req)
+	require.NoError(t, err, "list invites request should complete")
+	require.Equal(t, 200, resp.StatusCode, "list invites should return 200")
+
+	apiResp := setup.ParseAPIResponse(t, resp)
+	invites := setup.GetDataAsArray(t, apiResp)
+	require.Len(t, invites, 1, "only the invite that can still be used should be listed")
+
+	invite := invites[0].(map[string]interface{})
+	require.Equal(t, activeCode, invite["code"], "active invite should be listed")
+	require.Equal(t, float64(0), invite["usedCount"], "active invite should be unused")
+	require.Equal(t, "inviteowner", invite["creatorName"], "creator should be listed")
+
+	t.Log("✓ Active invites listed")
+
+	// Test 6: Revoke invite
+	t.Log("=== Test 6: Revoke Invite ===")
+	req = setup.CreateAuthRequest(http.MethodDelete, fmt.Sprintf("%s/%s", invitesURL, activeCode), nil, ownerToken)
+	resp, err = app.Test(req)
+	require.NoError(t, err, "revoke request should complete")
+	require.Equal(t, 200, resp.StatusCode, "revoke should return 200")
+
+	require.NotEqual(t, 200, joinFromInvite(t, app, userTokens[7], activeCode), "revoked invite should be rejected")
+
+	req = setup.CreateAuthRequest(http.MethodDelete, fmt.Sprintf("%s/%s", invitesURL, activeCode), nil, ownerToken)
+	resp, err = app.Test(req)
+	require.NoError(t, err, "request should complete")
+
+	result := setup.ParseJSONResponse(t, resp)
+	_, _, param := setup.ParseErrorDetail(t, result)
+	require.Equal(t, "code", param, "revoking twice should report the code")
+
+	t.Log("✓ Invite revoked")
+
+	t.Log("=== All Server Invite Tests Passed ===")
+}
+
+// TestInvitePreview tests the public GET /servers/invites/:inviteCode endpoint
+func TestInvitePreview(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test in short mode")
+	}
+
+	ctx := context.Background()
+
+	t.Log("=== Starting Test Infrastructure ===")
+	infra, err := setup.StartInfra(ctx, t)
+	require.NoError(t, err, "infrastructure should start successfully")
+	defer func() { _ = infra.Terminate(ctx, t) }()
+
+	t.Log("=== Running Database Migrations ===")
+	_ = setup.RunMigration(infra.PgURL, t)
+
+	t.Log("=== Setting Up Test Application ===")
+	app, db, _, _ := setup.SetupTestApp(t, infra.PgURL, infra.RedisURL, infra.MinioURL, infra.MailhogSMTP)
+	defer db.Close()
+
+	// Setup: owner with a server that has an avatar, and a joiner
+	t.Log("=== Setup: Creating Owner, Joiner And Server ===")
+	ownerToken := createTestUser(t, app, infra.MailhogURL, "previewowner@example.com", "previewowner", "pass123")
+	joinerToken := createTestUser(t, app, infra.MailhogURL, "previewjoiner@example.com", "previewjoiner", "pass123")
+	ownerId := getUserId(t, app, ownerToken)
+
+	server := createTestServer(t, app, ownerToken)
+	serverId := server["id"].(string)
+
+	avatarImageId := uuid.New()
+	avatarObjectKey := fmt.Sprintf("server/avatar/%s.webp", avatarImageId)
+	_, err = db.Exec(ctx, `INSERT INTO server_avatar_images (id, bucket, object_key, mime_type, size, variants, create_datetime, update_datetime, create_user_id, update_user_id)
+		VALUES ($1, 'virdan-test', $2, 'image/webp', 1, jsonb_build_object('medium', $2::text), NOW(), NOW(), $3, $3)`, avatarImageId, avatarObjectKey, ownerId)
+	require.NoError(t, err, "should insert avatar image")
+	_, err = db.Exec(ctx, "UPDATE servers SET avatar_image_id = $1 WHERE id = $2", avatarImageId, serverId)
+	require.NoError(t, err, "should set server avatar")
+
+	inviteCode := createTestInvite(t, app, ownerToken, serverId, 1)
+	previewURL := "/api/servers/invites/" + inviteCode
+
+	// Test 1: Unknown invite code
+	t.Log("=== Test 1: Preview Unknown Invite ===")
+	req := setup.CreateJSONRequest(http.MethodGet, "/api/servers/invites/ZZZZZZZZ", nil)
+	resp, err := app.Test(req)
+	require.NoError(t, err, "request should complete")
+	require.Equal(t, 404, resp.StatusCode, "unknown invite should return 404")
+
+	result := setup.ParseJSONResponse(t, resp)
+	code, message, param := setup.ParseErrorDetail(t, result)
+	require.Equal(t, "inviteCode", param, "error param should be 'inviteCode'")
+
+	t.Logf("✓ Validation Error: Code=%s, Message=%s, Param=%s", code, message, param)
+
+	// Test 2: Preview without logging in
+	t.Log("=== Test 2: Preview Active Invite ===")
+	req = setup.CreateJSONRequest(http.MethodGet, previewURL, nil)
+	resp, err = app.Test(req)
+	require.NoError(t, err, "preview request should complete")
+	require.Equal(t, 200, resp.StatusCode, "preview should be public")
+
+	result = setup.ParseJSONResponse(t, resp)
+	require.Equal(t, serverId, result["serverId"], "server id should match")
+	require.Equal(t, "Test Server", result["serverName"], "server name should match")
+	require.Equal(t, "previewowner", result["ownerName"], "owner name should match")
+	require.NotEmpty(t, result["categoryName"], "category should be resolved")
+	require.Equal(t, float64(1), result["memberCount"], "only the owner is a member")
+	require.Equal(t, "active", result["status"], "invite should be active")
+	require.Nil(t, result["bannerImageUrls"], "server has no banner")
+
+	avatarImageUrls, ok := result["avatarImageUrls"].(map[string]interface{})
+	require.True(t, ok, "avatar urls should be a map")
+	avatarImageUrl, ok := avatarImageUrls["medium"].(string)
+	require.True(t, ok, "avatar url should be a string")
+	require.True(t, strings.HasPrefix(avatarImageUrl, "http"), "avatar url should be absolute")
+	require.True(t, strings.HasSuffix(avatarImageUrl, "/virdan-test/"+avatarObjectKey), "avatar url should point at the object")
+
+	t.Log("✓ Active invite previewed")
+
+	// Test 3: Used up invite
+	t.Log("=== Test 3: Preview Used Up Invite ===")
+	require.Equal(t, 200, joinFromInvite(t, app, joinerToken, inviteCode), "join from invite should succeed")
+
+	req = setup.CreateJSONRequest(http.MethodGet, previewURL, nil)
+	resp, err = app.Test(req)
+	require.NoError(t, err, "preview request should complete")
+	require.Equal(t, 200, resp.StatusCode, "preview should return 200")
+
+	result = setup.ParseJSONResponse(t, resp)
+	require.Equal(t, "used_up", result["status"], "invite should be used up")
+	require.Equal(t, float64(2), result["memberCount"], "joiner should be counted")
+
+	t.Log("✓ Used up invite reported")
+
+	// Test 4: Expired and revoked invites
+	t.Log("=== Test 4: Preview Expired And Revoked Invites ===")
+	expiredCode := createTestInvite(t, app, ownerToken, serverId, 5)
+	_, err = db.Exec(ctx, "UPDATE server_invites SET expires_datetime = NOW() - INTERVAL '1 minute' WHERE code = $1", expiredCode)
+	require.NoError(t, err, "should expire the invite")
+
+	req = setup.CreateJSONRequest(http.MethodGet, "/api/servers/invites/"+expiredCode, nil)
+	resp, err = app.Test(req)
+	require.NoError(t, err, "preview request should complete")
+	require.Equal(t, "expired", setup.ParseJSONResponse(t, resp)["status"], "invite should be expired")
+
+	revokedCode := createTestInvite(t, app, ownerToken, serverId, 5)
+	req = setup.CreateAuthRequest(http.MethodDelete, fmt.Sprintf("/api/servers/%s/invites/%s", serverId, revokedCode), nil, ownerToken)
+	resp, err = app.Test(req)
+	require.NoError(t, err, "revoke request should complete")
+	require.Equal(t, 200, resp.StatusCode, "revoke should return 200")
+
+	req = setup.CreateJSONRequest(http.MethodGet, "/api/servers/invites/"+revokedCode, nil)
+	resp, err = app.Test(req)
+	require.NoError(t, err, "preview request should complete")
+	require.Equal(t, "revoked", setup.ParseJSONResponse(t, resp)["status"], "invite should be revoked")
+
+	t.Log("✓ Expired and revoked invites reported")
+
+	t.Log("=== All Invite Preview Tests Passed ===")
+}
@@ -655,3 +655,253 @@ func TestDeleteServer(t *testing.T) {
 
 	t.Log("=== All Delete Server Tests Passed ===")
 }
+
+// joinTestServer is a helper function to join a public server
+func joinTestServer(t *testing.T, app *fiber.App, accessToken, serverId string) {
+	req := setup.CreateAuthRequest(http.MethodPost, fmt.Sprintf("/api/servers/%s/join", serverId), nil, accessToken)
+	resp, err := app.Test(req)
+	require.NoError(t, err, "join server request should complete")
+	require.Equal(t, 200, resp.StatusCode, "join server should return 200")
+}
+
+// TestJoinServerSharedMemberRole tests that every joiner of a server shares one Member role
+func TestJoinServerSharedMemberRole(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test in short mode")
+	}
+
+	ctx := context.Background()
+
+	t.Log("=== Starting Test Infrastructure ===")
+	infra, err := setup.StartInfra(ctx, t)
+	require.NoError(t, err, "infrastructure should start successfully")
+	defer func() { _ = infra.Terminate(ctx, t) }()
+
+	t.Log("=== Running Database Migrations ===")
+	_ = setup.RunMigration(infra.PgURL, t)
+
+	t.Log("=== Setting Up Test Application ===")
+	app, db, _, _ := setup.SetupTestApp(t, infra.PgURL, infra.RedisURL, infra.MinioURL, infra.MailhogSMTP)
+	defer db.Close()
+
+	// Create owner and server
+	t.Log("=== Setup: Creating Owner And Server ===")
+	ownerToken := createTestUser(t, app, infra.MailhogURL, "joinowner@example.com", "joinowner", "pass123")
+	server := createTestServer(t, app, ownerToken)
+	serverId := server["id"].(string)
+
+	var memberRoleCount int
+	err = db.QueryRow(ctx, "SELECT COUNT(*) FROM server_roles WHERE server_id = $1 AND name = 'Member'", serverId).Scan(&memberRoleCount)
+	require.NoError(t, err, "should count member roles")
+	require.Equal(t, 1, memberRoleCount, "server should be created with its Member role")
+
+	// Test 1: Several users join the same server
+	t.Log("=== Test 1: Several Users Join ===")
+	joinerTokens := []string{}
+	for i := 1; i <= 3; i++ {
+		token := createTestUser(t, app, infra.MailhogURL, fmt.Sprintf("joiner%d@example.com", i), fmt.Sprintf("joiner%d", i), "pass123")
+		joinTestServer(t, app, token, serverId)
+		joinerTokens = append(joinerTokens, token)
+	}
+
+	t.Log("✓ Three users joined")
+
+	// Test 2: Joiners share one Member role
+	t.Log("=== Test 2: Joiners Share The Member Role ===")
+	err = db.QueryRow(ctx, "SELECT COUNT(*) FROM server_roles WHERE server_id = $1 AND name = 'Member'", serverId).Scan(&memberRoleCount)
+	require.NoError(t, err, "should count member roles")
+	require.Equal(t, 1, memberRoleCount, "joining should not create new roles")
+
+	var distinctRoles int
+	err = db.QueryRow(ctx, `SELECT COUNT(DISTINCT A.server_role_id) FROM server_members A
+		JOIN servers B ON B.id = A.server_id
+		WHERE A.server_id = $1 AND A.user_id <> B.owner_id`, serverId).Scan(&distinctRoles)
+	require.NoError(t, err, "should count distinct member roles")
+	require.Equal(t, 1, distinctRoles, "all joiners should reference the same role")
+
+	req := setup.CreateAuthRequest(http.MethodGet, fmt.Sprintf("/api/servers/%s/roles", serverId), nil, joinerTokens[0])
+	resp, err := app.Test(req)
+	require.NoError(t, err, "list roles request should complete")
+	require.Equal(t, 200, resp.StatusCode, "list roles should return 200")
+
+	apiResp := setup.ParseAPIResponse(t, resp)
+	for _, item := range setup.GetDataAsArray(t, apiResp) {
+		role := item.(map[string]interface{})
+		if role["name"] == "Member" {
+			require.Equal(t, float64(3), role["memberCount"], "Member role should have three members")
+			require.ElementsMatch(t, []interface{}{"create_invite", "create_post"}, role["permissions"], "Member role should have default permissions")
+		}
+	}
+
+	t.Log("✓ One shared Member role")
+
+	// Test 3: Joining twice is rejected
+	t.Log("=== Test 3: Join Twice ===")
+	req = setup.CreateAuthRequest(http.MethodPost, fmt.Sprintf("/api/servers/%s/join", serverId), nil, joinerTokens[0])
+	resp, err = app.Test(req)
+	require.NoError(t, err, "request should complete")
+	require.NotEqual(t, 200, resp.StatusCode, "joining twice should be rejected")
+
+	result := setup.ParseJSONResponse(t, resp)
+	_, message, _ := setup.ParseErrorDetail(t, result)
+	require.Contains(t, message, "already a member", "error message should mention membership")
+
+	t.Log("✓ Second join rejected")
+
+	// Test 4: Joining from an invite uses the same role
+	t.Log("=== Test 4: Join From Invite ===")
+	reqBody := []byte(`{"expiresInMinutes":60,"maxUses":5}`)
+	req = setup.CreateAuthRequest(http.MethodPost, fmt.Sprintf("/api/servers/%s/invites", serverId), reqBody, joinerTokens[1])
+	resp, err = app.Test(req)
+	require.NoError(t, err, "create invite request should complete")
+	require.Equal(t, 200, resp.StatusCode, "members should be able to create invites")
+
+	result = setup.ParseJSONResponse(t, resp)
+	inviteCode := result["inviteCode"].(string)
+
+	inviteeToken := createTestUser(t, app, infra.MailhogURL, "invitee@example.com", "invitee", "pass123")
+	reqBody = []byte(fmt.Sprintf(`{"inviteCode":"%s"}`, inviteCode))
+	req = setup.CreateAuthRequest(http.MethodPost, "/api/servers/join", reqBody, inviteeToken)
+	resp, err = app.Test(req)
+	require.NoError(t, err, "join from invite request should complete")
+	require.Equal(t, 200, resp.StatusCode, "join from invite should return 200")
+
+	err = db.QueryRow(ctx, "SELECT COUNT(*) FROM server_roles WHERE server_id = $1", serverId).Scan(&memberRoleCount)
+	require.NoError(t, err, "should count roles")
+	require.Equal(t, 2, memberRoleCount, "server should only have the Owner and Member roles")
+
+	t.Log("✓ Invitee shares the Member role")
+
+	t.Log("=== All Join Server Tests Passed ===")
+}
+
+// TestTransferOwnership tests the POST /servers/:id/transfer-ownership endpoint
+func TestTransferOwnership(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test in short mode")
+	}
+
+	ctx := context.Background()
+
+	t.Log("=== Starting Test Infrastructure ===")
+	infra, err := setup.StartInfra(ctx, t)
+	require.NoError(t, err, "infrastructure should start successfully")
+	defer func() { _ = infra.Terminate(ctx, t) }()
+
+	t.Log("=== Running Database Migrations ===")
+	_ = setup.RunMigration(infra.PgURL, t)
+
+	t.Log("=== Setting Up Test Application ===")
+	app, db, _, _ := setup.SetupTestApp(t, infra.PgURL, infra.RedisURL, infra.MinioURL, infra.MailhogSMTP)
+	defer db.Close()
+
+	// Setup: owner with a server, a member and an outsider
+	t.Log("=== Setup: Creating Owner, Member And Server ===")
+	ownerToken := createTestUser(t, app, infra.MailhogURL, "transferowner@example.com", "transferowner", "pass123")
+	memberToken := createTestUser(t, app, infra.MailhogURL, "transfermember@example.com", "transfermember", "pass123")
+	outsiderToken := createTestUser(t, app, infra.MailhogURL, "transferoutsider@example.com", "transferoutsider", "pass123")
+	ownerId := getUserId(t, app, ownerToken)
+	memberId := getUserId(t, app, memberToken)
+	outsiderId := getUserId(t, app, outsiderToken)
+
+	server := createTestServer(t, app, ownerToken)
+	serverId := server["id"].(string)
+	joinTestServer(t, app, memberToken, serverId)
+
+	transferURL := fmt.Sprintf("/api/servers/%s/transfer-ownership", serverId)
+	setup.ClearMailhogMessages(t, infra.MailhogURL)
+
+	// Test 1: Wrong password
+	t.Log("=== Test 1: Transfer With Wrong Password ===")
+	reqBody := []byte(fmt.Sprintf(`{"userId":"%s","password":"wrongpass"}`, memberId))
+	req := setup.CreateAuthRequest(http.MethodPost, transferURL, reqBody, ownerToken)
+	resp, err := app.Test(req)
+	require.NoError(t, err, "request should complete")
+	require.NotEqual(t, 200, resp.StatusCode, "wrong password should be rejected")
+
+	result := setup.ParseJSONResponse(t, resp)
+	code, message, param := setup.ParseErrorDetail(t, result)
+	require.Equal(t, "password", param, "error param should be 'password'")
+
+	t.Logf("✓ Validation Error: Code=%s, Message=%s, Param=%s", code, message, param)
+
+	// Test 2: New owner must be a member
+	t.Log("=== Test 2: Transfer To Non Member ===")
+	reqBody = []byte(fmt.Sprintf(`{"userId":"%s","password":"pass123"}`, outsiderId))
+	req = setup.CreateAuthRequest(http.MethodPost, transferURL, reqBody, ownerToken)
+	resp, err = app.Test(req)
+	require.NoError(t, err, "request should complete")
+	require.NotEqual(t, 200, resp.StatusCode, "non member should not become owner")
+
+	result = setup.ParseJSONResponse(t, resp)
+	_, _, param = setup.ParseErrorDetail(t, result)
+	require.Equal(t, "userId", param, "error param should be 'userId'")
+
+	t.Log("✓ Non member rejected")
+
+	// Test 3: Member can not transfer a server they do not own
+	t.Log("=== Test 3: Transfer By Non Owner ===")
+	reqBody = []byte(fmt.Sprintf(`{"userId":"%s","password":"pass123"}`, ownerId))
+	req = setup.CreateAuthRequest(http.MethodPost, transferURL, reqBody, memberToken)
+	resp, err = app.Test(req)
+	require.NoError(t, err, "request should complete")
+	require.NotEqual(t, 200, resp.StatusCode, "non owner should not transfer the server")
+
+	t.Log("✓ Non owner rejected")
+
+	// Test 4: Transfer to the member
+	t.Log("=== Test 4: Transfer Ownership ===")
+	reqBody = []byte(fmt.Sprintf(`{"userId":"%s","password":"pass123"}`, memberId))
+	req = setup.CreateAuthRequest(http.MethodPost, transferURL, reqBody, ownerToken)
+	resp, err = app.Test(req)
+	require.NoError(t, err, "transfer request should complete")
+	require.Equal(t, 200, resp.StatusCode, "transfer should return 200")
+
+	var newOwnerId string
+	err = db.QueryRow(ctx, "SELECT owner_id FROM servers WHERE id = $1", serverId).Scan(&newOwnerId)
+	require.NoError(t, err, "should read server owner")
+	require.Equal(t, memberId.String(), newOwnerId, "member should own the server")
+
+	roleQuery := `SELECT B.name FROM server_members A
+		JOIN server_roles B ON B.id = A.server_role_id
+		WHERE A.server_id = $1 AND A.user_id = $2`
+
+	var roleName string
+	err = db.QueryRow(ctx, roleQuery, serverId, memberId).Scan(&roleName)
+	require.NoError(t, err, "should read new owner role")
+	require.Equal(t, "Owner", roleName, "new owner should hold the Owner role")
+
+	err = db.QueryRow(ctx, roleQuery, serverId, ownerId).Scan(&roleName)
+	require.NoError(t, err, "should read previous owner role")
+	require.Equal(t, "Member", roleName, "previous owner should fall back to the Member role")
+
+	t.Log("✓ Ownership transferred")
+
+	// Test 5: Both parties are emailed
+	t.Log("=== Test 5: Transfer Emails ===")
+	require.Equal(t, 1, setup.CountMailhogMessages(t, infra.MailhogURL, "transferowner@example.com", "Server Ownership Transferred"), "previous owner should be emailed")
+	require.Equal(t, 1, setup.CountMailhogMessages(t, infra.MailhogURL, "transfermember@example.com", "Server Ownership Transferred"), "new owner should be emailed")
+
+	t.Log("✓ Both parties emailed")
+
+	// Test 6: Previous owner lost owner rights, new owner holds them
+	t.Log("=== Test 6: Owner Rights Moved ===")
+	req = setup.CreateAuthRequest(http.MethodPut, fmt.Sprintf("/api/servers/%s/name", serverId), []byte(`{"name":"Old Owner Rename"}`), ownerToken)
+	resp, err = app.Test(req)
+	require.NoError(t, err, "request should complete")
+	require.NotEqual(t, 200, resp.StatusCode, "previous owner should not manage the server")
+
+	req = setup.CreateAuthRequest(http.MethodPost, fmt.Sprintf("/api/servers/%s/leave", serverId), nil, ownerToken)
+	resp, err = app.Test(req)
+	require.NoError(t, err, "request should complete")
+	require.Equal(t, 200, resp.StatusCode, "previous owner should be able to leave")
+
+	req = setup.CreateAuthRequest(http.MethodPut, fmt.Sprintf("/api/servers/%s/name", serverId), []byte(`{"name":"New Owner Rename"}`), memberToken)
+	resp, err = app.Test(req)
+	require.NoError(t, err, "request should complete")
+	require.Equal(t, 200, resp.StatusCode, "new owner should manage the server")
+
+	t.Log("✓ Owner rights moved")
+
+	t.Log("=== All Transfer Ownership Tests Passed ===")
+}
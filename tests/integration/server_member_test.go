@@ -0,0 +1,191 @@
+package integration
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/ferdian3456/virdanproject/tests/integration/setup"
+	"github.com/stretchr/testify/require"
+)
+
+// TestServerMembers tests the member list, leave, kick and ban endpoints
+func TestServerMembers(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test in short mode")
+	}
+
+	ctx := context.Background()
+
+	t.Log("=== Starting Test Infrastructure ===")
+	infra, err := setup.StartInfra(ctx, t)
+	require.NoError(t, err, "infrastructure should start successfully")
+	defer func() { _ = infra.Terminate(ctx, t) }()
+
+	t.Log("=== Running Database Migrations ===")
+	_ = setup.RunMigration(infra.PgURL, t)
+
+	t.Log("=== Setting Up Test Application ===")
+	app, db, _, _ := setup.SetupTestApp(t, infra.PgURL, infra.RedisURL, infra.MinioURL, infra.MailhogSMTP)
+	defer db.Close()
+
+	// Setup: owner with a public server and a post, three joiners
+	t.Log("=== Setup: Creating Owner, Members And Server ===")
+	ownerToken := createTestUser(t, app, infra.MailhogURL, "memberowner@example.com", "memberowner", "pass123")
+	aliceToken := createTestUser(t, app, infra.MailhogURL, "memberalice@example.com", "memberalice", "pass123")
+	bobToken := createTestUser(t, app, infra.MailhogURL, "memberbob@example.com", "memberbob", "pass123")
+	carolToken := createTestUser(t, app, infra.MailhogURL, "membercarol@example.com", "membercarol", "pass123")
+	bobId := getUserId(t, app, bobToken)
+	carolId := getUserId(t, app, carolToken)
+
+	server := createTestServer(t, app, ownerToken)
+	serverId := server["id"].(string)
+	postId := createTestPost(t, app, ownerToken, serverId, "Members only")
+
+	joinTestServer(t, app, aliceToken, serverId)
+	joinTestServer(t, app, bobToken, serverId)
+	joinTestServer(t, app, carolToken, serverId)
+
+	membersURL := fmt.Sprintf("/api/servers/%s/members", serverId)
+	postURL := fmt.Sprintf("/api/posts/%s", postId)
+
+	// Test 1: List members with cursor pagination
+	t.Log("=== Test 1: List Members With Pagination ===")
+	req := setup.CreateAuthRequest(http.MethodGet, membersURL+"?limit=2", nil, aliceToken)
+	resp, err := app.Test(req)
+	require.NoError(t, err, "list members request should complete")
+	require.Equal(t, 200, resp.StatusCode, "list members should return 200")
+
+	result := setup.ParseJSONResponse(t, resp)
+	firstPage := result["data"].([]interface{})
+	require.Len(t, firstPage, 2, "first page should hold 2 members")
+	owner := firstPage[0].(map[string]interface{})
+	require.Equal(t, "memberowner", owner["username"], "owner joined first")
+	require.Equal(t, true, owner["isOwner"], "owner should be flagged")
+
+	nextCursor := result["page"].(map[string]interface{})["nextCursor"].(string)
+	require.NotEmpty(t, nextCursor, "next cursor should be present")
+
+	req = setup.CreateAuthRequest(http.MethodGet, membersURL+"?limit=2&cursor="+nextCursor, nil, aliceToken)
+	resp, err = app.Test(req)
+	require.NoError(t, err, "list members request should complete")
+	require.Equal(t, 200, resp.StatusCode, "list members should return 200")
+
+	result = setup.ParseJSONResponse(t, resp)
+	secondPage := result["data"].([]interface{})
+	require.Len(t, secondPage, 2, "second page should hold the remaining 2 members")
+	require.Equal(t, "Member", secondPage[0].(map[string]interface{})["roleName"], "joiners should hold the Member role")
+
+	req = setup.CreateAuthRequest(http.MethodGet, membersURL+"?limit=0", nil, aliceToken)
+	resp, err = app.Test(req)
+	require.NoError(t, err, "list members request should complete")
+	require.Equal(t, 404, resp.StatusCode, "zero limit should be rejected")
+
+	result = setup.ParseJSONResponse(t, resp)
+	_, _, param := setup.ParseErrorDetail(t, result)
+	require.Equal(t, "limit", param, "error param should be 'limit'")
+
+	t.Log("✓ Members listed across two pages")
+
+	// Test 2: Owner can not leave
+	t.Log("=== Test 2: Owner Leaves ===")
+	req = setup.CreateAuthRequest(http.MethodPost, fmt.Sprintf("/api/servers/%s/leave", serverId), nil, ownerToken)
+	resp, err = app.Test(req)
+	require.NoError(t, err, "request should complete")
+	require.NotEqual(t, 200, resp.StatusCode, "owner should not leave the server")
+
+	t.Log("✓ Owner can not leave")
+
+	// Test 3: Member leaves, loses access and can join again
+	t.Log("=== Test 3: Member Leaves And Rejoins ===")
+	req = setup.CreateAuthRequest(http.MethodPost, fmt.Sprintf("/api/servers/%s/leave", serverId), nil, aliceToken)
+	resp, err = app.Test(req)
+	require.NoError(t, err, "leave request should complete")
+	require.Equal(t, 200, resp.StatusCode, "leave should return 200")
+
+	req = setup.CreateAuthRequest(http.MethodGet, postURL, nil, aliceToken)
+	resp, err = app.Test(req)
+	require.NoError(t, err, "request should complete")
+	require.NotEqual(t, 200, resp.StatusCode, "member who left should lose access to posts")
+
+	joinTestServer(t, app, aliceToken, serverId)
+
+	req = setup.CreateAuthRequest(http.MethodGet, postURL, nil, aliceToken)
+	resp, err = app.Test(req)
+	require.NoError(t, err, "request should complete")
+	require.Equal(t, 200, resp.StatusCode, "rejoined member should see posts again")
+
+	t.Log("✓ Leave and rejoin work")
+
+	// Test 4: Member without kick_members can not kick
+	t.Log("=== Test 4: Kick Without Permission ===")
+	req = setup.CreateAuthRequest(http.MethodDelete, fmt.Sprintf("%s/%s", membersURL, bobId), nil, aliceToken)
+	resp, err = app.Test(req)
+	require.NoError(t, err, "request should complete")
+	require.NotEqual(t, 200, resp.StatusCode, "member without permission should be rejected")
+
+	result = setup.ParseJSONResponse(t, resp)
+	code, message, param := setup.ParseErrorDetail(t, result)
+	require.Contains(t, message, "kick_members", "error message should mention the missing permission")
+
+	t.Logf("✓ Validation Error: Code=%s, Message=%s, Param=%s", code, message, param)
+
+	// Test 5: Owner kicks a member
+	t.Log("=== Test 5: Owner Kicks Member ===")
+	req = setup.CreateAuthRequest(http.MethodDelete, fmt.Sprintf("%s/%s", membersURL, bobId), nil, ownerToken)
+	resp, err = app.Test(req)
+	require.NoError(t, err, "kick request should complete")
+	require.Equal(t, 200, resp.StatusCode, "kick should return 200")
+
+	req = setup.CreateAuthRequest(http.MethodGet, postURL, nil, bobToken)
+	resp, err = app.Test(req)
+	require.NoError(t, err, "request should complete")
+	require.NotEqual(t, 200, resp.StatusCode, "kicked member should lose access to posts")
+
+	t.Log("✓ Member kicked")
+
+	// Test 6: Banned member can not join again
+	t.Log("=== Test 6: Ban Member ===")
+	req = setup.CreateAuthRequest(http.MethodPost, fmt.Sprintf("/api/servers/%s/bans/%s", serverId, carolId), nil, ownerToken)
+	resp, err = app.Test(req)
+	require.NoError(t, err, "ban request should complete")
+	require.Equal(t, 200, resp.StatusCode, "ban should return 200")
+
+	req = setup.CreateAuthRequest(http.MethodPost, fmt.Sprintf("/api/servers/%s/join", serverId), nil, carolToken)
+	resp, err = app.Test(req)
+	require.NoError(t, err, "request should complete")
+	require.NotEqual(t, 200, resp.StatusCode, "banned user should not join")
+
+	result = setup.ParseJSONResponse(t, resp)
+	_, message, _ = setup.ParseErrorDetail(t, result)
+	require.Contains(t, message, "banned", "error message should mention the ban")
+
+	req = setup.CreateAuthRequest(http.MethodPost, fmt.Sprintf("/api/servers/%s/invites", serverId), []byte(`{"expiresInMinutes":60,"maxUses":5}`), ownerToken)
+	resp, err = app.Test(req)
+	require.NoError(t, err, "create invite request should complete")
+	require.Equal(t, 200, resp.StatusCode, "create invite should return 200")
+
+	result = setup.ParseJSONResponse(t, resp)
+	inviteCode := result["inviteCode"].(string)
+
+	req = setup.CreateAuthRequest(http.MethodPost, "/api/servers/join", []byte(fmt.Sprintf(`{"inviteCode":"%s"}`, inviteCode)), carolToken)
+	resp, err = app.Test(req)
+	require.NoError(t, err, "request should complete")
+	require.NotEqual(t, 200, resp.StatusCode, "banned user should not join from an invite")
+
+	t.Log("✓ Banned user refused")
+
+	// Test 7: Unban lets the user join again
+	t.Log("=== Test 7: Unban Member ===")
+	req = setup.CreateAuthRequest(http.MethodDelete, fmt.Sprintf("/api/servers/%s/bans/%s", serverId, carolId), nil, ownerToken)
+	resp, err = app.Test(req)
+	require.NoError(t, err, "unban request should complete")
+	require.Equal(t, 200, resp.StatusCode, "unban should return 200")
+
+	joinTestServer(t, app, carolToken, serverId)
+
+	t.Log("✓ Unbanned user joined again")
+
+	t.Log("=== All Server Member Tests Passed ===")
+}
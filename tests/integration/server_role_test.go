@@ -0,0 +1,213 @@
+package integration
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/ferdian3456/virdanproject/tests/integration/setup"
+	"github.com/stretchr/testify/require"
+)
+
+// TestServerRoles tests the /servers/:id/roles endpoints and member role assignment
+func TestServerRoles(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test in short mode")
+	}
+
+	ctx := context.Background()
+
+	t.Log("=== Starting Test Infrastructure ===")
+	infra, err := setup.StartInfra(ctx, t)
+	require.NoError(t, err, "infrastructure should start successfully")
+	defer func() { _ = infra.Terminate(ctx, t) }()
+
+	t.Log("=== Running Database Migrations ===")
+	_ = setup.RunMigration(infra.PgURL, t)
+
+	t.Log("=== Setting Up Test Application ===")
+	app, db, _, _ := setup.SetupTestApp(t, infra.PgURL, infra.RedisURL, infra.MinioURL, infra.MailhogSMTP)
+	defer db.Close()
+
+	// Setup: owner with a server and a post, member without any permission
+	t.Log("=== Setup: Creating Owner, Member And Server ===")
+	ownerToken := createTestUser(t, app, infra.MailhogURL, "roleowner@example.com", "roleowner", "pass123")
+	memberToken := createTestUser(t, app, infra.MailhogURL, "rolemember@example.com", "rolemember", "pass123")
+	memberId := getUserId(t, app, memberToken)
+
+	server := createTestServer(t, app, ownerToken)
+	serverId := server["id"].(string)
+	postId := createTestPost(t, app, ownerToken, serverId, "Post to be moderated")
+
+	addTestServerMember(t, db, uuid.MustParse(serverId), memberId)
+
+	rolesURL := fmt.Sprintf("/api/servers/%s/roles", serverId)
+
+	// Test 1: Member without manage_roles can not create roles
+	t.Log("=== Test 1: Create Role Without Permission ===")
+	req := setup.CreateAuthRequest(http.MethodPost, rolesURL, []byte(`{"name":"Sneaky","permissions":[]}`), memberToken)
+	resp, err := app.Test(req)
+	require.NoError(t, err, "request should complete")
+	require.NotEqual(t, 200, resp.StatusCode, "member without permission should be rejected")
+
+	result := setup.ParseJSONResponse(t, resp)
+	code, message, param := setup.ParseErrorDetail(t, result)
+	require.Contains(t, message, "manage_roles", "error message should mention the missing permission")
+
+	t.Logf("✓ Validation Error: Code=%s, Message=%s, Param=%s", code, message, param)
+
+	// Test 2: Unknown permission is rejected
+	t.Log("=== Test 2: Create Role With Unknown Permission ===")
+	req = setup.CreateAuthRequest(http.MethodPost, rolesURL, []byte(`{"name":"Flyer","permissions":["fly"]}`), ownerToken)
+	resp, err = app.Test(req)
+	require.NoError(t, err, "request should complete")
+
+	result = setup.ParseJSONResponse(t, resp)
+	_, _, param = setup.ParseErrorDetail(t, result)
+	require.Equal(t, "permissions", param, "error param should be 'permissions'")
+
+	t.Log("✓ Unknown permission rejected")
+
+	// Test 3: Owner creates a moderator role
+	t.Log("=== Test 3: Create Moderator Role ===")
+	reqBody := []byte(`{"name":"Moderator","permissions":["delete_any_post","manage_roles"]}`)
+	req = setup.CreateAuthRequest(http.MethodPost, rolesURL, reqBody, ownerToken)
+	resp, err = app.Test(req)
+	require.NoError(t, err, "create role request should complete")
+	require.Equal(t, 200, resp.StatusCode, "create role should return 200")
+
+	result = setup.ParseJSONResponse(t, resp)
+	moderatorRoleId := result["id"].(string)
+	require.Equal(t, "Moderator", result["name"], "role name should match")
+	require.ElementsMatch(t, []interface{}{"manage_roles", "delete_any_post"}, result["permissions"], "permissions should match")
+
+	req = setup.CreateAuthRequest(http.MethodPost, rolesURL, []byte(`{"name":"moderator","permissions":[]}`), ownerToken)
+	resp, err = app.Test(req)
+	require.NoError(t, err, "request should complete")
+
+	result = setup.ParseJSONResponse(t, resp)
+	_, _, param = setup.ParseErrorDetail(t, result)
+	require.Equal(t, "name", param, "duplicate role name should be rejected")
+
+	t.Logf("✓ Moderator role created: id=%s", moderatorRoleId)
+
+	// Test 4: Assign the moderator role to the member
+	t.Log("=== Test 4: Assign Role To Member ===")
+	reqBody = []byte(fmt.Sprintf(`{"roleId":"%s"}`, moderatorRoleId))
+	req = setup.CreateAuthRequest(http.MethodPut, fmt.Sprintf("/api/servers/%s/members/%s/role", serverId, memberId), reqBody, ownerToken)
+	resp, err = app.Test(req)
+	require.NoError(t, err, "assign role request should complete")
+	require.Equal(t, 200, resp.StatusCode, "assign role should return 200")
+
+	t.Log("✓ Moderator role assigned")
+
+	// Test 5: Moderator can not grant permissions they do not hold
+	t.Log("=== Test 5: Escalate Permissions ===")
+	req = setup.CreateAuthRequest(http.MethodPost, rolesURL, []byte(`{"name":"Admin","permissions":["manage_server"]}`), memberToken)
+	resp, err = app.Test(req)
+	require.NoError(t, err, "request should complete")
+	require.NotEqual(t, 200, resp.StatusCode, "escalation should be rejected")
+
+	result = setup.ParseJSONResponse(t, resp)
+	_, _, param = setup.ParseErrorDetail(t, result)
+	require.Equal(t, "permissions", param, "error param should be 'permissions'")
+
+	req = setup.CreateAuthRequest(http.MethodPut, fmt.Sprintf("/api/servers/%s/name", serverId), []byte(`{"name":"Hijacked Server"}`), memberToken)
+	resp, err = app.Test(req)
+	require.NoError(t, err, "request should complete")
+	require.NotEqual(t, 200, resp.StatusCode, "moderator without manage_server should not rename the server")
+
+	t.Log("✓ Escalation rejected")
+
+	// Test 6: Moderator without create_post can not post but can delete any post
+	t.Log("=== Test 6: Moderator Permissions On Posts ===")
+	req = setup.CreateAuthRequest(http.MethodPost, fmt.Sprintf("/api/servers/%s/posts", serverId), nil, memberToken)
+	resp, err = app.Test(req)
+	require.NoError(t, err, "request should complete")
+	require.NotEqual(t, 200, resp.StatusCode, "moderator without create_post should not post")
+
+	result = setup.ParseJSONResponse(t, resp)
+	_, message, _ = setup.ParseErrorDetail(t, result)
+	require.Contains(t, message, "create_post", "error message should mention the missing permission")
+
+	req = setup.CreateAuthRequest(http.MethodDelete, fmt.Sprintf("/api/servers/%s/posts/%s", serverId, postId), nil, memberToken)
+	resp, err = app.Test(req)
+	require.NoError(t, err, "delete post request should complete")
+	require.Equal(t, 200, resp.StatusCode, "moderator should delete the post of someone else")
+
+	t.Log("✓ Post permissions enforced")
+
+	// Test 7: List roles
+	t.Log("=== Test 7: List Roles ===")
+	req = setup.CreateAuthRequest(http.MethodGet, rolesURL, nil, memberToken)
+	resp, err = app.Test(req)
+	require.NoError(t, err, "list roles request should complete")
+	require.Equal(t, 200, resp.StatusCode, "list roles should return 200")
+
+	apiResp := setup.ParseAPIResponse(t, resp)
+	roles := setup.GetDataAsArray(t, apiResp)
+
+	var ownerRoleId string
+	for _, item := range roles {
+		role := item.(map[string]interface{})
+		switch role["name"] {
+		case "Owner":
+			ownerRoleId = role["id"].(string)
+			require.Equal(t, []interface{}{"*"}, role["permissions"], "owner role should hold every permission")
+		case "Moderator":
+			require.Equal(t, float64(1), role["memberCount"], "moderator role should have one member")
+		}
+	}
+	require.NotEmpty(t, ownerRoleId, "owner role should be listed")
+
+	t.Logf("✓ Listed %d roles", len(roles))
+
+	// Test 8: Owner role is protected
+	t.Log("=== Test 8: Modify Owner Role ===")
+	req = setup.CreateAuthRequest(http.MethodPut, fmt.Sprintf("%s/%s", rolesURL, ownerRoleId), []byte(`{"name":"Boss","permissions":[]}`), ownerToken)
+	resp, err = app.Test(req)
+	require.NoError(t, err, "request should complete")
+	require.NotEqual(t, 200, resp.StatusCode, "owner role should not be modified")
+
+	req = setup.CreateAuthRequest(http.MethodDelete, fmt.Sprintf("%s/%s", rolesURL, ownerRoleId), nil, ownerToken)
+	resp, err = app.Test(req)
+	require.NoError(t, err, "request should complete")
+	require.NotEqual(t, 200, resp.StatusCode, "owner role should not be deleted")
+
+	t.Log("✓ Owner role protected")
+
+	// Test 9: Update role
+	t.Log("=== Test 9: Update Moderator Role ===")
+	reqBody = []byte(`{"name":"Helper","permissions":["delete_any_comment"]}`)
+	req = setup.CreateAuthRequest(http.MethodPut, fmt.Sprintf("%s/%s", rolesURL, moderatorRoleId), reqBody, ownerToken)
+	resp, err = app.Test(req)
+	require.NoError(t, err, "update role request should complete")
+	require.Equal(t, 200, resp.StatusCode, "update role should return 200")
+
+	result = setup.ParseJSONResponse(t, resp)
+	require.Equal(t, "Helper", result["name"], "role name should be updated")
+	require.Equal(t, []interface{}{"delete_any_comment"}, result["permissions"], "permissions should be replaced")
+
+	t.Log("✓ Role updated")
+
+	// Test 10: Deleting a role moves its members to the default member role
+	t.Log("=== Test 10: Delete Role ===")
+	req = setup.CreateAuthRequest(http.MethodDelete, fmt.Sprintf("%s/%s", rolesURL, moderatorRoleId), nil, ownerToken)
+	resp, err = app.Test(req)
+	require.NoError(t, err, "delete role request should complete")
+	require.Equal(t, 200, resp.StatusCode, "delete role should return 200")
+
+	var roleName string
+	err = db.QueryRow(ctx, `SELECT B.name FROM server_members A
+		JOIN server_roles B ON B.id = A.server_role_id
+		WHERE A.server_id = $1 AND A.user_id = $2`, serverId, memberId).Scan(&roleName)
+	require.NoError(t, err, "should read member role")
+	require.Equal(t, "Member", roleName, "member should fall back to the default member role")
+
+	t.Log("✓ Role deleted and members reassigned")
+
+	t.Log("=== All Server Role Tests Passed ===")
+}
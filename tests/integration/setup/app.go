@@ -2,10 +2,13 @@ package setup
 
 import (
 	"context"
+	"net"
 	"strconv"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/ferdian3456/virdanproject/internal/config"
 	"github.com/ferdian3456/virdanproject/internal/delivery/http"
 	"github.com/ferdian3456/virdanproject/internal/delivery/http/middleware"
 	"github.com/ferdian3456/virdanproject/internal/delivery/http/route"
@@ -39,9 +42,12 @@ func SetupTestApp(t *testing.T, pgURL, redisURL, minioURL, mailhogSMTP string) (
 
 	// Set uppercase keys for compatibility with existing code
 	_ = testConfig.Set("JWT_SECRET_KEY", "test-secret-key-for-jwt-token-generation")
+	_ = testConfig.Set("MINIO_URL", minioURL)
+	_ = testConfig.Set("MINIO_HTTP", "http://")
 	_ = testConfig.Set("MINIO_BUCKET_NAME", "virdan-test")
 	_ = testConfig.Set("MINIO_ACCESS_KEY", "minioadmin")
 	_ = testConfig.Set("MINIO_SECRET_KEY", "minioadmin")
+	_ = testConfig.Set("APP_URL", "http://localhost:8080")
 
 	// Use MailHog for SMTP
 	// mailhogSMTP format: host:port (e.g., localhost:32768)
@@ -108,6 +114,11 @@ func SetupTestApp(t *testing.T, pgURL, redisURL, minioURL, mailhogSMTP string) (
 		t.Logf("MinIO bucket already exists: %s", bucketName)
 	}
 
+	err = config.SetBucketPolicy(ctx, minioClient, bucketName)
+	if err != nil {
+		t.Fatalf("failed to set minio bucket policy: %v", err)
+	}
+
 	// 6. Setup logger (use development config for test)
 	zapLogger := zap.NewExample()
 	defer func() {
@@ -118,16 +129,32 @@ func SetupTestApp(t *testing.T, pgURL, redisURL, minioURL, mailhogSMTP string) (
 	serverRepository := repository.NewServerRepository(zapLogger, dbPool, redisClient, minioClient)
 	userRepository := repository.NewUserRepository(zapLogger, dbPool, redisClient, minioClient)
 	postRepository := repository.NewPostRepository(zapLogger, dbPool, redisClient, minioClient)
+	uploadRepository := repository.NewUploadRepository(zapLogger, dbPool, redisClient, minioClient)
+	eventRepository := repository.NewEventRepository(zapLogger, dbPool, redisClient, minioClient)
+	notificationRepository := repository.NewNotificationRepository(zapLogger, dbPool, redisClient, minioClient)
 
 	// 8. Setup usecases
-	serverUsecase := usecase.NewServerUsecase(serverRepository, dbPool, zapLogger, testConfig)
+	serverUsecase := usecase.NewServerUsecase(serverRepository, userRepository, eventRepository, notificationRepository, dbPool, zapLogger, testConfig)
 	userUsecase := usecase.NewUserUsecase(userRepository, serverRepository, dbPool, zapLogger, testConfig)
-	postUsecase := usecase.NewPostUsecase(postRepository, dbPool, zapLogger, testConfig)
+	postUsecase := usecase.NewPostUsecase(postRepository, serverRepository, eventRepository, notificationRepository, dbPool, zapLogger, testConfig)
+	uploadUsecase := usecase.NewUploadUsecase(uploadRepository, userUsecase, serverUsecase, postUsecase, zapLogger, testConfig)
+	notificationUsecase := usecase.NewNotificationUsecase(notificationRepository, zapLogger, testConfig)
+	eventUsecase := usecase.NewEventUsecase(eventRepository, zapLogger, testConfig)
+	err = eventUsecase.Start(ctx)
+	if err != nil {
+		t.Fatalf("failed to subscribe to server events: %v", err)
+	}
 
 	// 9. Setup controllers
 	serverController := http.NewServerController(serverUsecase, zapLogger, testConfig)
 	userController := http.NewUserController(userUsecase, zapLogger, testConfig)
 	postController := http.NewPostController(postUsecase, zapLogger, testConfig)
+	uploadController := http.NewUploadController(uploadUsecase, zapLogger, testConfig)
+	eventController := http.NewEventController(eventUsecase, userUsecase, zapLogger, testConfig)
+	notificationController := http.NewNotificationController(notificationUsecase, zapLogger, testConfig)
+
+	// Ping often so tests see a session end on an open event connection without waiting long
+	eventController.PingInterval = time.Second
 
 	// 10. Setup middleware
 	authMiddleware := middleware.NewAuthMiddleware(nil, zapLogger, testConfig, userUsecase)
@@ -150,11 +177,14 @@ func SetupTestApp(t *testing.T, pgURL, redisURL, minioURL, mailhogSMTP string) (
 
 	// 12. Setup routes
 	routeConfig := route.RouteConfig{
-		App:              fiberApp,
-		UserController:   userController,
-		ServerController: serverController,
-		PostController:   postController,
-		AuthMiddleware:   authMiddleware,
+		App:                    fiberApp,
+		UserController:         userController,
+		ServerController:       serverController,
+		PostController:         postController,
+		UploadController:       uploadController,
+		EventController:        eventController,
+		NotificationController: notificationController,
+		AuthMiddleware:         authMiddleware,
 	}
 
 	routeConfig.SetupRoute()
@@ -163,3 +193,19 @@ func SetupTestApp(t *testing.T, pgURL, redisURL, minioURL, mailhogSMTP string) (
 
 	return fiberApp, dbPool, redisClient, minioClient
 }
+
+// ListenTestApp serves the app on a random local port for clients that need a real connection, such as
+// WebSocket clients. Keepalive is enabled again because fasthttp closes the connection before a hijack
+func ListenTestApp(t *testing.T, app *fiber.App) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen for test app: %v", err)
+	}
+
+	app.Server().DisableKeepalive = false
+
+	go func() { _ = app.Listener(listener) }()
+	t.Cleanup(func() { _ = app.Shutdown() })
+
+	return listener.Addr().String()
+}
@@ -245,6 +245,49 @@ func GetOTPFromMailhog(t *testing.T, mailhogURL, email string) string {
 	return ""
 }
 
+// ClearMailhogMessages deletes every message stored in MailHog
+// Dipakai sebelum mengirim email baru ke alamat yang sama supaya OTP lama tidak ikut terbaca
+func ClearMailhogMessages(t *testing.T, mailhogURL string) {
+	apiURL := fmt.Sprintf("%s/api/v1/messages", mailhogURL)
+
+	req, err := http.NewRequest(http.MethodDelete, apiURL, nil)
+	require.NoError(t, err, "failed to create MailHog delete request")
+
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err, "failed to delete messages from MailHog")
+	defer func() { _ = resp.Body.Close() }()
+
+	require.Equal(t, http.StatusOK, resp.StatusCode, "MailHog should delete messages")
+}
+
+// CountMailhogMessages counts the messages in MailHog sent to the email with the given subject
+func CountMailhogMessages(t *testing.T, mailhogURL, email, subject string) int {
+	apiURL := fmt.Sprintf("%s/api/v1/messages", mailhogURL)
+
+	// #nosec G107 -- apiURL is a trusted localhost test server (MailHog)
+	resp, err := http.Get(apiURL)
+	require.NoError(t, err, "failed to fetch messages from MailHog")
+	defer func() { _ = resp.Body.Close() }()
+
+	var messages []struct {
+		Content struct {
+			Headers map[string][]string `json:"Headers"`
+		} `json:"Content"`
+	}
+	err = json.NewDecoder(resp.Body).Decode(&messages)
+	require.NoError(t, err, "failed to parse MailHog JSON response")
+
+	count := 0
+	for _, message := range messages {
+		headers := message.Content.Headers
+		if len(headers["To"]) > 0 && headers["To"][0] == email && len(headers["Subject"]) > 0 && headers["Subject"][0] == subject {
+			count++
+		}
+	}
+
+	return count
+}
+
 // GenerateRandomString generates a random string of specified length
 // Uses lowercase letters and numbers for test data generation
 func GenerateRandomString(length int) string {
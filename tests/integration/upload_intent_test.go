@@ -0,0 +1,229 @@
+package integration
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/minio/minio-go/v7"
+	"go.uber.org/zap"
+
+	"github.com/ferdian3456/virdanproject/internal/constant"
+	"github.com/ferdian3456/virdanproject/internal/repository"
+	"github.com/ferdian3456/virdanproject/internal/usecase"
+	"github.com/ferdian3456/virdanproject/tests/integration/setup"
+	"github.com/stretchr/testify/require"
+)
+
+// createTestUploadIntent is a helper function to request an upload intent
+func createTestUploadIntent(t *testing.T, app *fiber.App, accessToken string, payload map[string]interface{}) (*http.Response, map[string]interface{}) {
+	reqBody, err := json.Marshal(payload)
+	require.NoError(t, err, "should marshal upload intent request")
+
+	req := setup.CreateAuthRequest(http.MethodPost, "/api/uploads/", reqBody, accessToken)
+	resp, err := app.Test(req)
+	require.NoError(t, err, "upload intent request should complete")
+
+	return resp, setup.ParseJSONResponse(t, resp)
+}
+
+// finalizeTestUpload is a helper function to finalize an upload intent
+func finalizeTestUpload(t *testing.T, app *fiber.App, accessToken, uploadId string) (*http.Response, map[string]interface{}) {
+	req := setup.CreateAuthRequest(http.MethodPost, fmt.Sprintf("/api/uploads/%s/finalize", uploadId), nil, accessToken)
+	resp, err := app.Test(req, -1)
+	require.NoError(t, err, "finalize request should complete")
+
+	return resp, setup.ParseJSONResponse(t, resp)
+}
+
+// postTestUpload is a helper function to send the image straight to MinIO with the presigned form fields,
+// it returns the status code of MinIO
+func postTestUpload(t *testing.T, intent map[string]interface{}, data []byte) int {
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	for key, value := range intent["uploadFields"].(map[string]interface{}) {
+		require.NoError(t, writer.WriteField(key, value.(string)), "should write upload field")
+	}
+
+	part, err := writer.CreateFormFile("file", "upload")
+	require.NoError(t, err, "should create upload file part")
+	_, err = part.Write(data)
+	require.NoError(t, err, "should write upload file")
+	require.NoError(t, writer.Close(), "should close upload form")
+
+	req, err := http.NewRequest(http.MethodPost, intent["uploadUrl"].(string), body)
+	require.NoError(t, err, "should build upload request")
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err, "upload to MinIO should complete")
+	defer func() { _ = resp.Body.Close() }()
+
+	return resp.StatusCode
+}
+
+// TestUploadIntents tests presigned uploads from intent to finalize and cleanup
+func TestUploadIntents(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test in short mode")
+	}
+
+	ctx := context.Background()
+
+	t.Log("=== Starting Test Infrastructure ===")
+	infra, err := setup.StartInfra(ctx, t)
+	require.NoError(t, err, "infrastructure should start successfully")
+	defer func() { _ = infra.Terminate(ctx, t) }()
+
+	t.Log("=== Running Database Migrations ===")
+	_ = setup.RunMigration(infra.PgURL, t)
+
+	t.Log("=== Setting Up Test Application ===")
+	app, db, _, minioClient := setup.SetupTestApp(t, infra.PgURL, infra.RedisURL, infra.MinioURL, infra.MailhogSMTP)
+	defer db.Close()
+
+	t.Log("=== Setup: Creating Users, Server And Post ===")
+	accessToken := createTestUser(t, app, infra.MailhogURL, "uploaduser@example.com", "uploaduser", "pass123")
+	otherToken := createTestUser(t, app, infra.MailhogURL, "uploadother@example.com", "uploadother", "pass123")
+	server := createTestServer(t, app, accessToken)
+	serverId := server["id"].(string)
+	postId := createTestPost(t, app, accessToken, serverId, "Upload post")
+
+	testImageData, err := getTestImage()
+	require.NoError(t, err, "should read test image")
+
+	// Test 1: Unknown purpose is rejected
+	t.Log("=== Test 1: Invalid Purpose ===")
+	resp, result := createTestUploadIntent(t, app, accessToken, map[string]interface{}{"purpose": "cover", "contentType": "image/jpeg"})
+	require.NotEqual(t, 200, resp.StatusCode, "unknown purpose should be rejected")
+
+	code, message, param := setup.ParseErrorDetail(t, result)
+	require.Equal(t, "purpose", param, "error param should be 'purpose'")
+
+	t.Logf("✓ Validation Error: Code=%s, Message=%s, Param=%s", code, message, param)
+
+	// Test 2: Server and post uploads need a target
+	t.Log("=== Test 2: Missing Target ===")
+	resp, result = createTestUploadIntent(t, app, accessToken, map[string]interface{}{"purpose": "post", "contentType": "image/jpeg"})
+	require.NotEqual(t, 200, resp.StatusCode, "missing target should be rejected")
+
+	_, _, param = setup.ParseErrorDetail(t, result)
+	require.Equal(t, "targetId", param, "error param should be 'targetId'")
+
+	t.Log("✓ Missing target rejected")
+
+	// Test 3: Finalizing before uploading keeps the intent
+	t.Log("=== Test 3: Finalize Before Upload ===")
+	resp, result = createTestUploadIntent(t, app, accessToken, map[string]interface{}{"purpose": "user_avatar", "contentType": "image/jpeg"})
+	require.Equal(t, 200, resp.StatusCode, "avatar intent should return 200")
+	require.Equal(t, http.MethodPost, result["method"], "upload should use POST")
+	require.NotEmpty(t, result["uploadUrl"], "intent should carry the presigned url")
+	require.NotEmpty(t, result["uploadFields"], "intent should carry the presigned form fields")
+
+	avatarUploadId := result["id"].(string)
+	avatarIntent := result
+
+	resp, result = finalizeTestUpload(t, app, accessToken, avatarUploadId)
+	require.NotEqual(t, 200, resp.StatusCode, "finalize without upload should be rejected")
+
+	_, message, _ = setup.ParseErrorDetail(t, result)
+	require.Equal(t, "Image has not been uploaded yet", message, "error should say nothing was uploaded")
+
+	t.Log("✓ Early finalize rejected")
+
+	// Test 4: Avatar uploaded straight to MinIO is attached on finalize
+	t.Log("=== Test 4: Finalize Avatar Upload ===")
+	tooLarge := make([]byte, constant.MAX_FILE_SIZE+1)
+	require.Equal(t, 400, postTestUpload(t, avatarIntent, tooLarge), "MinIO should refuse an object over the size limit")
+	require.Equal(t, 204, postTestUpload(t, avatarIntent, testImageData), "MinIO should accept the upload")
+
+	resp, result = finalizeTestUpload(t, app, otherToken, avatarUploadId)
+	require.NotEqual(t, 200, resp.StatusCode, "another user should not finalize the upload")
+
+	resp, _ = finalizeTestUpload(t, app, accessToken, avatarUploadId)
+	require.Equal(t, 200, resp.StatusCode, "finalize avatar should return 200")
+
+	req := setup.CreateAuthRequest(http.MethodGet, "/api/users/me", nil, accessToken)
+	resp, err = app.Test(req)
+	require.NoError(t, err, "get profile request should complete")
+	require.Equal(t, 200, resp.StatusCode, "get profile should return 200")
+
+	result = setup.ParseJSONResponse(t, resp)
+	require.NotEmpty(t, result["avatarImageUrls"], "profile should expose the uploaded avatar")
+
+	var objectKey string
+	err = db.QueryRow(ctx, "SELECT object_key FROM upload_intents WHERE id = $1", avatarUploadId).Scan(&objectKey)
+	require.Error(t, err, "finalized intent should be deleted")
+
+	t.Log("✓ Avatar attached")
+
+	// Test 5: An intent is only finalized once
+	t.Log("=== Test 5: Finalize Twice ===")
+	resp, result = finalizeTestUpload(t, app, accessToken, avatarUploadId)
+	require.NotEqual(t, 200, resp.StatusCode, "second finalize should be rejected")
+
+	_, message, _ = setup.ParseErrorDetail(t, result)
+	require.Equal(t, "Upload not found", message, "used intent should not be found")
+
+	t.Log("✓ Second finalize rejected")
+
+	// Test 6: Post upload is appended after the existing media
+	t.Log("=== Test 6: Finalize Post Upload ===")
+	resp, result = createTestUploadIntent(t, app, otherToken, map[string]interface{}{"purpose": "post", "targetId": postId, "contentType": "image/jpeg"})
+	require.NotEqual(t, 200, resp.StatusCode, "non member should not upload to the post")
+
+	resp, result = createTestUploadIntent(t, app, accessToken, map[string]interface{}{"purpose": "post", "targetId": postId, "contentType": "image/jpeg"})
+	require.Equal(t, 200, resp.StatusCode, "post intent should return 200")
+
+	postUploadId := result["id"].(string)
+	require.Equal(t, 204, postTestUpload(t, result, testImageData), "MinIO should accept the upload")
+
+	resp, _ = finalizeTestUpload(t, app, accessToken, postUploadId)
+	require.Equal(t, 200, resp.StatusCode, "finalize post image should return 200")
+
+	var total, lastPosition int
+	err = db.QueryRow(ctx, "SELECT COUNT(*), MAX(position) FROM server_post_media WHERE post_id = $1", postId).Scan(&total, &lastPosition)
+	require.NoError(t, err, "should count post media")
+	require.Equal(t, 2, total, "post should hold the created and the uploaded image")
+	require.Equal(t, 1, lastPosition, "uploaded image should come last")
+
+	t.Log("✓ Post image appended")
+
+	// Test 7: Expired intents are rejected and purged with their objects
+	t.Log("=== Test 7: Expired Upload ===")
+	resp, result = createTestUploadIntent(t, app, accessToken, map[string]interface{}{"purpose": "server_banner", "targetId": serverId, "contentType": "image/jpeg"})
+	require.Equal(t, 200, resp.StatusCode, "banner intent should return 200")
+
+	expiredUploadId := result["id"].(string)
+	require.Equal(t, 204, postTestUpload(t, result, testImageData), "MinIO should accept the upload")
+
+	err = db.QueryRow(ctx, "UPDATE upload_intents SET expires_datetime = NOW() - INTERVAL '1 minute' WHERE id = $1 RETURNING object_key", expiredUploadId).Scan(&objectKey)
+	require.NoError(t, err, "should expire the intent")
+
+	resp, result = finalizeTestUpload(t, app, accessToken, expiredUploadId)
+	require.NotEqual(t, 200, resp.StatusCode, "expired intent should be rejected")
+
+	_, message, _ = setup.ParseErrorDetail(t, result)
+	require.Equal(t, "Upload has expired", message, "error should say the upload expired")
+
+	uploadRepository := repository.NewUploadRepository(zap.NewNop(), db, nil, minioClient)
+	uploadUsecase := usecase.NewUploadUsecase(uploadRepository, nil, nil, nil, zap.NewNop(), nil)
+	err = uploadUsecase.PurgeExpiredUploadIntents(ctx)
+	require.NoError(t, err, "purge should succeed")
+
+	err = db.QueryRow(ctx, "SELECT COUNT(*) FROM upload_intents WHERE id = $1", expiredUploadId).Scan(&total)
+	require.NoError(t, err, "should count intents")
+	require.Equal(t, 0, total, "expired intent should be purged")
+
+	_, err = minioClient.StatObject(ctx, "virdan-test", objectKey, minio.StatObjectOptions{})
+	require.Error(t, err, "expired upload object should be removed from MinIO")
+
+	t.Log("✓ Expired upload purged")
+
+	t.Log("=== All Upload Intent Tests Passed ===")
+}
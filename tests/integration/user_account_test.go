@@ -0,0 +1,293 @@
+package integration
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/knadh/koanf/v2"
+	"github.com/minio/minio-go/v7"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+
+	"github.com/ferdian3456/virdanproject/internal/repository"
+	"github.com/ferdian3456/virdanproject/internal/usecase"
+	"github.com/ferdian3456/virdanproject/tests/integration/setup"
+	"github.com/stretchr/testify/require"
+)
+
+// createTestPost is a helper function to create a post with the test image and return its id
+func createTestPost(t *testing.T, app *fiber.App, accessToken, serverId, caption string) string {
+	testImageData, err := getTestImage()
+	require.NoError(t, err, "should read test image")
+
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+
+	h := make(textproto.MIMEHeader)
+	h.Set("Content-Disposition", `form-data; name="image"; filename="test_image.jpg"`)
+	h.Set("Content-Type", "image/jpeg")
+	part, err := writer.CreatePart(h)
+	require.NoError(t, err, "should create form part")
+	_, err = part.Write(testImageData)
+	require.NoError(t, err, "should write image data")
+
+	err = writer.WriteField("caption", caption)
+	require.NoError(t, err, "should write caption field")
+
+	err = writer.Close()
+	require.NoError(t, err, "should close writer")
+
+	req := setup.CreateAuthRequest(http.MethodPost, fmt.Sprintf("/api/servers/%s/posts", serverId), body.Bytes(), accessToken)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	resp, err := app.Test(req)
+	require.NoError(t, err, "create post request should complete")
+	require.Equal(t, 200, resp.StatusCode, "create post should return 200")
+
+	result := setup.ParseJSONResponse(t, resp)
+	return result["postId"].(string)
+}
+
+// addTestServerMember is a helper function to add an active member with a plain role straight into the database
+func addTestServerMember(t *testing.T, db *pgxpool.Pool, serverId, userId uuid.UUID) {
+	ctx := context.Background()
+	now := time.Now().UTC()
+
+	roleId := uuid.New()
+	_, err := db.Exec(ctx, `INSERT INTO server_roles (id, server_id, name, permissions, create_datetime, update_datetime, create_user_id, update_user_id)
+		VALUES ($1, $2, $3, '{}', $4, $4, $5, $5)`, roleId, serverId, "Guest-"+userId.String()[:8], now, userId)
+	require.NoError(t, err, "should create test role")
+
+	_, err = db.Exec(ctx, `INSERT INTO server_members (id, server_id, user_id, server_role_id, status, joined_datetime, create_datetime, update_datetime, create_user_id, update_user_id)
+		VALUES ($1, $2, $3, $4, 1, $5, $5, $5, $3, $3)`, uuid.New(), serverId, userId, roleId, now)
+	require.NoError(t, err, "should create test member")
+}
+
+// getUserId is a helper function to read the id of the logged in user
+func getUserId(t *testing.T, app *fiber.App, accessToken string) uuid.UUID {
+	req := setup.CreateAuthRequest(http.MethodGet, "/api/users/me", nil, accessToken)
+	resp, err := app.Test(req)
+	require.NoError(t, err, "get profile request should complete")
+	require.Equal(t, 200, resp.StatusCode, "get profile should return 200")
+
+	result := setup.ParseJSONResponse(t, resp)
+	return uuid.MustParse(result["id"].(string))
+}
+
+// newTestUserUsecase builds a UserUsecase against the test infrastructure for jobs that are not exposed over HTTP
+func newTestUserUsecase(db *pgxpool.Pool, rdb *redis.Client, minioClient *minio.Client) *usecase.UserUsecase {
+	zapLogger := zap.NewExample()
+	testConfig := koanf.New(".")
+	_ = testConfig.Set("MINIO_BUCKET_NAME", "virdan-test")
+
+	serverRepository := repository.NewServerRepository(zapLogger, db, rdb, minioClient)
+	userRepository := repository.NewUserRepository(zapLogger, db, rdb, minioClient)
+
+	return usecase.NewUserUsecase(userRepository, serverRepository, db, zapLogger, testConfig)
+}
+
+// TestDeleteAccount tests the DELETE /users/account and GET /users/account/export endpoints
+func TestDeleteAccount(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test in short mode")
+	}
+
+	ctx := context.Background()
+
+	t.Log("=== Starting Test Infrastructure ===")
+	infra, err := setup.StartInfra(ctx, t)
+	require.NoError(t, err, "infrastructure should start successfully")
+	defer func() { _ = infra.Terminate(ctx, t) }()
+
+	t.Log("=== Running Database Migrations ===")
+	_ = setup.RunMigration(infra.PgURL, t)
+
+	t.Log("=== Setting Up Test Application ===")
+	app, db, rdb, minioClient := setup.SetupTestApp(t, infra.PgURL, infra.RedisURL, infra.MinioURL, infra.MailhogSMTP)
+	defer db.Close()
+
+	// Setup: owner with a server and a post, member with a comment on that post
+	t.Log("=== Setup: Creating Owner, Member, Server And Post ===")
+	ownerToken := createTestUser(t, app, infra.MailhogURL, "accountowner@example.com", "accountowner", "pass123")
+	memberToken := createTestUser(t, app, infra.MailhogURL, "accountmember@example.com", "accountmember", "pass123")
+	ownerId := getUserId(t, app, ownerToken)
+	memberId := getUserId(t, app, memberToken)
+
+	server := createTestServer(t, app, ownerToken)
+	serverId := uuid.MustParse(server["id"].(string))
+	postId := createTestPost(t, app, ownerToken, serverId.String(), "Post that outlives a deleted commenter")
+
+	addTestServerMember(t, db, serverId, memberId)
+
+	reqBody := []byte(`{"content":"Comment from a soon deleted account"}`)
+	req := setup.CreateAuthRequest(http.MethodPost, fmt.Sprintf("/api/posts/%s/comments", postId), reqBody, memberToken)
+	resp, err := app.Test(req)
+	require.NoError(t, err, "create comment request should complete")
+	require.Equal(t, 200, resp.StatusCode, "create comment should return 200")
+
+	// Test 1: Delete account with wrong password
+	t.Log("=== Test 1: Delete Account With Wrong Password ===")
+	req = setup.CreateAuthRequest(http.MethodDelete, "/api/users/account", []byte(`{"password":"wrongpass"}`), memberToken)
+	resp, err = app.Test(req)
+	require.NoError(t, err, "request should complete")
+	require.Equal(t, 400, resp.StatusCode, "wrong password should return 400")
+
+	result := setup.ParseJSONResponse(t, resp)
+	_, _, param := setup.ParseErrorDetail(t, result)
+	require.Equal(t, "password", param, "error param should be 'password'")
+
+	t.Log("✓ Wrong password rejected")
+
+	// Test 2: Owner of a server with other members must act first
+	t.Log("=== Test 2: Delete Account While Owning Server With Members ===")
+	req = setup.CreateAuthRequest(http.MethodDelete, "/api/users/account", []byte(`{"password":"pass123"}`), ownerToken)
+	resp, err = app.Test(req)
+	require.NoError(t, err, "request should complete")
+	require.Equal(t, 400, resp.StatusCode, "owner with members should return 400")
+
+	result = setup.ParseJSONResponse(t, resp)
+	code, message, param := setup.ParseErrorDetail(t, result)
+	require.Equal(t, "servers", param, "error param should be 'servers'")
+
+	t.Logf("✓ Validation Error: Code=%s, Message=%s, Param=%s", code, message, param)
+
+	// Test 3: Export account data
+	t.Log("=== Test 3: Export Account Data ===")
+	req = setup.CreateAuthRequest(http.MethodGet, "/api/users/account/export", nil, memberToken)
+	resp, err = app.Test(req)
+	require.NoError(t, err, "export request should complete")
+	require.Equal(t, 200, resp.StatusCode, "export should return 200")
+
+	result = setup.ParseJSONResponse(t, resp)
+	require.Equal(t, "accountmember", result["profile"].(map[string]interface{})["username"], "profile should be exported")
+	require.Len(t, result["memberships"].([]interface{}), 1, "membership should be exported")
+	require.Len(t, result["comments"].([]interface{}), 1, "comment should be exported")
+
+	t.Log("✓ Account data exported")
+
+	// Test 4: Schedule account deletion
+	t.Log("=== Test 4: Schedule Account Deletion ===")
+	req = setup.CreateAuthRequest(http.MethodDelete, "/api/users/account", []byte(`{"password":"pass123"}`), memberToken)
+	resp, err = app.Test(req)
+	require.NoError(t, err, "request should complete")
+	require.Equal(t, 200, resp.StatusCode, "delete account should return 200")
+
+	result = setup.ParseJSONResponse(t, resp)
+	require.NotEmpty(t, result["deleteScheduledDatetime"], "deletion should be scheduled")
+
+	req = setup.CreateAuthRequest(http.MethodGet, "/api/users/me", nil, memberToken)
+	resp, err = app.Test(req)
+	require.NoError(t, err, "request should complete")
+	require.NotEqual(t, 200, resp.StatusCode, "tokens should be revoked after deletion request")
+
+	t.Log("✓ Account deletion scheduled")
+
+	// Test 5: Logging in during the grace period restores the account
+	t.Log("=== Test 5: Restore Account By Logging In ===")
+	memberToken = loginWithDevice(t, app, "accountmember", "pass123", "Laptop")["accessToken"].(string)
+
+	var scheduled *time.Time
+	err = db.QueryRow(ctx, "SELECT delete_scheduled_datetime FROM users WHERE id = $1", memberId).Scan(&scheduled)
+	require.NoError(t, err, "should read deletion schedule")
+	require.Nil(t, scheduled, "deletion should be cancelled")
+
+	t.Log("✓ Account restored")
+
+	// Test 6: Purge after the grace period anonymizes comments and keeps the post
+	t.Log("=== Test 6: Purge Account After Grace Period ===")
+	req = setup.CreateAuthRequest(http.MethodDelete, "/api/users/account", []byte(`{"password":"pass123"}`), memberToken)
+	resp, err = app.Test(req)
+	require.NoError(t, err, "request should complete")
+	require.Equal(t, 200, resp.StatusCode, "delete account should return 200")
+
+	_, err = db.Exec(ctx, "UPDATE users SET delete_scheduled_datetime = $1 WHERE id = $2", time.Now().UTC().Add(-time.Minute), memberId)
+	require.NoError(t, err, "should move deletion schedule into the past")
+
+	userUsecase := newTestUserUsecase(db, rdb, minioClient)
+	err = userUsecase.PurgeDeletedAccounts(ctx)
+	require.NoError(t, err, "purge should succeed")
+
+	var total int
+	err = db.QueryRow(ctx, "SELECT COUNT(*) FROM users WHERE id = $1", memberId).Scan(&total)
+	require.NoError(t, err, "should count users")
+	require.Equal(t, 0, total, "member should be hard deleted")
+
+	req = setup.CreateAuthRequest(http.MethodGet, fmt.Sprintf("/api/posts/%s/comments", postId), nil, ownerToken)
+	resp, err = app.Test(req)
+	require.NoError(t, err, "get comments request should complete")
+	require.Equal(t, 200, resp.StatusCode, "get comments should return 200")
+
+	apiResp := setup.ParseAPIResponse(t, resp)
+	comments := setup.GetDataAsArray(t, apiResp)
+	require.Len(t, comments, 1, "comment should survive account deletion")
+	require.Nil(t, comments[0].(map[string]interface{})["authorId"], "comment should be anonymized")
+	author := comments[0].(map[string]interface{})["author"].(map[string]interface{})
+	require.Equal(t, "deleted", author["status"], "deleted author should be a placeholder")
+	require.Equal(t, "Deleted user", author["username"], "deleted author should not keep a name")
+	require.Nil(t, author["role"], "deleted author should have no role")
+
+	t.Log("✓ Member purged, comment anonymized")
+
+	// Test 7: Purging the owner of an empty server removes the server and its objects
+	t.Log("=== Test 7: Purge Owner Of Empty Server ===")
+	var objectKey string
+	err = db.QueryRow(ctx, "SELECT object_key FROM server_post_images LIMIT 1").Scan(&objectKey)
+	require.NoError(t, err, "should read post image object key")
+
+	_, err = db.Exec(ctx, "UPDATE users SET delete_requested_datetime = $1, delete_scheduled_datetime = $1 WHERE id = $2", time.Now().UTC().Add(-time.Minute), ownerId)
+	require.NoError(t, err, "should schedule owner deletion in the past")
+
+	err = userUsecase.PurgeDeletedAccounts(ctx)
+	require.NoError(t, err, "purge should succeed")
+
+	err = db.QueryRow(ctx, "SELECT COUNT(*) FROM servers WHERE id = $1", serverId).Scan(&total)
+	require.NoError(t, err, "should count servers")
+	require.Equal(t, 0, total, "empty server should be deleted with its owner")
+
+	err = db.QueryRow(ctx, "SELECT COUNT(*) FROM server_post_images").Scan(&total)
+	require.NoError(t, err, "should count post images")
+	require.Equal(t, 0, total, "post image rows should be deleted")
+
+	_, err = minioClient.StatObject(ctx, "virdan-test", objectKey, minio.StatObjectOptions{})
+	require.Error(t, err, "post image object should be removed from MinIO")
+
+	t.Log("✓ Owner purged with empty server and objects")
+
+	// Test 8: Purging the owner of a server with members hands it to the longest standing member
+	t.Log("=== Test 8: Purge Owner Of Server With Members ===")
+	heirOwnerToken := createTestUser(t, app, infra.MailhogURL, "heirowner@example.com", "heirowner", "pass123")
+	heirOwnerId := getUserId(t, app, heirOwnerToken)
+	heirToken := createTestUser(t, app, infra.MailhogURL, "heir@example.com", "heir", "pass123")
+	heirId := getUserId(t, app, heirToken)
+	heirServerId := uuid.MustParse(createTestServer(t, app, heirOwnerToken)["id"].(string))
+	addTestServerMember(t, db, heirServerId, heirId)
+
+	_, err = db.Exec(ctx, "UPDATE users SET delete_requested_datetime = $1, delete_scheduled_datetime = $1 WHERE id = $2", time.Now().UTC().Add(-time.Minute), heirOwnerId)
+	require.NoError(t, err, "should schedule owner deletion in the past")
+
+	err = userUsecase.PurgeDeletedAccounts(ctx)
+	require.NoError(t, err, "purge should succeed")
+
+	var newOwnerId uuid.UUID
+	err = db.QueryRow(ctx, "SELECT owner_id FROM servers WHERE id = $1", heirServerId).Scan(&newOwnerId)
+	require.NoError(t, err, "server with members should be kept")
+	require.Equal(t, heirId, newOwnerId, "longest standing member should own the server")
+
+	var roleName string
+	err = db.QueryRow(ctx, `SELECT B.name FROM server_members A INNER JOIN server_roles B ON B.id = A.server_role_id
+		WHERE A.server_id = $1 AND A.user_id = $2`, heirServerId, heirId).Scan(&roleName)
+	require.NoError(t, err, "should read role of the new owner")
+	require.Equal(t, "Owner", roleName, "new owner should hold the Owner role")
+
+	t.Log("✓ Server handed to the longest standing member")
+
+	t.Log("=== All Delete Account Tests Passed ===")
+}
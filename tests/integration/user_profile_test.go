@@ -1,12 +1,17 @@
 package integration
 
 import (
+	"bytes"
 	"context"
 	"fmt"
+	"mime/multipart"
 	"net/http"
+	"net/textproto"
 	"testing"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/minio/minio-go/v7"
 
 	"github.com/ferdian3456/virdanproject/tests/integration/setup"
 	"github.com/stretchr/testify/require"
@@ -484,3 +489,268 @@ func TestUpdateBio(t *testing.T) {
 
 	t.Log("=== All Update Bio Tests Passed ===")
 }
+
+// TestChangePassword tests the PATCH /users/password endpoint
+func TestChangePassword(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test in short mode")
+	}
+
+	ctx := context.Background()
+
+	t.Log("=== Starting Test Infrastructure ===")
+	infra, err := setup.StartInfra(ctx, t)
+	require.NoError(t, err, "infrastructure should start successfully")
+	defer func() { _ = infra.Terminate(ctx, t) }()
+
+	t.Log("=== Running Database Migrations ===")
+	_ = setup.RunMigration(infra.PgURL, t)
+
+	t.Log("=== Setting Up Test Application ===")
+	app, db, _, _ := setup.SetupTestApp(t, infra.PgURL, infra.RedisURL, infra.MinioURL, infra.MailhogSMTP)
+	defer db.Close()
+
+	// Create test user with a second device logged in
+	t.Log("=== Setup: Creating Test User ===")
+	accessToken := createTestUser(t, app, infra.MailhogURL, "changepassword@example.com", "changepwuser", "pass123")
+	otherDevice := loginWithDevice(t, app, "changepwuser", "pass123", "Phone")
+
+	// Test 1: Change password with wrong current password
+	t.Log("=== Test 1: Change Password With Wrong Current Password ===")
+	reqBody := []byte(`{"currentPassword":"wrongpass","newPassword":"newpass123"}`)
+	req := setup.CreateAuthRequest(http.MethodPatch, "/api/users/password", reqBody, accessToken)
+	resp, err := app.Test(req)
+	require.NoError(t, err, "request should complete")
+	require.Equal(t, 400, resp.StatusCode, "wrong current password should return 400")
+
+	result := setup.ParseJSONResponse(t, resp)
+	code, message, param := setup.ParseErrorDetail(t, result)
+	require.Equal(t, "VALIDATION_ERROR", code, "error code should be VALIDATION_ERROR")
+	require.Equal(t, "currentPassword", param, "error param should be 'currentPassword'")
+
+	t.Logf("✓ Validation Error: Code=%s, Message=%s, Param=%s", code, message, param)
+
+	// Test 2: Change password with too short new password
+	t.Log("=== Test 2: Change Password With Too Short New Password ===")
+	reqBody = []byte(`{"currentPassword":"pass123","newPassword":"abc"}`)
+	req = setup.CreateAuthRequest(http.MethodPatch, "/api/users/password", reqBody, accessToken)
+	resp, err = app.Test(req)
+	require.NoError(t, err, "request should complete")
+	require.Equal(t, 400, resp.StatusCode, "short new password should return 400")
+
+	result = setup.ParseJSONResponse(t, resp)
+	_, message, param = setup.ParseErrorDetail(t, result)
+	require.Equal(t, "newPassword", param, "error param should be 'newPassword'")
+	require.Contains(t, message, "at least 5 characters", "error message should mention minimum length")
+
+	t.Log("✓ Short new password rejected")
+
+	// Test 3: Change password successfully
+	t.Log("=== Test 3: Change Password Successfully ===")
+	reqBody = []byte(`{"currentPassword":"pass123","newPassword":"newpass123"}`)
+	req = setup.CreateAuthRequest(http.MethodPatch, "/api/users/password", reqBody, accessToken)
+	resp, err = app.Test(req)
+	require.NoError(t, err, "request should complete")
+	require.Equal(t, 200, resp.StatusCode, "change password should return 200")
+
+	result = setup.ParseJSONResponse(t, resp)
+	newAccessToken, ok := result["accessToken"].(string)
+	require.True(t, ok, "accessToken should be present in response")
+	require.NotEmpty(t, result["refreshToken"], "refreshToken should be present in response")
+
+	t.Log("✓ Password changed successfully")
+
+	// Test 4: Calling client stays logged in with the fresh token pair
+	t.Log("=== Test 4: Calling Client Stays Logged In ===")
+	req = setup.CreateAuthRequest(http.MethodGet, "/api/users/me", nil, newAccessToken)
+	resp, err = app.Test(req)
+	require.NoError(t, err, "request should complete")
+	require.Equal(t, 200, resp.StatusCode, "new access token should be accepted")
+
+	t.Log("✓ Fresh token pair works")
+
+	// Test 5: Other sessions are revoked
+	t.Log("=== Test 5: Other Sessions Are Revoked ===")
+	req = setup.CreateAuthRequest(http.MethodGet, "/api/users/me", nil, otherDevice["accessToken"].(string))
+	resp, err = app.Test(req)
+	require.NoError(t, err, "request should complete")
+	require.NotEqual(t, 200, resp.StatusCode, "other device token should be revoked")
+
+	reqBody = []byte(fmt.Sprintf(`{"refreshToken":"%s"}`, otherDevice["refreshToken"].(string)))
+	req = setup.CreateJSONRequest(http.MethodPost, "/api/auth/refresh", reqBody)
+	resp, err = app.Test(req)
+	require.NoError(t, err, "request should complete")
+	require.Equal(t, 400, resp.StatusCode, "other device refresh token should be revoked")
+
+	t.Log("✓ Other sessions revoked")
+
+	// Test 6: Login with new password
+	t.Log("=== Test 6: Login With New Password ===")
+	_ = loginWithDevice(t, app, "changepwuser", "newpass123", "Laptop")
+
+	t.Log("✓ Login with new password successful")
+
+	t.Log("=== All Change Password Tests Passed ===")
+}
+
+// uploadTestAvatar is a helper function to upload the test image as the user avatar
+func uploadTestAvatar(t *testing.T, app *fiber.App, accessToken string) *http.Response {
+	testImageData, err := getTestImage()
+	require.NoError(t, err, "should read test image")
+
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+
+	h := make(textproto.MIMEHeader)
+	h.Set("Content-Disposition", `form-data; name="avatar"; filename="avatar.jpg"`)
+	h.Set("Content-Type", "image/jpeg")
+	part, err := writer.CreatePart(h)
+	require.NoError(t, err, "should create form part")
+	_, err = part.Write(testImageData)
+	require.NoError(t, err, "should write image data")
+
+	err = writer.Close()
+	require.NoError(t, err, "should close writer")
+
+	req := setup.CreateAuthRequest(http.MethodPut, "/api/users/avatar", body.Bytes(), accessToken)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	resp, err := app.Test(req)
+	require.NoError(t, err, "upload avatar request should complete")
+
+	return resp
+}
+
+// TestUpdateAvatar tests the PUT /users/avatar and DELETE /users/avatar endpoints
+func TestUpdateAvatar(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test in short mode")
+	}
+
+	ctx := context.Background()
+
+	t.Log("=== Starting Test Infrastructure ===")
+	infra, err := setup.StartInfra(ctx, t)
+	require.NoError(t, err, "infrastructure should start successfully")
+	defer func() { _ = infra.Terminate(ctx, t) }()
+
+	t.Log("=== Running Database Migrations ===")
+	_ = setup.RunMigration(infra.PgURL, t)
+
+	t.Log("=== Setting Up Test Application ===")
+	app, db, _, minioClient := setup.SetupTestApp(t, infra.PgURL, infra.RedisURL, infra.MinioURL, infra.MailhogSMTP)
+	defer db.Close()
+
+	// Create test user
+	t.Log("=== Setup: Creating Test User ===")
+	accessToken := createTestUser(t, app, infra.MailhogURL, "avataruser@example.com", "avataruser", "pass123")
+
+	// Test 1: Upload avatar without file
+	t.Log("=== Test 1: Upload Avatar Without File ===")
+	req := setup.CreateAuthRequest(http.MethodPut, "/api/users/avatar", nil, accessToken)
+	resp, err := app.Test(req)
+	require.NoError(t, err, "request should complete")
+	require.NotEqual(t, 200, resp.StatusCode, "missing avatar should be rejected")
+
+	result := setup.ParseJSONResponse(t, resp)
+	code, message, param := setup.ParseErrorDetail(t, result)
+	require.Equal(t, "avatar", param, "error param should be 'avatar'")
+
+	t.Logf("✓ Validation Error: Code=%s, Message=%s, Param=%s", code, message, param)
+
+	// Test 2: Upload avatar successfully
+	t.Log("=== Test 2: Upload Avatar Successfully ===")
+	resp = uploadTestAvatar(t, app, accessToken)
+	require.Equal(t, 200, resp.StatusCode, "upload avatar should return 200")
+
+	var firstObjectKey string
+	var firstVariants map[string]string
+	err = db.QueryRow(ctx, "SELECT object_key, variants FROM user_avatar_images").Scan(&firstObjectKey, &firstVariants)
+	require.NoError(t, err, "avatar row should exist")
+	require.Len(t, firstVariants, 3, "avatar should have thumb, medium and large variants")
+
+	stat, err := minioClient.StatObject(ctx, "virdan-test", firstObjectKey, minio.StatObjectOptions{})
+	require.NoError(t, err, "avatar original should exist in MinIO")
+	require.Equal(t, "image/jpeg", stat.ContentType, "original should keep its format")
+
+	for _, variantKey := range firstVariants {
+		stat, err = minioClient.StatObject(ctx, "virdan-test", variantKey, minio.StatObjectOptions{})
+		require.NoError(t, err, "avatar variant should exist in MinIO")
+		require.Equal(t, "image/webp", stat.ContentType, "variant should be converted to webp")
+	}
+
+	req = setup.CreateAuthRequest(http.MethodGet, "/api/users/me", nil, accessToken)
+	resp, err = app.Test(req)
+	require.NoError(t, err, "get profile request should complete")
+
+	result = setup.ParseJSONResponse(t, resp)
+	avatarImageUrls, ok := result["avatarImageUrls"].(map[string]interface{})
+	require.True(t, ok, "profile should expose the avatar urls")
+	require.Contains(t, avatarImageUrls["medium"], firstVariants["medium"], "profile should point to the new avatar")
+	for _, url := range avatarImageUrls {
+		require.NotContains(t, url, firstObjectKey, "original should not be exposed")
+	}
+
+	t.Log("✓ Avatar uploaded")
+
+	// Test 3: Replacing the avatar leaves no orphaned rows or objects
+	t.Log("=== Test 3: Replace Avatar ===")
+	resp = uploadTestAvatar(t, app, accessToken)
+	require.Equal(t, 200, resp.StatusCode, "replace avatar should return 200")
+
+	var total int
+	err = db.QueryRow(ctx, "SELECT COUNT(*) FROM user_avatar_images").Scan(&total)
+	require.NoError(t, err, "should count avatar rows")
+	require.Equal(t, 1, total, "only one avatar row should remain")
+
+	var secondObjectKey string
+	var avatarImageId *uuid.UUID
+	err = db.QueryRow(ctx, `SELECT B.object_key, A.avatar_image_id FROM users A
+		JOIN user_avatar_images B ON B.user_id = A.id`).Scan(&secondObjectKey, &avatarImageId)
+	require.NoError(t, err, "should read avatar row")
+	require.NotEqual(t, firstObjectKey, secondObjectKey, "avatar should be replaced")
+	require.NotNil(t, avatarImageId, "user should reference the avatar row")
+
+	_, err = minioClient.StatObject(ctx, "virdan-test", firstObjectKey, minio.StatObjectOptions{})
+	require.Error(t, err, "old avatar original should be removed from MinIO")
+
+	for _, variantKey := range firstVariants {
+		_, err = minioClient.StatObject(ctx, "virdan-test", variantKey, minio.StatObjectOptions{})
+		require.Error(t, err, "old avatar variant should be removed from MinIO")
+	}
+
+	t.Log("✓ Avatar replaced without leftovers")
+
+	// Test 4: Delete avatar
+	t.Log("=== Test 4: Delete Avatar ===")
+	req = setup.CreateAuthRequest(http.MethodDelete, "/api/users/avatar", nil, accessToken)
+	resp, err = app.Test(req)
+	require.NoError(t, err, "delete avatar request should complete")
+	require.Equal(t, 200, resp.StatusCode, "delete avatar should return 200")
+
+	err = db.QueryRow(ctx, "SELECT COUNT(*) FROM user_avatar_images").Scan(&total)
+	require.NoError(t, err, "should count avatar rows")
+	require.Equal(t, 0, total, "avatar row should be deleted")
+
+	_, err = minioClient.StatObject(ctx, "virdan-test", secondObjectKey, minio.StatObjectOptions{})
+	require.Error(t, err, "avatar object should be removed from MinIO")
+
+	req = setup.CreateAuthRequest(http.MethodGet, "/api/users/me", nil, accessToken)
+	resp, err = app.Test(req)
+	require.NoError(t, err, "get profile request should complete")
+
+	result = setup.ParseJSONResponse(t, resp)
+	require.Nil(t, result["avatarImageUrls"], "profile should have no avatar")
+
+	t.Log("✓ Avatar deleted")
+
+	// Test 5: Deleting without an avatar is a no-op
+	t.Log("=== Test 5: Delete Avatar Twice ===")
+	req = setup.CreateAuthRequest(http.MethodDelete, "/api/users/avatar", nil, accessToken)
+	resp, err = app.Test(req)
+	require.NoError(t, err, "delete avatar request should complete")
+	require.Equal(t, 200, resp.StatusCode, "deleting a missing avatar should return 200")
+
+	t.Log("✓ Delete is idempotent")
+
+	t.Log("=== All Update Avatar Tests Passed ===")
+}
@@ -0,0 +1,161 @@
+package integration
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/ferdian3456/virdanproject/tests/integration/setup"
+	"github.com/stretchr/testify/require"
+)
+
+// loginWithDevice is a helper function to login with a device label and return the token response
+func loginWithDevice(t *testing.T, app *fiber.App, username, password, deviceLabel string) map[string]interface{} {
+	reqBody := []byte(fmt.Sprintf(`{"username":"%s","password":"%s","deviceLabel":"%s"}`, username, password, deviceLabel))
+	req := setup.CreateJSONRequest(http.MethodPost, "/api/auth/login", reqBody)
+	resp, err := app.Test(req)
+	require.NoError(t, err, "login should succeed")
+	require.Equal(t, 200, resp.StatusCode, "login should return 200")
+
+	return setup.ParseJSONResponse(t, resp)
+}
+
+// TestUserSessions tests the /users/me/sessions endpoints
+func TestUserSessions(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test in short mode")
+	}
+
+	ctx := context.Background()
+
+	t.Log("=== Starting Test Infrastructure ===")
+	infra, err := setup.StartInfra(ctx, t)
+	require.NoError(t, err, "infrastructure should start successfully")
+	defer func() { _ = infra.Terminate(ctx, t) }()
+
+	t.Log("=== Running Database Migrations ===")
+	_ = setup.RunMigration(infra.PgURL, t)
+
+	t.Log("=== Setting Up Test Application ===")
+	app, db, _, _ := setup.SetupTestApp(t, infra.PgURL, infra.RedisURL, infra.MinioURL, infra.MailhogSMTP)
+	defer db.Close()
+
+	// Create test user
+	t.Log("=== Setup: Creating Test User ===")
+	_ = createTestUser(t, app, infra.MailhogURL, "sessionuser@example.com", "sessionuser", "pass123")
+
+	// Test 1: Logging in on a second device keeps the first one logged in
+	t.Log("=== Test 1: Login On Two Devices ===")
+	laptopToken := loginWithDevice(t, app, "sessionuser", "pass123", "Laptop")["accessToken"].(string)
+	phoneToken := loginWithDevice(t, app, "sessionuser", "pass123", "Phone")["accessToken"].(string)
+
+	for _, token := range []string{laptopToken, phoneToken} {
+		req := setup.CreateAuthRequest(http.MethodGet, "/api/users/me", nil, token)
+		resp, err := app.Test(req)
+		require.NoError(t, err, "request should complete")
+		require.Equal(t, 200, resp.StatusCode, "both devices should stay logged in")
+	}
+
+	t.Log("✓ Both devices are logged in")
+
+	// Test 2: List sessions
+	t.Log("=== Test 2: List Sessions ===")
+	req := setup.CreateAuthRequest(http.MethodGet, "/api/users/me/sessions", nil, laptopToken)
+	resp, err := app.Test(req)
+	require.NoError(t, err, "list sessions request should complete")
+	require.Equal(t, 200, resp.StatusCode, "list sessions should return 200")
+
+	apiResp := setup.ParseAPIResponse(t, resp)
+	sessions := setup.GetDataAsArray(t, apiResp)
+	require.GreaterOrEqual(t, len(sessions), 2, "should list at least the laptop and phone sessions")
+
+	var phoneSessionId string
+	currentCount := 0
+	for _, item := range sessions {
+		session := item.(map[string]interface{})
+		require.NotEmpty(t, session["lastSeenDatetime"], "session should have last seen datetime")
+		if session["current"].(bool) {
+			currentCount++
+			require.Equal(t, "Laptop", session["deviceLabel"], "current session should be the laptop")
+		}
+		if session["deviceLabel"] == "Phone" {
+			phoneSessionId = session["id"].(string)
+		}
+	}
+	require.Equal(t, 1, currentCount, "exactly one session should be marked as current")
+	require.NotEmpty(t, phoneSessionId, "phone session should be listed")
+
+	t.Logf("✓ Listed %d sessions", len(sessions))
+
+	// Test 3: Delete another session by id
+	t.Log("=== Test 3: Delete Phone Session ===")
+	req = setup.CreateAuthRequest(http.MethodDelete, "/api/users/me/sessions/"+phoneSessionId, nil, laptopToken)
+	resp, err = app.Test(req)
+	require.NoError(t, err, "delete session request should complete")
+	require.Equal(t, 200, resp.StatusCode, "delete session should return 200")
+
+	req = setup.CreateAuthRequest(http.MethodGet, "/api/users/me", nil, phoneToken)
+	resp, err = app.Test(req)
+	require.NoError(t, err, "request should complete")
+	require.NotEqual(t, 200, resp.StatusCode, "phone token should be revoked")
+
+	t.Log("✓ Phone session revoked")
+
+	// Test 4: Delete unknown session
+	t.Log("=== Test 4: Delete Unknown Session ===")
+	req = setup.CreateAuthRequest(http.MethodDelete, "/api/users/me/sessions/invalid-session-id", nil, laptopToken)
+	resp, err = app.Test(req)
+	require.NoError(t, err, "request should complete")
+
+	result := setup.ParseJSONResponse(t, resp)
+	code, message, param := setup.ParseErrorDetail(t, result)
+	require.Equal(t, "VALIDATION_ERROR", code, "error code should be VALIDATION_ERROR")
+	require.Equal(t, "sessionId", param, "error param should be 'sessionId'")
+
+	t.Logf("✓ Validation Error: Code=%s, Message=%s, Param=%s", code, message, param)
+
+	// Test 5: Log out everywhere else
+	t.Log("=== Test 5: Log Out Everywhere Else ===")
+	tabletToken := loginWithDevice(t, app, "sessionuser", "pass123", "Tablet")["accessToken"].(string)
+
+	req = setup.CreateAuthRequest(http.MethodPost, "/api/users/me/sessions/logout-others", nil, laptopToken)
+	resp, err = app.Test(req)
+	require.NoError(t, err, "logout others request should complete")
+	require.Equal(t, 200, resp.StatusCode, "logout others should return 200")
+
+	req = setup.CreateAuthRequest(http.MethodGet, "/api/users/me", nil, tabletToken)
+	resp, err = app.Test(req)
+	require.NoError(t, err, "request should complete")
+	require.NotEqual(t, 200, resp.StatusCode, "tablet token should be revoked")
+
+	req = setup.CreateAuthRequest(http.MethodGet, "/api/users/me/sessions", nil, laptopToken)
+	resp, err = app.Test(req)
+	require.NoError(t, err, "request should complete")
+	require.Equal(t, 200, resp.StatusCode, "laptop should stay logged in")
+
+	apiResp = setup.ParseAPIResponse(t, resp)
+	require.Len(t, setup.GetDataAsArray(t, apiResp), 1, "only the current session should remain")
+
+	t.Log("✓ Other sessions revoked")
+
+	// Test 6: Logout only ends the current session
+	t.Log("=== Test 6: Logout Ends Only Current Session ===")
+	desktopToken := loginWithDevice(t, app, "sessionuser", "pass123", "Desktop")["accessToken"].(string)
+
+	req = setup.CreateAuthRequest(http.MethodPost, "/api/users/logout", nil, desktopToken)
+	resp, err = app.Test(req)
+	require.NoError(t, err, "logout request should complete")
+	require.Equal(t, 200, resp.StatusCode, "logout should return 200")
+
+	req = setup.CreateAuthRequest(http.MethodGet, "/api/users/me", nil, laptopToken)
+	resp, err = app.Test(req)
+	require.NoError(t, err, "request should complete")
+	require.Equal(t, 200, resp.StatusCode, "laptop should stay logged in after desktop logout")
+
+	t.Log("✓ Logout ended only the current session")
+
+	t.Log("=== All User Session Tests Passed ===")
+}